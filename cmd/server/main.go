@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
@@ -15,10 +16,26 @@ import (
 
 	"github.com/Saumajitt/threatLog/internal/api"
 	"github.com/Saumajitt/threatLog/internal/api/handler"
+	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
 	"github.com/Saumajitt/threatLog/internal/config"
+	"github.com/Saumajitt/threatLog/internal/dedup"
+	"github.com/Saumajitt/threatLog/internal/geoip"
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/notify"
+	"github.com/Saumajitt/threatLog/internal/partition"
+	"github.com/Saumajitt/threatLog/internal/pipeline"
 	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/retention"
+	"github.com/Saumajitt/threatLog/internal/rules"
 	"github.com/Saumajitt/threatLog/internal/service"
+	"github.com/Saumajitt/threatLog/internal/spool"
+	"github.com/Saumajitt/threatLog/internal/syslog"
+	"github.com/Saumajitt/threatLog/internal/tail"
+	"github.com/Saumajitt/threatLog/internal/tenant"
+	"github.com/Saumajitt/threatLog/internal/threatintel"
 	"github.com/Saumajitt/threatLog/internal/worker"
+	"github.com/Saumajitt/threatLog/pkg/sigma"
+	"github.com/Saumajitt/threatLog/pkg/validator"
 )
 
 func main() {
@@ -63,6 +80,180 @@ func main() {
 	}
 	log.Info().Msg("Redis connected successfully")
 
+	// Initialize ingestion spool
+	var ingestSpool *spool.Spool
+	if cfg.Ingestion.Spool.Enabled {
+		ingestSpool, err = spool.Open(spool.Options{
+			Dir:           cfg.Ingestion.Spool.Dir,
+			SegmentSize:   cfg.Ingestion.Spool.SegmentSize,
+			Fsync:         cfg.Ingestion.Spool.Fsync,
+			FsyncInterval: cfg.Ingestion.Spool.FsyncInterval,
+			MaxDiskUsage:  cfg.Ingestion.Spool.MaxDiskUsage,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open ingestion spool")
+		}
+		defer ingestSpool.Close()
+	}
+
+	// Initialize the notifier before the rule engine so it stops after the
+	// engine flushes its last alerts
+	var notifier *notify.Notifier
+	if cfg.Notifications.Enabled {
+		channels := make([]notify.Channel, 0, len(cfg.Notifications.Channels))
+		for _, ch := range cfg.Notifications.Channels {
+			channels = append(channels, notificationChannel(ch))
+		}
+		if err := notify.Validate(channels); err != nil {
+			log.Fatal().Err(err).Msg("Invalid notification configuration")
+		}
+
+		notifier, err = notify.NewNotifier(pgRepo, channels, cfg.Notifications.BufferSize)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create notifier")
+		}
+		notifier.Start()
+		defer notifier.Stop()
+	}
+
+	// Initialize rule engine before the pool so it stops after the pool drains
+	if err := validator.ValidateFieldMapping(cfg.Rules.Sigma.FieldMapping); err != nil {
+		log.Fatal().Err(err).Msg("Invalid sigma field mapping")
+	}
+	sigmaMapping := sigma.NewFieldMapping(cfg.Rules.Sigma.FieldMapping)
+
+	var ruleEngine *rules.Engine
+	if cfg.Rules.Enabled {
+		ruleEngine = rules.NewEngine(pgRepo, cfg.Rules.ReloadInterval, cfg.Rules.ScheduleLag, cfg.Rules.MaxLateness, cfg.Rules.AlertBufferSize, sigmaMapping)
+		if notifier != nil {
+			ruleEngine.AddAlertObserver(notifier)
+		}
+		if err := ruleEngine.Start(); err != nil {
+			log.Fatal().Err(err).Msg("Failed to start rule engine")
+		}
+		defer ruleEngine.Stop()
+	}
+
+	// Initialize live tail hub; like the rule engine it stops after the pool
+	var tailHub *tail.Hub
+	if cfg.Tail.Enabled {
+		var fanout *repository.RedisRepository
+		if cfg.Tail.RedisFanout {
+			fanout = redisRepo
+		}
+		tailHub = tail.NewHub(cfg.Tail.ClientBuffer, cfg.Tail.MaxClients, fanout, cfg.Tail.RedisChannel)
+		tailHub.Start()
+		defer tailHub.Stop()
+	}
+
+	// Per-tenant quotas
+	quotas := tenant.Quotas{
+		Default: tenantQuota(cfg.Tenants.DefaultQuota),
+		Tenants: make(map[string]tenant.Quota, len(cfg.Tenants.Quotas)),
+	}
+	for tenantID, quota := range cfg.Tenants.Quotas {
+		if err := validator.ValidateTenantID(tenantID); err != nil {
+			log.Fatal().Err(err).Str("tenant_id", tenantID).Msg("Invalid tenant quota")
+		}
+		quotas.Tenants[tenantID] = tenantQuota(quota)
+	}
+
+	// Retention rules; legal holds also keep partitions from being dropped
+	retentionRules := make([]retention.Rule, 0, len(cfg.Retention.Rules))
+	for _, rule := range cfg.Retention.Rules {
+		retentionRules = append(retentionRules, retentionRule(rule))
+	}
+	if err := retention.Validate(retentionRules, cfg.Retention.ArchiveDir); err != nil {
+		log.Fatal().Err(err).Msg("Invalid retention configuration")
+	}
+
+	// Initialize partition maintenance so partitions exist before the pool writes
+	if cfg.Partitioning.Enabled {
+		interval, err := partition.ParseInterval(cfg.Partitioning.Interval)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid partitioning configuration")
+		}
+		if err := checkPartitionRetention(cfg.Partitioning.Retention, quotas, retentionRules); err != nil {
+			log.Fatal().Err(err).Msg("Invalid partitioning configuration")
+		}
+
+		maintainer := partition.NewMaintainer(pgRepo, interval, cfg.Partitioning.Premake,
+			cfg.Partitioning.Retention, cfg.Partitioning.CheckInterval, retention.Holds(retentionRules))
+		if err := maintainer.Start(); err != nil {
+			log.Fatal().Err(err).Msg("Failed to start partition maintainer")
+		}
+		defer maintainer.Stop()
+	}
+
+	// Initialize deduplication; it stops after the pool so repeat counts
+	// find their stored events
+	var deduper *dedup.Deduper
+	if cfg.Ingestion.Dedup.Enabled {
+		if err := dedup.ValidateFields(cfg.Ingestion.Dedup.Fields); err != nil {
+			log.Fatal().Err(err).Msg("Invalid dedup configuration")
+		}
+		if cfg.Ingestion.Dedup.Window < cfg.Ingestion.BatchTimeout {
+			log.Warn().Msg("Dedup window is shorter than the batch timeout, repeat counts may be lost")
+		}
+		deduper = dedup.NewDeduper(pgRepo, cfg.Ingestion.Dedup.Window, cfg.Ingestion.Dedup.Fields,
+			cfg.Ingestion.Dedup.Normalize, cfg.Ingestion.Dedup.Sources, cfg.Ingestion.Dedup.MaxEntries)
+		// Repeats are counted by the observers of stored events
+		if ruleEngine != nil {
+			deduper.AddObserver(ruleEngine)
+		}
+		if tailHub != nil {
+			deduper.AddObserver(tailHub)
+		}
+		if notifier != nil {
+			deduper.AddObserver(notifier)
+		}
+		deduper.Start()
+		defer deduper.Stop()
+	}
+
+	// Initialize threat intel matching; it stops after the pool so the last
+	// batches are still enriched
+	var threatStore *threatintel.Store
+	if cfg.ThreatIntel.Enabled {
+		feeds := make([]threatintel.Feed, 0, len(cfg.ThreatIntel.Feeds))
+		for _, feed := range cfg.ThreatIntel.Feeds {
+			feeds = append(feeds, threatFeed(feed))
+		}
+		if err := threatintel.Validate(feeds); err != nil {
+			log.Fatal().Err(err).Msg("Invalid threat intel configuration")
+		}
+
+		threatStore = threatintel.NewStore(pgRepo, feeds, cfg.ThreatIntel.ReloadInterval, cfg.ThreatIntel.MaxMatches)
+		if err := threatStore.Start(); err != nil {
+			log.Fatal().Err(err).Msg("Failed to start threat intel store")
+		}
+		defer threatStore.Stop()
+	}
+
+	// Initialize GeoIP enrichment; like threat intel it stops after the pool
+	var geoEnricher *geoip.Enricher
+	if cfg.GeoIP.Enabled {
+		geoEnricher = geoip.NewEnricher(cfg.GeoIP.Databases, cfg.GeoIP.Fields, cfg.GeoIP.ScanMessage,
+			cfg.GeoIP.MaxAddresses, cfg.GeoIP.ReloadInterval)
+		if err := geoEnricher.Start(); err != nil {
+			log.Fatal().Err(err).Msg("Failed to start geoip enricher")
+		}
+		defer geoEnricher.Stop()
+	}
+
+	// Initialize processing pipelines
+	var pipelines *pipeline.Pipelines
+	if len(cfg.Pipelines) > 0 {
+		configs := make([]pipeline.Config, 0, len(cfg.Pipelines))
+		for _, p := range cfg.Pipelines {
+			configs = append(configs, pipelineConfig(p))
+		}
+		pipelines, err = pipeline.New(configs)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid pipeline configuration")
+		}
+	}
+
 	// Initialize worker pool
 	pool := worker.NewPool(
 		cfg.Ingestion.WorkerCount,
@@ -70,23 +261,116 @@ func main() {
 		cfg.Ingestion.BatchSize,
 		cfg.Ingestion.BatchTimeout,
 		pgRepo,
+		ingestSpool,
+		worker.RetryPolicy{
+			MaxRetries:     cfg.Ingestion.Retry.MaxRetries,
+			InitialBackoff: cfg.Ingestion.Retry.InitialBackoff,
+			MaxBackoff:     cfg.Ingestion.Retry.MaxBackoff,
+		},
 	)
+	if ruleEngine != nil {
+		pool.AddObserver(ruleEngine)
+	}
+	if tailHub != nil {
+		pool.AddObserver(tailHub)
+	}
+	if notifier != nil {
+		pool.AddObserver(notifier)
+	}
+	if deduper != nil {
+		pool.SetDeduplicator(deduper)
+		pool.AddObserver(deduper)
+	}
+	if pipelines != nil {
+		pool.SetProcessor(pipelines)
+	}
+	if threatStore != nil {
+		pool.AddEnricher(threatStore)
+	}
+	if geoEnricher != nil {
+		pool.AddEnricher(geoEnricher)
+	}
+
+	// API and syslog ingestion share the tenant rate limits. Keys of events
+	// that end up dead-lettered are released so retries are ingested again.
+	limiter := tenant.NewLimiter(quotas)
+	ingestionService := service.NewIngestionService(pool, limiter, redisRepo, cfg.Ingestion.IdempotencyTTL)
+	pool.AddDeadLetterObserver(ingestionService)
+
 	pool.Start()
 	defer pool.Stop()
 
+	// Start syslog listeners
+	var syslogServer *syslog.Server
+	if cfg.Syslog.Enabled {
+		if err := validator.ValidateTenantID(cfg.Syslog.TenantID); err != nil {
+			log.Fatal().Err(err).Msg("Invalid syslog tenant")
+		}
+		syslogServer = syslog.NewServer(cfg.Syslog, pool, limiter)
+		if err := syslogServer.Start(); err != nil {
+			log.Fatal().Err(err).Msg("Failed to start syslog listeners")
+		}
+		defer syslogServer.Stop()
+	}
+
+	// Enforce tenant retentions and retention rules
+	var retentionEnforcer *retention.Enforcer
+	if cfg.Retention.Enabled {
+		retentionEnforcer = retention.NewEnforcer(pgRepo, quotas, retentionRules, cfg.Retention.Interval,
+			cfg.Retention.BatchSize, cfg.Retention.BatchPause, cfg.Retention.ArchiveDir)
+		retentionEnforcer.Start()
+		defer retentionEnforcer.Stop()
+	}
+
 	// Initialize services
-	ingestionService := service.NewIngestionService(pool)
 	queryService := service.NewQueryService(pgRepo, redisRepo, cfg.Cache.QueryCacheEnabled)
-	metricsService := service.NewMetricsService()
+	metricsService := service.NewMetricsService(deduper, geoEnricher, pipelines, syslogServer)
+	deadLetterService := service.NewDeadLetterService(pgRepo, pool)
+	ruleService := service.NewRuleService(pgRepo, ruleEngine, sigmaMapping)
+	retentionService := service.NewRetentionService(pgRepo, retentionEnforcer)
+	notificationService := service.NewNotificationService(pgRepo, notifier)
+	alertService := service.NewAlertService(pgRepo)
+	threatIntelService := service.NewThreatIntelService(pgRepo, threatStore)
+	authService, err := service.NewAuthService(pgRepo, cfg.Auth.BootstrapKey, cfg.Auth.TokenSecret)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create auth service")
+	}
+	authService.Start()
+	defer authService.Stop()
 
 	// Initialize handlers
 	ingestHandler := handler.NewIngestHandler(ingestionService, metricsService)
 	queryHandler := handler.NewQueryHandler(queryService, metricsService)
 	metricsHandler := handler.NewMetricsHandler(metricsService)
 	healthHandler := handler.NewHealthHandler(pgRepo, redisRepo)
+	deadLetterHandler := handler.NewDeadLetterHandler(deadLetterService)
+	ruleHandler := handler.NewRuleHandler(ruleService)
+	tailHandler := handler.NewTailHandler(tailHub, cfg.Tail.HeartbeatInterval)
+	apiKeyHandler := handler.NewAPIKeyHandler(authService)
+	retentionHandler := handler.NewRetentionHandler(retentionService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	alertHandler := handler.NewAlertHandler(alertService)
+	threatIntelHandler := handler.NewThreatIntelHandler(threatIntelService)
+
+	// A nil authenticator leaves the API open
+	var authenticator custommw.Authenticator
+	if cfg.Auth.Enabled {
+		bootstrapKey, err := authService.EnsureBootstrapKey(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to check for API keys")
+		}
+		if bootstrapKey != "" {
+			log.Warn().
+				Str("bootstrap_key", bootstrapKey).
+				Msg("No operator API key exists; generated a bootstrap admin key for this run. Create an admin key with it and restart.")
+		}
+		authenticator = authService
+	} else {
+		log.Warn().Msg("API authentication is disabled")
+	}
 
 	// Setup router
-	router := api.NewRouter(ingestHandler, queryHandler, metricsHandler, healthHandler)
+	router := api.NewRouter(ingestHandler, queryHandler, metricsHandler, healthHandler, deadLetterHandler, ruleHandler, tailHandler, apiKeyHandler, retentionHandler, notificationHandler, alertHandler, threatIntelHandler, authenticator, cfg.Auth.CORSAllowedOrigins)
 	r := router.Setup()
 
 	// Create HTTP server
@@ -96,6 +380,10 @@ func main() {
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
+	if tailHub != nil {
+		// End tail streams so Shutdown does not wait on them
+		srv.RegisterOnShutdown(tailHub.Stop)
+	}
 
 	// Start server in goroutine
 	go func() {
@@ -160,3 +448,145 @@ func initRedis(cfg config.RedisConfig) *redis.Client {
 
 	return client
 }
+
+// tenantQuota converts a configured quota
+func tenantQuota(cfg config.QuotaConfig) tenant.Quota {
+	return tenant.Quota{
+		IngestRate:  cfg.IngestRate,
+		IngestBurst: cfg.IngestBurst,
+		Retention:   cfg.Retention,
+	}
+}
+
+// retentionRule converts a configured retention rule
+func retentionRule(cfg config.RetentionRuleConfig) retention.Rule {
+	return retention.Rule{
+		Name: cfg.Name,
+		Match: repository.LogMatcher{
+			Severities: cfg.Severity,
+			Source:     cfg.Source,
+			Tenant:     cfg.Tenant,
+		},
+		MaxAge:    cfg.MaxAge,
+		Action:    cfg.Action,
+		LegalHold: cfg.LegalHold,
+	}
+}
+
+// notificationChannel converts a configured notification channel
+func notificationChannel(cfg config.NotificationChannelConfig) notify.Channel {
+	return notify.Channel{
+		Name:   cfg.Name,
+		Type:   cfg.Type,
+		URL:    cfg.URL,
+		Secret: cfg.Secret,
+		SMTP: notify.SMTP{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+			To:       cfg.To,
+		},
+		Events:    cfg.Events,
+		Alerts:    cfg.Alerts,
+		Tenants:   cfg.Tenants,
+		Subject:   cfg.Subject,
+		Body:      cfg.Body,
+		RateLimit: cfg.RateLimit,
+		Burst:     cfg.Burst,
+		Retry: notify.RetryPolicy{
+			MaxRetries:     cfg.MaxRetries,
+			InitialBackoff: cfg.InitialBackoff,
+			MaxBackoff:     cfg.MaxBackoff,
+		},
+		Timeout: cfg.Timeout,
+	}
+}
+
+// threatFeed converts a configured threat feed
+func threatFeed(cfg config.ThreatFeedConfig) threatintel.Feed {
+	return threatintel.Feed{
+		Name:     cfg.Name,
+		Path:     cfg.Path,
+		Format:   cfg.Format,
+		Severity: cfg.Severity,
+	}
+}
+
+// pipelineConfig converts a configured pipeline
+func pipelineConfig(cfg config.PipelineConfig) pipeline.Config {
+	stages := make([]pipeline.StageConfig, 0, len(cfg.Processors))
+	for _, proc := range cfg.Processors {
+		when := make([]model.RuleCondition, 0, len(proc.When))
+		for _, cond := range proc.When {
+			when = append(when, model.RuleCondition{
+				Field:    cond.Field,
+				Operator: cond.Operator,
+				Value:    cond.Value,
+				Values:   cond.Values,
+			})
+		}
+		renames := make([]pipeline.Rename, 0, len(proc.Rename))
+		for _, r := range proc.Rename {
+			renames = append(renames, pipeline.Rename{From: r.From, To: r.To})
+		}
+
+		stages = append(stages, pipeline.StageConfig{
+			Name:        proc.Name,
+			Type:        proc.Type,
+			When:        when,
+			OnError:     proc.OnError,
+			Rename:      renames,
+			Field:       proc.Field,
+			Format:      proc.Format,
+			Pattern:     proc.Pattern,
+			Target:      proc.Target,
+			Tags:        proc.Tags,
+			Set:         proc.Set,
+			Fields:      proc.Fields,
+			Patterns:    proc.Patterns,
+			Replacement: proc.Replacement,
+		})
+	}
+
+	return pipeline.Config{
+		Name:    cfg.Name,
+		Sources: cfg.Sources,
+		Tenants: cfg.Tenants,
+		Stages:  stages,
+	}
+}
+
+// checkPartitionRetention rejects a partition retention shorter than the
+// longest retention rule, as dropping partitions would delete events the
+// rule still keeps. Tenant retentions are upper bounds, so a longer one
+// only warns.
+func checkPartitionRetention(partitionRetention time.Duration, quotas tenant.Quotas, rules []retention.Rule) error {
+	if partitionRetention <= 0 {
+		return nil
+	}
+
+	check := func(tenantID string, quota tenant.Quota) {
+		if quota.Retention == 0 || quota.Retention > partitionRetention {
+			log.Warn().
+				Str("tenant_id", tenantID).
+				Dur("partition_retention", partitionRetention).
+				Dur("tenant_retention", quota.Retention).
+				Msg("Partitions are dropped before the tenant's retention expires")
+		}
+	}
+
+	check("default quota", quotas.Default)
+	for tenantID, quota := range quotas.Tenants {
+		check(tenantID, quota)
+	}
+
+	for _, rule := range rules {
+		if !rule.LegalHold && rule.MaxAge > partitionRetention {
+			return fmt.Errorf("partition retention %s is shorter than the max_age %s of retention rule %q",
+				partitionRetention, rule.MaxAge, rule.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/service"
+	"github.com/Saumajitt/threatLog/pkg/validator"
+)
+
+type AlertHandler struct {
+	alertService *service.AlertService
+}
+
+func NewAlertHandler(alertService *service.AlertService) *AlertHandler {
+	return &AlertHandler{
+		alertService: alertService,
+	}
+}
+
+// HandleList lists the caller's alerts, newest first
+func (h *AlertHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	filter := model.AlertFilter{
+		TenantID: custommw.TenantFromContext(r.Context()),
+		Assignee: queryParams.Get("assignee"),
+		RuleID:   queryParams.Get("rule_id"),
+		Severity: queryParams.Get("severity"),
+		Limit:    model.DefaultAlertLimit,
+	}
+
+	if s := queryParams.Get("status"); s != "" {
+		for _, status := range strings.Split(s, ",") {
+			switch status {
+			case model.AlertOpen, model.AlertAcknowledged, model.AlertResolved, model.AlertSuppressed:
+				filter.Statuses = append(filter.Statuses, status)
+			default:
+				h.respondError(w, http.StatusBadRequest, "invalid_parameter", "status must be open, acknowledged, resolved or suppressed", nil)
+				return
+			}
+		}
+	}
+
+	if filter.RuleID != "" {
+		if _, err := uuid.Parse(filter.RuleID); err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid rule_id", nil)
+			return
+		}
+	}
+
+	if filter.Severity != "" && !model.IsValidSeverity(filter.Severity) {
+		h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid severity", nil)
+		return
+	}
+
+	if s := queryParams.Get("start_time"); s != "" {
+		startTime, err := validator.ParseTimestamp(s)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid start_time", nil)
+			return
+		}
+		filter.StartTime = &startTime
+	}
+
+	if s := queryParams.Get("end_time"); s != "" {
+		endTime, err := validator.ParseTimestamp(s)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid end_time", nil)
+			return
+		}
+		filter.EndTime = &endTime
+	}
+
+	if l := queryParams.Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed <= 0 || parsed > model.MaxAlertLimit {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "limit must be between 1 and 1000", nil)
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	if o := queryParams.Get("offset"); o != "" {
+		parsed, err := strconv.Atoi(o)
+		if err != nil || parsed < 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "offset cannot be negative", nil)
+			return
+		}
+		filter.Offset = parsed
+	}
+
+	response, err := h.alertService.ListAlerts(r.Context(), filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list alerts")
+		h.respondError(w, http.StatusInternalServerError, "query_failed", "Failed to list alerts", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// HandleGet returns an alert with its linked logs, comments and audit trail
+func (h *AlertHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.pathID(w, r, "alert")
+	if !ok {
+		return
+	}
+
+	detail, err := h.alertService.GetAlert(r.Context(), custommw.TenantFromContext(r.Context()), id)
+	if err != nil {
+		h.respondAlertError(w, err, "Failed to get alert")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, detail)
+}
+
+// HandleUpdateStatus acknowledges, resolves or reopens an alert
+func (h *AlertHandler) HandleUpdateStatus(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.pathID(w, r, "alert")
+	if !ok {
+		return
+	}
+
+	var req model.AlertStatusRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	if err := validator.ValidateAlertStatusRequest(req); err != nil {
+		h.respondError(w, http.StatusUnprocessableEntity, "validation_failed", err.Error(), nil)
+		return
+	}
+
+	ctx := r.Context()
+	alert, err := h.alertService.UpdateStatus(ctx, custommw.TenantFromContext(ctx), id, custommw.ActorFromContext(ctx), req)
+	if err != nil {
+		h.respondAlertError(w, err, "Failed to update alert")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, alert)
+}
+
+// HandleAssign assigns or unassigns an alert
+func (h *AlertHandler) HandleAssign(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.pathID(w, r, "alert")
+	if !ok {
+		return
+	}
+
+	var req model.AlertAssignRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	if err := validator.ValidateAlertAssignRequest(req); err != nil {
+		h.respondError(w, http.StatusUnprocessableEntity, "validation_failed", err.Error(), nil)
+		return
+	}
+
+	ctx := r.Context()
+	alert, err := h.alertService.Assign(ctx, custommw.TenantFromContext(ctx), id, custommw.ActorFromContext(ctx), req)
+	if err != nil {
+		h.respondAlertError(w, err, "Failed to assign alert")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, alert)
+}
+
+// HandleComment adds a comment to an alert
+func (h *AlertHandler) HandleComment(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.pathID(w, r, "alert")
+	if !ok {
+		return
+	}
+
+	var req model.AlertCommentRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	if err := validator.ValidateAlertComment(req); err != nil {
+		h.respondError(w, http.StatusUnprocessableEntity, "validation_failed", err.Error(), nil)
+		return
+	}
+
+	ctx := r.Context()
+	comment, err := h.alertService.AddComment(ctx, custommw.TenantFromContext(ctx), id, custommw.ActorFromContext(ctx), req)
+	if err != nil {
+		h.respondAlertError(w, err, "Failed to add comment")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, comment)
+}
+
+// HandleListSuppressions lists the caller's active suppressions, or all of
+// them with all=true
+func (h *AlertHandler) HandleListSuppressions(w http.ResponseWriter, r *http.Request) {
+	all := false
+	if v := r.URL.Query().Get("all"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "all must be true or false", nil)
+			return
+		}
+		all = parsed
+	}
+
+	response, err := h.alertService.ListSuppressions(r.Context(), custommw.TenantFromContext(r.Context()), all)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list suppressions")
+		h.respondError(w, http.StatusInternalServerError, "query_failed", "Failed to list suppressions", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// HandleCreateSuppression creates a suppression
+func (h *AlertHandler) HandleCreateSuppression(w http.ResponseWriter, r *http.Request) {
+	var req model.SuppressionRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	if err := validator.ValidateSuppressionRequest(req); err != nil {
+		h.respondError(w, http.StatusUnprocessableEntity, "validation_failed", err.Error(), nil)
+		return
+	}
+
+	ctx := r.Context()
+	suppression, err := h.alertService.CreateSuppression(ctx, custommw.TenantFromContext(ctx), custommw.ActorFromContext(ctx), req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create suppression")
+		h.respondError(w, http.StatusInternalServerError, "suppression_operation_failed", "Failed to create suppression", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, suppression)
+}
+
+// HandleExpireSuppression ends a suppression early
+func (h *AlertHandler) HandleExpireSuppression(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.pathID(w, r, "suppression")
+	if !ok {
+		return
+	}
+
+	if err := h.alertService.ExpireSuppression(r.Context(), custommw.TenantFromContext(r.Context()), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "not_found", "Suppression not found", nil)
+			return
+		}
+		log.Error().Err(err).Msg("Failed to expire suppression")
+		h.respondError(w, http.StatusInternalServerError, "suppression_operation_failed", "Failed to expire suppression", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AlertHandler) pathID(w http.ResponseWriter, r *http.Request, kind string) (string, bool) {
+	id := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(id); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid "+kind+" id", nil)
+		return "", false
+	}
+	return id, true
+}
+
+func (h *AlertHandler) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON payload", nil)
+		return false
+	}
+	return true
+}
+
+func (h *AlertHandler) respondAlertError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		h.respondError(w, http.StatusNotFound, "not_found", "Alert not found", nil)
+	case errors.Is(err, repository.ErrInvalidTransition):
+		h.respondError(w, http.StatusConflict, "invalid_transition", "Alert cannot move to the requested status from its current status", nil)
+	default:
+		log.Error().Err(err).Msg(message)
+		h.respondError(w, http.StatusInternalServerError, "alert_operation_failed", message, nil)
+	}
+}
+
+func (h *AlertHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *AlertHandler) respondError(w http.ResponseWriter, status int, error, message string, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model.ErrorResponse{
+		Error:   error,
+		Message: message,
+		Details: details,
+	})
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/service"
+	"github.com/Saumajitt/threatLog/pkg/validator"
+)
+
+type APIKeyHandler struct {
+	authService *service.AuthService
+}
+
+func NewAPIKeyHandler(authService *service.AuthService) *APIKeyHandler {
+	return &APIKeyHandler{
+		authService: authService,
+	}
+}
+
+// HandleList lists the API keys the caller manages
+func (h *APIKeyHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	response, err := h.authService.ListKeys(r.Context(), custommw.AdminScope(r.Context()))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list api keys")
+		h.respondError(w, http.StatusInternalServerError, "query_failed", "Failed to list api keys", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// HandleCreate creates an API key; the secret is only returned here
+func (h *APIKeyHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var req model.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON payload", nil)
+		return
+	}
+
+	if err := validator.ValidateAPIKeyRequest(req); err != nil {
+		h.respondError(w, http.StatusUnprocessableEntity, "validation_failed", err.Error(), nil)
+		return
+	}
+
+	// Keys are created in the caller's tenant; only operators may pick another
+	callerTenant := custommw.TenantFromContext(r.Context())
+	if req.TenantID == "" {
+		req.TenantID = callerTenant
+	} else if req.TenantID != callerTenant && custommw.AdminScope(r.Context()) != "" {
+		h.respondError(w, http.StatusForbidden, "forbidden", "Cannot create keys for another tenant", nil)
+		return
+	}
+
+	response, err := h.authService.CreateKey(r.Context(), req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create api key")
+		h.respondError(w, http.StatusInternalServerError, "create_failed", "Failed to create api key", nil)
+		return
+	}
+
+	log.Info().
+		Str("key_id", response.ID).
+		Str("key_name", response.Name).
+		Str("tenant_id", response.TenantID).
+		Strs("roles", response.Roles).
+		Msg("API key created")
+	h.respondJSON(w, http.StatusCreated, response)
+}
+
+// HandleTailToken issues a short-lived token for opening a live tail with
+// the access_token query parameter
+func (h *APIKeyHandler) HandleTailToken(w http.ResponseWriter, r *http.Request) {
+	identity, ok := custommw.IdentityFromContext(r.Context())
+	if !ok {
+		h.respondError(w, http.StatusBadRequest, "auth_disabled", "Authentication is disabled; live tail needs no token", nil)
+		return
+	}
+
+	response, err := h.authService.IssueTailToken(identity)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to issue tail token")
+		h.respondError(w, http.StatusInternalServerError, "create_failed", "Failed to issue tail token", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, response)
+}
+
+// HandleRevoke revokes an API key
+func (h *APIKeyHandler) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(id); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid key id", nil)
+		return
+	}
+
+	if err := h.authService.RevokeKey(r.Context(), custommw.AdminScope(r.Context()), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "not_found", "API key not found or already revoked", nil)
+			return
+		}
+		log.Error().Err(err).Msg("Failed to revoke api key")
+		h.respondError(w, http.StatusInternalServerError, "revoke_failed", "Failed to revoke api key", nil)
+		return
+	}
+
+	log.Info().Str("key_id", id).Msg("API key revoked")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIKeyHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *APIKeyHandler) respondError(w http.ResponseWriter, status int, error, message string, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model.ErrorResponse{
+		Error:   error,
+		Message: message,
+		Details: details,
+	})
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/service"
+)
+
+type DeadLetterHandler struct {
+	deadLetterService *service.DeadLetterService
+}
+
+func NewDeadLetterHandler(deadLetterService *service.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+// HandleList lists dead letters
+func (h *DeadLetterHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	status := queryParams.Get("status")
+	switch status {
+	case "":
+		status = model.DeadLetterPending
+	case model.DeadLetterPending, model.DeadLetterReplayed, model.DeadLetterAll:
+	default:
+		h.respondError(w, http.StatusBadRequest, "invalid_parameter", "status must be pending, replayed or all", nil)
+		return
+	}
+
+	limit := 100
+	if l := queryParams.Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed <= 0 || parsed > 1000 {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "limit must be between 1 and 1000", nil)
+			return
+		}
+		limit = parsed
+	}
+
+	offset := 0
+	if o := queryParams.Get("offset"); o != "" {
+		parsed, err := strconv.Atoi(o)
+		if err != nil || parsed < 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "offset cannot be negative", nil)
+			return
+		}
+		offset = parsed
+	}
+
+	response, err := h.deadLetterService.List(r.Context(), custommw.AdminScope(r.Context()), status, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list dead letters")
+		h.respondError(w, http.StatusInternalServerError, "query_failed", "Failed to list dead letters", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// HandleReplay resubmits dead letters for ingestion
+func (h *DeadLetterHandler) HandleReplay(w http.ResponseWriter, r *http.Request) {
+	var req model.ReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON payload", nil)
+		return
+	}
+
+	response, err := h.deadLetterService.Replay(r.Context(), custommw.AdminScope(r.Context()), req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to replay dead letters")
+		h.respondError(w, http.StatusInternalServerError, "replay_failed", "Failed to replay dead letters", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, response)
+}
+
+func (h *DeadLetterHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *DeadLetterHandler) respondError(w http.ResponseWriter, status int, error, message string, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model.ErrorResponse{
+		Error:   error,
+		Message: message,
+		Details: details,
+	})
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/export"
+	"github.com/Saumajitt/threatLog/pkg/validator"
+	"github.com/rs/zerolog/log"
+)
+
+// exportWriteTimeout bounds each write to an export client. Exports outlast
+// the server's write timeout, so every write gets its own deadline instead.
+const exportWriteTimeout = 30 * time.Second
+
+// HandleExport streams every log matching the query filters as NDJSON, CSV
+// or Parquet, optionally gzipped. The format comes from the format
+// parameter or else the Accept header, and defaults to NDJSON.
+func (h *QueryHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	req, err := parseQueryFilters(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_parameter", err.Error(), nil)
+		return
+	}
+
+	format := queryParams.Get("format")
+	if format == "" {
+		format = negotiateExportFormat(r.Header.Get("Accept"))
+	}
+	if export.ContentType(format) == "" {
+		h.respondError(w, http.StatusBadRequest, "invalid_parameter", export.ErrUnknownFormat.Error(), nil)
+		return
+	}
+
+	// Parquet compresses its own columns, so gzip would only add overhead
+	compress := queryParams.Get("gzip") == "true"
+	if compress && format == export.FormatParquet {
+		h.respondError(w, http.StatusBadRequest, "invalid_parameter", "gzip is not supported for parquet exports", nil)
+		return
+	}
+
+	// Validate request
+	if err := validator.ValidateExportRequest(req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "validation_failed", err.Error(), searchErrorDetails(err))
+		return
+	}
+
+	// Headers are sent with the first bytes of the export, so errors before
+	// any row is written still get a proper error response
+	contentType := export.ContentType(format)
+	filename := "logs." + format
+	if compress {
+		contentType = "application/gzip"
+		filename += ".gz"
+	}
+	out := &exportResponse{
+		w:           w,
+		rc:          http.NewResponseController(w),
+		contentType: contentType,
+		filename:    filename,
+	}
+
+	var dst io.Writer = out
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(out)
+		dst = gz
+	}
+
+	enc, err := export.NewWriter(format, dst)
+	if err == nil {
+		err = h.queryService.ExportLogs(r.Context(), req, enc.Write)
+	}
+	if err == nil {
+		err = enc.Close()
+	}
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if err == nil && !out.started {
+		// Nothing was buffered, so send the empty export's headers
+		out.start()
+	}
+
+	if err != nil {
+		if r.Context().Err() != nil {
+			log.Debug().Err(err).Msg("Export cancelled by client")
+			return
+		}
+		log.Error().Err(err).Msg("Failed to export logs")
+		if !out.started {
+			h.respondError(w, http.StatusInternalServerError, "export_failed", "Failed to export logs", nil)
+			return
+		}
+		// The status is already sent; drop the connection so the client
+		// sees a truncated export rather than a complete-looking one
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// negotiateExportFormat picks the first export format named in an Accept
+// header, defaulting to NDJSON
+func negotiateExportFormat(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if format := export.FormatForMediaType(mediaType); format != "" {
+			return format
+		}
+	}
+	return export.FormatNDJSON
+}
+
+// exportResponse sends the export headers on the first write and gives each
+// write its own deadline
+type exportResponse struct {
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (e *exportResponse) start() {
+	e.started = true
+	e.w.Header().Set("Content-Type", e.contentType)
+	e.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": e.filename}))
+	e.w.Header().Set("Cache-Control", "no-store")
+	e.w.Header().Set("X-Accel-Buffering", "no")
+	e.w.WriteHeader(http.StatusOK)
+}
+
+func (e *exportResponse) Write(p []byte) (int, error) {
+	if !e.started {
+		e.start()
+	}
+	e.rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+	return e.w.Write(p)
+}
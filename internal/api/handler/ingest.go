@@ -2,12 +2,17 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
 	"github.com/Saumajitt/threatLog/internal/model"
 	"github.com/Saumajitt/threatLog/internal/service"
+	"github.com/Saumajitt/threatLog/internal/tenant"
 	"github.com/Saumajitt/threatLog/pkg/validator"
 )
 
@@ -45,14 +50,28 @@ func (h *IngestHandler) HandleIngest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	idempotencyKey, ok := h.idempotencyKey(w, r)
+	if !ok {
+		return
+	}
+
 	// Ingest log
-	response, err := h.ingestionService.IngestLog(req)
+	response, err := h.ingestionService.IngestLog(r.Context(), custommw.TenantFromContext(r.Context()), idempotencyKey, req)
 	if err != nil {
+		if h.respondQuotaError(w, err) {
+			return
+		}
 		log.Error().Err(err).Msg("Failed to ingest log")
 		h.respondError(w, http.StatusInternalServerError, "ingestion_failed", "Failed to ingest log", nil)
 		return
 	}
 
+	// A retried event was already created
+	if response.Status == model.IngestStatusDuplicate {
+		h.respondJSON(w, http.StatusOK, response)
+		return
+	}
+
 	h.respondJSON(w, http.StatusCreated, response)
 }
 
@@ -84,9 +103,17 @@ func (h *IngestHandler) HandleBatchIngest(w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	idempotencyKey, ok := h.idempotencyKey(w, r)
+	if !ok {
+		return
+	}
+
 	// Ingest batch
-	response, err := h.ingestionService.IngestBatch(req)
+	response, err := h.ingestionService.IngestBatch(r.Context(), custommw.TenantFromContext(r.Context()), idempotencyKey, req)
 	if err != nil {
+		if h.respondQuotaError(w, err) {
+			return
+		}
 		log.Error().Err(err).Msg("Failed to ingest batch")
 		h.respondError(w, http.StatusInternalServerError, "ingestion_failed", "Failed to ingest batch", nil)
 		return
@@ -95,6 +122,39 @@ func (h *IngestHandler) HandleBatchIngest(w http.ResponseWriter, r *http.Request
 	h.respondJSON(w, http.StatusAccepted, response)
 }
 
+// idempotencyKey returns the validated Idempotency-Key header, if any
+func (h *IngestHandler) idempotencyKey(w http.ResponseWriter, r *http.Request) (string, bool) {
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		return "", true
+	}
+	if err := validator.ValidateEventID(key); err != nil {
+		h.respondError(w, http.StatusUnprocessableEntity, "validation_failed", "Invalid Idempotency-Key header", map[string]interface{}{
+			"header": "Idempotency-Key",
+		})
+		return "", false
+	}
+	return key, true
+}
+
+// respondQuotaError responds 429 with Retry-After when err is a quota error
+func (h *IngestHandler) respondQuotaError(w http.ResponseWriter, err error) bool {
+	var quotaErr *tenant.QuotaError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(quotaErr.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	h.respondError(w, http.StatusTooManyRequests, "quota_exceeded", "Tenant ingestion rate exceeded", map[string]interface{}{
+		"retry_after_seconds": retryAfter,
+	})
+	return true
+}
+
 func (h *IngestHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
 	"github.com/Saumajitt/threatLog/internal/service"
 )
 
@@ -19,7 +20,7 @@ func NewMetricsHandler(metricsService *service.MetricsService) *MetricsHandler {
 
 // HandleMetrics returns system metrics
 func (h *MetricsHandler) HandleMetrics(w http.ResponseWriter, r *http.Request) {
-	metrics := h.metricsService.GetMetrics()
+	metrics := h.metricsService.GetMetrics(custommw.AdminScope(r.Context()))
 	
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/notify"
+	"github.com/Saumajitt/threatLog/internal/service"
+)
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// HandleListChannels lists the configured notification channels
+func (h *NotificationHandler) HandleListChannels(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOperator(w, r) {
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, h.notificationService.ListChannels())
+}
+
+// HandleListDeliveries lists the latest notification deliveries
+func (h *NotificationHandler) HandleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOperator(w, r) {
+		return
+	}
+
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed <= 0 || parsed > 500 {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "limit must be between 1 and 500", nil)
+			return
+		}
+		limit = parsed
+	}
+
+	response, err := h.notificationService.ListDeliveries(r.Context(), r.URL.Query().Get("channel"), limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list notification deliveries")
+		h.respondError(w, http.StatusInternalServerError, "query_failed", "Failed to list notification deliveries", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// HandleTest sends a test notification through a channel and returns its delivery
+func (h *NotificationHandler) HandleTest(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOperator(w, r) {
+		return
+	}
+
+	delivery, err := h.notificationService.Test(r.Context(), chi.URLParam(r, "name"))
+	switch {
+	case errors.Is(err, service.ErrNotificationsDisabled):
+		h.respondError(w, http.StatusConflict, "notifications_disabled", "Notifications are disabled", nil)
+		return
+	case errors.Is(err, notify.ErrUnknownChannel):
+		h.respondError(w, http.StatusNotFound, "not_found", "Notification channel not found", nil)
+		return
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to send test notification")
+		h.respondError(w, http.StatusInternalServerError, "test_failed", "Failed to send test notification", nil)
+		return
+	}
+
+	// A failed delivery is still a successful test; the delivery says why it failed
+	h.respondJSON(w, http.StatusOK, delivery)
+}
+
+// requireOperator rejects tenant admins; channels are shared by every tenant
+func (h *NotificationHandler) requireOperator(w http.ResponseWriter, r *http.Request) bool {
+	if custommw.AdminScope(r.Context()) != "" {
+		h.respondError(w, http.StatusForbidden, "forbidden", "Notifications are managed by operators", nil)
+		return false
+	}
+	return true
+}
+
+func (h *NotificationHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *NotificationHandler) respondError(w http.ResponseWriter, status int, error, message string, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model.ErrorResponse{
+		Error:   error,
+		Message: message,
+		Details: details,
+	})
+}
@@ -2,14 +2,20 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
 	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
 	"github.com/Saumajitt/threatLog/internal/service"
+	"github.com/Saumajitt/threatLog/pkg/searchql"
 	"github.com/Saumajitt/threatLog/pkg/validator"
 )
 
@@ -39,30 +45,18 @@ func (h *QueryHandler) HandleQuery(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
 	queryParams := r.URL.Query()
 
-	// Parse timestamps
-	startTime, err := validator.ParseTimestamp(queryParams.Get("start_time"))
+	filters, err := parseQueryFilters(r)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid start_time", nil)
+		h.respondError(w, http.StatusBadRequest, "invalid_parameter", err.Error(), nil)
 		return
 	}
 
-	endTime, err := validator.ParseTimestamp(queryParams.Get("end_time"))
-	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid end_time", nil)
-		return
-	}
+	// Parse sort order
+	sort := queryParams.Get("sort")
 
-	// Parse severity (comma-separated)
-	var severities []string
-	if sev := queryParams.Get("severity"); sev != "" {
-		severities = strings.Split(sev, ",")
-		for i := range severities {
-			severities[i] = strings.TrimSpace(severities[i])
-		}
-	}
-
-	// Parse source
-	source := queryParams.Get("source")
+	// Parse pagination cursor and count mode
+	cursor := queryParams.Get("cursor")
+	count := queryParams.Get("count")
 
 	// Parse limit
 	limit := 100
@@ -81,18 +75,16 @@ func (h *QueryHandler) HandleQuery(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Build query request
-	req := model.QueryRequest{
-		StartTime: startTime,
-		EndTime:   endTime,
-		Severity:  severities,
-		Source:    source,
-		Limit:     limit,
-		Offset:    offset,
-	}
+	req := filters
+	req.Sort = sort
+	req.Cursor = cursor
+	req.Count = count
+	req.Limit = limit
+	req.Offset = offset
 
 	// Validate request
 	if err := validator.ValidateQueryRequest(req); err != nil {
-		h.respondError(w, http.StatusBadRequest, "validation_failed", err.Error(), nil)
+		h.respondError(w, http.StatusBadRequest, "validation_failed", err.Error(), searchErrorDetails(err))
 		return
 	}
 
@@ -107,6 +99,204 @@ func (h *QueryHandler) HandleQuery(w http.ResponseWriter, r *http.Request) {
 	h.respondJSON(w, http.StatusOK, response)
 }
 
+// HandleAggregate handles time-bucketed counts, top values and cardinality
+// estimates over the logs matching the query filters
+func (h *QueryHandler) HandleAggregate(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	cacheHit := false
+	defer func() {
+		h.metricsService.RecordQuery(time.Since(start), cacheHit)
+	}()
+
+	queryParams := r.URL.Query()
+
+	filters, err := parseQueryFilters(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_parameter", err.Error(), nil)
+		return
+	}
+
+	// Parse top, the number of values returned per group-by field
+	top := 10
+	if t := queryParams.Get("top"); t != "" {
+		parsed, err := strconv.Atoi(t)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid top", nil)
+			return
+		}
+		top = parsed
+	}
+
+	req := model.AggregateRequest{
+		Filter:      filters,
+		Interval:    queryParams.Get("interval"),
+		GroupBy:     splitList(queryParams.Get("group_by")),
+		Top:         top,
+		Cardinality: splitList(queryParams.Get("cardinality")),
+	}
+
+	// Validate request
+	if err := validator.ValidateAggregateRequest(req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "validation_failed", err.Error(), searchErrorDetails(err))
+		return
+	}
+
+	response, err := h.queryService.AggregateLogs(r.Context(), req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to aggregate logs")
+		h.respondError(w, http.StatusInternalServerError, "query_failed", "Failed to aggregate logs", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// HandleGet returns a single log by ID
+func (h *QueryHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.logID(w, r)
+	if !ok {
+		return
+	}
+
+	event, err := h.queryService.GetLog(r.Context(), custommw.TenantFromContext(r.Context()), id)
+	if err != nil {
+		h.respondLogError(w, err, "Failed to get log")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, event)
+}
+
+// HandleContext returns a log with the events from the same source
+// immediately before and after it
+func (h *QueryHandler) HandleContext(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.logID(w, r)
+	if !ok {
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	before := model.DefaultContextEvents
+	if b := queryParams.Get("before"); b != "" {
+		parsed, err := strconv.Atoi(b)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid before", nil)
+			return
+		}
+		before = parsed
+	}
+
+	after := model.DefaultContextEvents
+	if a := queryParams.Get("after"); a != "" {
+		parsed, err := strconv.Atoi(a)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid after", nil)
+			return
+		}
+		after = parsed
+	}
+
+	if err := validator.ValidateLogContext(before, after); err != nil {
+		h.respondError(w, http.StatusBadRequest, "validation_failed", err.Error(), nil)
+		return
+	}
+
+	response, err := h.queryService.GetLogContext(r.Context(), custommw.TenantFromContext(r.Context()), id, before, after)
+	if err != nil {
+		h.respondLogError(w, err, "Failed to get log context")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+func (h *QueryHandler) logID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(id); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid log id", nil)
+		return "", false
+	}
+	return id, true
+}
+
+func (h *QueryHandler) respondLogError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, repository.ErrNotFound) {
+		h.respondError(w, http.StatusNotFound, "not_found", "Log not found", nil)
+		return
+	}
+
+	log.Error().Err(err).Msg(message)
+	h.respondError(w, http.StatusInternalServerError, "query_failed", message, nil)
+}
+
+// parseQueryFilters reads the time range and filters shared by queries and
+// aggregations for the caller's tenant
+func parseQueryFilters(r *http.Request) (model.QueryRequest, error) {
+	queryParams := r.URL.Query()
+
+	// Parse timestamps
+	startTime, err := validator.ParseTimestamp(queryParams.Get("start_time"))
+	if err != nil {
+		return model.QueryRequest{}, errors.New("Invalid start_time")
+	}
+
+	endTime, err := validator.ParseTimestamp(queryParams.Get("end_time"))
+	if err != nil {
+		return model.QueryRequest{}, errors.New("Invalid end_time")
+	}
+
+	// Parse attribute filters (attr.<key>=<value> and attr_exists=<key>,<key>)
+	var attributes map[string]string
+	for param, values := range queryParams {
+		if key, ok := strings.CutPrefix(param, "attr."); ok && len(values) > 0 {
+			if attributes == nil {
+				attributes = make(map[string]string)
+			}
+			attributes[key] = values[0]
+		}
+	}
+
+	return model.QueryRequest{
+		TenantID:        custommw.TenantFromContext(r.Context()),
+		StartTime:       startTime,
+		EndTime:         endTime,
+		Severity:        splitList(queryParams.Get("severity")),
+		Source:          queryParams.Get("source"),
+		Attributes:      attributes,
+		AttributeExists: splitList(queryParams.Get("attr_exists")),
+		Query:           strings.TrimSpace(queryParams.Get("q")),
+		Search:          queryParams.Get("search"),
+	}, nil
+}
+
+// searchErrorDetails locates a search language error for the response
+func searchErrorDetails(err error) map[string]interface{} {
+	var parseErr *searchql.ParseError
+	if !errors.As(err, &parseErr) {
+		return nil
+	}
+	return map[string]interface{}{
+		"parameter": "search",
+		"position":  parseErr.Pos,
+		"token":     parseErr.Token,
+		"reason":    parseErr.Message,
+	}
+}
+
+// splitList splits a comma-separated parameter, trimming each item
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	items := strings.Split(value, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
 func (h *QueryHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+
+	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/service"
+)
+
+type RetentionHandler struct {
+	retentionService *service.RetentionService
+}
+
+func NewRetentionHandler(retentionService *service.RetentionService) *RetentionHandler {
+	return &RetentionHandler{
+		retentionService: retentionService,
+	}
+}
+
+// HandleListRuns lists the latest retention runs
+func (h *RetentionHandler) HandleListRuns(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOperator(w, r) {
+		return
+	}
+
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed <= 0 || parsed > 100 {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "limit must be between 1 and 100", nil)
+			return
+		}
+		limit = parsed
+	}
+
+	response, err := h.retentionService.ListRuns(r.Context(), limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list retention runs")
+		h.respondError(w, http.StatusInternalServerError, "query_failed", "Failed to list retention runs", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// HandleRun queues a retention run
+func (h *RetentionHandler) HandleRun(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOperator(w, r) {
+		return
+	}
+
+	if err := h.retentionService.Trigger(); err != nil {
+		if errors.Is(err, service.ErrRetentionDisabled) {
+			h.respondError(w, http.StatusConflict, "retention_disabled", "Retention is disabled", nil)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "run_failed", "Failed to queue retention run", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, map[string]string{"status": "queued"})
+}
+
+// requireOperator rejects tenant admins; retention spans every tenant
+func (h *RetentionHandler) requireOperator(w http.ResponseWriter, r *http.Request) bool {
+	if custommw.AdminScope(r.Context()) != "" {
+		h.respondError(w, http.StatusForbidden, "forbidden", "Retention is managed by operators", nil)
+		return false
+	}
+	return true
+}
+
+func (h *RetentionHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *RetentionHandler) respondError(w http.ResponseWriter, status int, error, message string, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model.ErrorResponse{
+		Error:   error,
+		Message: message,
+		Details: details,
+	})
+}
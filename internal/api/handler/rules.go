@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+
+	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/service"
+	"github.com/Saumajitt/threatLog/pkg/validator"
+)
+
+// maxSigmaBodySize limits Sigma import and translate payloads
+const maxSigmaBodySize = 1 << 20
+
+type RuleHandler struct {
+	ruleService *service.RuleService
+}
+
+func NewRuleHandler(ruleService *service.RuleService) *RuleHandler {
+	return &RuleHandler{
+		ruleService: ruleService,
+	}
+}
+
+// HandleList lists rules
+func (h *RuleHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	response, err := h.ruleService.ListRules(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list rules")
+		h.respondError(w, http.StatusInternalServerError, "query_failed", "Failed to list rules", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// HandleGet returns a single rule
+func (h *RuleHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.ruleID(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.ruleService.GetRule(r.Context(), id)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to get rule")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, rule)
+}
+
+// HandleCreate creates a rule
+func (h *RuleHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOperator(w, r) {
+		return
+	}
+
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.ruleService.CreateRule(r.Context(), req)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to create rule")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, rule)
+}
+
+// HandleUpdate replaces a rule
+func (h *RuleHandler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOperator(w, r) {
+		return
+	}
+
+	id, ok := h.ruleID(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.ruleService.UpdateRule(r.Context(), id, req)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to update rule")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, rule)
+}
+
+// HandleDelete deletes a rule
+func (h *RuleHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOperator(w, r) {
+		return
+	}
+
+	id, ok := h.ruleID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ruleService.DeleteRule(r.Context(), id); err != nil {
+		h.respondServiceError(w, err, "Failed to delete rule")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleTranslateSigma translates Sigma YAML without storing it
+func (h *RuleHandler) HandleTranslateSigma(w http.ResponseWriter, r *http.Request) {
+	data, ok := h.readSigma(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.ruleService.TranslateSigma(data)
+	if err != nil {
+		h.respondSigmaError(w, err, "Failed to translate sigma rules")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// HandleImportSigma imports Sigma YAML as sigma rules
+func (h *RuleHandler) HandleImportSigma(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOperator(w, r) {
+		return
+	}
+
+	enabled := true
+	if v := r.URL.Query().Get("enabled"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid enabled parameter", nil)
+			return
+		}
+		enabled = parsed
+	}
+	schedule := r.URL.Query().Get("schedule")
+
+	data, ok := h.readSigma(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.ruleService.ImportSigma(r.Context(), data, enabled, schedule)
+	if err != nil {
+		h.respondSigmaError(w, err, "Failed to import sigma rules")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// requireOperator rejects tenant admins; rules apply to every tenant
+func (h *RuleHandler) requireOperator(w http.ResponseWriter, r *http.Request) bool {
+	if custommw.AdminScope(r.Context()) != "" {
+		h.respondError(w, http.StatusForbidden, "forbidden", "Detection rules are managed by operators", nil)
+		return false
+	}
+	return true
+}
+
+// readSigma reads a Sigma YAML request body
+func (h *RuleHandler) readSigma(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSigmaBodySize))
+	if err != nil {
+		h.respondError(w, http.StatusRequestEntityTooLarge, "invalid_request", "Sigma payload exceeds 1MB", nil)
+		return nil, false
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Empty Sigma payload", nil)
+		return nil, false
+	}
+	return data, true
+}
+
+func (h *RuleHandler) ruleID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(id); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid rule id", nil)
+		return "", false
+	}
+	return id, true
+}
+
+func (h *RuleHandler) decodeRequest(w http.ResponseWriter, r *http.Request) (model.RuleRequest, bool) {
+	var req model.RuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON payload", nil)
+		return req, false
+	}
+
+	if err := validator.ValidateRuleRequest(req); err != nil {
+		h.respondError(w, http.StatusUnprocessableEntity, "validation_failed", err.Error(), nil)
+		return req, false
+	}
+
+	return req, true
+}
+
+func (h *RuleHandler) respondServiceError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, repository.ErrNotFound) {
+		h.respondError(w, http.StatusNotFound, "not_found", "Rule not found", nil)
+		return
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		h.respondError(w, http.StatusConflict, "conflict", "A rule with this name already exists", nil)
+		return
+	}
+
+	log.Error().Err(err).Msg(message)
+	h.respondError(w, http.StatusInternalServerError, "rule_operation_failed", message, nil)
+}
+
+func (h *RuleHandler) respondSigmaError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, service.ErrInvalidSigmaPayload) {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", err.Error(), nil)
+		return
+	}
+	h.respondServiceError(w, err, message)
+}
+
+func (h *RuleHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *RuleHandler) respondError(w http.ResponseWriter, status int, error, message string, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model.ErrorResponse{
+		Error:   error,
+		Message: message,
+		Details: details,
+	})
+}
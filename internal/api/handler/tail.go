@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/tail"
+	"github.com/Saumajitt/threatLog/pkg/validator"
+)
+
+// tailWriteTimeout bounds each write to a tail client
+const tailWriteTimeout = 10 * time.Second
+
+type TailHandler struct {
+	hub       *tail.Hub
+	heartbeat time.Duration
+}
+
+// NewTailHandler creates a tail handler. hub may be nil when live tail is disabled.
+func NewTailHandler(hub *tail.Hub, heartbeat time.Duration) *TailHandler {
+	return &TailHandler{
+		hub:       hub,
+		heartbeat: heartbeat,
+	}
+}
+
+// HandleTail streams matching events as they are stored, over Server-Sent
+// Events or, for upgrade requests, a WebSocket
+func (h *TailHandler) HandleTail(w http.ResponseWriter, r *http.Request) {
+	if h.hub == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "tail_disabled", "Live tail is disabled", nil)
+		return
+	}
+
+	req := parseTailRequest(r)
+	if err := validator.ValidateTailRequest(req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "validation_failed", err.Error(), nil)
+		return
+	}
+
+	filter, err := tail.NewFilter(req)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "validation_failed", err.Error(), nil)
+		return
+	}
+
+	sub, err := h.hub.Subscribe(filter)
+	if err != nil {
+		h.respondError(w, http.StatusServiceUnavailable, "tail_unavailable", err.Error(), nil)
+		return
+	}
+	defer sub.Close()
+
+	if tail.IsWebSocketUpgrade(r) {
+		h.serveWebSocket(w, r, sub)
+		return
+	}
+	h.serveSSE(w, r, sub)
+}
+
+func (h *TailHandler) serveSSE(w http.ResponseWriter, r *http.Request, sub *tail.Subscription) {
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// write sends one SSE message, bounded by its own deadline instead of
+	// the server's write timeout
+	write := func(format string, args ...interface{}) bool {
+		rc.SetWriteDeadline(time.Now().Add(tailWriteTimeout))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	if !write(": tailing\n\n") {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event := <-sub.Events():
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if !write("id: %s\nevent: log\ndata: %s\n\n", event.ID, data) {
+				return
+			}
+		case <-heartbeat.C:
+			if !write(": ping\n\n") {
+				return
+			}
+		case <-sub.Done():
+			if err := sub.Err(); err != nil {
+				data, _ := json.Marshal(model.ErrorResponse{Error: tailErrorCode(err), Message: err.Error()})
+				write("event: error\ndata: %s\n\n", data)
+			}
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (h *TailHandler) serveWebSocket(w http.ResponseWriter, r *http.Request, sub *tail.Subscription) {
+	conn, err := tail.Upgrade(w, r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", err.Error(), nil)
+		return
+	}
+	defer conn.Close()
+
+	clientGone := make(chan struct{})
+	go func() {
+		defer close(clientGone)
+		conn.ReadLoop()
+	}()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event := <-sub.Events():
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if err := conn.WriteText(data, tailWriteTimeout); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.Ping(tailWriteTimeout); err != nil {
+				return
+			}
+		case <-sub.Done():
+			code := tail.CloseNormal
+			if errors.Is(sub.Err(), tail.ErrSlowConsumer) {
+				code = tail.ClosePolicyViolation
+			} else if errors.Is(sub.Err(), tail.ErrHubStopped) {
+				code = tail.CloseGoingAway
+			}
+			reason := ""
+			if sub.Err() != nil {
+				reason = sub.Err().Error()
+			}
+			conn.CloseWithCode(code, reason, tailWriteTimeout)
+			return
+		case <-clientGone:
+			return
+		}
+	}
+}
+
+// parseTailRequest reads the query-compatible tail filters for the caller's tenant
+func parseTailRequest(r *http.Request) model.TailRequest {
+	queryParams := r.URL.Query()
+
+	req := model.TailRequest{TenantID: custommw.TenantFromContext(r.Context())}
+	if sev := queryParams.Get("severity"); sev != "" {
+		for _, s := range strings.Split(sev, ",") {
+			req.Severity = append(req.Severity, strings.TrimSpace(s))
+		}
+	}
+	req.Source = queryParams.Get("source")
+	req.Query = strings.TrimSpace(queryParams.Get("q"))
+
+	for param, values := range queryParams {
+		if key, ok := strings.CutPrefix(param, "attr."); ok && len(values) > 0 {
+			if req.Attributes == nil {
+				req.Attributes = make(map[string]string)
+			}
+			req.Attributes[key] = values[0]
+		}
+	}
+	if exists := queryParams.Get("attr_exists"); exists != "" {
+		for _, key := range strings.Split(exists, ",") {
+			req.AttributeExists = append(req.AttributeExists, strings.TrimSpace(key))
+		}
+	}
+
+	return req
+}
+
+func tailErrorCode(err error) string {
+	switch {
+	case errors.Is(err, tail.ErrSlowConsumer):
+		return "slow_consumer"
+	case errors.Is(err, tail.ErrHubStopped):
+		return "shutting_down"
+	default:
+		return "tail_ended"
+	}
+}
+
+func (h *TailHandler) respondError(w http.ResponseWriter, status int, error, message string, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model.ErrorResponse{
+		Error:   error,
+		Message: message,
+		Details: details,
+	})
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/service"
+	"github.com/Saumajitt/threatLog/pkg/validator"
+)
+
+// maxFeedBodySize limits uploaded threat feeds
+const maxFeedBodySize = 64 << 20
+
+type ThreatIntelHandler struct {
+	threatIntelService *service.ThreatIntelService
+}
+
+func NewThreatIntelHandler(threatIntelService *service.ThreatIntelService) *ThreatIntelHandler {
+	return &ThreatIntelHandler{
+		threatIntelService: threatIntelService,
+	}
+}
+
+// HandleListFeeds lists the loaded threat feeds
+func (h *ThreatIntelHandler) HandleListFeeds(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOperator(w, r) {
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, h.threatIntelService.ListFeeds())
+}
+
+// HandleUploadFeed stores the request body as a feed and loads it
+func (h *ThreatIntelHandler) HandleUploadFeed(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOperator(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	upload := model.ThreatFeedUpload{
+		Name:      chi.URLParam(r, "name"),
+		Format:    query.Get("format"),
+		Severity:  query.Get("severity"),
+		ExpiresIn: query.Get("expires_in"),
+	}
+	if err := validator.ValidateThreatFeedUpload(upload); err != nil {
+		h.respondError(w, http.StatusUnprocessableEntity, "validation_failed", err.Error(), nil)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxFeedBodySize))
+	if err != nil {
+		h.respondError(w, http.StatusRequestEntityTooLarge, "invalid_request", "Feed exceeds 64MB", nil)
+		return
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Empty feed", nil)
+		return
+	}
+
+	feed, err := h.threatIntelService.UploadFeed(r.Context(), upload, data, custommw.ActorFromContext(r.Context()))
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to upload threat feed")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, feed)
+}
+
+// HandleExpireFeed unloads an uploaded feed
+func (h *ThreatIntelHandler) HandleExpireFeed(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOperator(w, r) {
+		return
+	}
+
+	if err := h.threatIntelService.ExpireFeed(r.Context(), chi.URLParam(r, "name")); err != nil {
+		h.respondServiceError(w, err, "Failed to expire threat feed")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ThreatIntelHandler) respondServiceError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrThreatIntelDisabled):
+		h.respondError(w, http.StatusConflict, "threat_intel_disabled", "Threat intel is disabled", nil)
+	case errors.Is(err, service.ErrFeedConfigured):
+		h.respondError(w, http.StatusConflict, "feed_configured", "Feed is read from a configured file", nil)
+	case errors.Is(err, service.ErrInvalidFeed):
+		h.respondError(w, http.StatusUnprocessableEntity, "validation_failed", err.Error(), nil)
+	case errors.Is(err, repository.ErrNotFound):
+		h.respondError(w, http.StatusNotFound, "not_found", "Threat feed not found", nil)
+	default:
+		log.Error().Err(err).Msg(message)
+		h.respondError(w, http.StatusInternalServerError, "feed_operation_failed", message, nil)
+	}
+}
+
+// requireOperator rejects tenant admins; feeds apply to every tenant
+func (h *ThreatIntelHandler) requireOperator(w http.ResponseWriter, r *http.Request) bool {
+	if custommw.AdminScope(r.Context()) != "" {
+		h.respondError(w, http.StatusForbidden, "forbidden", "Threat feeds are managed by operators", nil)
+		return false
+	}
+	return true
+}
+
+func (h *ThreatIntelHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *ThreatIntelHandler) respondError(w http.ResponseWriter, status int, error, message string, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model.ErrorResponse{
+		Error:   error,
+		Message: message,
+		Details: details,
+	})
+}
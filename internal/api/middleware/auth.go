@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/service"
+)
+
+// Authenticator resolves a bearer token, or a live tail token, to an
+// identity. Unknown, revoked and expired keys and tokens return
+// service.ErrInvalidAPIKey.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*model.Identity, error)
+	AuthenticateTailToken(token string) (*model.Identity, error)
+}
+
+type contextKey int
+
+const (
+	identityKey contextKey = iota
+	identitySlotKey
+)
+
+// identitySlot lets Logger, which runs before authentication, see the
+// identity that Authenticate attaches further down the chain
+type identitySlot struct {
+	identity *model.Identity
+}
+
+// IdentityFromContext returns the authenticated identity of a request, if any
+func IdentityFromContext(ctx context.Context) (*model.Identity, bool) {
+	identity, ok := ctx.Value(identityKey).(*model.Identity)
+	return identity, ok
+}
+
+// TenantFromContext returns the tenant of the authenticated caller, or the
+// default tenant when the request is not authenticated
+func TenantFromContext(ctx context.Context) string {
+	if identity, ok := IdentityFromContext(ctx); ok && identity.TenantID != "" {
+		return identity.TenantID
+	}
+	return model.DefaultTenant
+}
+
+// ActorFromContext names the caller of a request for audit trails: the API
+// key name, or its ID when unnamed. It is "anonymous" when authentication
+// is disabled.
+func ActorFromContext(ctx context.Context) string {
+	identity, ok := IdentityFromContext(ctx)
+	if !ok {
+		return "anonymous"
+	}
+	if identity.Name != "" {
+		return identity.Name
+	}
+	return identity.KeyID
+}
+
+// AdminScope returns the tenant an admin request is limited to. It is empty,
+// meaning every tenant, for operators and when authentication is disabled.
+func AdminScope(ctx context.Context) string {
+	identity, ok := IdentityFromContext(ctx)
+	if !ok || identity.IsOperator() {
+		return ""
+	}
+	return identity.TenantID
+}
+
+// WithIdentity attaches an identity to a context
+func WithIdentity(ctx context.Context, identity *model.Identity) context.Context {
+	if slot, ok := ctx.Value(identitySlotKey).(*identitySlot); ok {
+		slot.identity = identity
+	}
+	return context.WithValue(ctx, identityKey, identity)
+}
+
+// Authenticate requires a valid "Authorization: Bearer <key>" header and
+// attaches the key's identity to the request context
+func Authenticate(auth Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="threatlog"`)
+				respondAuthError(w, http.StatusUnauthorized, "unauthorized", "Missing bearer token")
+				return
+			}
+
+			identity, err := auth.Authenticate(r.Context(), token)
+			if err != nil {
+				if !errors.Is(err, service.ErrInvalidAPIKey) {
+					log.Error().Err(err).Msg("Failed to authenticate request")
+					respondAuthError(w, http.StatusServiceUnavailable, "auth_unavailable", "Authentication is unavailable")
+					return
+				}
+				w.Header().Set("WWW-Authenticate", `Bearer realm="threatlog", error="invalid_token"`)
+				respondAuthError(w, http.StatusUnauthorized, "unauthorized", "Invalid API key")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+		})
+	}
+}
+
+// AuthenticateTail authenticates like Authenticate, but also accepts a live
+// tail token in the access_token query parameter, as browser EventSource
+// and WebSocket clients cannot set headers
+func AuthenticateTail(auth Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withHeader := Authenticate(auth)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.URL.Query().Get("access_token")
+			if token == "" || r.Header.Get("Authorization") != "" {
+				withHeader.ServeHTTP(w, r)
+				return
+			}
+
+			identity, err := auth.AuthenticateTailToken(token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="threatlog", error="invalid_token"`)
+				respondAuthError(w, http.StatusUnauthorized, "unauthorized", "Invalid or expired access token")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+		})
+	}
+}
+
+// RequireRole rejects requests whose identity does not hold role
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := IdentityFromContext(r.Context())
+			if !ok {
+				respondAuthError(w, http.StatusUnauthorized, "unauthorized", "Missing bearer token")
+				return
+			}
+			if !identity.HasRole(role) {
+				respondAuthError(w, http.StatusForbidden, "forbidden", "API key lacks the "+role+" role")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func respondAuthError(w http.ResponseWriter, status int, error, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model.ErrorResponse{
+		Error:   error,
+		Message: message,
+	})
+}
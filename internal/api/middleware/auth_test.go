@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/service"
+)
+
+type fakeAuthenticator map[string]*model.Identity
+
+func (f fakeAuthenticator) Authenticate(ctx context.Context, token string) (*model.Identity, error) {
+	if token == "broken" {
+		return nil, errors.New("database unavailable")
+	}
+	identity, ok := f[token]
+	if !ok {
+		return nil, service.ErrInvalidAPIKey
+	}
+	return identity, nil
+}
+
+func (f fakeAuthenticator) AuthenticateTailToken(token string) (*model.Identity, error) {
+	if token != "tail-token" {
+		return nil, service.ErrInvalidAPIKey
+	}
+	return &model.Identity{KeyID: "2", Name: "analyst", Roles: []string{model.RoleRead}}, nil
+}
+
+func testAuthenticator() fakeAuthenticator {
+	return fakeAuthenticator{
+		"ingest-key": {KeyID: "1", Name: "shipper", Roles: []string{model.RoleIngest}},
+		"read-key":   {KeyID: "2", Name: "analyst", Roles: []string{model.RoleRead}},
+		"admin-key":  {KeyID: "3", Name: "ops", Roles: []string{model.RoleAdmin}},
+	}
+}
+
+func TestAuthenticateAndRequireRole(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	h := Authenticate(testAuthenticator())(RequireRole(model.RoleRead)(ok))
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"missing header", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic read-key", http.StatusUnauthorized},
+		{"unknown key", "Bearer nope", http.StatusUnauthorized},
+		{"lookup failure", "Bearer broken", http.StatusServiceUnavailable},
+		{"missing role", "Bearer ingest-key", http.StatusForbidden},
+		{"matching role", "Bearer read-key", http.StatusNoContent},
+		{"admin implies role", "bearer admin-key", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/logs/query", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.status == http.StatusUnauthorized {
+				assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAuthenticateTail(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	tail := AuthenticateTail(testAuthenticator())(RequireRole(model.RoleRead)(ok))
+	other := Authenticate(testAuthenticator())(ok)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		query   string
+		header  string
+		status  int
+	}{
+		{"query token", tail, "?access_token=tail-token", "", http.StatusNoContent},
+		{"invalid query token", tail, "?access_token=nope", "", http.StatusUnauthorized},
+		{"api key in query", tail, "?access_token=read-key", "", http.StatusUnauthorized},
+		{"header", tail, "", "Bearer read-key", http.StatusNoContent},
+		{"header wins", tail, "?access_token=tail-token", "Bearer nope", http.StatusUnauthorized},
+		{"missing", tail, "", "", http.StatusUnauthorized},
+		{"other routes", other, "?access_token=tail-token", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/logs/tail"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestRequireRoleWithoutIdentity(t *testing.T) {
+	h := RequireRole(model.RoleRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestIdentityIsLogged(t *testing.T) {
+	var buf bytes.Buffer
+	original := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = original }()
+
+	var seen *model.Identity
+	h := Logger(Authenticate(testAuthenticator())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = IdentityFromContext(r.Context())
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil)
+	req.Header.Set("Authorization", "Bearer admin-key")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if assert.NotNil(t, seen) {
+		assert.Equal(t, "ops", seen.Name)
+	}
+	assert.Contains(t, buf.String(), `"key_id":"3"`)
+	assert.Contains(t, buf.String(), `"key_name":"ops"`)
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -14,18 +15,22 @@ func Logger(next http.Handler) http.Handler {
 		start := time.Now()
 
 		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		slot := &identitySlot{}
 
 		defer func() {
-			log.Info().
+			event := log.Info().
 				Str("method", r.Method).
 				Str("path", r.URL.Path).
 				Str("remote_addr", r.RemoteAddr).
 				Int("status", ww.Status()).
 				Int("bytes", ww.BytesWritten()).
-				Dur("duration_ms", time.Since(start)).
-				Msg("HTTP request")
+				Dur("duration_ms", time.Since(start))
+			if slot.identity != nil {
+				event = event.Str("key_id", slot.identity.KeyID).Str("key_name", slot.identity.Name)
+			}
+			event.Msg("HTTP request")
 		}()
 
-		next.ServeHTTP(ww, r)
+		next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), identitySlotKey, slot)))
 	})
 }
\ No newline at end of file
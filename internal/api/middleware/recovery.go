@@ -13,6 +13,13 @@ func Recovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
+				// Handlers abort responses already under way, such as
+				// streams, with ErrAbortHandler; the server drops the
+				// connection so the client sees a truncated response
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
 				log.Error().
 					Interface("error", err).
 					Str("path", r.URL.Path).
@@ -1,19 +1,32 @@
 package api
 
 import (
+	"net/http"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
 	"github.com/Saumajitt/threatLog/internal/api/handler"
 	custommw "github.com/Saumajitt/threatLog/internal/api/middleware"
+	"github.com/Saumajitt/threatLog/internal/model"
 )
 
 type Router struct {
-	ingestHandler  *handler.IngestHandler
-	queryHandler   *handler.QueryHandler
-	metricsHandler *handler.MetricsHandler
-	healthHandler  *handler.HealthHandler
+	ingestHandler       *handler.IngestHandler
+	queryHandler        *handler.QueryHandler
+	metricsHandler      *handler.MetricsHandler
+	healthHandler       *handler.HealthHandler
+	deadLetterHandler   *handler.DeadLetterHandler
+	ruleHandler         *handler.RuleHandler
+	tailHandler         *handler.TailHandler
+	apiKeyHandler       *handler.APIKeyHandler
+	retentionHandler    *handler.RetentionHandler
+	notificationHandler *handler.NotificationHandler
+	alertHandler        *handler.AlertHandler
+	threatIntelHandler  *handler.ThreatIntelHandler
+	authenticator       custommw.Authenticator
+	allowedOrigins      []string
 }
 
 func NewRouter(
@@ -21,12 +34,32 @@ func NewRouter(
 	queryHandler *handler.QueryHandler,
 	metricsHandler *handler.MetricsHandler,
 	healthHandler *handler.HealthHandler,
+	deadLetterHandler *handler.DeadLetterHandler,
+	ruleHandler *handler.RuleHandler,
+	tailHandler *handler.TailHandler,
+	apiKeyHandler *handler.APIKeyHandler,
+	retentionHandler *handler.RetentionHandler,
+	notificationHandler *handler.NotificationHandler,
+	alertHandler *handler.AlertHandler,
+	threatIntelHandler *handler.ThreatIntelHandler,
+	authenticator custommw.Authenticator,
+	allowedOrigins []string,
 ) *Router {
 	return &Router{
-		ingestHandler:  ingestHandler,
-		queryHandler:   queryHandler,
-		metricsHandler: metricsHandler,
-		healthHandler:  healthHandler,
+		ingestHandler:       ingestHandler,
+		queryHandler:        queryHandler,
+		metricsHandler:      metricsHandler,
+		healthHandler:       healthHandler,
+		deadLetterHandler:   deadLetterHandler,
+		ruleHandler:         ruleHandler,
+		tailHandler:         tailHandler,
+		apiKeyHandler:       apiKeyHandler,
+		retentionHandler:    retentionHandler,
+		notificationHandler: notificationHandler,
+		alertHandler:        alertHandler,
+		threatIntelHandler:  threatIntelHandler,
+		authenticator:       authenticator,
+		allowedOrigins:      allowedOrigins,
 	}
 }
 
@@ -41,31 +74,126 @@ func (rt *Router) Setup() *chi.Mux {
 	r.Use(custommw.Recovery)
 	r.Use(middleware.Compress(5))
 
-	// CORS
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
-		MaxAge:           300,
-	}))
+	// CORS. The handler allows every origin when given none, so without
+	// configured origins it is left out and browsers keep the same-origin
+	// policy.
+	if len(rt.allowedOrigins) > 0 {
+		r.Use(cors.Handler(cors.Options{
+			AllowedOrigins:   rt.allowedOrigins,
+			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key"},
+			ExposedHeaders:   []string{"Link"},
+			AllowCredentials: false,
+			MaxAge:           300,
+		}))
+	}
 
 	// Health check
 	r.Get("/health", rt.healthHandler.HandleHealth)
 
+	// Live tail (SSE or WebSocket). It sits outside /api/v1 so it can also
+	// be opened with a short-lived token in the query string.
+	r.With(rt.authenticateTail(), rt.requireRole(model.RoleRead)).Get("/api/v1/logs/tail", rt.tailHandler.HandleTail)
+
 	// API routes
 	r.Route("/api/v1", func(r chi.Router) {
+		if rt.authenticator != nil {
+			r.Use(custommw.Authenticate(rt.authenticator))
+		}
+
 		// Ingestion endpoints
-		r.Post("/logs/ingest", rt.ingestHandler.HandleIngest)
-		r.Post("/logs/ingest/batch", rt.ingestHandler.HandleBatchIngest)
+		r.Group(func(r chi.Router) {
+			r.Use(rt.requireRole(model.RoleIngest))
+			r.Post("/logs/ingest", rt.ingestHandler.HandleIngest)
+			r.Post("/logs/ingest/batch", rt.ingestHandler.HandleBatchIngest)
+		})
+
+		// Read endpoints
+		r.Group(func(r chi.Router) {
+			r.Use(rt.requireRole(model.RoleRead))
+
+			// Query endpoint
+			r.Get("/logs/query", rt.queryHandler.HandleQuery)
+			r.Get("/logs/aggregate", rt.queryHandler.HandleAggregate)
+			r.Get("/logs/export", rt.queryHandler.HandleExport)
+
+			// Single logs and the events around them
+			r.Get("/logs/{id}", rt.queryHandler.HandleGet)
+			r.Get("/logs/{id}/context", rt.queryHandler.HandleContext)
+
+			// Tokens for opening a live tail without headers
+			r.Post("/logs/tail/token", rt.apiKeyHandler.HandleTailToken)
+
+			// Detection rules
+			r.Get("/rules", rt.ruleHandler.HandleList)
+			r.Get("/rules/{id}", rt.ruleHandler.HandleGet)
+
+			// Alerts
+			r.Get("/alerts", rt.alertHandler.HandleList)
+			r.Get("/alerts/suppressions", rt.alertHandler.HandleListSuppressions)
+			r.Get("/alerts/{id}", rt.alertHandler.HandleGet)
+
+			// Metrics endpoint
+			r.Get("/metrics", rt.metricsHandler.HandleMetrics)
+		})
+
+		// Admin endpoints
+		r.Group(func(r chi.Router) {
+			r.Use(rt.requireRole(model.RoleAdmin))
 
-		// Query endpoint
-		r.Get("/logs/query", rt.queryHandler.HandleQuery)
+			// Detection rule management
+			r.Post("/rules", rt.ruleHandler.HandleCreate)
+			r.Post("/rules/sigma/import", rt.ruleHandler.HandleImportSigma)
+			r.Post("/rules/sigma/translate", rt.ruleHandler.HandleTranslateSigma)
+			r.Put("/rules/{id}", rt.ruleHandler.HandleUpdate)
+			r.Delete("/rules/{id}", rt.ruleHandler.HandleDelete)
 
-		// Metrics endpoint
-		r.Get("/metrics", rt.metricsHandler.HandleMetrics)
+			// Alert triage and suppressions
+			r.Put("/alerts/{id}/status", rt.alertHandler.HandleUpdateStatus)
+			r.Put("/alerts/{id}/assignee", rt.alertHandler.HandleAssign)
+			r.Post("/alerts/{id}/comments", rt.alertHandler.HandleComment)
+			r.Post("/alerts/suppressions", rt.alertHandler.HandleCreateSuppression)
+			r.Delete("/alerts/suppressions/{id}", rt.alertHandler.HandleExpireSuppression)
+
+			r.Get("/admin/deadletter", rt.deadLetterHandler.HandleList)
+			r.Post("/admin/deadletter/replay", rt.deadLetterHandler.HandleReplay)
+
+			// API keys
+			r.Get("/admin/keys", rt.apiKeyHandler.HandleList)
+			r.Post("/admin/keys", rt.apiKeyHandler.HandleCreate)
+			r.Delete("/admin/keys/{id}", rt.apiKeyHandler.HandleRevoke)
+
+			// Retention
+			r.Get("/admin/retention/runs", rt.retentionHandler.HandleListRuns)
+			r.Post("/admin/retention/run", rt.retentionHandler.HandleRun)
+
+			// Notifications
+			r.Get("/admin/notifications/channels", rt.notificationHandler.HandleListChannels)
+			r.Post("/admin/notifications/channels/{name}/test", rt.notificationHandler.HandleTest)
+			r.Get("/admin/notifications/deliveries", rt.notificationHandler.HandleListDeliveries)
+
+			// Threat intel feeds
+			r.Get("/admin/threatintel/feeds", rt.threatIntelHandler.HandleListFeeds)
+			r.Put("/admin/threatintel/feeds/{name}", rt.threatIntelHandler.HandleUploadFeed)
+			r.Delete("/admin/threatintel/feeds/{name}", rt.threatIntelHandler.HandleExpireFeed)
+		})
 	})
 
 	return r
-}
\ No newline at end of file
+}
+
+// authenticateTail authenticates live tail requests when authentication is enabled
+func (rt *Router) authenticateTail() func(http.Handler) http.Handler {
+	if rt.authenticator == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return custommw.AuthenticateTail(rt.authenticator)
+}
+
+// requireRole enforces a role when authentication is enabled
+func (rt *Router) requireRole(role string) func(http.Handler) http.Handler {
+	if rt.authenticator == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return custommw.RequireRole(role)
+}
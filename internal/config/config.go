@@ -9,11 +9,22 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	Server    ServerConfig    `mapstructure:"server"`
-	Postgres  PostgresConfig  `mapstructure:"postgres"`
-	Redis     RedisConfig     `mapstructure:"redis"`
-	Ingestion IngestionConfig `mapstructure:"ingestion"`
-	Cache     CacheConfig     `mapstructure:"cache"`
+	Server        ServerConfig        `mapstructure:"server"`
+	Postgres      PostgresConfig      `mapstructure:"postgres"`
+	Redis         RedisConfig         `mapstructure:"redis"`
+	Ingestion     IngestionConfig     `mapstructure:"ingestion"`
+	Cache         CacheConfig         `mapstructure:"cache"`
+	Syslog        SyslogConfig        `mapstructure:"syslog"`
+	Rules         RulesConfig         `mapstructure:"rules"`
+	Tail          TailConfig          `mapstructure:"tail"`
+	Auth          AuthConfig          `mapstructure:"auth"`
+	Tenants       TenantsConfig       `mapstructure:"tenants"`
+	Partitioning  PartitioningConfig  `mapstructure:"partitioning"`
+	Retention     RetentionConfig     `mapstructure:"retention"`
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+	ThreatIntel   ThreatIntelConfig   `mapstructure:"threat_intel"`
+	GeoIP         GeoIPConfig         `mapstructure:"geoip"`
+	Pipelines     []PipelineConfig    `mapstructure:"pipelines"`
 }
 
 // ServerConfig holds HTTP server configuration
@@ -51,6 +62,41 @@ type IngestionConfig struct {
 	BufferSize   int           `mapstructure:"buffer_size"`
 	BatchSize    int           `mapstructure:"batch_size"`
 	BatchTimeout time.Duration `mapstructure:"batch_timeout"`
+	Spool        SpoolConfig   `mapstructure:"spool"`
+	Retry        RetryConfig   `mapstructure:"retry"`
+	// IdempotencyTTL is how long client-supplied event IDs are remembered
+	IdempotencyTTL time.Duration `mapstructure:"idempotency_ttl"`
+	Dedup          DedupConfig   `mapstructure:"dedup"`
+}
+
+// DedupConfig holds repeated event collapsing configuration
+type DedupConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Window  time.Duration `mapstructure:"window"`
+	// Fields are fingerprinted along with the tenant: severity, source,
+	// message or attr.<key>
+	Fields    []string `mapstructure:"fields"`
+	Normalize bool     `mapstructure:"normalize"`
+	// Sources are globs; empty deduplicates every source
+	Sources    []string `mapstructure:"sources"`
+	MaxEntries int      `mapstructure:"max_entries"`
+}
+
+// RetryConfig holds batch insert retry configuration
+type RetryConfig struct {
+	MaxRetries     int           `mapstructure:"max_retries"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+// SpoolConfig holds on-disk ingestion spool configuration
+type SpoolConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	Dir           string        `mapstructure:"dir"`
+	SegmentSize   int64         `mapstructure:"segment_size"`
+	Fsync         string        `mapstructure:"fsync"`
+	FsyncInterval time.Duration `mapstructure:"fsync_interval"`
+	MaxDiskUsage  int64         `mapstructure:"max_disk_usage"`
 }
 
 // CacheConfig holds cache configuration
@@ -59,6 +105,230 @@ type CacheConfig struct {
 	QueryCacheEnabled bool          `mapstructure:"query_cache_enabled"`
 }
 
+// SyslogConfig holds syslog listener configuration
+type SyslogConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	UDPPort        int           `mapstructure:"udp_port"`
+	TCPPort        int           `mapstructure:"tcp_port"`
+	Framing        string        `mapstructure:"framing"`
+	MaxMessageSize int           `mapstructure:"max_message_size"`
+	ReadTimeout    time.Duration `mapstructure:"read_timeout"`
+	// TenantID owns every event received over syslog
+	TenantID string `mapstructure:"tenant_id"`
+}
+
+// RulesConfig holds detection rule engine configuration
+type RulesConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	ReloadInterval  time.Duration `mapstructure:"reload_interval"`
+	AlertBufferSize int           `mapstructure:"alert_buffer_size"`
+	// ScheduleLag holds scheduled searches back from the current time, so
+	// events stored late are still searched
+	ScheduleLag time.Duration `mapstructure:"schedule_lag"`
+	// MaxLateness is how long after its timestamp an event still counts
+	// towards threshold rules
+	MaxLateness time.Duration `mapstructure:"max_lateness"`
+	Sigma       SigmaConfig   `mapstructure:"sigma"`
+}
+
+// SigmaConfig holds Sigma rule translation configuration
+type SigmaConfig struct {
+	// FieldMapping maps Sigma field names (case-insensitive) onto severity,
+	// source, message or attr.<key>; unmapped fields become attr.<field>
+	FieldMapping map[string]string `mapstructure:"field_mapping"`
+}
+
+// TailConfig holds live tail configuration
+type TailConfig struct {
+	Enabled           bool          `mapstructure:"enabled"`
+	ClientBuffer      int           `mapstructure:"client_buffer"`
+	MaxClients        int           `mapstructure:"max_clients"`
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
+	RedisFanout       bool          `mapstructure:"redis_fanout"`
+	RedisChannel      string        `mapstructure:"redis_channel"`
+}
+
+// AuthConfig holds API authentication configuration
+type AuthConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// BootstrapKey is accepted as an admin key, for creating the first keys
+	BootstrapKey string `mapstructure:"bootstrap_key"`
+	// TokenSecret signs live tail tokens; instances behind a load balancer
+	// must share it
+	TokenSecret        string   `mapstructure:"token_secret"`
+	CORSAllowedOrigins []string `mapstructure:"cors_allowed_origins"`
+}
+
+// TenantsConfig holds per-tenant quotas
+type TenantsConfig struct {
+	// DefaultQuota applies to tenants without an entry in Quotas
+	DefaultQuota QuotaConfig            `mapstructure:"default_quota"`
+	Quotas       map[string]QuotaConfig `mapstructure:"quotas"`
+}
+
+// QuotaConfig holds the quotas of a tenant; zero values mean unlimited
+type QuotaConfig struct {
+	IngestRate  float64       `mapstructure:"ingest_rate"`
+	IngestBurst int           `mapstructure:"ingest_burst"`
+	Retention   time.Duration `mapstructure:"retention"`
+}
+
+// PartitioningConfig holds logs table partition maintenance configuration
+type PartitioningConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Interval string `mapstructure:"interval"`
+	// Premake is how many partitions are kept ahead of the current one
+	Premake int `mapstructure:"premake"`
+	// Retention drops partitions entirely older than it; zero keeps them
+	Retention     time.Duration `mapstructure:"retention"`
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+}
+
+// RetentionConfig holds retention enforcement configuration
+type RetentionConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"`
+	// BatchSize caps the logs removed per statement, BatchPause separates them
+	BatchSize  int           `mapstructure:"batch_size"`
+	BatchPause time.Duration `mapstructure:"batch_pause"`
+	// ArchiveDir receives the gzipped NDJSON files of archive rules
+	ArchiveDir string                `mapstructure:"archive_dir"`
+	Rules      []RetentionRuleConfig `mapstructure:"rules"`
+}
+
+// RetentionRuleConfig holds a retention rule; the first matching rule applies
+type RetentionRuleConfig struct {
+	Name     string   `mapstructure:"name"`
+	Severity []string `mapstructure:"severity"`
+	// Source and Tenant are globs; empty matches every log
+	Source string        `mapstructure:"source"`
+	Tenant string        `mapstructure:"tenant"`
+	MaxAge time.Duration `mapstructure:"max_age"`
+	Action string        `mapstructure:"action"`
+	// LegalHold keeps matching logs from every deletion
+	LegalHold bool `mapstructure:"legal_hold"`
+}
+
+// NotificationsConfig holds alert notification configuration
+type NotificationsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// BufferSize caps the notifications queued per channel
+	BufferSize int                         `mapstructure:"buffer_size"`
+	Channels   []NotificationChannelConfig `mapstructure:"channels"`
+}
+
+// NotificationChannelConfig holds a notification channel
+type NotificationChannelConfig struct {
+	Name string `mapstructure:"name"`
+	// Type is webhook, email, slack or teams
+	Type string `mapstructure:"type"`
+	URL  string `mapstructure:"url"`
+	// Secret signs webhook payloads with HMAC-SHA256
+	Secret       string   `mapstructure:"secret"`
+	SMTPHost     string   `mapstructure:"smtp_host"`
+	SMTPPort     int      `mapstructure:"smtp_port"`
+	SMTPUsername string   `mapstructure:"smtp_username"`
+	SMTPPassword string   `mapstructure:"smtp_password"`
+	From         string   `mapstructure:"from"`
+	To           []string `mapstructure:"to"`
+	// Events sends CRITICAL events, Alerts sends rule alerts
+	Events bool `mapstructure:"events"`
+	Alerts bool `mapstructure:"alerts"`
+	// Tenants are globs; empty notifies every tenant
+	Tenants []string `mapstructure:"tenants"`
+	// Subject and Body are text/template overrides of the default message
+	Subject string `mapstructure:"subject"`
+	Body    string `mapstructure:"body"`
+	// RateLimit is notifications per minute; zero is unlimited
+	RateLimit      float64       `mapstructure:"rate_limit"`
+	Burst          int           `mapstructure:"burst"`
+	MaxRetries     int           `mapstructure:"max_retries"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+	Timeout        time.Duration `mapstructure:"timeout"`
+}
+
+// ThreatIntelConfig holds indicator of compromise matching configuration
+type ThreatIntelConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+	// MaxMatches caps the indicators tagged on a single event
+	MaxMatches int                `mapstructure:"max_matches"`
+	Feeds      []ThreatFeedConfig `mapstructure:"feeds"`
+}
+
+// ThreatFeedConfig holds a feed read from a local file
+type ThreatFeedConfig struct {
+	Name string `mapstructure:"name"`
+	Path string `mapstructure:"path"`
+	// Format is stix, misp or csv
+	Format string `mapstructure:"format"`
+	// Severity raises matching events to at least this severity
+	Severity string `mapstructure:"severity"`
+}
+
+// GeoIPConfig holds GeoIP and ASN enrichment configuration
+type GeoIPConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Databases are MMDB files, such as GeoLite2-City and GeoLite2-ASN
+	Databases []string `mapstructure:"databases"`
+	// Fields are the attributes whose addresses are looked up; empty looks at every attribute
+	Fields      []string `mapstructure:"fields"`
+	ScanMessage bool     `mapstructure:"scan_message"`
+	// MaxAddresses caps the addresses looked up per event
+	MaxAddresses   int           `mapstructure:"max_addresses"`
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+}
+
+// PipelineConfig holds a chain of processors; events go through the first
+// pipeline matching their source and tenant
+type PipelineConfig struct {
+	Name string `mapstructure:"name"`
+	// Sources and Tenants are globs; empty matches every source or tenant
+	Sources    []string          `mapstructure:"sources"`
+	Tenants    []string          `mapstructure:"tenants"`
+	Processors []ProcessorConfig `mapstructure:"processors"`
+}
+
+// ProcessorConfig holds a pipeline processor; only the settings of its type apply
+type ProcessorConfig struct {
+	Name string `mapstructure:"name"`
+	// Type is drop, rename, parse, tag or redact
+	Type string `mapstructure:"type"`
+	// When restricts the processor to events matching every condition
+	When []ConditionConfig `mapstructure:"when"`
+	// OnError is pass_through, drop or dead_letter
+	OnError string `mapstructure:"on_error"`
+	// Rename moves attributes
+	Rename []RenameConfig `mapstructure:"rename"`
+	// Field is parsed as Format (json, kv or regex with Pattern) into Target
+	Field   string `mapstructure:"field"`
+	Format  string `mapstructure:"format"`
+	Pattern string `mapstructure:"pattern"`
+	Target  string `mapstructure:"target"`
+	// Tags are added to the tags attribute, Set sets attributes
+	Tags []string          `mapstructure:"tags"`
+	Set  map[string]string `mapstructure:"set"`
+	// Fields are redacted whole, or only where Patterns match
+	Fields      []string `mapstructure:"fields"`
+	Patterns    []string `mapstructure:"patterns"`
+	Replacement string   `mapstructure:"replacement"`
+}
+
+// ConditionConfig holds a condition on an event field, as in rules
+type ConditionConfig struct {
+	Field    string   `mapstructure:"field"`
+	Operator string   `mapstructure:"operator"`
+	Value    string   `mapstructure:"value"`
+	Values   []string `mapstructure:"values"`
+}
+
+// RenameConfig holds an attribute rename
+type RenameConfig struct {
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+}
+
 // Load loads configuration from file or environment variables
 func Load() (*Config, error) {
 	viper.SetConfigName("config")
@@ -117,10 +387,95 @@ func setDefaults() {
 	viper.SetDefault("ingestion.buffer_size", 10000)
 	viper.SetDefault("ingestion.batch_size", 100)
 	viper.SetDefault("ingestion.batch_timeout", "1s")
+	viper.SetDefault("ingestion.spool.enabled", false)
+	viper.SetDefault("ingestion.spool.dir", "./data/spool")
+	viper.SetDefault("ingestion.spool.segment_size", 64*1024*1024)
+	viper.SetDefault("ingestion.spool.fsync", "interval")
+	viper.SetDefault("ingestion.spool.fsync_interval", "1s")
+	viper.SetDefault("ingestion.spool.max_disk_usage", 1024*1024*1024)
+	viper.SetDefault("ingestion.retry.max_retries", 5)
+	viper.SetDefault("ingestion.retry.initial_backoff", "100ms")
+	viper.SetDefault("ingestion.retry.max_backoff", "10s")
+	viper.SetDefault("ingestion.idempotency_ttl", "24h")
+	viper.SetDefault("ingestion.dedup.enabled", false)
+	viper.SetDefault("ingestion.dedup.window", "60s")
+	viper.SetDefault("ingestion.dedup.fields", []string{"source", "severity", "message"})
+	viper.SetDefault("ingestion.dedup.normalize", true)
+	viper.SetDefault("ingestion.dedup.sources", []string{})
+	viper.SetDefault("ingestion.dedup.max_entries", 100000)
 
 	// Cache defaults
 	viper.SetDefault("cache.ttl", "5m")
 	viper.SetDefault("cache.query_cache_enabled", true)
+
+	// Syslog defaults
+	viper.SetDefault("syslog.enabled", false)
+	viper.SetDefault("syslog.udp_port", 5514)
+	viper.SetDefault("syslog.tcp_port", 5514)
+	viper.SetDefault("syslog.framing", "auto")
+	viper.SetDefault("syslog.max_message_size", 65536)
+	viper.SetDefault("syslog.read_timeout", "5m")
+	viper.SetDefault("syslog.tenant_id", "default")
+
+	// Rules defaults
+	viper.SetDefault("rules.enabled", true)
+	viper.SetDefault("rules.reload_interval", "30s")
+	viper.SetDefault("rules.alert_buffer_size", 1000)
+	viper.SetDefault("rules.schedule_lag", "2m")
+	viper.SetDefault("rules.max_lateness", "5m")
+
+	// Tail defaults
+	viper.SetDefault("tail.enabled", true)
+	viper.SetDefault("tail.client_buffer", 256)
+	viper.SetDefault("tail.max_clients", 100)
+	viper.SetDefault("tail.heartbeat_interval", "15s")
+	viper.SetDefault("tail.redis_fanout", false)
+	viper.SetDefault("tail.redis_channel", "threatlog:tail")
+
+	// Auth defaults
+	viper.SetDefault("auth.enabled", true)
+	viper.SetDefault("auth.bootstrap_key", "")
+	viper.SetDefault("auth.token_secret", "")
+	viper.SetDefault("auth.cors_allowed_origins", []string{})
+
+	// Tenant defaults
+	viper.SetDefault("tenants.default_quota.ingest_rate", 0)
+	viper.SetDefault("tenants.default_quota.ingest_burst", 0)
+	viper.SetDefault("tenants.default_quota.retention", "0s")
+
+	// Partitioning defaults
+	viper.SetDefault("partitioning.enabled", true)
+	viper.SetDefault("partitioning.interval", "daily")
+	viper.SetDefault("partitioning.premake", 3)
+	viper.SetDefault("partitioning.retention", "0s")
+	viper.SetDefault("partitioning.check_interval", "1h")
+
+	// Retention defaults
+	viper.SetDefault("retention.enabled", true)
+	viper.SetDefault("retention.interval", "1h")
+	viper.SetDefault("retention.batch_size", 10000)
+	viper.SetDefault("retention.batch_pause", "100ms")
+	viper.SetDefault("retention.archive_dir", "./data/archive")
+
+	// Notification defaults
+	viper.SetDefault("notifications.enabled", false)
+	viper.SetDefault("notifications.buffer_size", 1000)
+
+	// Threat intel defaults
+	viper.SetDefault("threat_intel.enabled", false)
+	viper.SetDefault("threat_intel.reload_interval", "1m")
+	viper.SetDefault("threat_intel.max_matches", 10)
+
+	// GeoIP defaults
+	viper.SetDefault("geoip.enabled", false)
+	viper.SetDefault("geoip.databases", []string{})
+	viper.SetDefault("geoip.fields", []string{})
+	viper.SetDefault("geoip.scan_message", false)
+	viper.SetDefault("geoip.max_addresses", 10)
+	viper.SetDefault("geoip.reload_interval", "1m")
+
+	// Pipeline defaults
+	viper.SetDefault("pipelines", []any{})
 }
 
 // GetDSN returns PostgreSQL connection string
@@ -134,4 +489,4 @@ func (c *PostgresConfig) GetDSN() string {
 // GetRedisAddr returns Redis address
 func (c *RedisConfig) GetRedisAddr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
-}
\ No newline at end of file
+}
@@ -0,0 +1,454 @@
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/rules"
+)
+
+// maxStatsSources caps the sources with statistics of their own; further
+// sources are counted under otherSources
+const maxStatsSources = 1000
+
+// otherSources collects the statistics of sources beyond maxStatsSources
+const otherSources = "_other"
+
+// topSources is how many sources Stats reports
+const topSources = 50
+
+// entry tracks the stored event that repeats of a fingerprint collapse into
+type entry struct {
+	id        string
+	tenantID  string
+	timestamp time.Time
+	expires   time.Time
+	repeats   int
+	lastSeen  time.Time
+	// event is the stored event, once the worker pool has stored it, and
+	// reported the repeats observers have been told of
+	event    *model.LogEvent
+	reported int
+}
+
+// repeatEvent returns the stored event standing for the repeats not yet
+// reported
+func (e *entry) repeatEvent() model.LogEvent {
+	event := *e.event
+	first, last := e.timestamp, e.lastSeen
+	event.RepeatCount = e.repeats - e.reported
+	event.FirstSeen = &first
+	event.LastSeen = &last
+	return event
+}
+
+// Observer is notified of the repeats collapsed into stored events, so it
+// can count them. Each event is the stored event with RepeatCount set to
+// the repeats since the previous notification. ObserveRepeats runs on the
+// deduper's goroutine and must not block.
+type Observer interface {
+	ObserveRepeats(events []model.LogEvent)
+}
+
+type statsKey struct {
+	tenantID string
+	source   string
+}
+
+// SourceStats holds the deduplication statistics of a source
+type SourceStats struct {
+	TenantID  string `json:"tenant_id"`
+	Source    string `json:"source"`
+	Events    int64  `json:"events"`
+	Collapsed int64  `json:"collapsed"`
+}
+
+// Stats holds deduplication statistics
+type Stats struct {
+	Events       int64 `json:"events"`
+	Collapsed    int64 `json:"collapsed"`
+	Fingerprints int   `json:"tracked_fingerprints"`
+	// MissedUpdates counts repeat counts that found no stored event
+	MissedUpdates int64 `json:"missed_updates"`
+	// Sources lists the sources with the most collapsed events
+	Sources []SourceStats `json:"sources"`
+}
+
+// Deduper collapses identical events into the first one stored. Events are
+// identical when they share the tenant and fingerprint fields; the first
+// event opens a window, and repeats arriving within it are dropped and
+// counted. Observers are told of the repeats every second once the first
+// event is stored, and when the window closes the count is added to the
+// stored event. Counts of open windows are lost on a crash.
+type Deduper struct {
+	repo       *repository.PostgresRepository
+	window     time.Duration
+	fields     []string
+	normalize  bool
+	sources    []string
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	// byID indexes entries by the ID of their stored event
+	byID          map[string]*entry
+	stats         map[statsKey]*SourceStats
+	missedUpdates int64
+
+	observers []Observer
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewDeduper creates a new deduper. fields are fingerprinted in order; with
+// normalize, messages are compared case-insensitively with digits and
+// whitespace runs collapsed. Only sources matching one of the globs are
+// deduplicated, every source when there are none. At most maxEntries
+// windows are open at once.
+func NewDeduper(
+	repo *repository.PostgresRepository,
+	window time.Duration,
+	fields []string,
+	normalize bool,
+	sources []string,
+	maxEntries int,
+) *Deduper {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Deduper{
+		repo:       repo,
+		window:     window,
+		fields:     fields,
+		normalize:  normalize,
+		sources:    sources,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*entry),
+		byID:       make(map[string]*entry),
+		stats:      make(map[statsKey]*SourceStats),
+		ctx:        ctx,
+		cancel:     cancel,
+		now:        time.Now,
+	}
+}
+
+// ValidateFields checks fingerprint fields
+func ValidateFields(fields []string) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("at least one dedup field is required")
+	}
+	for _, field := range fields {
+		switch field {
+		case model.FieldSeverity, model.FieldSource, model.FieldMessage:
+			continue
+		}
+		if key, ok := strings.CutPrefix(field, model.AttributeFieldPrefix); !ok || key == "" {
+			return fmt.Errorf("invalid dedup field %q", field)
+		}
+	}
+	return nil
+}
+
+// AddObserver registers an observer of repeats; it must be called before Start
+func (d *Deduper) AddObserver(o Observer) {
+	d.observers = append(d.observers, o)
+}
+
+// Start starts the loop that reports repeats and closes expired windows
+func (d *Deduper) Start() {
+	d.wg.Add(1)
+	go d.loop()
+}
+
+// Stop closes every window. Stop the worker pool first, so the events that
+// counts are added to are stored.
+func (d *Deduper) Stop() {
+	d.cancel()
+	d.wg.Wait()
+	d.flush(true)
+}
+
+// Collapse reports whether an event repeats one stored within the window
+// and should be dropped; its ID is then set to that of the stored event.
+// Otherwise the event opens a window and is marked as the first of its
+// repeats.
+func (d *Deduper) Collapse(event *model.LogEvent) bool {
+	if !d.applies(event.Source) {
+		return false
+	}
+
+	fp := d.fingerprint(*event)
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := d.sourceStats(event.TenantID, event.Source)
+	stats.Events++
+
+	e, ok := d.entries[fp]
+	if ok && now.Before(e.expires) {
+		e.repeats++
+		if event.Timestamp.After(e.lastSeen) {
+			e.lastSeen = event.Timestamp
+		}
+		event.ID = e.id
+		stats.Collapsed++
+		return true
+	}
+	if !ok && len(d.entries) >= d.maxEntries {
+		return false
+	}
+	if ok && e.repeats > 0 {
+		// The expired window has not been flushed yet; keep its count
+		// under a key of its own
+		d.entries[fp+e.id] = e
+	} else if ok {
+		delete(d.byID, e.id)
+	}
+
+	first, last := event.Timestamp, event.Timestamp
+	event.RepeatCount = 1
+	event.FirstSeen = &first
+	event.LastSeen = &last
+
+	e = &entry{
+		id:        event.ID,
+		tenantID:  event.TenantID,
+		timestamp: event.Timestamp,
+		expires:   now.Add(d.window),
+		lastSeen:  event.Timestamp,
+	}
+	d.entries[fp] = e
+	d.byID[e.id] = e
+	return false
+}
+
+// Forget closes the window opened by an event that could not be stored
+func (d *Deduper) Forget(event model.LogEvent) {
+	if !d.applies(event.Source) {
+		return
+	}
+	fp := d.fingerprint(event)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if e, ok := d.entries[fp]; ok && e.id == event.ID {
+		delete(d.entries, fp)
+		delete(d.byID, e.id)
+	}
+}
+
+// Observe records the stored copy of the events that opened a window, as
+// observers are told of repeats with the event as it was stored
+func (d *Deduper) Observe(events []model.LogEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.byID) == 0 {
+		return
+	}
+	for i := range events {
+		if e, ok := d.byID[events[i].ID]; ok && e.event == nil {
+			event := events[i]
+			e.event = &event
+		}
+	}
+}
+
+// Stats returns deduplication statistics. An empty tenantID covers every tenant.
+func (d *Deduper) Stats(tenantID string) Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := Stats{Sources: make([]SourceStats, 0)}
+	for key, s := range d.stats {
+		if tenantID != "" && key.tenantID != tenantID {
+			continue
+		}
+		stats.Events += s.Events
+		stats.Collapsed += s.Collapsed
+		stats.Sources = append(stats.Sources, *s)
+	}
+	for _, e := range d.entries {
+		if tenantID == "" || e.tenantID == tenantID {
+			stats.Fingerprints++
+		}
+	}
+	if tenantID == "" {
+		stats.MissedUpdates = d.missedUpdates
+	}
+
+	sort.Slice(stats.Sources, func(i, j int) bool {
+		a, b := stats.Sources[i], stats.Sources[j]
+		if a.Collapsed != b.Collapsed {
+			return a.Collapsed > b.Collapsed
+		}
+		return a.Events > b.Events
+	})
+	if len(stats.Sources) > topSources {
+		stats.Sources = stats.Sources[:topSources]
+	}
+	return stats
+}
+
+func (d *Deduper) loop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.flush(false)
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// flush reports new repeats to observers, closes expired windows, or every
+// window with all, and adds their repeats to the stored events. Repeats of
+// events that were never stored are not reported.
+func (d *Deduper) flush(all bool) {
+	now := d.now()
+
+	d.mu.Lock()
+	var updates []model.LogRepeats
+	var repeats []model.LogEvent
+	for fp, e := range d.entries {
+		if e.event != nil && e.repeats > e.reported {
+			repeats = append(repeats, e.repeatEvent())
+			e.reported = e.repeats
+		}
+
+		if !all && now.Before(e.expires) {
+			continue
+		}
+		delete(d.entries, fp)
+		delete(d.byID, e.id)
+		if e.repeats > 0 {
+			updates = append(updates, model.LogRepeats{
+				ID:        e.id,
+				Timestamp: e.timestamp,
+				Repeats:   e.repeats,
+				LastSeen:  e.lastSeen,
+			})
+		}
+	}
+	d.mu.Unlock()
+
+	if len(repeats) > 0 {
+		for _, o := range d.observers {
+			o.ObserveRepeats(repeats)
+		}
+	}
+
+	if len(updates) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updated, err := d.repo.UpdateLogRepeats(ctx, updates)
+	if err != nil {
+		log.Error().Err(err).Int("events", len(updates)).Msg("Failed to store repeat counts")
+	}
+	if missed := int64(len(updates)) - updated; missed > 0 {
+		d.mu.Lock()
+		d.missedUpdates += missed
+		d.mu.Unlock()
+		if err == nil {
+			log.Debug().Int64("events", missed).Msg("Repeat counts found no stored event")
+		}
+	}
+}
+
+// applies reports whether events of a source are deduplicated
+func (d *Deduper) applies(source string) bool {
+	if len(d.sources) == 0 {
+		return true
+	}
+	for _, pattern := range d.sources {
+		if ok, _ := path.Match(pattern, source); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceStats returns the statistics of a source; d.mu must be held
+func (d *Deduper) sourceStats(tenantID, source string) *SourceStats {
+	key := statsKey{tenantID: tenantID, source: source}
+	if s, ok := d.stats[key]; ok {
+		return s
+	}
+	if len(d.stats) >= maxStatsSources {
+		key.source = otherSources
+		if s, ok := d.stats[key]; ok {
+			return s
+		}
+	}
+
+	s := &SourceStats{TenantID: key.tenantID, Source: key.source}
+	d.stats[key] = s
+	return s
+}
+
+// fingerprint identifies the events that collapse into each other
+func (d *Deduper) fingerprint(event model.LogEvent) string {
+	h := sha256.New()
+	h.Write([]byte(event.TenantID))
+	for _, field := range d.fields {
+		value, _ := rules.FieldValue(event, field)
+		if field == model.FieldMessage && d.normalize {
+			value = normalizeMessage(value)
+		}
+		h.Write([]byte{0})
+		h.Write([]byte(value))
+	}
+	return string(h.Sum(nil)[:16])
+}
+
+// normalizeMessage lowercases a message and collapses runs of digits and
+// whitespace, so messages differing only in counters or spacing match
+func normalizeMessage(message string) string {
+	var sb strings.Builder
+	sb.Grow(len(message))
+
+	var prev rune
+	for _, r := range strings.TrimSpace(message) {
+		switch {
+		case unicode.IsDigit(r):
+			r = '0'
+		case unicode.IsSpace(r):
+			r = ' '
+		default:
+			r = unicode.ToLower(r)
+		}
+		if (r == '0' || r == ' ') && r == prev {
+			continue
+		}
+		sb.WriteRune(r)
+		prev = r
+	}
+	return sb.String()
+}
@@ -0,0 +1,157 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+func newTestDeduper(sources []string) (*Deduper, *time.Time) {
+	d := NewDeduper(nil, time.Minute, []string{model.FieldSource, model.FieldSeverity, model.FieldMessage}, true, sources, 100)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	return d, &now
+}
+
+func testEvent(tenantID, source, message string) *model.LogEvent {
+	return &model.LogEvent{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		Timestamp: time.Now(),
+		Severity:  model.SeverityLow,
+		Source:    source,
+		Message:   message,
+	}
+}
+
+func TestCollapseWithinWindow(t *testing.T) {
+	d, now := newTestDeduper(nil)
+
+	first := testEvent("red", "fw-01", "Connection refused from 10.0.0.1")
+	require.False(t, d.Collapse(first))
+	assert.Equal(t, 1, first.RepeatCount)
+	require.NotNil(t, first.FirstSeen)
+	assert.Equal(t, first.Timestamp, *first.FirstSeen)
+
+	// Counters and spacing are normalized away, and the repeat takes the
+	// stored event's ID
+	repeat := testEvent("red", "fw-01", "connection  refused from 10.0.0.27")
+	assert.True(t, d.Collapse(repeat))
+	assert.Equal(t, first.ID, repeat.ID)
+	// Other tenants and sources have windows of their own
+	assert.False(t, d.Collapse(testEvent("blue", "fw-01", "Connection refused from 10.0.0.1")))
+	assert.False(t, d.Collapse(testEvent("red", "fw-02", "Connection refused from 10.0.0.1")))
+
+	e := d.entries[d.fingerprint(*first)]
+	require.NotNil(t, e)
+	assert.Equal(t, first.ID, e.id)
+	assert.Equal(t, 1, e.repeats)
+
+	// Once the window closes the next repeat is stored again, and the old
+	// window's count is kept until it is flushed
+	*now = now.Add(2 * time.Minute)
+	next := testEvent("red", "fw-01", "Connection refused from 10.0.0.1")
+	assert.False(t, d.Collapse(next))
+	assert.Len(t, d.entries, 4)
+}
+
+func TestCollapseOnlyMatchingSources(t *testing.T) {
+	d, _ := newTestDeduper([]string{"fw-*"})
+
+	assert.False(t, d.Collapse(testEvent("red", "web-01", "GET /")))
+	assert.False(t, d.Collapse(testEvent("red", "web-01", "GET /")))
+
+	assert.False(t, d.Collapse(testEvent("red", "fw-01", "drop")))
+	assert.True(t, d.Collapse(testEvent("red", "fw-01", "drop")))
+}
+
+func TestForget(t *testing.T) {
+	d, _ := newTestDeduper(nil)
+
+	first := testEvent("red", "fw-01", "drop")
+	require.False(t, d.Collapse(first))
+	d.Forget(*first)
+
+	// The event was never stored, so the next one opens a new window
+	assert.False(t, d.Collapse(testEvent("red", "fw-01", "drop")))
+}
+
+type repeatRecorder struct {
+	events []model.LogEvent
+}
+
+func (r *repeatRecorder) ObserveRepeats(events []model.LogEvent) {
+	r.events = append(r.events, events...)
+}
+
+func TestRepeatsReportedToObservers(t *testing.T) {
+	d, _ := newTestDeduper(nil)
+	observer := &repeatRecorder{}
+	d.AddObserver(observer)
+
+	first := testEvent("red", "fw-01", "drop")
+	require.False(t, d.Collapse(first))
+	require.True(t, d.Collapse(testEvent("red", "fw-01", "drop")))
+	require.True(t, d.Collapse(testEvent("red", "fw-01", "drop")))
+
+	// Repeats are held back until the first event is stored
+	d.flush(false)
+	assert.Empty(t, observer.events)
+
+	stored := *first
+	stored.Attributes = map[string]any{"zone": "dmz"}
+	d.Observe([]model.LogEvent{stored})
+	d.flush(false)
+	require.Len(t, observer.events, 1)
+	assert.Equal(t, first.ID, observer.events[0].ID)
+	assert.Equal(t, 2, observer.events[0].RepeatCount)
+	assert.Equal(t, "dmz", observer.events[0].Attributes["zone"], "the stored event is reported")
+
+	// Only repeats since the previous report are counted
+	require.True(t, d.Collapse(testEvent("red", "fw-01", "drop")))
+	d.flush(false)
+	require.Len(t, observer.events, 2)
+	assert.Equal(t, 1, observer.events[1].RepeatCount)
+
+	d.flush(false)
+	assert.Len(t, observer.events, 2)
+}
+
+func TestStats(t *testing.T) {
+	d, _ := newTestDeduper(nil)
+
+	for i := 0; i < 3; i++ {
+		d.Collapse(testEvent("red", "fw-01", "drop"))
+	}
+	d.Collapse(testEvent("blue", "web-01", "GET /"))
+
+	all := d.Stats("")
+	assert.Equal(t, int64(4), all.Events)
+	assert.Equal(t, int64(2), all.Collapsed)
+	assert.Equal(t, 2, all.Fingerprints)
+	require.Len(t, all.Sources, 2)
+	assert.Equal(t, SourceStats{TenantID: "red", Source: "fw-01", Events: 3, Collapsed: 2}, all.Sources[0])
+
+	blue := d.Stats("blue")
+	assert.Equal(t, int64(1), blue.Events)
+	assert.Equal(t, int64(0), blue.Collapsed)
+	assert.Equal(t, 1, blue.Fingerprints)
+	assert.Len(t, blue.Sources, 1)
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	assert.Equal(t, "user 0 failed login after 0 attempts", normalizeMessage("  User 42 failed\tlogin after  3 attempts "))
+	assert.Equal(t, normalizeMessage("port 8080"), normalizeMessage("PORT 443"))
+}
+
+func TestValidateFields(t *testing.T) {
+	assert.NoError(t, ValidateFields([]string{"source", "message", "attr.user.name"}))
+	assert.Error(t, ValidateFields(nil))
+	assert.Error(t, ValidateFields([]string{"host"}))
+	assert.Error(t, ValidateFields([]string{"attr."}))
+}
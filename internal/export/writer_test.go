@@ -0,0 +1,131 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+func testLogs() []model.LogEvent {
+	ts := time.Date(2026, 1, 29, 10, 0, 0, 0, time.UTC)
+	lastSeen := ts.Add(time.Minute)
+	return []model.LogEvent{
+		{
+			ID:          "a",
+			TenantID:    "acme",
+			Timestamp:   ts,
+			Severity:    "HIGH",
+			Source:      "firewall",
+			Message:     "denied, \"quoted\"",
+			Attributes:  map[string]interface{}{"src_ip": "10.0.0.1"},
+			IngestedAt:  ts,
+			RepeatCount: 3,
+			FirstSeen:   &ts,
+			LastSeen:    &lastSeen,
+		},
+		{
+			ID:         "b",
+			TenantID:   "acme",
+			Timestamp:  ts.Add(time.Second),
+			Severity:   "INFO",
+			Source:     "app",
+			Message:    "started",
+			IngestedAt: ts,
+		},
+	}
+}
+
+func writeAll(t *testing.T, format string) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf)
+	require.NoError(t, err)
+	for _, log := range testLogs() {
+		require.NoError(t, w.Write(&log))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	lines := bytes.Split(bytes.TrimSpace(writeAll(t, FormatNDJSON)), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var log model.LogEvent
+	require.NoError(t, json.Unmarshal(lines[0], &log))
+	assert.Equal(t, "a", log.ID)
+	assert.Equal(t, "10.0.0.1", log.Attributes["src_ip"])
+}
+
+func TestCSVWriter(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeAll(t, FormatCSV))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, []string{
+		"a", "acme", "2026-01-29T10:00:00Z", "HIGH", "firewall", "denied, \"quoted\"",
+		`{"src_ip":"10.0.0.1"}`, "2026-01-29T10:00:00Z", "3", "2026-01-29T10:00:00Z", "2026-01-29T10:01:00Z",
+	}, records[1])
+	assert.Equal(t, "", records[2][6])
+	assert.Equal(t, "", records[2][10])
+}
+
+func TestCSVWriterEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(&model.LogEvent{ID: "a", Source: "@fw", Message: `=HYPERLINK("http://evil")`}))
+	require.NoError(t, w.Write(&model.LogEvent{ID: "b", Source: "-1+1", Message: "+cmd"}))
+	require.NoError(t, w.Write(&model.LogEvent{ID: "c", Source: "fw", Message: "a=b"}))
+	require.NoError(t, w.Close())
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"'@fw", `'=HYPERLINK("http://evil")`}, records[1][4:6])
+	assert.Equal(t, []string{"'-1+1", "'+cmd"}, records[2][4:6])
+	assert.Equal(t, []string{"fw", "a=b"}, records[3][4:6])
+}
+
+func TestParquetWriter(t *testing.T) {
+	data := writeAll(t, FormatParquet)
+
+	rows, err := parquet.Read[parquetLog](bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, "firewall", rows[0].Source)
+	assert.Equal(t, `{"src_ip":"10.0.0.1"}`, rows[0].Attributes)
+	assert.True(t, rows[0].Timestamp.Equal(testLogs()[0].Timestamp))
+	require.NotNil(t, rows[0].LastSeen)
+	assert.True(t, rows[0].LastSeen.Equal(*testLogs()[0].LastSeen))
+	assert.Nil(t, rows[1].LastSeen)
+}
+
+func TestEmptyExports(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatParquet, &buf)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	rows, err := parquet.Read[parquetLog](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+func TestFormatForMediaType(t *testing.T) {
+	assert.Equal(t, FormatCSV, FormatForMediaType("text/csv"))
+	assert.Equal(t, FormatParquet, FormatForMediaType("application/vnd.apache.parquet"))
+	assert.Equal(t, FormatNDJSON, FormatForMediaType("application/jsonl"))
+	assert.Equal(t, "", FormatForMediaType("text/html"))
+
+	_, err := NewWriter("xml", &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
@@ -0,0 +1,437 @@
+// Package geoip adds the location and network owner of IP addresses to log
+// events, from MaxMind-format (MMDB) databases on local disk
+package geoip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/pkg/ipscan"
+	"github.com/Saumajitt/threatLog/pkg/validator"
+)
+
+// record holds the fields read from city, country and ASN databases; each
+// database fills the fields it has
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+		Names   struct {
+			EN string `maxminddb:"en"`
+		} `maxminddb:"names"`
+	} `maxminddb:"country"`
+	City struct {
+		Names struct {
+			EN string `maxminddb:"en"`
+		} `maxminddb:"names"`
+	} `maxminddb:"city"`
+	ASN uint   `maxminddb:"autonomous_system_number"`
+	Org string `maxminddb:"autonomous_system_organization"`
+}
+
+// database is an open database with the version of its file: the
+// modification time and size
+type database struct {
+	path     string
+	version  string
+	reader   *maxminddb.Reader
+	loadedAt time.Time
+	err      string
+}
+
+// DatabaseStats describes a loaded database
+type DatabaseStats struct {
+	Path      string    `json:"path"`
+	Type      string    `json:"type,omitempty"`
+	BuildTime time.Time `json:"build_time,omitempty"`
+	LoadedAt  time.Time `json:"loaded_at,omitempty"`
+	// Error is the last load failure; the previous file stays in use
+	Error string `json:"error,omitempty"`
+}
+
+// Stats holds enrichment statistics
+type Stats struct {
+	// Hits counts addresses found in at least one database, Misses those
+	// found in none. Private and reserved addresses are not looked up.
+	Hits      int64           `json:"hits"`
+	Misses    int64           `json:"misses"`
+	Databases []DatabaseStats `json:"databases"`
+}
+
+// Enricher looks up the IP addresses in event attributes, and optionally in
+// the message, and stores what the databases know about them. An address in
+// attribute src_ip is described in src_ip_geo, nested attributes use their
+// path joined by underscores, and lists of addresses get a list. Databases
+// are reopened when their file changes.
+type Enricher struct {
+	paths          []string
+	fields         map[string]bool
+	scanMessage    bool
+	maxAddresses   int
+	reloadInterval time.Duration
+
+	// reloadMu serializes reloads
+	reloadMu sync.Mutex
+
+	// mu guards dbs; lookups hold it for reading so readers are only closed
+	// once no lookup uses them
+	mu  sync.RWMutex
+	dbs []*database
+
+	hits   atomic.Int64
+	misses atomic.Int64
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewEnricher creates a new GeoIP enricher. fields restricts lookups to the
+// listed top-level attributes; empty looks at every attribute. At most
+// maxAddresses addresses are looked up per event.
+func NewEnricher(
+	paths []string,
+	fields []string,
+	scanMessage bool,
+	maxAddresses int,
+	reloadInterval time.Duration,
+) *Enricher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var fieldSet map[string]bool
+	if len(fields) > 0 {
+		fieldSet = make(map[string]bool, len(fields))
+		for _, field := range fields {
+			fieldSet[field] = true
+		}
+	}
+
+	return &Enricher{
+		paths:          paths,
+		fields:         fieldSet,
+		scanMessage:    scanMessage,
+		maxAddresses:   maxAddresses,
+		reloadInterval: reloadInterval,
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+}
+
+// Start opens the databases and starts the reload loop. Every database must
+// open for Start to succeed.
+func (e *Enricher) Start() error {
+	if len(e.paths) == 0 {
+		return errors.New("geoip requires at least one database")
+	}
+
+	e.Reload()
+	for _, db := range e.dbs {
+		if db.reader == nil {
+			return fmt.Errorf("failed to open geoip database %s: %s", db.path, db.err)
+		}
+	}
+
+	e.wg.Add(1)
+	go e.reloadLoop()
+
+	return nil
+}
+
+// Stop stops the reload loop and closes the databases
+func (e *Enricher) Stop() {
+	log.Info().Msg("Stopping geoip enricher")
+	e.cancel()
+	e.wg.Wait()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, db := range e.dbs {
+		if db.reader != nil {
+			db.reader.Close()
+		}
+	}
+	e.dbs = nil
+	log.Info().Msg("Geoip enricher stopped")
+}
+
+// Reload reopens the databases whose file changed. A database that fails
+// to open keeps serving the previous file.
+func (e *Enricher) Reload() {
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+
+	e.mu.RLock()
+	current := make(map[string]*database, len(e.dbs))
+	for _, db := range e.dbs {
+		current[db.path] = db
+	}
+	e.mu.RUnlock()
+
+	dbs := make([]*database, 0, len(e.paths))
+	changed := false
+	for _, path := range e.paths {
+		db := e.open(path, current[path])
+		changed = changed || db != current[path]
+		dbs = append(dbs, db)
+	}
+	if !changed {
+		return
+	}
+
+	e.mu.Lock()
+	e.dbs = dbs
+	e.mu.Unlock()
+
+	// Close the readers that were replaced, now that no lookup uses them
+	for path, old := range current {
+		if old.reader == nil {
+			continue
+		}
+		if replaced := e.find(dbs, path); replaced == nil || replaced.reader != old.reader {
+			old.reader.Close()
+		}
+	}
+}
+
+func (e *Enricher) find(dbs []*database, path string) *database {
+	for _, db := range dbs {
+		if db.path == path {
+			return db
+		}
+	}
+	return nil
+}
+
+// open opens a database unless its file is unchanged, returning prev then
+func (e *Enricher) open(path string, prev *database) *database {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return e.failed(path, prev, err)
+	}
+	version := strconv.FormatInt(stat.ModTime().UnixNano(), 10) + "-" + strconv.FormatInt(stat.Size(), 10)
+	if prev != nil && prev.version == version && prev.err == "" {
+		return prev
+	}
+
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return e.failed(path, prev, err)
+	}
+
+	log.Info().
+		Str("path", path).
+		Str("type", reader.Metadata.DatabaseType).
+		Time("build_time", reader.Metadata.BuildTime()).
+		Msg("Loaded geoip database")
+
+	return &database{path: path, version: version, reader: reader, loadedAt: time.Now()}
+}
+
+// failed records a load failure, keeping the previously opened file
+func (e *Enricher) failed(path string, prev *database, err error) *database {
+	log.Error().Err(err).Str("path", path).Msg("Failed to load geoip database")
+
+	if prev != nil && prev.err == err.Error() {
+		return prev
+	}
+	db := &database{path: path, err: err.Error()}
+	if prev != nil {
+		db.version, db.reader, db.loadedAt = prev.version, prev.reader, prev.loadedAt
+	}
+	return db
+}
+
+// Stats returns enrichment statistics
+func (e *Enricher) Stats() Stats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	stats := Stats{
+		Hits:      e.hits.Load(),
+		Misses:    e.misses.Load(),
+		Databases: make([]DatabaseStats, 0, len(e.dbs)),
+	}
+	for _, db := range e.dbs {
+		info := DatabaseStats{Path: db.path, LoadedAt: db.loadedAt, Error: db.err}
+		if db.reader != nil {
+			info.Type = db.reader.Metadata.DatabaseType
+			info.BuildTime = db.reader.Metadata.BuildTime()
+		}
+		stats.Databases = append(stats.Databases, info)
+	}
+	return stats
+}
+
+// Enrich adds the location and network owner of the addresses in an event
+func (e *Enricher) Enrich(event *model.LogEvent) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	l := &lookup{Enricher: e, geo: make(map[string]any), budget: e.maxAddresses}
+
+	keys := make([]string, 0, len(event.Attributes))
+	for key := range event.Attributes {
+		if e.fields != nil && !e.fields[key] {
+			continue
+		}
+		if strings.HasSuffix(key, model.GeoAttributeSuffix) || key == model.ThreatIntelAttribute {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		l.attribute(event.Attributes[key], key, 0)
+	}
+
+	if e.scanMessage {
+		var found []any
+		for _, addr := range ipscan.Find(event.Message) {
+			if info, ok := l.lookup(addr); ok {
+				found = append(found, info)
+			}
+		}
+		if len(found) > 0 {
+			l.geo[model.MessageGeoAttribute] = found
+		}
+	}
+
+	if len(l.geo) == 0 {
+		return
+	}
+
+	// Attributes may be shared with the caller, so they are copied
+	attributes := make(map[string]any, len(event.Attributes)+len(l.geo))
+	maps.Copy(attributes, event.Attributes)
+	maps.Copy(attributes, l.geo)
+	event.Attributes = attributes
+}
+
+// lookup collects the descriptions of the addresses of an event
+type lookup struct {
+	*Enricher
+	geo    map[string]any
+	budget int
+}
+
+// attribute describes the addresses in an attribute value, walking nested
+// objects and lists of addresses
+func (l *lookup) attribute(value any, name string, depth int) {
+	if l.budget <= 0 || depth > validator.MaxAttributeDepth {
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		if addr, ok := ipscan.Parse(v); ok {
+			if info, ok := l.lookup(addr); ok {
+				l.geo[name+model.GeoAttributeSuffix] = info
+			}
+		}
+	case []any:
+		var found []any
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				continue
+			}
+			if addr, ok := ipscan.Parse(s); ok {
+				if info, ok := l.lookup(addr); ok {
+					found = append(found, info)
+				}
+			}
+		}
+		if len(found) > 0 {
+			l.geo[name+model.GeoAttributeSuffix] = found
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			l.attribute(v[key], name+"_"+key, depth+1)
+		}
+	}
+}
+
+// lookup describes a public address from every database that knows it
+func (l *lookup) lookup(addr netip.Addr) (map[string]any, bool) {
+	if l.budget <= 0 || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return nil, false
+	}
+	l.budget--
+
+	info := map[string]any{"ip": addr.String()}
+	for _, db := range l.dbs {
+		if db.reader == nil {
+			continue
+		}
+		// IPv6 lookups fail on IPv4-only databases
+		if addr.Is6() && db.reader.Metadata.IPVersion == 4 {
+			continue
+		}
+
+		var rec record
+		if err := db.reader.Lookup(addr).Decode(&rec); err != nil {
+			log.Debug().Err(err).Str("path", db.path).Msg("Failed to look up address")
+			continue
+		}
+		setString(info, "country", rec.Country.ISOCode)
+		setString(info, "country_name", rec.Country.Names.EN)
+		setString(info, "city", rec.City.Names.EN)
+		setString(info, "org", rec.Org)
+		if rec.ASN != 0 {
+			info["asn"] = int(rec.ASN)
+		}
+	}
+
+	if len(info) == 1 {
+		l.misses.Add(1)
+		return nil, false
+	}
+	l.hits.Add(1)
+	return info, true
+}
+
+func setString(info map[string]any, key, value string) {
+	if value != "" {
+		info[key] = value
+	}
+}
+
+// reloadLoop periodically reopens changed databases
+func (e *Enricher) reloadLoop() {
+	defer e.wg.Done()
+
+	if e.reloadInterval <= 0 {
+		<-e.ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(e.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.Reload()
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
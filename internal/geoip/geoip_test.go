@@ -0,0 +1,277 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// writeTestDB writes an IPv4 MMDB database with a record per prefix. The
+// file is replaced by a rename, as geoipupdate does, since open databases
+// are memory mapped.
+func writeTestDB(t *testing.T, path, databaseType string, records map[string]map[string]any) {
+	t.Helper()
+
+	type node struct{ child [2]*node }
+	type leaf struct {
+		parent *node
+		bit    int
+		data   map[string]any
+	}
+
+	root := &node{}
+	var leaves []leaf
+	prefixes := make([]string, 0, len(records))
+	for prefix := range records {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, p := range prefixes {
+		prefix := netip.MustParsePrefix(p)
+		addr := prefix.Addr().As4()
+		n := root
+		for i := 0; i < prefix.Bits(); i++ {
+			bit := int(addr[i/8]>>(7-i%8)) & 1
+			if i == prefix.Bits()-1 {
+				leaves = append(leaves, leaf{parent: n, bit: bit, data: records[p]})
+				break
+			}
+			if n.child[bit] == nil {
+				n.child[bit] = &node{}
+			}
+			n = n.child[bit]
+		}
+	}
+
+	// Number the nodes breadth first
+	nodes := []*node{root}
+	ids := map[*node]int{root: 0}
+	for i := 0; i < len(nodes); i++ {
+		for _, c := range nodes[i].child {
+			if c != nil {
+				ids[c] = len(nodes)
+				nodes = append(nodes, c)
+			}
+		}
+	}
+	count := len(nodes)
+
+	var data []byte
+	values := make(map[*node][2]int)
+	for _, n := range nodes {
+		values[n] = [2]int{count, count}
+		for bit, c := range n.child {
+			if c != nil {
+				v := values[n]
+				v[bit] = ids[c]
+				values[n] = v
+			}
+		}
+	}
+	for _, l := range leaves {
+		v := values[l.parent]
+		v[l.bit] = count + 16 + len(data)
+		values[l.parent] = v
+		data = append(data, encodeMMDB(l.data)...)
+	}
+
+	var tree []byte
+	for _, n := range nodes {
+		for _, v := range values[n] {
+			tree = append(tree, byte(v>>16), byte(v>>8), byte(v))
+		}
+	}
+
+	file := append(tree, make([]byte, 16)...)
+	file = append(file, data...)
+	file = append(file, "\xab\xcd\xefMaxMind.com"...)
+	file = append(file, encodeMMDB(map[string]any{
+		"node_count":                  uint32(count),
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(4),
+		"database_type":               databaseType,
+		"languages":                   []any{"en"},
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint32(1700000000),
+		"description":                 map[string]any{"en": "test"},
+	})...)
+
+	replaceFile(t, path, file)
+}
+
+func replaceFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, data, 0o644))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+// encodeMMDB encodes a value in the MMDB data section format
+func encodeMMDB(value any) []byte {
+	header := func(typ, size int) []byte {
+		var b []byte
+		if typ > 7 {
+			b = []byte{0, byte(typ - 7)}
+		} else {
+			b = []byte{byte(typ << 5)}
+		}
+		if size < 29 {
+			b[0] |= byte(size)
+			return b
+		}
+		b[0] |= 29
+		return append(b, byte(size-29))
+	}
+
+	switch v := value.(type) {
+	case string:
+		return append(header(2, len(v)), v...)
+	case uint16:
+		return append(header(5, 2), byte(v>>8), byte(v))
+	case uint32:
+		return binary.BigEndian.AppendUint32(header(6, 4), v)
+	case []any:
+		b := header(11, len(v))
+		for _, item := range v {
+			b = append(b, encodeMMDB(item)...)
+		}
+		return b
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b := header(7, len(v))
+		for _, key := range keys {
+			b = append(b, encodeMMDB(key)...)
+			b = append(b, encodeMMDB(v[key])...)
+		}
+		return b
+	}
+	panic("unsupported value")
+}
+
+func testDatabases(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	city := filepath.Join(dir, "city.mmdb")
+	asn := filepath.Join(dir, "asn.mmdb")
+
+	writeTestDB(t, city, "GeoLite2-City", map[string]map[string]any{
+		"81.2.69.0/24": {
+			"country": map[string]any{"iso_code": "GB", "names": map[string]any{"en": "United Kingdom"}},
+			"city":    map[string]any{"names": map[string]any{"en": "London"}},
+		},
+		"89.160.20.0/24": {
+			"country": map[string]any{"iso_code": "SE", "names": map[string]any{"en": "Sweden"}},
+		},
+	})
+	writeTestDB(t, asn, "GeoLite2-ASN", map[string]map[string]any{
+		"81.2.69.0/24": {
+			"autonomous_system_number":       uint32(20712),
+			"autonomous_system_organization": "Andrews & Arnold Ltd",
+		},
+	})
+	return city, asn
+}
+
+func startEnricher(t *testing.T, paths []string, fields []string, scanMessage bool) *Enricher {
+	e := NewEnricher(paths, fields, scanMessage, 10, 0)
+	require.NoError(t, e.Start())
+	t.Cleanup(e.Stop)
+	return e
+}
+
+func TestEnrichAttributes(t *testing.T) {
+	city, asn := testDatabases(t)
+	e := startEnricher(t, []string{city, asn}, nil, false)
+
+	attributes := map[string]any{
+		"src_ip":  "81.2.69.142",
+		"dst_ip":  "10.0.0.1",
+		"net":     map[string]any{"peer": "89.160.20.128:443"},
+		"clients": []any{"89.160.20.1", "1.1.1.1", 7.0},
+		"user":    "alice",
+	}
+	event := &model.LogEvent{Message: "Connection from 81.2.69.142", Attributes: attributes}
+	e.Enrich(event)
+
+	assert.Equal(t, map[string]any{
+		"ip":           "81.2.69.142",
+		"country":      "GB",
+		"country_name": "United Kingdom",
+		"city":         "London",
+		"asn":          20712,
+		"org":          "Andrews & Arnold Ltd",
+	}, event.Attributes["src_ip_geo"])
+	assert.Equal(t, map[string]any{"ip": "89.160.20.128", "country": "SE", "country_name": "Sweden"}, event.Attributes["net_peer_geo"])
+	assert.Equal(t, []any{map[string]any{"ip": "89.160.20.1", "country": "SE", "country_name": "Sweden"}}, event.Attributes["clients_geo"])
+	assert.NotContains(t, event.Attributes, "dst_ip_geo", "private addresses are not looked up")
+	assert.NotContains(t, event.Attributes, model.MessageGeoAttribute)
+	assert.NotContains(t, attributes, "src_ip_geo", "caller's attributes are not modified")
+
+	stats := e.Stats()
+	assert.Equal(t, int64(3), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	require.Len(t, stats.Databases, 2)
+	assert.Equal(t, "GeoLite2-City", stats.Databases[0].Type)
+	assert.Equal(t, time.Unix(1700000000, 0), stats.Databases[0].BuildTime)
+}
+
+func TestEnrichFieldsAndMessage(t *testing.T) {
+	city, _ := testDatabases(t)
+	e := startEnricher(t, []string{city}, []string{"src_ip"}, true)
+
+	event := &model.LogEvent{
+		Message:    "Blocked 89.160.20.5 and 81.2.69.1 twice: 89.160.20.5",
+		Attributes: map[string]any{"src_ip": "81.2.69.142", "dst_ip": "89.160.20.9"},
+	}
+	e.Enrich(event)
+
+	assert.Contains(t, event.Attributes, "src_ip_geo")
+	assert.NotContains(t, event.Attributes, "dst_ip_geo")
+	found := event.Attributes[model.MessageGeoAttribute].([]any)
+	require.Len(t, found, 2)
+	assert.Equal(t, "89.160.20.5", found[0].(map[string]any)["ip"])
+	assert.Equal(t, "London", found[1].(map[string]any)["city"])
+}
+
+func TestReloadChangedDatabase(t *testing.T) {
+	city, _ := testDatabases(t)
+	e := startEnricher(t, []string{city}, nil, false)
+
+	writeTestDB(t, city, "GeoLite2-City", map[string]map[string]any{
+		"81.2.69.0/24": {"country": map[string]any{"iso_code": "IE"}},
+	})
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(city, later, later))
+	e.Reload()
+
+	event := &model.LogEvent{Attributes: map[string]any{"src_ip": "81.2.69.142"}}
+	e.Enrich(event)
+	assert.Equal(t, "IE", event.Attributes["src_ip_geo"].(map[string]any)["country"])
+
+	// A broken file keeps the previous database in use
+	replaceFile(t, city, []byte("not a database"))
+	e.Reload()
+
+	event = &model.LogEvent{Attributes: map[string]any{"src_ip": "81.2.69.142"}}
+	e.Enrich(event)
+	assert.Equal(t, "IE", event.Attributes["src_ip_geo"].(map[string]any)["country"])
+	assert.NotEmpty(t, e.Stats().Databases[0].Error)
+}
+
+func TestStartRequiresDatabases(t *testing.T) {
+	assert.Error(t, NewEnricher(nil, nil, false, 10, 0).Start())
+	assert.Error(t, NewEnricher([]string{filepath.Join(t.TempDir(), "missing.mmdb")}, nil, false, 10, 0).Start())
+}
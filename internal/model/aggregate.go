@@ -0,0 +1,107 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// IntervalAuto picks a histogram interval from the queried time range
+const IntervalAuto = "auto"
+
+// Histogram limits
+const (
+	// AutoBuckets is the most buckets an automatic interval produces
+	AutoBuckets = 100
+	// MaxBuckets is the most buckets a fixed interval may produce
+	MaxBuckets = 1000
+)
+
+var (
+	ErrInvalidInterval = errors.New("interval must be auto or a duration of whole seconds, at least 1s")
+	ErrTooManyBuckets  = errors.New("interval produces more than 1000 buckets")
+)
+
+// autoIntervals are the intervals an automatic histogram chooses from
+var autoIntervals = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+	7 * 24 * time.Hour,
+}
+
+// AggregateRequest represents aggregation parameters. Filter selects the
+// logs like a query; its pagination and sort fields are ignored.
+type AggregateRequest struct {
+	Filter QueryRequest `json:"filter"`
+	// Interval is IntervalAuto or a duration such as 5m
+	Interval string `json:"interval,omitempty"`
+	// GroupBy and Cardinality fields are severity, source or attr.<key>
+	GroupBy     []string `json:"group_by,omitempty"`
+	Top         int      `json:"top"`
+	Cardinality []string `json:"cardinality,omitempty"`
+}
+
+// BucketInterval returns the histogram interval of the request
+func (r AggregateRequest) BucketInterval() (time.Duration, error) {
+	span := r.Filter.EndTime.Sub(r.Filter.StartTime)
+
+	if r.Interval == "" || r.Interval == IntervalAuto {
+		for _, interval := range autoIntervals {
+			if span/interval < AutoBuckets {
+				return interval, nil
+			}
+		}
+		// Beyond the largest step, widen it in whole multiples
+		widest := autoIntervals[len(autoIntervals)-1]
+		return widest * (span/(widest*AutoBuckets) + 1), nil
+	}
+
+	interval, err := time.ParseDuration(r.Interval)
+	if err != nil || interval < time.Second || interval%time.Second != 0 {
+		return 0, ErrInvalidInterval
+	}
+	if span/interval >= MaxBuckets {
+		return 0, ErrTooManyBuckets
+	}
+	return interval, nil
+}
+
+// AggregateResponse represents aggregation results
+type AggregateResponse struct {
+	Total           int64             `json:"total"`
+	IntervalSeconds int64             `json:"interval_seconds"`
+	Buckets         []HistogramBucket `json:"buckets"`
+	Groups          []GroupResult     `json:"groups,omitempty"`
+	// Cardinality holds estimated distinct value counts per field
+	Cardinality map[string]int64 `json:"cardinality,omitempty"`
+}
+
+// HistogramBucket counts the logs from Start until the next bucket
+type HistogramBucket struct {
+	Start time.Time `json:"start"`
+	Count int64     `json:"count"`
+}
+
+// GroupResult holds the most frequent values of a field. Other counts the
+// logs with a value outside the top values; logs without one are not counted.
+type GroupResult struct {
+	Field  string       `json:"field"`
+	Values []GroupCount `json:"values"`
+	Other  int64        `json:"other"`
+}
+
+// GroupCount counts the logs with a field value
+type GroupCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
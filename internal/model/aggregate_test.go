@@ -0,0 +1,51 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketInterval(t *testing.T) {
+	start := time.Date(2026, 1, 29, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		span     time.Duration
+		interval string
+		want     time.Duration
+	}{
+		{time.Minute, "", time.Second},
+		{time.Hour, IntervalAuto, time.Minute},
+		{24 * time.Hour, IntervalAuto, 30 * time.Minute},
+		{30 * 24 * time.Hour, IntervalAuto, 12 * time.Hour},
+		{5 * 365 * 24 * time.Hour, IntervalAuto, 3 * 7 * 24 * time.Hour},
+		{24 * time.Hour, "5m", 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		req := AggregateRequest{
+			Filter:   QueryRequest{StartTime: start, EndTime: start.Add(tt.span)},
+			Interval: tt.interval,
+		}
+		got, err := req.BucketInterval()
+		require.NoError(t, err, tt.span)
+		assert.Equal(t, tt.want, got, tt.span)
+	}
+}
+
+func TestBucketIntervalInvalid(t *testing.T) {
+	start := time.Date(2026, 1, 29, 0, 0, 0, 0, time.UTC)
+	req := AggregateRequest{Filter: QueryRequest{StartTime: start, EndTime: start.Add(24 * time.Hour)}}
+
+	for _, interval := range []string{"hourly", "500ms", "1.5s", "-1m"} {
+		req.Interval = interval
+		_, err := req.BucketInterval()
+		assert.ErrorIs(t, err, ErrInvalidInterval, interval)
+	}
+
+	req.Interval = "1m"
+	_, err := req.BucketInterval()
+	assert.ErrorIs(t, err, ErrTooManyBuckets)
+}
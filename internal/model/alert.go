@@ -0,0 +1,167 @@
+package model
+
+import "time"
+
+// Alert statuses. Suppressed alerts matched an active suppression when they
+// were raised; they are stored but not notified.
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+	AlertSuppressed   = "suppressed"
+)
+
+// Alert audit actions
+const (
+	AlertActionCreated    = "created"
+	AlertActionSuppressed = "suppressed"
+	AlertActionStatus     = "status_changed"
+	AlertActionAssigned   = "assigned"
+)
+
+// AlertActorSystem is the audit actor of changes made by ThreatLog itself
+const AlertActorSystem = "system"
+
+// Alert list limits
+const (
+	DefaultAlertLimit = 100
+	MaxAlertLimit     = 1000
+)
+
+// MaxSuppressionDuration bounds how long a suppression can mute alerts
+const MaxSuppressionDuration = 30 * 24 * time.Hour
+
+// Alert is raised when a detection rule matches
+type Alert struct {
+	ID            string    `json:"id"`
+	TenantID      string    `json:"tenant_id"`
+	RuleID        string    `json:"rule_id"`
+	RuleName      string    `json:"rule_name"`
+	Severity      string    `json:"severity"`
+	GroupKey      string    `json:"group_key,omitempty"`
+	EventCount    int       `json:"event_count"`
+	LogIDs        []string  `json:"log_ids"`
+	Message       string    `json:"message"`
+	TriggeredAt   time.Time `json:"triggered_at"`
+	Status        string    `json:"status"`
+	Assignee      string    `json:"assignee,omitempty"`
+	SuppressionID string    `json:"suppression_id,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// AlertTransitionAllowed reports whether an alert can be moved from one
+// status to another by a user. Resolved alerts must be reopened before they
+// are acknowledged again, and nothing moves an alert to suppressed.
+func AlertTransitionAllowed(from, to string) bool {
+	if from == to {
+		return false
+	}
+	switch to {
+	case AlertOpen, AlertResolved:
+		return true
+	case AlertAcknowledged:
+		return from != AlertResolved
+	default:
+		return false
+	}
+}
+
+// AlertFilter selects alerts of a tenant. Empty fields match every alert.
+type AlertFilter struct {
+	TenantID  string
+	Statuses  []string
+	Assignee  string
+	RuleID    string
+	Severity  string
+	StartTime *time.Time
+	EndTime   *time.Time
+	Limit     int
+	Offset    int
+}
+
+// AlertListResponse represents a page of alerts
+type AlertListResponse struct {
+	Total  int     `json:"total"`
+	Count  int     `json:"count"`
+	Alerts []Alert `json:"alerts"`
+}
+
+// AlertComment is a note left on an alert
+type AlertComment struct {
+	ID        string    `json:"id"`
+	AlertID   string    `json:"alert_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AlertAuditEntry records a change of an alert's status or assignee
+type AlertAuditEntry struct {
+	ID         int64     `json:"id"`
+	AlertID    string    `json:"alert_id"`
+	Actor      string    `json:"actor"`
+	Action     string    `json:"action"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AlertDetail is an alert with its linked logs, comments and audit trail.
+// Linked logs that no longer exist, such as those removed by retention, are
+// listed in MissingLogIDs.
+type AlertDetail struct {
+	Alert
+	Logs          []LogEvent        `json:"logs"`
+	MissingLogIDs []string          `json:"missing_log_ids,omitempty"`
+	Comments      []AlertComment    `json:"comments"`
+	Audit         []AlertAuditEntry `json:"audit"`
+}
+
+// AlertStatusRequest moves an alert to another status, with an optional comment
+type AlertStatusRequest struct {
+	Status  string `json:"status"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// AlertAssignRequest assigns an alert; an empty assignee unassigns it
+type AlertAssignRequest struct {
+	Assignee string `json:"assignee"`
+}
+
+// AlertCommentRequest adds a comment to an alert
+type AlertCommentRequest struct {
+	Body string `json:"body"`
+}
+
+// AlertSuppression mutes alerts raised between StartsAt and ExpiresAt that
+// match its rule, group and severity. Empty matchers match every alert.
+type AlertSuppression struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"tenant_id"`
+	RuleID    string    `json:"rule_id,omitempty"`
+	GroupKey  string    `json:"group_key,omitempty"`
+	Severity  string    `json:"severity,omitempty"`
+	Reason    string    `json:"reason"`
+	CreatedBy string    `json:"created_by"`
+	StartsAt  time.Time `json:"starts_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SuppressionRequest creates a suppression lasting Duration from StartsAt,
+// or from now when StartsAt is unset
+type SuppressionRequest struct {
+	RuleID   string     `json:"rule_id,omitempty"`
+	GroupKey string     `json:"group_key,omitempty"`
+	Severity string     `json:"severity,omitempty"`
+	Reason   string     `json:"reason"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	Duration string     `json:"duration"`
+}
+
+// SuppressionListResponse represents a list of suppressions
+type SuppressionListResponse struct {
+	Count        int                `json:"count"`
+	Suppressions []AlertSuppression `json:"suppressions"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertTransitionAllowed(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{AlertOpen, AlertAcknowledged, true},
+		{AlertOpen, AlertResolved, true},
+		{AlertAcknowledged, AlertOpen, true},
+		{AlertAcknowledged, AlertResolved, true},
+		{AlertResolved, AlertOpen, true},
+		{AlertResolved, AlertAcknowledged, false},
+		{AlertSuppressed, AlertAcknowledged, true},
+		{AlertSuppressed, AlertResolved, true},
+		{AlertOpen, AlertOpen, false},
+		{AlertOpen, AlertSuppressed, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, AlertTransitionAllowed(tt.from, tt.to), "%s -> %s", tt.from, tt.to)
+	}
+}
@@ -0,0 +1,93 @@
+package model
+
+import "time"
+
+// API key roles
+const (
+	RoleIngest = "ingest"
+	RoleRead   = "read"
+	RoleAdmin  = "admin"
+)
+
+// IsValidRole checks if a role is valid
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleIngest, RoleRead, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// APIKey is a stored API key. Only a hash of the secret is kept.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	TenantID   string     `json:"tenant_id"`
+	Prefix     string     `json:"prefix"`
+	Roles      []string   `json:"roles"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the key can authenticate at t
+func (k *APIKey) Active(t time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || t.Before(*k.ExpiresAt)
+}
+
+// APIKeyRequest represents the API request to create a key
+type APIKeyRequest struct {
+	Name string `json:"name"`
+	// TenantID defaults to the caller's tenant
+	TenantID  string     `json:"tenant_id,omitempty"`
+	Roles     []string   `json:"roles"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKeyCreateResponse returns a new key; the secret is only shown once
+type APIKeyCreateResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// TailTokenResponse returns a short-lived token for opening a live tail
+// from clients that cannot set headers
+type TailTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// APIKeyListResponse represents a list of API keys
+type APIKeyListResponse struct {
+	Count int      `json:"count"`
+	Keys  []APIKey `json:"keys"`
+}
+
+// Identity is the authenticated caller of a request
+type Identity struct {
+	KeyID    string   `json:"key_id"`
+	Name     string   `json:"name"`
+	TenantID string   `json:"tenant_id"`
+	Roles    []string `json:"roles"`
+}
+
+// HasRole reports whether the identity holds role; admin holds every role
+func (i *Identity) HasRole(role string) bool {
+	for _, r := range i.Roles {
+		if r == role || r == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOperator reports whether the identity administers every tenant. Admins
+// of the default tenant are operators.
+func (i *Identity) IsOperator() bool {
+	return i.TenantID == DefaultTenant && i.HasRole(RoleAdmin)
+}
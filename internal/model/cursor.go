@@ -0,0 +1,45 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursorPayload is the position encoded in a pagination cursor
+type cursorPayload struct {
+	Timestamp time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// EncodeCursor encodes the (timestamp, id) of the last returned row as an opaque token
+func EncodeCursor(timestamp time.Time, id string) string {
+	data, _ := json.Marshal(cursorPayload{Timestamp: timestamp, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a token produced by EncodeCursor
+func DecodeCursor(cursor string) (time.Time, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	if payload.Timestamp.IsZero() {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	if _, err := uuid.Parse(payload.ID); err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	return payload.Timestamp, payload.ID, nil
+}
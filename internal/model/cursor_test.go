@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	timestamp := time.Date(2026, 1, 29, 12, 0, 0, 123456000, time.UTC)
+
+	cursor := EncodeCursor(timestamp, "550e8400-e29b-41d4-a716-446655440000")
+	gotTime, gotID, err := DecodeCursor(cursor)
+
+	require.NoError(t, err)
+	assert.True(t, timestamp.Equal(gotTime))
+	assert.Equal(t, "550e8400-e29b-41d4-a716-446655440000", gotID)
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, cursor := range []string{"", "not base64!", "bm90IGpzb24", "e30"} {
+		_, _, err := DecodeCursor(cursor)
+		assert.ErrorIs(t, err, ErrInvalidCursor, cursor)
+	}
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// Dead letter statuses
+const (
+	DeadLetterPending  = "pending"
+	DeadLetterReplayed = "replayed"
+	DeadLetterAll      = "all"
+)
+
+// DeadLetter is a log event that could not be stored, with the reason
+type DeadLetter struct {
+	ID         int64      `json:"id"`
+	Log        LogEvent   `json:"log"`
+	Error      string     `json:"error"`
+	FailedAt   time.Time  `json:"failed_at"`
+	ReplayedAt *time.Time `json:"replayed_at,omitempty"`
+}
+
+// DeadLetterListResponse represents a page of dead letters
+type DeadLetterListResponse struct {
+	Total       int          `json:"total"`
+	Count       int          `json:"count"`
+	DeadLetters []DeadLetter `json:"dead_letters"`
+}
+
+// ReplayRequest selects dead letters to resubmit; empty IDs replays all pending
+type ReplayRequest struct {
+	IDs []int64 `json:"ids,omitempty"`
+}
+
+// ReplayResponse represents the outcome of a dead letter replay
+type ReplayResponse struct {
+	Replayed int                 `json:"replayed"`
+	Failed   int                 `json:"failed"`
+	Errors   []map[string]string `json:"errors,omitempty"`
+}
@@ -0,0 +1,10 @@
+package model
+
+// Attributes set on events with addresses found in GeoIP databases
+const (
+	// GeoAttributeSuffix names the description of the addresses of an
+	// attribute, such as src_ip_geo for src_ip
+	GeoAttributeSuffix = "_geo"
+	// MessageGeoAttribute describes the addresses found in the message
+	MessageGeoAttribute = "message_geo"
+)
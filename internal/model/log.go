@@ -15,14 +15,33 @@ const (
 	SeverityInfo     = "INFO"
 )
 
+// DefaultTenant owns events ingested without an authenticated tenant, such
+// as syslog or requests made while authentication is disabled
+const DefaultTenant = "default"
+
 // LogEvent represents a single log entry
 type LogEvent struct {
-	ID         string    `json:"id" db:"id"`
-	Timestamp  time.Time `json:"timestamp" db:"timestamp"`
-	Severity   string    `json:"severity" db:"severity"`
-	Source     string    `json:"source" db:"source"`
-	Message    string    `json:"message" db:"message"`
-	IngestedAt time.Time `json:"ingested_at,omitempty" db:"ingested_at"`
+	ID         string         `json:"id" db:"id"`
+	TenantID   string         `json:"tenant_id,omitempty" db:"tenant_id"`
+	Timestamp  time.Time      `json:"timestamp" db:"timestamp"`
+	Severity   string         `json:"severity" db:"severity"`
+	Source     string         `json:"source" db:"source"`
+	Message    string         `json:"message" db:"message"`
+	Attributes map[string]any `json:"attributes,omitempty" db:"attributes"`
+	IngestedAt time.Time      `json:"ingested_at,omitempty" db:"ingested_at"`
+	// RepeatCount counts the identical events collapsed into this one, seen
+	// between FirstSeen and LastSeen
+	RepeatCount int        `json:"repeat_count,omitempty" db:"repeat_count"`
+	FirstSeen   *time.Time `json:"first_seen,omitempty" db:"first_seen"`
+	LastSeen    *time.Time `json:"last_seen,omitempty" db:"last_seen"`
+}
+
+// LogRepeats adds repeats collapsed after a log was stored
+type LogRepeats struct {
+	ID        string
+	Timestamp time.Time
+	Repeats   int
+	LastSeen  time.Time
 }
 
 // NewLogEvent creates a new log event with generated ID
@@ -46,22 +65,69 @@ func IsValidSeverity(severity string) bool {
 	}
 }
 
-// IngestRequest represents the API request for log ingestion
+// SeverityRank orders severities from INFO (1) to CRITICAL (5); unknown
+// severities rank 0
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityCritical:
+		return 5
+	case SeverityHigh:
+		return 4
+	case SeverityMedium:
+		return 3
+	case SeverityLow:
+		return 2
+	case SeverityInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Sort orders for log queries
+const (
+	SortTimestamp = "timestamp"
+	SortRelevance = "relevance"
+)
+
+// Total count modes for log queries
+const (
+	CountExact    = "exact"
+	CountEstimate = "estimate"
+)
+
+// IngestRequest represents the API request for log ingestion. Retries with
+// the same ID are ingested once.
 type IngestRequest struct {
-	Timestamp time.Time `json:"timestamp"`
-	Severity  string    `json:"severity"`
-	Source    string    `json:"source"`
-	Message   string    `json:"message"`
+	// ID is the client's key for the event. The event is stored under an ID
+	// derived from it, with the key kept in the ClientIDAttribute attribute.
+	ID         string         `json:"id,omitempty"`
+	Timestamp  time.Time      `json:"timestamp"`
+	Severity   string         `json:"severity"`
+	Source     string         `json:"source"`
+	Message    string         `json:"message"`
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
+// ClientIDAttribute holds the id a client gave an event
+const ClientIDAttribute = "client_id"
+
 // BatchIngestRequest represents batch ingestion request
 type BatchIngestRequest struct {
 	Logs []IngestRequest `json:"logs"`
 }
 
+// Ingest response statuses
+const (
+	IngestStatusIngested  = "ingested"
+	IngestStatusDuplicate = "duplicate"
+)
+
 // IngestResponse represents the API response for log ingestion
 type IngestResponse struct {
-	ID        string    `json:"id"`
+	ID string `json:"id"`
+	// ClientID echoes the id of the request, which ID is derived from
+	ClientID  string    `json:"client_id,omitempty"`
 	Status    string    `json:"status"`
 	Timestamp time.Time `json:"timestamp"`
 }
@@ -71,23 +137,57 @@ type BatchIngestResponse struct {
 	Accepted int                    `json:"accepted"`
 	Rejected int                    `json:"rejected"`
 	Errors   []map[string]string    `json:"errors,omitempty"`
+	// Duplicates lists the entries that were already ingested
+	Duplicates []BatchDuplicate `json:"duplicates,omitempty"`
+}
+
+// BatchDuplicate identifies a batch entry that was already ingested
+type BatchDuplicate struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	ClientID string `json:"client_id,omitempty"`
 }
 
 // QueryRequest represents query parameters
 type QueryRequest struct {
-	StartTime time.Time `json:"start_time"`
-	EndTime   time.Time `json:"end_time"`
-	Severity  []string  `json:"severity,omitempty"`
-	Source    string    `json:"source,omitempty"`
-	Limit     int       `json:"limit"`
-	Offset    int       `json:"offset"`
+	// TenantID is set from the caller, never from query parameters
+	TenantID        string            `json:"-"`
+	StartTime       time.Time         `json:"start_time"`
+	EndTime         time.Time         `json:"end_time"`
+	Severity        []string          `json:"severity,omitempty"`
+	Source          string            `json:"source,omitempty"`
+	Attributes      map[string]string `json:"attributes,omitempty"`
+	AttributeExists []string          `json:"attribute_exists,omitempty"`
+	Query           string            `json:"q,omitempty"`
+	Search          string            `json:"search,omitempty"`
+	Sort            string            `json:"sort,omitempty"`
+	Cursor          string            `json:"cursor,omitempty"`
+	Count           string            `json:"count,omitempty"`
+	Limit           int               `json:"limit"`
+	Offset          int               `json:"offset"`
 }
 
 // QueryResponse represents query results
 type QueryResponse struct {
-	Total int        `json:"total"`
-	Count int        `json:"count"`
-	Logs  []LogEvent `json:"logs"`
+	Total          int        `json:"total"`
+	TotalEstimated bool       `json:"total_estimated,omitempty"`
+	Count          int        `json:"count"`
+	Logs           []LogEvent `json:"logs"`
+	NextCursor     string     `json:"next_cursor,omitempty"`
+}
+
+// Context sizes for the events around a log
+const (
+	DefaultContextEvents = 10
+	MaxContextEvents     = 100
+)
+
+// LogContextResponse is a log with the events from the same source
+// immediately before and after it, both oldest first
+type LogContextResponse struct {
+	Log    LogEvent   `json:"log"`
+	Before []LogEvent `json:"before"`
+	After  []LogEvent `json:"after"`
 }
 
 // ErrorResponse represents error response
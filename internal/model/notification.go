@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// Notification channel types
+const (
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+	ChannelSlack   = "slack"
+	ChannelTeams   = "teams"
+)
+
+// Notification kinds
+const (
+	NotificationEvent = "event"
+	NotificationAlert = "alert"
+	NotificationTest  = "test"
+)
+
+// Notification delivery statuses
+const (
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// NotificationDelivery records a notification sent, or given up on, by a channel
+type NotificationDelivery struct {
+	ID          string `json:"id"`
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channel_type"`
+	Kind        string `json:"kind"`
+	TenantID    string `json:"tenant_id"`
+	// ReferenceID is the ID of the notified log or alert
+	ReferenceID string `json:"reference_id,omitempty"`
+	Subject     string `json:"subject"`
+	Status      string `json:"status"`
+	Attempts    int    `json:"attempts"`
+	// Suppressed counts notifications dropped by the channel's rate limit
+	// since its previous delivery
+	Suppressed  int       `json:"suppressed,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// NotificationChannelInfo describes a configured channel without its secrets
+type NotificationChannelInfo struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Events bool   `json:"events"`
+	Alerts bool   `json:"alerts"`
+}
+
+// NotificationChannelListResponse represents a list of notification channels
+type NotificationChannelListResponse struct {
+	Count    int                       `json:"count"`
+	Channels []NotificationChannelInfo `json:"channels"`
+}
+
+// NotificationDeliveryListResponse represents a list of notification deliveries
+type NotificationDeliveryListResponse struct {
+	Count      int                    `json:"count"`
+	Deliveries []NotificationDelivery `json:"deliveries"`
+}
@@ -0,0 +1,30 @@
+package model
+
+// Pipeline processor types
+const (
+	ProcessorDrop   = "drop"
+	ProcessorRename = "rename"
+	ProcessorParse  = "parse"
+	ProcessorTag    = "tag"
+	ProcessorRedact = "redact"
+)
+
+// What happens to an event when a processor fails on it
+const (
+	OnErrorPassThrough = "pass_through"
+	OnErrorDrop        = "drop"
+	OnErrorDeadLetter  = "dead_letter"
+)
+
+// Formats of the parse processor
+const (
+	ParseJSON  = "json"
+	ParseKV    = "kv"
+	ParseRegex = "regex"
+)
+
+// TagsAttribute lists the tags added by tag processors
+const TagsAttribute = "tags"
+
+// DefaultRedaction replaces redacted values
+const DefaultRedaction = "[REDACTED]"
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// Retention actions
+const (
+	RetentionDelete  = "delete"
+	RetentionArchive = "archive"
+)
+
+// RetentionRun reports what a retention run removed
+type RetentionRun struct {
+	ID         int64                 `json:"id"`
+	StartedAt  time.Time             `json:"started_at"`
+	FinishedAt time.Time             `json:"finished_at"`
+	Deleted    int64                 `json:"deleted"`
+	Archived   int64                 `json:"archived"`
+	Rules      []RetentionRuleReport `json:"rules"`
+}
+
+// RetentionRuleReport reports what a single retention rule removed
+type RetentionRuleReport struct {
+	Rule     string    `json:"rule"`
+	Action   string    `json:"action"`
+	Before   time.Time `json:"before"`
+	Deleted  int64     `json:"deleted"`
+	Archived int64     `json:"archived"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// RetentionRunListResponse represents a list of retention runs
+type RetentionRunListResponse struct {
+	Count int            `json:"count"`
+	Runs  []RetentionRun `json:"runs"`
+}
@@ -0,0 +1,107 @@
+package model
+
+import "time"
+
+// Rule types
+const (
+	RuleTypeConditions = "conditions"
+	RuleTypeSigma      = "sigma"
+)
+
+// Rule condition fields; attributes are addressed as "attr.<key>"
+const (
+	FieldSeverity        = "severity"
+	FieldSource          = "source"
+	FieldMessage         = "message"
+	AttributeFieldPrefix = "attr."
+)
+
+// Rule condition operators
+const (
+	OperatorEquals    = "equals"
+	OperatorNotEquals = "not_equals"
+	OperatorIn        = "in"
+	OperatorContains  = "contains"
+	OperatorRegex     = "regex"
+	OperatorExists    = "exists"
+)
+
+// Rule is a user-defined detection rule evaluated against ingested events.
+// A conditions rule matches when all conditions match; a sigma rule matches
+// its Sigma detection. Without a threshold every matching event raises an
+// alert; with one, an alert is raised when Count matching events arrive
+// within Window, grouped by GroupBy. Sigma rules with a Schedule run as a
+// periodic search over the logs table instead of on the ingest stream.
+type Rule struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Enabled     bool            `json:"enabled"`
+	Severity    string          `json:"severity"`
+	Type        string          `json:"type"`
+	Conditions  []RuleCondition `json:"conditions,omitempty"`
+	Sigma       string          `json:"sigma,omitempty"`
+	Schedule    string          `json:"schedule,omitempty"`
+	Threshold   *RuleThreshold  `json:"threshold,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// RuleCondition matches a single event field
+type RuleCondition struct {
+	Field    string   `json:"field"`
+	Operator string   `json:"operator"`
+	Value    string   `json:"value,omitempty"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// RuleThreshold raises an alert when Count events with timestamps within
+// Window of each other match
+type RuleThreshold struct {
+	Count   int    `json:"count"`
+	Window  string `json:"window"`
+	GroupBy string `json:"group_by,omitempty"`
+}
+
+// RuleRequest represents the API request to create or update a rule
+type RuleRequest struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Enabled     *bool           `json:"enabled,omitempty"`
+	Severity    string          `json:"severity"`
+	Type        string          `json:"type,omitempty"`
+	Conditions  []RuleCondition `json:"conditions,omitempty"`
+	Sigma       string          `json:"sigma,omitempty"`
+	Schedule    string          `json:"schedule,omitempty"`
+	Threshold   *RuleThreshold  `json:"threshold,omitempty"`
+}
+
+// RuleListResponse represents a list of rules
+type RuleListResponse struct {
+	Count int    `json:"count"`
+	Rules []Rule `json:"rules"`
+}
+
+// SigmaTranslation describes how a Sigma rule translates
+type SigmaTranslation struct {
+	Index       int           `json:"index"`
+	Title       string        `json:"title,omitempty"`
+	Supported   bool          `json:"supported"`
+	Errors      []string      `json:"errors,omitempty"`
+	Unsupported []string      `json:"unsupported,omitempty"`
+	Warnings    []string      `json:"warnings,omitempty"`
+	Fields      []string      `json:"fields,omitempty"`
+	SQL         string        `json:"sql,omitempty"`
+	Args        []interface{} `json:"args,omitempty"`
+}
+
+// SigmaTranslateResponse represents the result of translating Sigma rules
+type SigmaTranslateResponse struct {
+	Rules []SigmaTranslation `json:"rules"`
+}
+
+// SigmaImportResponse represents the result of importing Sigma rules
+type SigmaImportResponse struct {
+	Imported []Rule             `json:"imported"`
+	Rejected []SigmaTranslation `json:"rejected"`
+}
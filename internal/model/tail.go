@@ -0,0 +1,13 @@
+package model
+
+// TailRequest holds the filters of a live tail subscription. They match
+// the corresponding QueryRequest filters.
+type TailRequest struct {
+	// TenantID is set from the caller, never from query parameters
+	TenantID        string            `json:"-"`
+	Severity        []string          `json:"severity,omitempty"`
+	Source          string            `json:"source,omitempty"`
+	Attributes      map[string]string `json:"attributes,omitempty"`
+	AttributeExists []string          `json:"attribute_exists,omitempty"`
+	Query           string            `json:"q,omitempty"`
+}
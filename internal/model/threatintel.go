@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// Indicator types
+const (
+	IndicatorIP     = "ip"
+	IndicatorDomain = "domain"
+	IndicatorURL    = "url"
+	IndicatorHash   = "hash"
+)
+
+// Threat feed formats
+const (
+	FeedFormatSTIX = "stix"
+	FeedFormatMISP = "misp"
+	FeedFormatCSV  = "csv"
+)
+
+// Threat feed origins
+const (
+	FeedOriginConfig = "config"
+	FeedOriginUpload = "upload"
+)
+
+// Attributes set on events that match indicators
+const (
+	// ThreatIntelAttribute lists the indicators an event matched
+	ThreatIntelAttribute = "threat_intel"
+	// OriginalSeverityAttribute keeps the severity of an event raised by a feed
+	OriginalSeverityAttribute = "original_severity"
+)
+
+// ThreatFeed describes a loaded indicator feed
+type ThreatFeed struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Origin string `json:"origin"`
+	// Severity is the minimum severity of matching events; empty leaves it
+	Severity   string `json:"severity,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Indicators int    `json:"indicators"`
+	// Skipped counts entries that were not usable indicators
+	Skipped    int        `json:"skipped"`
+	UploadedBy string     `json:"uploaded_by,omitempty"`
+	UploadedAt *time.Time `json:"uploaded_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LoadedAt   time.Time  `json:"loaded_at"`
+	// Error is the last load failure; the feed keeps its previous indicators
+	Error string `json:"error,omitempty"`
+}
+
+// ThreatFeedListResponse represents the loaded feeds
+type ThreatFeedListResponse struct {
+	Count int          `json:"count"`
+	Feeds []ThreatFeed `json:"feeds"`
+}
+
+// ThreatFeedUpload describes an uploaded feed; the feed itself is the request body
+type ThreatFeedUpload struct {
+	Name     string
+	Format   string
+	Severity string
+	// ExpiresIn unloads the feed after a duration; empty keeps it until expired
+	ExpiresIn string
+}
@@ -0,0 +1,208 @@
+package notify
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// Channel is a configured notification destination
+type Channel struct {
+	Name string
+	Type string
+	// URL receives webhook, Slack and Teams notifications
+	URL string
+	// Secret signs webhook payloads; empty sends them unsigned
+	Secret string
+	SMTP   SMTP
+	// Events sends CRITICAL events, Alerts sends rule alerts
+	Events bool
+	Alerts bool
+	// Tenants are globs; empty notifies every tenant
+	Tenants []string
+	// Subject and Body are text/template overrides of the default message
+	Subject string
+	Body    string
+	// RateLimit is the sustained number of notifications per minute, with
+	// bursts of up to Burst; zero is unlimited
+	RateLimit float64
+	Burst     int
+	Retry     RetryPolicy
+	Timeout   time.Duration
+}
+
+// SMTP holds the mail server and addresses of an email channel
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// RetryPolicy controls how failed deliveries are retried
+type RetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// backoff returns the delay before the given retry attempt (1-based),
+// doubling from InitialBackoff up to MaxBackoff
+func (rp RetryPolicy) backoff(attempt int) time.Duration {
+	delay := rp.InitialBackoff << (attempt - 1)
+	if delay <= 0 || (rp.MaxBackoff > 0 && delay > rp.MaxBackoff) {
+		delay = rp.MaxBackoff
+	}
+	return max(delay, 0)
+}
+
+// Validate checks a channel set
+func Validate(channels []Channel) error {
+	names := make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		if ch.Name == "" {
+			return fmt.Errorf("notification channel name is required")
+		}
+		if names[ch.Name] {
+			return fmt.Errorf("duplicate notification channel %q", ch.Name)
+		}
+		names[ch.Name] = true
+
+		switch ch.Type {
+		case model.ChannelWebhook, model.ChannelSlack, model.ChannelTeams:
+			u, err := url.Parse(ch.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("notification channel %q: url must be an http or https URL", ch.Name)
+			}
+		case model.ChannelEmail:
+			if ch.SMTP.Host == "" || ch.SMTP.Port <= 0 {
+				return fmt.Errorf("notification channel %q: smtp host and port are required", ch.Name)
+			}
+			if ch.SMTP.From == "" || len(ch.SMTP.To) == 0 {
+				return fmt.Errorf("notification channel %q: from and to addresses are required", ch.Name)
+			}
+		default:
+			return fmt.Errorf("notification channel %q: type must be webhook, email, slack or teams", ch.Name)
+		}
+
+		for _, pattern := range ch.Tenants {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("notification channel %q: invalid tenant glob %q", ch.Name, pattern)
+			}
+		}
+		if ch.RateLimit < 0 || ch.Burst < 0 {
+			return fmt.Errorf("notification channel %q: rate_limit and burst cannot be negative", ch.Name)
+		}
+		if ch.Retry.MaxRetries < 0 {
+			return fmt.Errorf("notification channel %q: max_retries cannot be negative", ch.Name)
+		}
+		if _, err := parseTemplates(ch); err != nil {
+			return fmt.Errorf("notification channel %q: %w", ch.Name, err)
+		}
+	}
+	return nil
+}
+
+// wants reports whether the channel sends a kind of notification for a tenant
+func (ch Channel) wants(kind, tenantID string) bool {
+	switch kind {
+	case model.NotificationEvent:
+		if !ch.Events {
+			return false
+		}
+	case model.NotificationAlert:
+		if !ch.Alerts {
+			return false
+		}
+	}
+
+	if len(ch.Tenants) == 0 {
+		return true
+	}
+	for _, pattern := range ch.Tenants {
+		if ok, _ := path.Match(pattern, tenantID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// limiter is a token bucket rate limiting a channel
+type limiter struct {
+	perSecond float64
+	burst     float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newLimiter creates a limiter of perMinute notifications with bursts of
+// burst, which defaults to one; it returns nil when perMinute is zero
+func newLimiter(perMinute float64, burst int) *limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &limiter{
+		perSecond: perMinute / 60,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+	}
+}
+
+// allow takes a token at now if one is available
+func (l *limiter) allow(now time.Time) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.perSecond)
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// templates holds the parsed subject and body templates of a channel
+type templates struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func parseTemplates(ch Channel) (*templates, error) {
+	subject, body := defaultSubject, defaultBody
+	if ch.Subject != "" {
+		subject = ch.Subject
+	}
+	if ch.Body != "" {
+		body = ch.Body
+	}
+
+	st, err := template.New("subject").Option("missingkey=zero").Parse(subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+	bt, err := template.New("body").Option("missingkey=zero").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	return &templates{subject: st, body: bt}, nil
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// defaultSubject and defaultBody render notifications of channels without
+// their own templates
+const (
+	defaultSubject = `[ThreatLog] {{.Severity}} {{.Title}}`
+	defaultBody    = `{{.Message}}
+
+Tenant: {{.TenantID}}
+Time: {{.Time.Format "2006-01-02T15:04:05Z07:00"}}
+{{- with .Event}}
+Source: {{.Source}}
+Log ID: {{.ID}}
+{{- end}}
+{{- with .Alert}}
+Rule: {{.RuleName}}
+Events: {{.EventCount}}
+Alert ID: {{.ID}}
+{{- end}}
+{{- if .Suppressed}}
+
+{{.Suppressed}} earlier notifications were suppressed by the rate limit.
+{{- end}}
+`
+)
+
+// Notification is something worth telling people about: a CRITICAL event,
+// a rule alert or a test
+type Notification struct {
+	Kind     string
+	TenantID string
+	Severity string
+	// Title is a one-line summary, Message the event or alert message
+	Title   string
+	Message string
+	Time    time.Time
+	// Event or Alert is set for event and alert notifications
+	Event *model.LogEvent
+	Alert *model.Alert
+	// Suppressed is set at delivery to the notifications the channel's
+	// rate limit dropped since its previous delivery
+	Suppressed int
+}
+
+// referenceID returns the ID of the notified log or alert
+func (n *Notification) referenceID() string {
+	switch {
+	case n.Event != nil:
+		return n.Event.ID
+	case n.Alert != nil:
+		return n.Alert.ID
+	default:
+		return ""
+	}
+}
+
+// eventNotification notifies of a stored event
+func eventNotification(event model.LogEvent) Notification {
+	return Notification{
+		Kind:     model.NotificationEvent,
+		TenantID: event.TenantID,
+		Severity: event.Severity,
+		Title:    fmt.Sprintf("event from %s", event.Source),
+		Message:  event.Message,
+		Time:     event.Timestamp,
+		Event:    &event,
+	}
+}
+
+// repeatNotification notifies of the repeats of an event, whose repeat
+// count holds the number of new repeats
+func repeatNotification(event model.LogEvent) Notification {
+	notification := eventNotification(event)
+	notification.Title = fmt.Sprintf("event from %s repeated %d times", event.Source, event.RepeatCount)
+	return notification
+}
+
+// alertNotification notifies of a rule alert
+func alertNotification(alert model.Alert) Notification {
+	return Notification{
+		Kind:     model.NotificationAlert,
+		TenantID: alert.TenantID,
+		Severity: alert.Severity,
+		Title:    fmt.Sprintf("alert: %s", alert.RuleName),
+		Message:  alert.Message,
+		Time:     alert.TriggeredAt,
+		Alert:    &alert,
+	}
+}
+
+// testNotification checks that a channel is set up correctly
+func testNotification(channel string) Notification {
+	return Notification{
+		Kind:     model.NotificationTest,
+		TenantID: model.DefaultTenant,
+		Severity: model.SeverityInfo,
+		Title:    "test notification",
+		Message:  fmt.Sprintf("This is a test notification for channel %q.", channel),
+		Time:     time.Now().UTC(),
+	}
+}
+
+// message is a rendered notification
+type message struct {
+	Subject string
+	Body    string
+}
+
+// render fills the channel's templates with a notification
+func (t *templates) render(n *Notification) (message, error) {
+	var subject, body strings.Builder
+	if err := t.subject.Execute(&subject, n); err != nil {
+		return message{}, fmt.Errorf("failed to render subject: %w", err)
+	}
+	if err := t.body.Execute(&body, n); err != nil {
+		return message{}, fmt.Errorf("failed to render body: %w", err)
+	}
+
+	// Subjects end up in mail headers, so they must stay on one line
+	return message{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Body:    body.String(),
+	}, nil
+}
@@ -0,0 +1,273 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+)
+
+// ErrUnknownChannel is returned when a named channel is not configured
+var ErrUnknownChannel = errors.New("notification channel not found")
+
+// Defaults of channels that leave their timeout or backoff unset
+const (
+	defaultTimeout        = 10 * time.Second
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+)
+
+// Notifier delivers notifications of CRITICAL events and rule alerts to the
+// configured channels. Each channel has its own queue and worker, so a slow
+// or failing destination never delays the others or ingestion, and every
+// delivery is recorded in the delivery log.
+type Notifier struct {
+	repo     *repository.PostgresRepository
+	channels []*channel
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// channel is the runtime state of a configured channel
+type channel struct {
+	cfg       Channel
+	sender    sender
+	templates *templates
+	limiter   *limiter
+	queue     chan Notification
+	// suppressed counts notifications dropped since the last delivery
+	suppressed atomic.Int64
+}
+
+// NewNotifier creates a notifier. Channels must have passed Validate.
+func NewNotifier(repo *repository.PostgresRepository, channels []Channel, bufferSize int) (*Notifier, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	n := &Notifier{
+		repo:   repo,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	client := &http.Client{}
+	for _, cfg := range channels {
+		t, err := parseTemplates(cfg)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = defaultTimeout
+		}
+		if cfg.Retry.InitialBackoff <= 0 {
+			cfg.Retry.InitialBackoff = defaultInitialBackoff
+		}
+		if cfg.Retry.MaxBackoff <= 0 {
+			cfg.Retry.MaxBackoff = defaultMaxBackoff
+		}
+		n.channels = append(n.channels, &channel{
+			cfg:       cfg,
+			sender:    newSender(cfg, client),
+			templates: t,
+			limiter:   newLimiter(cfg.RateLimit, cfg.Burst),
+			queue:     make(chan Notification, bufferSize),
+		})
+	}
+
+	return n, nil
+}
+
+// Start starts a delivery worker per channel
+func (n *Notifier) Start() {
+	for _, ch := range n.channels {
+		n.wg.Add(1)
+		go n.worker(ch)
+	}
+}
+
+// Stop stops the workers. Notifications still queued are dropped.
+func (n *Notifier) Stop() {
+	log.Info().Msg("Stopping notifier")
+	n.cancel()
+	n.wg.Wait()
+	log.Info().Msg("Notifier stopped")
+}
+
+// Observe notifies of stored CRITICAL events
+func (n *Notifier) Observe(events []model.LogEvent) {
+	for _, event := range events {
+		if event.Severity == model.SeverityCritical {
+			n.enqueue(eventNotification(event))
+		}
+	}
+}
+
+// ObserveRepeats notifies of repeats of CRITICAL events collapsed into
+// stored ones
+func (n *Notifier) ObserveRepeats(events []model.LogEvent) {
+	for _, event := range events {
+		if event.Severity == model.SeverityCritical {
+			n.enqueue(repeatNotification(event))
+		}
+	}
+}
+
+// ObserveAlerts notifies of stored rule alerts
+func (n *Notifier) ObserveAlerts(alerts []model.Alert) {
+	for _, alert := range alerts {
+		n.enqueue(alertNotification(alert))
+	}
+}
+
+// enqueue hands a notification to every channel that wants it without
+// blocking. Notifications over a channel's rate limit or beyond its queue
+// are dropped and counted on the channel's next delivery.
+func (n *Notifier) enqueue(notification Notification) {
+	now := time.Now()
+	for _, ch := range n.channels {
+		if !ch.cfg.wants(notification.Kind, notification.TenantID) {
+			continue
+		}
+		if !ch.limiter.allow(now) {
+			ch.suppressed.Add(1)
+			continue
+		}
+
+		select {
+		case ch.queue <- notification:
+		default:
+			ch.suppressed.Add(1)
+			log.Warn().Str("channel", ch.cfg.Name).Msg("Notification queue full, dropping notification")
+		}
+	}
+}
+
+// Channels describes the configured channels
+func (n *Notifier) Channels() []model.NotificationChannelInfo {
+	infos := make([]model.NotificationChannelInfo, 0, len(n.channels))
+	for _, ch := range n.channels {
+		infos = append(infos, model.NotificationChannelInfo{
+			Name:   ch.cfg.Name,
+			Type:   ch.cfg.Type,
+			Events: ch.cfg.Events,
+			Alerts: ch.cfg.Alerts,
+		})
+	}
+	return infos
+}
+
+// Test sends a test notification through a channel right away, bypassing
+// its rate limit, queue and retries, and records the delivery
+func (n *Notifier) Test(ctx context.Context, name string) (*model.NotificationDelivery, error) {
+	for _, ch := range n.channels {
+		if ch.cfg.Name != name {
+			continue
+		}
+
+		notification := testNotification(name)
+		delivery := ch.deliver(ctx, &notification, 0)
+		if err := n.repo.InsertNotificationDelivery(ctx, delivery); err != nil {
+			log.Error().Err(err).Str("channel", name).Msg("Failed to record notification delivery")
+		}
+		return &delivery, nil
+	}
+	return nil, ErrUnknownChannel
+}
+
+// worker delivers the queued notifications of a channel in order
+func (n *Notifier) worker(ch *channel) {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case notification := <-ch.queue:
+			notification.Suppressed = int(ch.suppressed.Swap(0))
+			delivery := ch.deliver(n.ctx, &notification, ch.cfg.Retry.MaxRetries)
+			if delivery.Status == model.DeliveryFailed {
+				log.Error().
+					Str("channel", ch.cfg.Name).
+					Str("kind", notification.Kind).
+					Int("attempts", delivery.Attempts).
+					Str("error", delivery.Error).
+					Msg("Failed to deliver notification")
+			}
+			n.record(delivery)
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
+
+// record stores a delivery in the delivery log
+func (n *Notifier) record(delivery model.NotificationDelivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := n.repo.InsertNotificationDelivery(ctx, delivery); err != nil {
+		log.Error().Err(err).Str("channel", delivery.Channel).Msg("Failed to record notification delivery")
+	}
+}
+
+// deliver renders and sends a notification, retrying failures that are not
+// permanent up to retries times with backoff, and reports the outcome
+func (ch *channel) deliver(ctx context.Context, notification *Notification, retries int) model.NotificationDelivery {
+	delivery := model.NotificationDelivery{
+		ID:          uuid.New().String(),
+		Channel:     ch.cfg.Name,
+		ChannelType: ch.cfg.Type,
+		Kind:        notification.Kind,
+		TenantID:    notification.TenantID,
+		ReferenceID: notification.referenceID(),
+		Subject:     notification.Title,
+		Suppressed:  notification.Suppressed,
+		CreatedAt:   time.Now(),
+	}
+
+	err := ch.send(ctx, &delivery, notification, retries)
+
+	delivery.CompletedAt = time.Now()
+	if err != nil {
+		delivery.Status = model.DeliveryFailed
+		delivery.Error = err.Error()
+	} else {
+		delivery.Status = model.DeliveryDelivered
+	}
+	return delivery
+}
+
+func (ch *channel) send(ctx context.Context, delivery *model.NotificationDelivery, notification *Notification, retries int) error {
+	msg, err := ch.templates.render(notification)
+	if err != nil {
+		return err
+	}
+	delivery.Subject = msg.Subject
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(ch.cfg.Retry.backoff(attempt)):
+			case <-ctx.Done():
+				return err
+			}
+		}
+
+		delivery.Attempts++
+		attemptCtx, cancel := context.WithTimeout(ctx, ch.cfg.Timeout)
+		err = ch.sender.send(attemptCtx, delivery.ID, notification, msg)
+		cancel()
+
+		if err == nil || isPermanent(err) || attempt >= retries || ctx.Err() != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+func testChannel(name, url string) Channel {
+	return Channel{
+		Name:   name,
+		Type:   model.ChannelWebhook,
+		URL:    url,
+		Events: true,
+		Alerts: true,
+		Retry:  RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	}
+}
+
+func newTestChannel(t *testing.T, cfg Channel) *channel {
+	n, err := NewNotifier(nil, []Channel{cfg}, 10)
+	require.NoError(t, err)
+	return n.channels[0]
+}
+
+func criticalEvent() model.LogEvent {
+	return model.LogEvent{
+		ID:        "log-1",
+		TenantID:  "acme",
+		Timestamp: time.Date(2026, 1, 29, 10, 0, 0, 0, time.UTC),
+		Severity:  model.SeverityCritical,
+		Source:    "fw-01",
+		Message:   "Ransomware detected",
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := testChannel("ops", "https://hooks.example.com/x")
+	assert.NoError(t, Validate([]Channel{valid}))
+
+	tests := []struct {
+		name   string
+		modify func(ch *Channel)
+		want   string
+	}{
+		{"missing name", func(ch *Channel) { ch.Name = "" }, "name is required"},
+		{"unknown type", func(ch *Channel) { ch.Type = "pager" }, "type must be"},
+		{"bad url", func(ch *Channel) { ch.URL = "ftp://example.com" }, "url must be"},
+		{"email without smtp", func(ch *Channel) { ch.Type = model.ChannelEmail }, "smtp host and port"},
+		{"bad template", func(ch *Channel) { ch.Body = "{{.Message" }, "invalid body template"},
+		{"negative rate", func(ch *Channel) { ch.RateLimit = -1 }, "cannot be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := valid
+			tt.modify(&ch)
+			err := Validate([]Channel{ch})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+
+	err := Validate([]Channel{valid, valid})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate")
+}
+
+func TestWebhookSignature(t *testing.T) {
+	var received webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get(HeaderTimestamp)
+		if r.Header.Get(HeaderSignature) != "sha256="+Sign("s3cret", timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.Unmarshal(body, &received)
+	}))
+	defer server.Close()
+
+	cfg := testChannel("hook", server.URL)
+	cfg.Secret = "s3cret"
+	ch := newTestChannel(t, cfg)
+
+	n := eventNotification(criticalEvent())
+	delivery := ch.deliver(context.Background(), &n, 0)
+
+	assert.Equal(t, model.DeliveryDelivered, delivery.Status, delivery.Error)
+	assert.Equal(t, "log-1", delivery.ReferenceID)
+	assert.Equal(t, "[ThreatLog] CRITICAL event from fw-01", delivery.Subject)
+	assert.Equal(t, model.NotificationEvent, received.Kind)
+	assert.Equal(t, "acme", received.TenantID)
+	require.NotNil(t, received.Event)
+	assert.Equal(t, "log-1", received.Event.ID)
+	assert.Contains(t, received.Body, "Ransomware detected")
+}
+
+func TestDeliverRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/flaky") && calls.Add(1) < 3:
+			w.WriteHeader(http.StatusBadGateway)
+		case strings.HasSuffix(r.URL.Path, "/rejected"):
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	n := eventNotification(criticalEvent())
+
+	flaky := newTestChannel(t, testChannel("flaky", server.URL+"/flaky"))
+	delivery := flaky.deliver(context.Background(), &n, 2)
+	assert.Equal(t, model.DeliveryDelivered, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+
+	calls.Store(0)
+	rejected := newTestChannel(t, testChannel("rejected", server.URL+"/rejected"))
+	delivery = rejected.deliver(context.Background(), &n, 2)
+	assert.Equal(t, model.DeliveryFailed, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts, "client errors are not retried")
+	assert.Contains(t, delivery.Error, "400")
+}
+
+func TestEnqueueRateLimit(t *testing.T) {
+	limited := testChannel("limited", "https://hooks.example.com/a")
+	limited.RateLimit = 1
+	limited.Burst = 2
+
+	alertsOnly := testChannel("alerts", "https://hooks.example.com/b")
+	alertsOnly.Events = false
+
+	otherTenant := testChannel("other", "https://hooks.example.com/c")
+	otherTenant.Tenants = []string{"globex-*"}
+
+	n, err := NewNotifier(nil, []Channel{limited, alertsOnly, otherTenant}, 10)
+	require.NoError(t, err)
+
+	info := criticalEvent()
+	info.Severity = model.SeverityInfo
+	n.Observe([]model.LogEvent{criticalEvent(), criticalEvent(), criticalEvent(), info})
+
+	assert.Len(t, n.channels[0].queue, 2)
+	assert.Equal(t, int64(1), n.channels[0].suppressed.Load())
+	assert.Len(t, n.channels[1].queue, 0)
+	assert.Len(t, n.channels[2].queue, 0)
+
+	n.ObserveAlerts([]model.Alert{{ID: "alert-1", TenantID: "acme", RuleName: "Brute force"}})
+	assert.Len(t, n.channels[1].queue, 1)
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(60, 2)
+	now := time.Now()
+	assert.True(t, l.allow(now))
+	assert.True(t, l.allow(now))
+	assert.False(t, l.allow(now))
+	assert.True(t, l.allow(now.Add(time.Second)))
+
+	assert.Nil(t, newLimiter(0, 5))
+	assert.True(t, (*limiter)(nil).allow(now))
+}
+
+func TestRender(t *testing.T) {
+	tmpl, err := parseTemplates(Channel{})
+	require.NoError(t, err)
+
+	n := alertNotification(model.Alert{
+		ID:          "alert-1",
+		TenantID:    "acme",
+		RuleName:    "Brute force",
+		Severity:    model.SeverityHigh,
+		EventCount:  12,
+		Message:     "12 failed logins",
+		TriggeredAt: time.Date(2026, 1, 29, 10, 0, 0, 0, time.UTC),
+	})
+	n.Suppressed = 4
+
+	msg, err := tmpl.render(&n)
+	require.NoError(t, err)
+	assert.Equal(t, "[ThreatLog] HIGH alert: Brute force", msg.Subject)
+	assert.Contains(t, msg.Body, "12 failed logins")
+	assert.Contains(t, msg.Body, "Events: 12")
+	assert.Contains(t, msg.Body, "4 earlier notifications were suppressed")
+
+	tmpl, err = parseTemplates(Channel{Subject: "{{.TenantID}}\n{{.Kind}}", Body: "{{.Message}}"})
+	require.NoError(t, err)
+	msg, err = tmpl.render(&n)
+	require.NoError(t, err)
+	assert.Equal(t, "acme alert", msg.Subject)
+	assert.Equal(t, "12 failed logins", msg.Body)
+}
+
+func TestChatPayloads(t *testing.T) {
+	msg := message{Subject: "Alert", Body: "line one\nline two"}
+
+	assert.Equal(t, map[string]string{"text": "*Alert*\nline one\nline two"}, slackPayload(msg))
+
+	teams := teamsPayload(msg).(map[string]string)
+	assert.Equal(t, "MessageCard", teams["@type"])
+	assert.Equal(t, "Alert", teams["title"])
+	assert.Equal(t, "line one\n\nline two", teams["text"])
+}
@@ -0,0 +1,274 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// Webhook request headers
+const (
+	HeaderSignature = "X-ThreatLog-Signature"
+	HeaderTimestamp = "X-ThreatLog-Timestamp"
+	HeaderDelivery  = "X-ThreatLog-Delivery"
+)
+
+// sender delivers rendered notifications to one kind of destination
+type sender interface {
+	send(ctx context.Context, delivery string, n *Notification, msg message) error
+}
+
+// permanentError marks failures that retrying cannot fix, such as a
+// rejected request or recipient
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+func newSender(ch Channel, client *http.Client) sender {
+	switch ch.Type {
+	case model.ChannelWebhook:
+		return &webhookSender{url: ch.URL, secret: ch.Secret, client: client}
+	case model.ChannelSlack:
+		return &chatSender{url: ch.URL, client: client, payload: slackPayload}
+	case model.ChannelTeams:
+		return &chatSender{url: ch.URL, client: client, payload: teamsPayload}
+	default:
+		return &emailSender{smtp: ch.SMTP}
+	}
+}
+
+// webhookPayload is the JSON body of generic webhook notifications
+type webhookPayload struct {
+	Kind       string          `json:"kind"`
+	TenantID   string          `json:"tenant_id"`
+	Severity   string          `json:"severity"`
+	Subject    string          `json:"subject"`
+	Body       string          `json:"body"`
+	Time       time.Time       `json:"time"`
+	Suppressed int             `json:"suppressed,omitempty"`
+	Event      *model.LogEvent `json:"event,omitempty"`
+	Alert      *model.Alert    `json:"alert,omitempty"`
+}
+
+// webhookSender posts notifications as JSON. With a secret, the payload is
+// signed with HMAC-SHA256 over "<timestamp>.<body>", so receivers can check
+// both its origin and its freshness.
+type webhookSender struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func (s *webhookSender) send(ctx context.Context, delivery string, n *Notification, msg message) error {
+	body, err := json.Marshal(webhookPayload{
+		Kind:       n.Kind,
+		TenantID:   n.TenantID,
+		Severity:   n.Severity,
+		Subject:    msg.Subject,
+		Body:       msg.Body,
+		Time:       n.Time,
+		Suppressed: n.Suppressed,
+		Event:      n.Event,
+		Alert:      n.Alert,
+	})
+	if err != nil {
+		return &permanentError{err}
+	}
+
+	headers := http.Header{}
+	headers.Set(HeaderDelivery, delivery)
+	if s.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers.Set(HeaderTimestamp, timestamp)
+		headers.Set(HeaderSignature, "sha256="+Sign(s.secret, timestamp, body))
+	}
+
+	return post(ctx, s.client, s.url, body, headers)
+}
+
+// Sign returns the hex HMAC-SHA256 signature of a webhook body sent at timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// chatSender posts notifications to Slack or Teams incoming webhooks
+type chatSender struct {
+	url     string
+	client  *http.Client
+	payload func(msg message) any
+}
+
+func (s *chatSender) send(ctx context.Context, delivery string, n *Notification, msg message) error {
+	body, err := json.Marshal(s.payload(msg))
+	if err != nil {
+		return &permanentError{err}
+	}
+	return post(ctx, s.client, s.url, body, nil)
+}
+
+func slackPayload(msg message) any {
+	return map[string]string{
+		"text": "*" + msg.Subject + "*\n" + msg.Body,
+	}
+}
+
+func teamsPayload(msg message) any {
+	return map[string]string{
+		"@type":    "MessageCard",
+		"@context": "https://schema.org/extensions",
+		"summary":  msg.Subject,
+		"title":    msg.Subject,
+		// Teams renders text as markdown, where a single newline is not a break
+		"text": strings.ReplaceAll(msg.Body, "\n", "\n\n"),
+	}
+}
+
+// post sends a JSON body. Client errors other than timeouts and rate
+// limiting are permanent.
+func post(ctx context.Context, client *http.Client, url string, body []byte, headers http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err}
+	}
+	for key, values := range headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ThreatLog-Notifier")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	err = fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return &permanentError{err}
+	}
+	return err
+}
+
+// emailSender mails plain text notifications, upgrading to TLS when the
+// server offers STARTTLS
+type emailSender struct {
+	smtp SMTP
+}
+
+func (s *emailSender) send(ctx context.Context, delivery string, n *Notification, msg message) error {
+	addr := net.JoinHostPort(s.smtp.Host, strconv.Itoa(s.smtp.Port))
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.smtp.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.smtp.Host}); err != nil {
+			return err
+		}
+	}
+	if s.smtp.Username != "" {
+		auth := smtp.PlainAuth("", s.smtp.Username, s.smtp.Password, s.smtp.Host)
+		if err := client.Auth(auth); err != nil {
+			return smtpError(err)
+		}
+	}
+
+	if err := client.Mail(s.smtp.From); err != nil {
+		return smtpError(err)
+	}
+	for _, to := range s.smtp.To {
+		if err := client.Rcpt(to); err != nil {
+			return smtpError(err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return smtpError(err)
+	}
+	if _, err := w.Write(s.compose(delivery, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return smtpError(err)
+	}
+	return client.Quit()
+}
+
+// compose builds the mail for a message
+func (s *emailSender) compose(delivery string, msg message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.smtp.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.smtp.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@threatlog>\r\n", delivery)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// smtpError marks permanent (5xx) SMTP replies
+func smtpError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return &permanentError{err}
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package partition
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Partition intervals
+const (
+	Hourly = "hourly"
+	Daily  = "daily"
+)
+
+// namePrefix starts the name of every managed partition
+const namePrefix = "logs_p"
+
+// Name layouts; their lengths tell hourly and daily partitions apart
+const (
+	hourlyLayout = "2006010215"
+	dailyLayout  = "20060102"
+)
+
+// Interval describes the time range covered by each partition. All
+// boundaries are in UTC.
+type Interval struct {
+	period time.Duration
+	layout string
+}
+
+// ParseInterval returns the interval for "hourly" or "daily"
+func ParseInterval(s string) (Interval, error) {
+	switch s {
+	case Hourly:
+		return Interval{period: time.Hour, layout: hourlyLayout}, nil
+	case Daily:
+		return Interval{period: 24 * time.Hour, layout: dailyLayout}, nil
+	default:
+		return Interval{}, fmt.Errorf("unknown partition interval %q", s)
+	}
+}
+
+// Start returns the start of the partition containing t
+func (i Interval) Start(t time.Time) time.Time {
+	return t.UTC().Truncate(i.period)
+}
+
+// Next returns the start of the partition after the one starting at start
+func (i Interval) Next(start time.Time) time.Time {
+	return start.Add(i.period)
+}
+
+// Name returns the name of the partition starting at start
+func (i Interval) Name(start time.Time) string {
+	return namePrefix + start.UTC().Format(i.layout)
+}
+
+// ParseName returns the range of a managed partition from its name. It
+// recognises both hourly and daily names, so partitions created before the
+// interval was changed are still maintained.
+func ParseName(name string) (from, to time.Time, ok bool) {
+	suffix, found := strings.CutPrefix(name, namePrefix)
+	if !found {
+		return time.Time{}, time.Time{}, false
+	}
+
+	for _, interval := range []Interval{
+		{period: time.Hour, layout: hourlyLayout},
+		{period: 24 * time.Hour, layout: dailyLayout},
+	} {
+		if len(suffix) != len(interval.layout) {
+			continue
+		}
+		start, err := time.Parse(interval.layout, suffix)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		return start, interval.Next(start), true
+	}
+
+	return time.Time{}, time.Time{}, false
+}
@@ -0,0 +1,205 @@
+package partition
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/repository"
+)
+
+// pruneBatchSize caps the logs deleted from a catch-all partition per statement
+const pruneBatchSize = 10000
+
+// Maintainer keeps the partitions of the logs table in shape: it creates
+// partitions ahead of time and drops managed partitions whose whole range
+// is older than the retention. Expired logs that landed in logs_default or
+// logs_legacy are deleted row by row instead. Logs under legal hold are
+// kept.
+type Maintainer struct {
+	repo          *repository.PostgresRepository
+	interval      Interval
+	premake       int
+	retention     time.Duration
+	checkInterval time.Duration
+	holds         []repository.LogMatcher
+	// held remembers the expired partitions found to hold logs under legal
+	// hold. Held logs are never deleted and holds only change on restart,
+	// so they are not scanned again.
+	held map[string]bool
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewMaintainer creates a new partition maintainer. A zero retention keeps
+// partitions forever. holds select the logs under legal hold.
+func NewMaintainer(
+	repo *repository.PostgresRepository,
+	interval Interval,
+	premake int,
+	retention time.Duration,
+	checkInterval time.Duration,
+	holds []repository.LogMatcher,
+) *Maintainer {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Maintainer{
+		repo:          repo,
+		interval:      interval,
+		premake:       premake,
+		retention:     retention,
+		checkInterval: checkInterval,
+		holds:         holds,
+		held:          make(map[string]bool),
+		ctx:           ctx,
+		cancel:        cancel,
+		now:           time.Now,
+	}
+}
+
+// Start checks that logs is partitioned, runs a first maintenance pass and
+// starts the maintenance loop
+func (m *Maintainer) Start() error {
+	partitioned, err := m.repo.IsLogsPartitioned(m.ctx)
+	if err != nil {
+		return err
+	}
+	if !partitioned {
+		return errors.New("logs table is not partitioned, apply migrations/010_partition_logs.sql")
+	}
+
+	m.maintain()
+
+	m.wg.Add(1)
+	go m.loop()
+	return nil
+}
+
+// Stop stops the maintenance loop
+func (m *Maintainer) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+func (m *Maintainer) loop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.maintain()
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// maintain runs one maintenance pass
+func (m *Maintainer) maintain() {
+	existing, err := m.repo.ListLogPartitions(m.ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list log partitions")
+		return
+	}
+
+	now := m.now()
+	create, drop, prune := m.plan(existing, now)
+
+	for _, start := range create {
+		name := m.interval.Name(start)
+		err := m.repo.CreateLogPartition(m.ctx, name, start, m.interval.Next(start))
+		switch {
+		case errors.Is(err, repository.ErrPartitionOverlap):
+			// Covered by a partition of another interval, or by logs_legacy
+			log.Debug().Str("partition", name).Msg("Skipping partition that overlaps an existing one")
+		case err != nil:
+			log.Error().Err(err).Str("partition", name).Msg("Failed to create log partition")
+		default:
+			log.Info().Str("partition", name).Msg("Log partition created")
+		}
+	}
+
+	for _, name := range drop {
+		err := m.repo.DropLogPartition(m.ctx, name, m.holds)
+		switch {
+		case errors.Is(err, repository.ErrPartitionHeld):
+			m.held[name] = true
+			log.Warn().Str("partition", name).Msg("Keeping expired log partition with logs under legal hold")
+		case err != nil:
+			log.Error().Err(err).Str("partition", name).Msg("Failed to drop expired log partition")
+		default:
+			log.Info().Str("partition", name).Msg("Expired log partition dropped")
+		}
+	}
+
+	for _, name := range prune {
+		m.prune(name, now.Add(-m.retention))
+	}
+}
+
+// prune deletes the logs older than cutoff from a catch-all partition in
+// batches, so late events and pre-partitioning rows expire like the rest
+func (m *Maintainer) prune(name string, cutoff time.Time) {
+	var total int64
+	for m.ctx.Err() == nil {
+		n, err := m.repo.PrunePartition(m.ctx, name, cutoff, m.holds, pruneBatchSize)
+		if err != nil {
+			log.Error().Err(err).Str("partition", name).Msg("Failed to prune expired logs")
+			break
+		}
+		total += n
+		if n < pruneBatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		log.Info().Str("partition", name).Int64("deleted", total).Msg("Expired logs pruned")
+	}
+}
+
+// plan returns the starts of the partitions to create, from the current one
+// to premake partitions ahead, the managed partitions to drop and the
+// catch-all partitions to prune. Partitions known to be held are not
+// dropped. Past partitions are never created: events too old for the
+// current one go to logs_default and are pruned from there.
+func (m *Maintainer) plan(existing []string, now time.Time) ([]time.Time, []string, []string) {
+	present := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		present[name] = true
+	}
+
+	var create []time.Time
+	start := m.interval.Start(now)
+	for i := 0; i <= m.premake; i++ {
+		if !present[m.interval.Name(start)] {
+			create = append(create, start)
+		}
+		start = m.interval.Next(start)
+	}
+
+	var drop, prune []string
+	if m.retention > 0 {
+		cutoff := now.Add(-m.retention)
+		for _, name := range existing {
+			if _, to, ok := ParseName(name); ok && !to.After(cutoff) && !m.held[name] {
+				drop = append(drop, name)
+			}
+			if name == repository.DefaultLogPartition || name == repository.LegacyLogPartition {
+				prune = append(prune, name)
+			}
+		}
+	}
+
+	return create, drop, prune
+}
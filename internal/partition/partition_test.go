@@ -0,0 +1,88 @@
+package partition
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterval(t *testing.T) {
+	at := time.Date(2026, 3, 9, 14, 35, 0, 0, time.FixedZone("CET", 3600))
+
+	daily, err := ParseInterval(Daily)
+	require.NoError(t, err)
+	start := daily.Start(at)
+	assert.Equal(t, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, "logs_p20260309", daily.Name(start))
+
+	hourly, err := ParseInterval(Hourly)
+	require.NoError(t, err)
+	start = hourly.Start(at)
+	assert.Equal(t, time.Date(2026, 3, 9, 13, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, "logs_p2026030913", hourly.Name(start))
+	assert.Equal(t, "logs_p2026030914", hourly.Name(hourly.Next(start)))
+
+	_, err = ParseInterval("weekly")
+	assert.Error(t, err)
+}
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		ok   bool
+	}{
+		{"logs_p20260309", time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), true},
+		{"logs_p2026030923", time.Date(2026, 3, 9, 23, 0, 0, 0, time.UTC), time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), true},
+		{"logs_default", time.Time{}, time.Time{}, false},
+		{"logs_legacy", time.Time{}, time.Time{}, false},
+		{"logs_p2026", time.Time{}, time.Time{}, false},
+		{"logs_p20261399", time.Time{}, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, ok := ParseName(tt.name)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.from, from)
+			assert.Equal(t, tt.to, to)
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	daily, _ := ParseInterval(Daily)
+	m := NewMaintainer(nil, daily, 2, 48*time.Hour, time.Hour, nil)
+	now := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+
+	create, drop, prune := m.plan([]string{
+		"logs_default",
+		"logs_legacy",
+		"logs_p20260306",
+		"logs_p20260307",
+		"logs_p20260308",
+		"logs_p20260309",
+	}, now)
+
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC),
+	}, create)
+	// logs_p20260306 ends exactly at the cutoff; logs_p20260307 still has live rows
+	assert.Equal(t, []string{"logs_p20260306"}, drop)
+	// Late events and pre-partitioning rows are pruned from the catch-all partitions
+	assert.Equal(t, []string{"logs_default", "logs_legacy"}, prune)
+
+	// Partitions found to be held are not checked again
+	m.held["logs_p20260306"] = true
+	_, drop, _ = m.plan([]string{"logs_p20260306"}, now)
+	assert.Empty(t, drop)
+
+	m.retention = 0
+	_, drop, prune = m.plan([]string{"logs_default", "logs_p20200101"}, now)
+	assert.Empty(t, drop)
+	assert.Empty(t, prune)
+}
@@ -0,0 +1,314 @@
+// Package pipeline runs configured chains of processors over events before
+// they are stored
+package pipeline
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/rules"
+	"github.com/Saumajitt/threatLog/pkg/validator"
+)
+
+// Config is a chain of processors for the events of some sources
+type Config struct {
+	Name string
+	// Sources and Tenants are globs; empty matches every source or tenant
+	Sources []string
+	Tenants []string
+	Stages  []StageConfig
+}
+
+// StageConfig is a processor in a pipeline. Only the settings of its type
+// apply.
+type StageConfig struct {
+	// Name defaults to the type and position of the stage
+	Name string
+	Type string
+	// When restricts the stage to events matching every condition
+	When []model.RuleCondition
+	// OnError is pass_through (the default), drop or dead_letter
+	OnError string
+
+	// rename
+	Rename []Rename
+	// parse: Field (default message) read as Format, into Target or the
+	// top level of the attributes
+	Field   string
+	Format  string
+	Pattern string
+	Target  string
+	// tag
+	Tags []string
+	Set  map[string]string
+	// redact: Fields are replaced whole, or only where Patterns match
+	Fields      []string
+	Patterns    []string
+	Replacement string
+}
+
+// Rename moves an attribute
+type Rename struct {
+	From string
+	To   string
+}
+
+// stage is a compiled processor with its statistics
+type stage struct {
+	name      string
+	typ       string
+	when      func(event model.LogEvent) bool
+	onError   string
+	processor Processor
+
+	events   atomic.Int64
+	dropped  atomic.Int64
+	errors   atomic.Int64
+	duration atomic.Int64
+}
+
+type pipeline struct {
+	name    string
+	sources []string
+	tenants []string
+	stages  []*stage
+
+	events       atomic.Int64
+	dropped      atomic.Int64
+	deadLettered atomic.Int64
+}
+
+// StageStats holds the statistics of a stage
+type StageStats struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Events counts the events the stage ran on
+	Events  int64 `json:"events"`
+	Dropped int64 `json:"dropped"`
+	Errors  int64 `json:"errors"`
+	// AvgDurationMicros is the mean time the stage took per event
+	AvgDurationMicros float64 `json:"avg_duration_us"`
+}
+
+// Stats holds the statistics of a pipeline
+type Stats struct {
+	Name         string       `json:"name"`
+	Events       int64        `json:"events"`
+	Dropped      int64        `json:"dropped"`
+	DeadLettered int64        `json:"dead_lettered"`
+	Stages       []StageStats `json:"stages"`
+}
+
+// Pipelines runs each event through the first pipeline matching its source
+// and tenant. Events no pipeline matches are stored unchanged.
+type Pipelines struct {
+	pipelines []*pipeline
+}
+
+// New compiles and validates pipelines
+func New(configs []Config) (*Pipelines, error) {
+	p := &Pipelines{}
+	names := make(map[string]bool, len(configs))
+
+	for _, cfg := range configs {
+		if cfg.Name == "" {
+			return nil, fmt.Errorf("pipeline name is required")
+		}
+		if names[cfg.Name] {
+			return nil, fmt.Errorf("duplicate pipeline %q", cfg.Name)
+		}
+		names[cfg.Name] = true
+
+		for _, pattern := range append(append([]string{}, cfg.Sources...), cfg.Tenants...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("pipeline %q: invalid glob %q", cfg.Name, pattern)
+			}
+		}
+		if len(cfg.Stages) == 0 {
+			return nil, fmt.Errorf("pipeline %q: at least one processor is required", cfg.Name)
+		}
+
+		pl := &pipeline{name: cfg.Name, sources: cfg.Sources, tenants: cfg.Tenants}
+		stageNames := make(map[string]bool, len(cfg.Stages))
+		for i, stageCfg := range cfg.Stages {
+			st, err := newStage(stageCfg, i)
+			if err != nil {
+				return nil, fmt.Errorf("pipeline %q: processor %d: %w", cfg.Name, i, err)
+			}
+			if stageNames[st.name] {
+				return nil, fmt.Errorf("pipeline %q: duplicate processor %q", cfg.Name, st.name)
+			}
+			stageNames[st.name] = true
+			pl.stages = append(pl.stages, st)
+		}
+		p.pipelines = append(p.pipelines, pl)
+	}
+
+	return p, nil
+}
+
+func newStage(cfg StageConfig, index int) (*stage, error) {
+	st := &stage{name: cfg.Name, typ: cfg.Type, onError: cfg.OnError}
+	if st.name == "" {
+		st.name = fmt.Sprintf("%s-%d", cfg.Type, index+1)
+	}
+
+	switch st.onError {
+	case "":
+		st.onError = model.OnErrorPassThrough
+	case model.OnErrorPassThrough, model.OnErrorDrop, model.OnErrorDeadLetter:
+	default:
+		return nil, fmt.Errorf("on_error must be pass_through, drop or dead_letter")
+	}
+
+	processor, err := newProcessor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	st.processor = processor
+
+	if len(cfg.When) > 0 {
+		for i, cond := range cfg.When {
+			if err := validator.ValidateCondition(cond); err != nil {
+				return nil, fmt.Errorf("when condition %d: %w", i, err)
+			}
+		}
+		if st.when, err = rules.CompileConditions(cfg.When); err != nil {
+			return nil, err
+		}
+	}
+
+	return st, nil
+}
+
+// Process runs a batch through the pipelines. It returns the events to
+// store and those to dead-letter; the other events were dropped. The batch
+// is reused for the result.
+func (p *Pipelines) Process(batch []model.LogEvent) ([]model.LogEvent, []model.DeadLetter) {
+	kept := batch[:0]
+	var deadLetters []model.DeadLetter
+
+	for _, event := range batch {
+		pl := p.match(event)
+		if pl == nil {
+			kept = append(kept, event)
+			continue
+		}
+
+		processed, keep, err := pl.run(event)
+		switch {
+		case err != nil:
+			deadLetters = append(deadLetters, model.DeadLetter{
+				Log:      event,
+				Error:    err.Error(),
+				FailedAt: time.Now(),
+			})
+		case keep:
+			kept = append(kept, processed)
+		}
+	}
+
+	return kept, deadLetters
+}
+
+// match returns the first pipeline for the source and tenant of an event
+func (p *Pipelines) match(event model.LogEvent) *pipeline {
+	for _, pl := range p.pipelines {
+		if matchAny(pl.sources, event.Source) && matchAny(pl.tenants, event.TenantID) {
+			return pl
+		}
+	}
+	return nil
+}
+
+func matchAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// run passes an event through every stage. It returns the processed event
+// and whether to keep it, or the error to dead-letter the original event
+// with.
+func (pl *pipeline) run(original model.LogEvent) (model.LogEvent, bool, error) {
+	pl.events.Add(1)
+
+	// Attributes may be shared with the caller, so processors get a copy
+	event := original
+	event.Attributes = maps.Clone(original.Attributes)
+
+	for _, st := range pl.stages {
+		if st.when != nil && !st.when(event) {
+			continue
+		}
+
+		start := time.Now()
+		keep, err := st.processor.Process(&event)
+		st.duration.Add(int64(time.Since(start)))
+		st.events.Add(1)
+
+		if err != nil {
+			st.errors.Add(1)
+			log.Debug().Err(err).Str("pipeline", pl.name).Str("processor", st.name).Str("log_id", event.ID).Msg("Processor failed")
+
+			switch st.onError {
+			case model.OnErrorDrop:
+				pl.dropped.Add(1)
+				return event, false, nil
+			case model.OnErrorDeadLetter:
+				pl.deadLettered.Add(1)
+				return original, false, fmt.Errorf("pipeline %s: processor %s: %w", pl.name, st.name, err)
+			}
+			continue
+		}
+
+		if !keep {
+			st.dropped.Add(1)
+			pl.dropped.Add(1)
+			return event, false, nil
+		}
+	}
+
+	return event, true, nil
+}
+
+// Stats returns the statistics of every pipeline, in configuration order
+func (p *Pipelines) Stats() []Stats {
+	stats := make([]Stats, 0, len(p.pipelines))
+	for _, pl := range p.pipelines {
+		s := Stats{
+			Name:         pl.name,
+			Events:       pl.events.Load(),
+			Dropped:      pl.dropped.Load(),
+			DeadLettered: pl.deadLettered.Load(),
+			Stages:       make([]StageStats, 0, len(pl.stages)),
+		}
+		for _, st := range pl.stages {
+			ss := StageStats{
+				Name:    st.name,
+				Type:    st.typ,
+				Events:  st.events.Load(),
+				Dropped: st.dropped.Load(),
+				Errors:  st.errors.Load(),
+			}
+			if ss.Events > 0 {
+				ss.AvgDurationMicros = float64(st.duration.Load()) / float64(ss.Events) / float64(time.Microsecond)
+			}
+			s.Stages = append(s.Stages, ss)
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
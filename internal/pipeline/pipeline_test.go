@@ -0,0 +1,191 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+func newTestPipelines(t *testing.T, configs ...Config) *Pipelines {
+	t.Helper()
+	p, err := New(configs)
+	require.NoError(t, err)
+	return p
+}
+
+func processOne(t *testing.T, p *Pipelines, event model.LogEvent) (model.LogEvent, bool) {
+	t.Helper()
+	kept, deadLetters := p.Process([]model.LogEvent{event})
+	require.Empty(t, deadLetters)
+	if len(kept) == 0 {
+		return model.LogEvent{}, false
+	}
+	return kept[0], true
+}
+
+func TestParseProcessor(t *testing.T) {
+	p := newTestPipelines(t,
+		Config{Name: "json", Sources: []string{"app-*"}, Stages: []StageConfig{
+			{Type: model.ProcessorParse, Format: model.ParseJSON},
+		}},
+		Config{Name: "kv", Sources: []string{"firewall"}, Stages: []StageConfig{
+			{Type: model.ProcessorParse, Format: model.ParseKV, Target: "attr.fw"},
+		}},
+		Config{Name: "regex", Sources: []string{"sshd"}, Stages: []StageConfig{
+			{Type: model.ProcessorParse, Format: model.ParseRegex, Pattern: `user (?P<user>\S+) from (?P<src_ip>\S+)`},
+		}},
+	)
+
+	event, _ := processOne(t, p, model.LogEvent{Source: "app-web", Message: `{"user": "alice", "status": 200}`})
+	assert.Equal(t, map[string]any{"user": "alice", "status": float64(200)}, event.Attributes)
+
+	event, _ = processOne(t, p, model.LogEvent{Source: "firewall", Message: `action=deny src=10.0.0.1 rule="block all" stray`})
+	assert.Equal(t, map[string]any{"action": "deny", "src": "10.0.0.1", "rule": "block all"}, event.Attributes["fw"])
+
+	event, _ = processOne(t, p, model.LogEvent{Source: "sshd", Message: "Failed password for user root from 192.0.2.1 port 22"})
+	assert.Equal(t, map[string]any{"user": "root", "src_ip": "192.0.2.1"}, event.Attributes)
+
+	// Unparsed events pass through unchanged by default
+	event, _ = processOne(t, p, model.LogEvent{Source: "app-web", Message: "plain text"})
+	assert.Empty(t, event.Attributes)
+}
+
+func TestRenameTagAndDrop(t *testing.T) {
+	p := newTestPipelines(t, Config{Name: "web", Stages: []StageConfig{
+		{
+			Type: model.ProcessorDrop,
+			When: []model.RuleCondition{{Field: "severity", Operator: model.OperatorEquals, Value: model.SeverityInfo}},
+		},
+		{Type: model.ProcessorRename, Rename: []Rename{{From: "attr.ip", To: "attr.src_ip"}}},
+		{Type: model.ProcessorTag, Tags: []string{"web", "edge"}, Set: map[string]string{"env": "prod"}},
+	}})
+
+	attributes := map[string]any{"ip": "192.0.2.1", "tags": []any{"web"}}
+	event, kept := processOne(t, p, model.LogEvent{Severity: model.SeverityHigh, Attributes: attributes})
+	require.True(t, kept)
+	assert.Equal(t, map[string]any{
+		"src_ip": "192.0.2.1",
+		"tags":   []any{"web", "edge"},
+		"env":    "prod",
+	}, event.Attributes)
+	assert.Equal(t, "192.0.2.1", attributes["ip"], "caller's attributes are not modified")
+
+	_, kept = processOne(t, p, model.LogEvent{Severity: model.SeverityInfo})
+	assert.False(t, kept)
+
+	stats := p.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(2), stats[0].Events)
+	assert.Equal(t, int64(1), stats[0].Dropped)
+	assert.Equal(t, "drop-1", stats[0].Stages[0].Name)
+	assert.Equal(t, int64(1), stats[0].Stages[0].Events, "when skips the stage")
+	assert.Equal(t, int64(1), stats[0].Stages[1].Events)
+}
+
+func TestRedactProcessor(t *testing.T) {
+	p := newTestPipelines(t,
+		Config{Name: "fields", Sources: []string{"auth"}, Stages: []StageConfig{
+			{Type: model.ProcessorRedact, Fields: []string{"attr.password", "attr.token"}},
+		}},
+		Config{Name: "patterns", Stages: []StageConfig{
+			{Type: model.ProcessorRedact, Patterns: []string{`\d{4}-\d{4}-\d{4}-\d{4}`}, Replacement: "****"},
+		}},
+	)
+
+	event, _ := processOne(t, p, model.LogEvent{
+		Source:     "auth",
+		Message:    "login",
+		Attributes: map[string]any{"password": "hunter2", "pin": 1234, "user": "alice"},
+	})
+	assert.Equal(t, map[string]any{"password": model.DefaultRedaction, "pin": 1234, "user": "alice"}, event.Attributes)
+
+	nested := map[string]any{"card": "1111-2222-3333-4444"}
+	event, _ = processOne(t, p, model.LogEvent{
+		Source:     "shop",
+		Message:    "Charged 1111-2222-3333-4444",
+		Attributes: map[string]any{"payment": nested, "cards": []any{"5555-6666-7777-8888"}},
+	})
+	assert.Equal(t, "Charged ****", event.Message)
+	assert.Equal(t, map[string]any{"card": "****"}, event.Attributes["payment"])
+	assert.Equal(t, []any{"****"}, event.Attributes["cards"])
+	assert.Equal(t, "1111-2222-3333-4444", nested["card"], "nested values are copied")
+}
+
+func TestErrorPolicies(t *testing.T) {
+	parse := func(onError string) StageConfig {
+		return StageConfig{Name: "parse", Type: model.ProcessorParse, Format: model.ParseJSON, OnError: onError}
+	}
+	tag := StageConfig{Type: model.ProcessorTag, Tags: []string{"seen"}}
+	p := newTestPipelines(t,
+		Config{Name: "pass", Sources: []string{"pass"}, Stages: []StageConfig{parse(""), tag}},
+		Config{Name: "drop", Sources: []string{"drop"}, Stages: []StageConfig{parse(model.OnErrorDrop), tag}},
+		Config{Name: "dead", Sources: []string{"dead"}, Stages: []StageConfig{tag, parse(model.OnErrorDeadLetter)}},
+	)
+
+	batch := []model.LogEvent{
+		{ID: "1", Source: "pass", Message: "not json"},
+		{ID: "2", Source: "drop", Message: "not json"},
+		{ID: "3", Source: "dead", Message: "not json"},
+		{ID: "4", Source: "other", Message: "not json"},
+	}
+	kept, deadLetters := p.Process(batch)
+
+	require.Len(t, kept, 2)
+	assert.Equal(t, "1", kept[0].ID)
+	assert.Equal(t, []any{"seen"}, kept[0].Attributes[model.TagsAttribute], "later stages still run")
+	assert.Equal(t, "4", kept[1].ID)
+
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, "3", deadLetters[0].Log.ID)
+	assert.Nil(t, deadLetters[0].Log.Attributes, "the original event is dead-lettered")
+	assert.Contains(t, deadLetters[0].Error, "pipeline dead: processor parse")
+
+	stats := p.Stats()
+	assert.Equal(t, int64(1), stats[0].Stages[0].Errors)
+	assert.Equal(t, int64(1), stats[1].Dropped)
+	assert.Equal(t, int64(1), stats[2].DeadLettered)
+}
+
+func TestMatchTenants(t *testing.T) {
+	p := newTestPipelines(t,
+		Config{Name: "acme", Tenants: []string{"acme-*"}, Stages: []StageConfig{{Type: model.ProcessorTag, Tags: []string{"acme"}}}},
+		Config{Name: "all", Stages: []StageConfig{{Type: model.ProcessorTag, Tags: []string{"all"}}}},
+	)
+
+	event, _ := processOne(t, p, model.LogEvent{TenantID: "acme-eu"})
+	assert.Equal(t, []any{"acme"}, event.Attributes[model.TagsAttribute], "only the first matching pipeline runs")
+
+	event, _ = processOne(t, p, model.LogEvent{TenantID: "globex"})
+	assert.Equal(t, []any{"all"}, event.Attributes[model.TagsAttribute])
+}
+
+func TestNewValidation(t *testing.T) {
+	valid := Config{Name: "p", Stages: []StageConfig{{Type: model.ProcessorDrop}}}
+	_, err := New([]Config{valid})
+	require.NoError(t, err)
+
+	invalid := []Config{
+		{Stages: valid.Stages},
+		{Name: "p"},
+		{Name: "p", Sources: []string{"["}, Stages: valid.Stages},
+		{Name: "p", Stages: []StageConfig{{Type: "lowercase"}}},
+		{Name: "p", Stages: []StageConfig{{Type: model.ProcessorDrop, OnError: "retry"}}},
+		{Name: "p", Stages: []StageConfig{{Type: model.ProcessorDrop}, {Type: model.ProcessorDrop, Name: "drop-1"}}},
+		{Name: "p", Stages: []StageConfig{{Type: model.ProcessorDrop, When: []model.RuleCondition{{Field: "host", Operator: model.OperatorExists}}}}},
+		{Name: "p", Stages: []StageConfig{{Type: model.ProcessorRename, Rename: []Rename{{From: "ip", To: "attr.src_ip"}}}}},
+		{Name: "p", Stages: []StageConfig{{Type: model.ProcessorParse, Format: model.ParseRegex, Pattern: `user \S+`}}},
+		{Name: "p", Stages: []StageConfig{{Type: model.ProcessorParse, Format: "xml"}}},
+		{Name: "p", Stages: []StageConfig{{Type: model.ProcessorTag}}},
+		{Name: "p", Stages: []StageConfig{{Type: model.ProcessorRedact}}},
+	}
+	for i, cfg := range invalid {
+		_, err := New([]Config{cfg})
+		assert.Error(t, err, "config %d", i)
+	}
+
+	_, err = New([]Config{valid, valid})
+	assert.Error(t, err)
+}
@@ -0,0 +1,364 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/rules"
+)
+
+// Processor transforms an event in place. It returns false to drop the
+// event; an error leaves it to the error policy of the stage.
+type Processor interface {
+	Process(event *model.LogEvent) (bool, error)
+}
+
+// newProcessor builds the processor of a stage
+func newProcessor(cfg StageConfig) (Processor, error) {
+	switch cfg.Type {
+	case model.ProcessorDrop:
+		return dropProcessor{}, nil
+	case model.ProcessorRename:
+		return newRenameProcessor(cfg.Rename)
+	case model.ProcessorParse:
+		return newParseProcessor(cfg)
+	case model.ProcessorTag:
+		if len(cfg.Tags) == 0 && len(cfg.Set) == 0 {
+			return nil, errors.New("tag requires tags or set")
+		}
+		return tagProcessor{tags: cfg.Tags, set: cfg.Set}, nil
+	case model.ProcessorRedact:
+		return newRedactProcessor(cfg)
+	default:
+		return nil, fmt.Errorf("type must be drop, rename, parse, tag or redact")
+	}
+}
+
+// attributeKey returns the key of an attr.<key> field
+func attributeKey(field string) (string, error) {
+	key, ok := strings.CutPrefix(field, model.AttributeFieldPrefix)
+	if !ok || key == "" {
+		return "", fmt.Errorf("field %q must be attr.<key>", field)
+	}
+	return key, nil
+}
+
+// dropProcessor drops every event it runs on; stages select events with when
+type dropProcessor struct{}
+
+func (dropProcessor) Process(*model.LogEvent) (bool, error) {
+	return false, nil
+}
+
+type rename struct {
+	from, to string
+}
+
+// renameProcessor moves attributes to other keys
+type renameProcessor struct {
+	renames []rename
+}
+
+func newRenameProcessor(cfg []Rename) (Processor, error) {
+	if len(cfg) == 0 {
+		return nil, errors.New("rename requires at least one rename")
+	}
+
+	p := renameProcessor{}
+	for _, r := range cfg {
+		from, err := attributeKey(r.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := attributeKey(r.To)
+		if err != nil {
+			return nil, err
+		}
+		p.renames = append(p.renames, rename{from: from, to: to})
+	}
+	return p, nil
+}
+
+func (p renameProcessor) Process(event *model.LogEvent) (bool, error) {
+	for _, r := range p.renames {
+		if value, ok := event.Attributes[r.from]; ok {
+			delete(event.Attributes, r.from)
+			event.Attributes[r.to] = value
+		}
+	}
+	return true, nil
+}
+
+// parseProcessor extracts attributes from the text of a field
+type parseProcessor struct {
+	field   string
+	format  string
+	pattern *regexp.Regexp
+	target  string
+}
+
+func newParseProcessor(cfg StageConfig) (Processor, error) {
+	p := parseProcessor{field: cfg.Field, format: cfg.Format}
+	if p.field == "" {
+		p.field = model.FieldMessage
+	}
+	if p.field != model.FieldMessage {
+		if _, err := attributeKey(p.field); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Target != "" {
+		target, err := attributeKey(cfg.Target)
+		if err != nil {
+			return nil, err
+		}
+		p.target = target
+	}
+
+	switch cfg.Format {
+	case model.ParseJSON, model.ParseKV:
+	case model.ParseRegex:
+		re, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		named := false
+		for _, name := range re.SubexpNames() {
+			named = named || name != ""
+		}
+		if !named {
+			return nil, errors.New("pattern must have named groups")
+		}
+		p.pattern = re
+	default:
+		return nil, errors.New("format must be json, kv or regex")
+	}
+	return p, nil
+}
+
+func (p parseProcessor) Process(event *model.LogEvent) (bool, error) {
+	value, ok := rules.FieldValue(*event, p.field)
+	if !ok {
+		return true, nil
+	}
+
+	fields := make(map[string]any)
+	switch p.format {
+	case model.ParseJSON:
+		if err := json.Unmarshal([]byte(value), &fields); err != nil {
+			return true, fmt.Errorf("%s is not a JSON object", p.field)
+		}
+	case model.ParseKV:
+		fields = parseKV(value)
+		if len(fields) == 0 {
+			return true, fmt.Errorf("%s has no key=value pairs", p.field)
+		}
+	case model.ParseRegex:
+		match := p.pattern.FindStringSubmatch(value)
+		if match == nil {
+			return true, fmt.Errorf("%s does not match the pattern", p.field)
+		}
+		for i, name := range p.pattern.SubexpNames() {
+			if name != "" && match[i] != "" {
+				fields[name] = match[i]
+			}
+		}
+	}
+
+	if event.Attributes == nil {
+		event.Attributes = make(map[string]any, len(fields))
+	}
+	if p.target != "" {
+		event.Attributes[p.target] = fields
+	} else {
+		maps.Copy(event.Attributes, fields)
+	}
+	return true, nil
+}
+
+// parseKV reads whitespace separated key=value pairs; values may be double
+// quoted. Words that are not pairs are skipped.
+func parseKV(text string) map[string]any {
+	fields := make(map[string]any)
+
+	for i := 0; i < len(text); {
+		if text[i] == ' ' || text[i] == '\t' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(text) && text[i] != '=' && text[i] != ' ' && text[i] != '\t' {
+			i++
+		}
+		if i == len(text) || text[i] != '=' || i == start {
+			continue
+		}
+		key := text[start:i]
+		i++
+
+		if i < len(text) && text[i] == '"' {
+			var b strings.Builder
+			for i++; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' && i+1 < len(text) {
+					i++
+				}
+				b.WriteByte(text[i])
+			}
+			i++
+			fields[key] = b.String()
+			continue
+		}
+
+		start = i
+		for i < len(text) && text[i] != ' ' && text[i] != '\t' {
+			i++
+		}
+		fields[key] = text[start:i]
+	}
+
+	return fields
+}
+
+// tagProcessor adds tags and sets attributes
+type tagProcessor struct {
+	tags []string
+	set  map[string]string
+}
+
+func (p tagProcessor) Process(event *model.LogEvent) (bool, error) {
+	if event.Attributes == nil {
+		event.Attributes = make(map[string]any, len(p.set)+1)
+	}
+
+	if len(p.tags) > 0 {
+		var tags []any
+		switch existing := event.Attributes[model.TagsAttribute].(type) {
+		case nil:
+		case []any:
+			tags = append(tags, existing...)
+		case string:
+			tags = append(tags, existing)
+		default:
+			return true, fmt.Errorf("%s attribute is not a list", model.TagsAttribute)
+		}
+
+		for _, tag := range p.tags {
+			found := false
+			for _, t := range tags {
+				found = found || t == tag
+			}
+			if !found {
+				tags = append(tags, tag)
+			}
+		}
+		event.Attributes[model.TagsAttribute] = tags
+	}
+
+	for key, value := range p.set {
+		event.Attributes[key] = value
+	}
+	return true, nil
+}
+
+// redactProcessor replaces sensitive values. Without patterns the whole
+// value of each field is replaced.
+type redactProcessor struct {
+	message     bool
+	keys        []string
+	patterns    []*regexp.Regexp
+	replacement string
+}
+
+func newRedactProcessor(cfg StageConfig) (Processor, error) {
+	p := redactProcessor{replacement: cfg.Replacement}
+	if p.replacement == "" {
+		p.replacement = model.DefaultRedaction
+	}
+
+	if len(cfg.Fields) == 0 && len(cfg.Patterns) == 0 {
+		return nil, errors.New("redact requires fields or patterns")
+	}
+	for _, field := range cfg.Fields {
+		if field == model.FieldMessage {
+			p.message = true
+			continue
+		}
+		key, err := attributeKey(field)
+		if err != nil {
+			return nil, err
+		}
+		p.keys = append(p.keys, key)
+	}
+	for _, pattern := range cfg.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	return p, nil
+}
+
+func (p redactProcessor) Process(event *model.LogEvent) (bool, error) {
+	// Without fields, patterns apply to the message and every attribute
+	if len(p.keys) == 0 && !p.message {
+		event.Message = p.redact(event.Message)
+		for key, value := range event.Attributes {
+			event.Attributes[key] = p.redactValue(value)
+		}
+		return true, nil
+	}
+
+	if p.message {
+		event.Message = p.redact(event.Message)
+	}
+	for _, key := range p.keys {
+		if value, ok := event.Attributes[key]; ok {
+			event.Attributes[key] = p.redactValue(value)
+		}
+	}
+	return true, nil
+}
+
+func (p redactProcessor) redact(text string) string {
+	if len(p.patterns) == 0 {
+		return p.replacement
+	}
+	for _, re := range p.patterns {
+		text = re.ReplaceAllLiteralString(text, p.replacement)
+	}
+	return text
+}
+
+// redactValue redacts the strings in a value; nested objects and lists are
+// copied rather than modified, as they may be shared
+func (p redactProcessor) redactValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return p.redact(v)
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, item := range v {
+			redacted[key] = p.redactValue(item)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = p.redactValue(item)
+		}
+		return redacted
+	default:
+		// Numbers and booleans are only redacted as a whole
+		if len(p.patterns) == 0 && v != nil {
+			return p.replacement
+		}
+		return v
+	}
+}
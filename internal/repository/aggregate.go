@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// hllRegisters is the number of HyperLogLog registers used for cardinality
+// estimates, for a standard error of about 3%. It must match the mask and
+// the width of the rank bits in cardinalityQuery.
+const hllRegisters = 1024
+
+// cardinalityQuery builds HyperLogLog registers over a 64-bit hash of each
+// value: the low 10 bits pick the register, the rank is the position of the
+// first set bit among the high 54. Only the per-register maximum is kept, so
+// memory does not grow with the number of distinct values.
+const cardinalityQuery = `
+	SELECT COUNT(*), COALESCE(SUM(power(2, -rank)), 0)
+	FROM (
+		SELECT h & 1023 AS register,
+			MAX(COALESCE(NULLIF(position(B'1' IN substring(h::bit(64) FROM 1 FOR 54)), 0), 55)) AS rank
+		FROM (SELECT hashtextextended(%s, 0) AS h FROM logs WHERE %s AND %s IS NOT NULL) hashes
+		GROUP BY 1
+	) registers
+`
+
+// AggregateLogs counts the logs of req.Filter in buckets of interval, finds
+// the top values of each group-by field and estimates the cardinality of
+// fields, all in SQL.
+func (r *PostgresRepository) AggregateLogs(ctx context.Context, req model.AggregateRequest, interval time.Duration) (*model.AggregateResponse, error) {
+	if req.Filter.TenantID == "" {
+		return nil, ErrMissingTenant
+	}
+
+	response := &model.AggregateResponse{
+		IntervalSeconds: int64(interval / time.Second),
+	}
+
+	counts, err := r.histogram(ctx, req.Filter, interval)
+	if err != nil {
+		return nil, err
+	}
+	response.Buckets = fillBuckets(counts, req.Filter.StartTime, req.Filter.EndTime, interval)
+	for _, bucket := range response.Buckets {
+		response.Total += bucket.Count
+	}
+
+	for _, field := range req.GroupBy {
+		group, err := r.topValues(ctx, req.Filter, field, req.Top)
+		if err != nil {
+			return nil, err
+		}
+		response.Groups = append(response.Groups, *group)
+	}
+
+	if len(req.Cardinality) > 0 {
+		response.Cardinality = make(map[string]int64, len(req.Cardinality))
+		for _, field := range req.Cardinality {
+			estimate, err := r.cardinality(ctx, req.Filter, field)
+			if err != nil {
+				return nil, err
+			}
+			response.Cardinality[field] = estimate
+		}
+	}
+
+	return response, nil
+}
+
+// histogram counts the logs of a filter per bucket start, including the
+// repeats collapsed into them. Buckets are aligned to the Unix epoch.
+func (r *PostgresRepository) histogram(ctx context.Context, req model.QueryRequest, interval time.Duration) (map[int64]int64, error) {
+	filter, err := buildLogFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT date_bin(%s::interval, timestamp, TIMESTAMPTZ 'epoch') AS bucket, SUM(repeat_count)
+		FROM logs
+		WHERE %s
+		GROUP BY 1
+	`, filter.arg(interval), filter.where())
+
+	rows, err := r.pool.Query(ctx, query, filter.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate logs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]int64)
+	for rows.Next() {
+		var bucket time.Time
+		var count int64
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan bucket: %w", err)
+		}
+		counts[bucket.Unix()] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// topValues returns the top most frequent values of a field, counting the
+// repeats collapsed into logs
+func (r *PostgresRepository) topValues(ctx context.Context, req model.QueryRequest, field string, top int) (*model.GroupResult, error) {
+	filter, err := buildLogFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The window sum is taken over every group before the limit applies
+	expr := filter.fieldExpr(field)
+	query := fmt.Sprintf(`
+		SELECT value, n, SUM(n) OVER ()::bigint
+		FROM (
+			SELECT %s AS value, SUM(repeat_count) AS n
+			FROM logs
+			WHERE %s AND %s IS NOT NULL
+			GROUP BY 1
+		) value_counts
+		ORDER BY n DESC, value
+		LIMIT %s
+	`, expr, filter.where(), expr, filter.arg(top))
+
+	rows, err := r.pool.Query(ctx, query, filter.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to group logs by %s: %w", field, err)
+	}
+	defer rows.Close()
+
+	group := &model.GroupResult{Field: field, Values: make([]model.GroupCount, 0, top)}
+	var withValue, inTop int64
+	for rows.Next() {
+		var value model.GroupCount
+		if err := rows.Scan(&value.Value, &value.Count, &withValue); err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		group.Values = append(group.Values, value)
+		inTop += value.Count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to group logs by %s: %w", field, err)
+	}
+
+	group.Other = withValue - inTop
+	return group, nil
+}
+
+// cardinality estimates the number of distinct values of a field
+func (r *PostgresRepository) cardinality(ctx context.Context, req model.QueryRequest, field string) (int64, error) {
+	filter, err := buildLogFilter(req)
+	if err != nil {
+		return 0, err
+	}
+
+	expr := filter.fieldExpr(field)
+	query := fmt.Sprintf(cardinalityQuery, expr, filter.where(), expr)
+
+	var filled int64
+	var harmonicSum float64
+	if err := r.pool.QueryRow(ctx, query, filter.args...).Scan(&filled, &harmonicSum); err != nil {
+		return 0, fmt.Errorf("failed to estimate cardinality of %s: %w", field, err)
+	}
+
+	return hllEstimate(filled, harmonicSum), nil
+}
+
+// hllEstimate turns the registers of cardinalityQuery into an estimate:
+// filled is the number of non-empty registers and harmonicSum the sum of
+// 2^-rank over them. Small cardinalities use linear counting.
+func hllEstimate(filled int64, harmonicSum float64) int64 {
+	m := float64(hllRegisters)
+	// Empty registers have rank 0 and contribute 2^0 each
+	z := harmonicSum + (m - float64(filled))
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / z
+
+	if estimate <= 2.5*m && filled < hllRegisters {
+		estimate = m * math.Log(m/(m-float64(filled)))
+	}
+	return int64(math.Round(estimate))
+}
+
+// fillBuckets returns every bucket between start and end in order, with
+// the counts found and zero for the rest
+func fillBuckets(counts map[int64]int64, start, end time.Time, interval time.Duration) []model.HistogramBucket {
+	step := int64(interval / time.Second)
+	first := floorDiv(start.Unix(), step) * step
+	last := floorDiv(end.Unix(), step) * step
+
+	buckets := make([]model.HistogramBucket, 0, (last-first)/step+1)
+	for t := first; t <= last; t += step {
+		buckets = append(buckets, model.HistogramBucket{
+			Start: time.Unix(t, 0).UTC(),
+			Count: counts[t],
+		})
+	}
+	return buckets
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
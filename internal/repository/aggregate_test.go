@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+func TestHLLEstimate(t *testing.T) {
+	assert.Equal(t, int64(0), hllEstimate(0, 0))
+
+	// Few values land in distinct registers with rank 1 and are counted linearly
+	assert.Equal(t, int64(10), hllEstimate(10, 10*0.5))
+
+	// With every register filled the harmonic mean applies
+	assert.Equal(t, int64(738), hllEstimate(hllRegisters, hllRegisters))
+	assert.InDelta(t, 1_000_000, hllEstimate(hllRegisters, 0.7213/(1+1.079/1024)*1024*1024/1_000_000), 1)
+}
+
+func TestFillBuckets(t *testing.T) {
+	start := time.Date(2026, 1, 29, 12, 7, 30, 0, time.UTC)
+	end := time.Date(2026, 1, 29, 12, 31, 0, 0, time.UTC)
+	counts := map[int64]int64{
+		time.Date(2026, 1, 29, 12, 10, 0, 0, time.UTC).Unix(): 4,
+		time.Date(2026, 1, 29, 12, 30, 0, 0, time.UTC).Unix(): 1,
+	}
+
+	buckets := fillBuckets(counts, start, end, 10*time.Minute)
+
+	require.Len(t, buckets, 4)
+	assert.Equal(t, time.Date(2026, 1, 29, 12, 0, 0, 0, time.UTC), buckets[0].Start)
+	assert.Equal(t, []int64{0, 4, 0, 1}, []int64{buckets[0].Count, buckets[1].Count, buckets[2].Count, buckets[3].Count})
+}
+
+func TestBuildLogFilter(t *testing.T) {
+	req := model.QueryRequest{
+		TenantID:        "red",
+		StartTime:       time.Date(2026, 1, 29, 0, 0, 0, 0, time.UTC),
+		EndTime:         time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC),
+		Severity:        []string{model.SeverityHigh, model.SeverityCritical},
+		AttributeExists: []string{"user"},
+		Query:           "denied",
+	}
+
+	filter, err := buildLogFilter(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, "tenant_id = $1 AND timestamp >= $2 AND timestamp <= $3 AND severity IN ($4,$5) AND "+
+		"attributes ? $6 AND message_tsv @@ plainto_tsquery('simple', $7)", filter.where())
+	assert.Len(t, filter.args, 7)
+
+	assert.Equal(t, "(attributes ->> $8::text)", filter.fieldExpr("attr.src_ip"))
+	assert.Equal(t, "src_ip", filter.args[7])
+	assert.Equal(t, "severity", filter.fieldExpr(model.FieldSeverity))
+}
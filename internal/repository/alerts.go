@@ -0,0 +1,488 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// ErrInvalidTransition is returned when an alert cannot move to the requested status
+var ErrInvalidTransition = errors.New("invalid alert status transition")
+
+const alertColumns = "id, tenant_id, rule_id, rule_name, severity, group_key, event_count, log_ids, message, triggered_at, status, assignee, COALESCE(suppression_id::text, ''), updated_at"
+
+const suppressionColumns = "id, tenant_id, COALESCE(rule_id::text, ''), COALESCE(group_key, ''), COALESCE(severity, ''), reason, created_by, starts_at, expires_at, created_at"
+
+// InsertAlerts stores alerts raised by detection rules. Alerts matching an
+// active suppression of their tenant are stored as suppressed. The status
+// and suppression of each stored alert are set on alerts.
+func (r *PostgresRepository) InsertAlerts(ctx context.Context, alerts []model.Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO alerts (id, tenant_id, rule_id, rule_name, severity, group_key, event_count, log_ids, message, triggered_at, status, suppression_id, updated_at)
+		SELECT $1::uuid, $2::varchar, $3::uuid, $4::varchar, $5::varchar, $6::text, $7::int, $8::uuid[], $9::text, $10::timestamptz,
+			CASE WHEN s.id IS NULL THEN $11 ELSE $12 END, s.id, $10
+		FROM (SELECT 1) AS one
+		LEFT JOIN LATERAL (
+			SELECT id FROM alert_suppressions
+			WHERE tenant_id = $2
+				AND (rule_id IS NULL OR rule_id = $3)
+				AND (group_key IS NULL OR group_key = $6)
+				AND (severity IS NULL OR severity = $5)
+				AND starts_at <= $10 AND expires_at > $10
+			ORDER BY expires_at DESC
+			LIMIT 1
+		) s ON TRUE
+		RETURNING status, COALESCE(suppression_id::text, '')
+	`
+
+	for i := range alerts {
+		alert := &alerts[i]
+		alert.TenantID = tenantOrDefault(alert.TenantID)
+
+		err := tx.QueryRow(ctx, query,
+			alert.ID,
+			alert.TenantID,
+			alert.RuleID,
+			alert.RuleName,
+			alert.Severity,
+			alert.GroupKey,
+			alert.EventCount,
+			alert.LogIDs,
+			alert.Message,
+			alert.TriggeredAt,
+			model.AlertOpen,
+			model.AlertSuppressed,
+		).Scan(&alert.Status, &alert.SuppressionID)
+		if err != nil {
+			return fmt.Errorf("failed to insert alert: %w", err)
+		}
+		alert.UpdatedAt = alert.TriggeredAt
+
+		entry := model.AlertAuditEntry{
+			AlertID:   alert.ID,
+			Actor:     model.AlertActorSystem,
+			Action:    model.AlertActionCreated,
+			ToStatus:  alert.Status,
+			CreatedAt: alert.TriggeredAt,
+		}
+		if alert.Status == model.AlertSuppressed {
+			entry.Action = model.AlertActionSuppressed
+			entry.Detail = alert.SuppressionID
+		}
+		if err := insertAlertAudit(ctx, tx, entry); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListAlerts lists the alerts of a filter, newest first, and the total
+// number of matching alerts
+func (r *PostgresRepository) ListAlerts(ctx context.Context, filter model.AlertFilter) ([]model.Alert, int, error) {
+	if filter.TenantID == "" {
+		return nil, 0, ErrMissingTenant
+	}
+
+	b := &sqlBuilder{}
+	where := b.alertFilter(filter)
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM alerts WHERE " + where
+	if err := r.pool.QueryRow(ctx, countQuery, b.args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count alerts: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+alertColumns+`
+		FROM alerts
+		WHERE %s
+		ORDER BY triggered_at DESC, id DESC
+		LIMIT %s OFFSET %s
+	`, where, b.arg(filter.Limit), b.arg(filter.Offset))
+
+	rows, err := r.pool.Query(ctx, query, b.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := make([]model.Alert, 0)
+	for rows.Next() {
+		var alert model.Alert
+		if err := scanAlert(rows, &alert); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, total, rows.Err()
+}
+
+// GetAlert retrieves an alert of a tenant
+func (r *PostgresRepository) GetAlert(ctx context.Context, tenantID, id string) (*model.Alert, error) {
+	if tenantID == "" {
+		return nil, ErrMissingTenant
+	}
+
+	var alert model.Alert
+	err := scanAlert(r.pool.QueryRow(ctx, `
+		SELECT `+alertColumns+`
+		FROM alerts
+		WHERE tenant_id = $1 AND id = $2
+	`, tenantID, id), &alert)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get alert: %w", err)
+	}
+
+	return &alert, nil
+}
+
+// UpdateAlertStatus moves an alert to status, recording the change in its
+// audit trail and comment as a comment of actor. It returns
+// ErrInvalidTransition when model.AlertTransitionAllowed forbids the change.
+func (r *PostgresRepository) UpdateAlertStatus(ctx context.Context, tenantID, id, status, actor, comment string) (*model.Alert, error) {
+	return r.updateAlert(ctx, tenantID, id, func(tx pgx.Tx, alert *model.Alert, now time.Time) error {
+		if !model.AlertTransitionAllowed(alert.Status, status) {
+			return ErrInvalidTransition
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE alerts SET status = $1, updated_at = $2 WHERE id = $3`, status, now, id); err != nil {
+			return fmt.Errorf("failed to update alert: %w", err)
+		}
+
+		err := insertAlertAudit(ctx, tx, model.AlertAuditEntry{
+			AlertID:    id,
+			Actor:      actor,
+			Action:     model.AlertActionStatus,
+			FromStatus: alert.Status,
+			ToStatus:   status,
+			CreatedAt:  now,
+		})
+		if err != nil {
+			return err
+		}
+
+		if comment != "" {
+			err := insertAlertComment(ctx, tx, model.AlertComment{
+				ID:        uuid.New().String(),
+				AlertID:   id,
+				Author:    actor,
+				Body:      comment,
+				CreatedAt: now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		alert.Status = status
+		return nil
+	})
+}
+
+// AssignAlert sets the assignee of an alert, recording the change in its
+// audit trail. Assigning the current assignee changes nothing.
+func (r *PostgresRepository) AssignAlert(ctx context.Context, tenantID, id, assignee, actor string) (*model.Alert, error) {
+	return r.updateAlert(ctx, tenantID, id, func(tx pgx.Tx, alert *model.Alert, now time.Time) error {
+		if alert.Assignee == assignee {
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE alerts SET assignee = $1, updated_at = $2 WHERE id = $3`, assignee, now, id); err != nil {
+			return fmt.Errorf("failed to update alert: %w", err)
+		}
+
+		err := insertAlertAudit(ctx, tx, model.AlertAuditEntry{
+			AlertID:   id,
+			Actor:     actor,
+			Action:    model.AlertActionAssigned,
+			Detail:    assignee,
+			CreatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+
+		alert.Assignee = assignee
+		return nil
+	})
+}
+
+// updateAlert locks an alert of a tenant and applies change to it in a
+// single transaction
+func (r *PostgresRepository) updateAlert(ctx context.Context, tenantID, id string, change func(tx pgx.Tx, alert *model.Alert, now time.Time) error) (*model.Alert, error) {
+	if tenantID == "" {
+		return nil, ErrMissingTenant
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var alert model.Alert
+	err = scanAlert(tx.QueryRow(ctx, `
+		SELECT `+alertColumns+`
+		FROM alerts
+		WHERE tenant_id = $1 AND id = $2
+		FOR UPDATE
+	`, tenantID, id), &alert)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get alert: %w", err)
+	}
+
+	before := alert
+	now := time.Now()
+	if err := change(tx, &alert, now); err != nil {
+		return nil, err
+	}
+	if alert.Status != before.Status || alert.Assignee != before.Assignee {
+		alert.UpdatedAt = now
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit alert update: %w", err)
+	}
+	return &alert, nil
+}
+
+// InsertAlertComment adds a comment to an alert of a tenant
+func (r *PostgresRepository) InsertAlertComment(ctx context.Context, tenantID string, comment model.AlertComment) error {
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO alert_comments (id, alert_id, author, body, created_at)
+		SELECT $1, id, $3, $4, $5
+		FROM alerts
+		WHERE tenant_id = $2 AND id = $6
+	`, comment.ID, tenantID, comment.Author, comment.Body, comment.CreatedAt, comment.AlertID)
+	if err != nil {
+		return fmt.Errorf("failed to insert alert comment: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListAlertComments lists the comments of an alert, oldest first
+func (r *PostgresRepository) ListAlertComments(ctx context.Context, alertID string) ([]model.AlertComment, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, alert_id, author, body, created_at
+		FROM alert_comments
+		WHERE alert_id = $1
+		ORDER BY created_at, id
+	`, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := make([]model.AlertComment, 0)
+	for rows.Next() {
+		var c model.AlertComment
+		if err := rows.Scan(&c.ID, &c.AlertID, &c.Author, &c.Body, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan alert comment: %w", err)
+		}
+		comments = append(comments, c)
+	}
+
+	return comments, rows.Err()
+}
+
+// ListAlertAudit lists the audit trail of an alert, oldest first
+func (r *PostgresRepository) ListAlertAudit(ctx context.Context, alertID string) ([]model.AlertAuditEntry, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, alert_id, actor, action, from_status, to_status, detail, created_at
+		FROM alert_audit
+		WHERE alert_id = $1
+		ORDER BY id
+	`, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert audit: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]model.AlertAuditEntry, 0)
+	for rows.Next() {
+		var e model.AlertAuditEntry
+		if err := rows.Scan(&e.ID, &e.AlertID, &e.Actor, &e.Action, &e.FromStatus, &e.ToStatus, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan alert audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// InsertAlertSuppression stores a suppression. Empty matchers are stored as
+// NULL, matching every alert.
+func (r *PostgresRepository) InsertAlertSuppression(ctx context.Context, s model.AlertSuppression) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO alert_suppressions (id, tenant_id, rule_id, group_key, severity, reason, created_by, starts_at, expires_at, created_at)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9, $10)
+	`,
+		s.ID,
+		tenantOrDefault(s.TenantID),
+		s.RuleID,
+		s.GroupKey,
+		s.Severity,
+		s.Reason,
+		s.CreatedBy,
+		s.StartsAt,
+		s.ExpiresAt,
+		s.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert alert suppression: %w", err)
+	}
+	return nil
+}
+
+// ListAlertSuppressions lists the suppressions of a tenant, latest expiry
+// first. Unless all is set, suppressions that expired before now are left out.
+func (r *PostgresRepository) ListAlertSuppressions(ctx context.Context, tenantID string, all bool, now time.Time) ([]model.AlertSuppression, error) {
+	if tenantID == "" {
+		return nil, ErrMissingTenant
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+suppressionColumns+`
+		FROM alert_suppressions
+		WHERE tenant_id = $1 AND ($2 OR expires_at > $3)
+		ORDER BY expires_at DESC, id
+	`, tenantID, all, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	suppressions := make([]model.AlertSuppression, 0)
+	for rows.Next() {
+		var s model.AlertSuppression
+		err := rows.Scan(
+			&s.ID,
+			&s.TenantID,
+			&s.RuleID,
+			&s.GroupKey,
+			&s.Severity,
+			&s.Reason,
+			&s.CreatedBy,
+			&s.StartsAt,
+			&s.ExpiresAt,
+			&s.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert suppression: %w", err)
+		}
+		suppressions = append(suppressions, s)
+	}
+
+	return suppressions, rows.Err()
+}
+
+// ExpireAlertSuppression ends a suppression of a tenant at the given time.
+// Suppressions are kept rather than deleted, so suppressed alerts can still
+// be traced to them.
+func (r *PostgresRepository) ExpireAlertSuppression(ctx context.Context, tenantID, id string, at time.Time) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE alert_suppressions
+		SET starts_at = LEAST(starts_at, $3), expires_at = LEAST(expires_at, $3)
+		WHERE tenant_id = $1 AND id = $2
+	`, tenantID, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to expire alert suppression: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func insertAlertAudit(ctx context.Context, tx pgx.Tx, e model.AlertAuditEntry) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO alert_audit (alert_id, actor, action, from_status, to_status, detail, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, e.AlertID, e.Actor, e.Action, e.FromStatus, e.ToStatus, e.Detail, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert alert audit entry: %w", err)
+	}
+	return nil
+}
+
+func insertAlertComment(ctx context.Context, tx pgx.Tx, c model.AlertComment) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO alert_comments (id, alert_id, author, body, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, c.ID, c.AlertID, c.Author, c.Body, c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert alert comment: %w", err)
+	}
+	return nil
+}
+
+func scanAlert(row pgx.Row, alert *model.Alert) error {
+	return row.Scan(
+		&alert.ID,
+		&alert.TenantID,
+		&alert.RuleID,
+		&alert.RuleName,
+		&alert.Severity,
+		&alert.GroupKey,
+		&alert.EventCount,
+		&alert.LogIDs,
+		&alert.Message,
+		&alert.TriggeredAt,
+		&alert.Status,
+		&alert.Assignee,
+		&alert.SuppressionID,
+		&alert.UpdatedAt,
+	)
+}
+
+// alertFilter builds the predicate of an alert filter
+func (b *sqlBuilder) alertFilter(f model.AlertFilter) string {
+	conditions := []string{"tenant_id = " + b.arg(f.TenantID)}
+	if len(f.Statuses) > 0 {
+		conditions = append(conditions, "status = ANY("+b.arg(f.Statuses)+")")
+	}
+	if f.Assignee != "" {
+		conditions = append(conditions, "assignee = "+b.arg(f.Assignee))
+	}
+	if f.RuleID != "" {
+		conditions = append(conditions, "rule_id = "+b.arg(f.RuleID))
+	}
+	if f.Severity != "" {
+		conditions = append(conditions, "severity = "+b.arg(f.Severity))
+	}
+	if f.StartTime != nil {
+		conditions = append(conditions, "triggered_at >= "+b.arg(*f.StartTime))
+	}
+	if f.EndTime != nil {
+		conditions = append(conditions, "triggered_at < "+b.arg(*f.EndTime))
+	}
+	return strings.Join(conditions, " AND ")
+}
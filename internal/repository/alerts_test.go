@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+func TestAlertFilterPredicate(t *testing.T) {
+	b := &sqlBuilder{}
+	assert.Equal(t, "tenant_id = $1", b.alertFilter(model.AlertFilter{TenantID: "acme"}))
+
+	start := time.Date(2026, 1, 29, 0, 0, 0, 0, time.UTC)
+	b = &sqlBuilder{}
+	where := b.alertFilter(model.AlertFilter{
+		TenantID:  "acme",
+		Statuses:  []string{model.AlertOpen, model.AlertAcknowledged},
+		Assignee:  "alice",
+		Severity:  model.SeverityHigh,
+		StartTime: &start,
+	})
+
+	assert.Equal(t,
+		"tenant_id = $1 AND status = ANY($2) AND assignee = $3 AND severity = $4 AND triggered_at >= $5",
+		where)
+	assert.Equal(t, []interface{}{"acme", []string{"open", "acknowledged"}, "alice", "HIGH", start}, b.args)
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// apiKeyColumns is the column list selected for API keys, in scanAPIKey order
+const apiKeyColumns = "id, name, tenant_id, prefix, roles, created_at, expires_at, last_used_at, revoked_at"
+
+// CreateAPIKey stores an API key with the hash of its secret
+func (r *PostgresRepository) CreateAPIKey(ctx context.Context, key *model.APIKey, hash []byte) error {
+	query := `
+		INSERT INTO api_keys (id, name, tenant_id, prefix, key_hash, roles, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.pool.Exec(ctx, query,
+		key.ID,
+		key.Name,
+		key.TenantID,
+		key.Prefix,
+		hash,
+		key.Roles,
+		key.CreatedAt,
+		key.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert api key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKeyByHash retrieves the API key with the given secret hash
+func (r *PostgresRepository) GetAPIKeyByHash(ctx context.Context, hash []byte) (*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	var key model.APIKey
+	if err := scanAPIKey(r.pool.QueryRow(ctx, query, hash), &key); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+// ListAPIKeys lists the API keys of a tenant, or of every tenant when
+// tenantID is empty, newest first
+func (r *PostgresRepository) ListAPIKeys(ctx context.Context, tenantID string) ([]model.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + ` FROM api_keys
+		WHERE $1 = '' OR tenant_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]model.APIKey, 0)
+	for rows.Next() {
+		var key model.APIKey
+		if err := scanAPIKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revokes an API key of a tenant, or of any tenant when tenantID is empty
+func (r *PostgresRepository) RevokeAPIKey(ctx context.Context, tenantID, id string) error {
+	query := `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL AND ($2 = '' OR tenant_id = $2)
+	`
+
+	tag, err := r.pool.Exec(ctx, query, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// HasOperatorKey reports whether an active admin key of the default tenant exists
+func (r *PostgresRepository) HasOperatorKey(ctx context.Context) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM api_keys
+			WHERE tenant_id = $1 AND $2 = ANY(roles)
+				AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		)
+	`
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, query, model.DefaultTenant, model.RoleAdmin).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check api keys: %w", err)
+	}
+	return exists, nil
+}
+
+// TouchAPIKeys records when API keys were last used
+func (r *PostgresRepository) TouchAPIKeys(ctx context.Context, lastUsed map[string]time.Time) error {
+	if len(lastUsed) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(lastUsed))
+	times := make([]time.Time, 0, len(lastUsed))
+	for id, t := range lastUsed {
+		ids = append(ids, id)
+		times = append(times, t)
+	}
+
+	query := `
+		UPDATE api_keys k
+		SET last_used_at = u.used
+		FROM unnest($1::uuid[], $2::timestamptz[]) AS u(id, used)
+		WHERE k.id = u.id AND (k.last_used_at IS NULL OR k.last_used_at < u.used)
+	`
+
+	if _, err := r.pool.Exec(ctx, query, ids, times); err != nil {
+		return fmt.Errorf("failed to update api key usage: %w", err)
+	}
+	return nil
+}
+
+// scanAPIKey scans a row selected with apiKeyColumns into an API key
+func scanAPIKey(row pgx.Row, key *model.APIKey) error {
+	return row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.TenantID,
+		&key.Prefix,
+		&key.Roles,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// InsertDeadLetters stores log events that could not be inserted
+func (r *PostgresRepository) InsertDeadLetters(ctx context.Context, deadLetters []model.DeadLetter) error {
+	if len(deadLetters) == 0 {
+		return nil
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO dead_letters (log_id, tenant_id, payload, error, failed_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	for _, dl := range deadLetters {
+		payload, err := json.Marshal(dl.Log)
+		if err != nil {
+			return fmt.Errorf("failed to marshal dead letter: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, query, dl.Log.ID, tenantOrDefault(dl.Log.TenantID), payload, dl.Error, dl.FailedAt); err != nil {
+			return fmt.Errorf("failed to insert dead letter: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListDeadLetters lists dead letters by status, newest first. An empty
+// tenantID lists the dead letters of every tenant.
+func (r *PostgresRepository) ListDeadLetters(ctx context.Context, tenantID, status string, limit, offset int) ([]model.DeadLetter, int, error) {
+	whereClause := "($1 = '' OR tenant_id = $1)"
+	switch status {
+	case model.DeadLetterPending:
+		whereClause += " AND replayed_at IS NULL"
+	case model.DeadLetterReplayed:
+		whereClause += " AND replayed_at IS NOT NULL"
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM dead_letters WHERE %s", whereClause)
+	if err := r.pool.QueryRow(ctx, countQuery, tenantID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, payload, error, failed_at, replayed_at
+		FROM dead_letters
+		WHERE %s
+		ORDER BY failed_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, whereClause)
+
+	deadLetters, err := r.queryDeadLetters(ctx, query, tenantID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deadLetters, total, nil
+}
+
+// GetPendingDeadLetters returns pending dead letters, restricted to ids when
+// given. An empty tenantID returns the dead letters of every tenant.
+func (r *PostgresRepository) GetPendingDeadLetters(ctx context.Context, tenantID string, ids []int64, limit int) ([]model.DeadLetter, error) {
+	if len(ids) > 0 {
+		return r.queryDeadLetters(ctx, `
+			SELECT id, payload, error, failed_at, replayed_at
+			FROM dead_letters
+			WHERE replayed_at IS NULL AND ($1 = '' OR tenant_id = $1) AND id = ANY($2)
+			ORDER BY id
+			LIMIT $3
+		`, tenantID, ids, limit)
+	}
+
+	return r.queryDeadLetters(ctx, `
+		SELECT id, payload, error, failed_at, replayed_at
+		FROM dead_letters
+		WHERE replayed_at IS NULL AND ($1 = '' OR tenant_id = $1)
+		ORDER BY id
+		LIMIT $2
+	`, tenantID, limit)
+}
+
+// MarkDeadLettersReplayed records that dead letters were resubmitted
+func (r *PostgresRepository) MarkDeadLettersReplayed(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := r.pool.Exec(ctx, `
+		UPDATE dead_letters SET replayed_at = NOW()
+		WHERE id = ANY($1)
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to mark dead letters replayed: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) queryDeadLetters(ctx context.Context, query string, args ...interface{}) ([]model.DeadLetter, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	deadLetters := make([]model.DeadLetter, 0)
+	for rows.Next() {
+		var dl model.DeadLetter
+		var payload []byte
+		if err := rows.Scan(&dl.ID, &payload, &dl.Error, &dl.FailedAt, &dl.ReplayedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		if err := json.Unmarshal(payload, &dl.Log); err != nil {
+			return nil, fmt.Errorf("failed to decode dead letter payload: %w", err)
+		}
+		deadLetters = append(deadLetters, dl)
+	}
+
+	return deadLetters, rows.Err()
+}
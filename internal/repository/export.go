@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// exportPageSize is the number of logs read per export query
+const exportPageSize = 1000
+
+// StreamLogs calls fn for every log matching the query filters, oldest
+// first; pagination and sort fields of req are ignored. Logs are read in
+// pages that seek past the last log of the previous page, and each page is
+// read in full before fn sees it, so a slow consumer never holds a
+// transaction or locks on logs open and memory stays bounded however many
+// logs match.
+func (r *PostgresRepository) StreamLogs(ctx context.Context, req model.QueryRequest, fn func(*model.LogEvent) error) error {
+	if req.TenantID == "" {
+		return ErrMissingTenant
+	}
+
+	var last *model.LogEvent
+	for {
+		page, err := r.exportPage(ctx, req, last)
+		if err != nil {
+			return err
+		}
+
+		for i := range page {
+			if err := fn(&page[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < exportPageSize {
+			return nil
+		}
+		last = &page[len(page)-1]
+	}
+}
+
+// exportPage returns the next page of an export, after the log last when
+// it is set
+func (r *PostgresRepository) exportPage(ctx context.Context, req model.QueryRequest, last *model.LogEvent) ([]model.LogEvent, error) {
+	filter, err := buildLogFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	whereClause := filter.where()
+	if last != nil {
+		// The plain timestamp bound lets earlier partitions be pruned
+		timeArg := filter.arg(last.Timestamp)
+		whereClause += fmt.Sprintf(" AND timestamp >= %s AND (timestamp, id) > (%s, %s)", timeArg, timeArg, filter.arg(last.ID))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM logs
+		WHERE %s
+		ORDER BY timestamp, id
+		LIMIT %d
+	`, logColumns, whereClause, exportPageSize)
+
+	rows, err := r.pool.Query(ctx, query, filter.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch logs: %w", err)
+	}
+	defer rows.Close()
+
+	page := make([]model.LogEvent, 0, exportPageSize)
+	for rows.Next() {
+		var log model.LogEvent
+		if err := scanLog(rows, &log); err != nil {
+			return nil, fmt.Errorf("failed to scan log: %w", err)
+		}
+		page = append(page, log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch logs: %w", err)
+	}
+	return page, nil
+}
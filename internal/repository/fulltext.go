@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Saumajitt/threatLog/pkg/fulltext"
+)
+
+// searchConfig is the text search configuration used for message_tsv.
+// "simple" avoids stemming so IOCs and identifiers match exactly.
+const searchConfig = "'simple'"
+
+// searchCompiler compiles a parsed search query into a parameterized SQL predicate
+type searchCompiler struct {
+	args   []interface{}
+	argPos int
+	// tsQueries collects the positive tsquery leaves used for ranking
+	tsQueries []string
+}
+
+// compile returns the SQL predicate for expr
+func (c *searchCompiler) compile(expr fulltext.Expr, negated bool) string {
+	switch e := expr.(type) {
+	case fulltext.And:
+		return fmt.Sprintf("(%s AND %s)", c.compile(e.Left, negated), c.compile(e.Right, negated))
+	case fulltext.Or:
+		return fmt.Sprintf("(%s OR %s)", c.compile(e.Left, negated), c.compile(e.Right, negated))
+	case fulltext.Not:
+		return fmt.Sprintf("NOT %s", c.compile(e.Operand, !negated))
+	case fulltext.Phrase:
+		return c.tsPredicate(fmt.Sprintf("phraseto_tsquery(%s, %s)", searchConfig, c.arg(e.Text)), negated)
+	case fulltext.Term:
+		switch e.Kind {
+		case fulltext.TermPrefix:
+			return c.tsPredicate(fmt.Sprintf("to_tsquery(%s, %s)", searchConfig, c.arg(prefixLexeme(e.Text))), negated)
+		case fulltext.TermWildcard:
+			// Served by the trigram index on message
+			return fmt.Sprintf("message ILIKE %s", c.arg(wildcardPattern(e.Text)))
+		default:
+			return c.tsPredicate(fmt.Sprintf("plainto_tsquery(%s, %s)", searchConfig, c.arg(e.Text)), negated)
+		}
+	default:
+		return "TRUE"
+	}
+}
+
+// rankExpr returns the relevance expression for the compiled query
+func (c *searchCompiler) rankExpr() string {
+	if len(c.tsQueries) == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("ts_rank(message_tsv, %s)", strings.Join(c.tsQueries, " || "))
+}
+
+func (c *searchCompiler) tsPredicate(tsQuery string, negated bool) string {
+	if !negated {
+		c.tsQueries = append(c.tsQueries, tsQuery)
+	}
+	return fmt.Sprintf("message_tsv @@ %s", tsQuery)
+}
+
+func (c *searchCompiler) arg(value interface{}) string {
+	c.args = append(c.args, value)
+	placeholder := fmt.Sprintf("$%d", c.argPos)
+	c.argPos++
+	return placeholder
+}
+
+// prefixLexeme quotes a word as a tsquery prefix lexeme ('word':*)
+func prefixLexeme(word string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(word)
+	return "'" + escaped + "':*"
+}
+
+// wildcardPattern converts * and ? wildcards into an unanchored ILIKE pattern
+func wildcardPattern(term string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+	return "%" + strings.NewReplacer("*", "%", "?", "_").Replace(escaped) + "%"
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+const deliveryColumns = "id, channel, channel_type, kind, tenant_id, reference_id, subject, status, attempts, suppressed, error, created_at, completed_at"
+
+// InsertNotificationDelivery records a notification delivery
+func (r *PostgresRepository) InsertNotificationDelivery(ctx context.Context, d model.NotificationDelivery) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO notification_deliveries (`+deliveryColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`,
+		d.ID,
+		d.Channel,
+		d.ChannelType,
+		d.Kind,
+		tenantOrDefault(d.TenantID),
+		d.ReferenceID,
+		d.Subject,
+		d.Status,
+		d.Attempts,
+		d.Suppressed,
+		d.Error,
+		d.CreatedAt,
+		d.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert notification delivery: %w", err)
+	}
+	return nil
+}
+
+// ListNotificationDeliveries lists notification deliveries, newest first,
+// optionally of a single channel
+func (r *PostgresRepository) ListNotificationDeliveries(ctx context.Context, channel string, limit int) ([]model.NotificationDelivery, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM notification_deliveries
+		WHERE $1 = '' OR channel = $1
+		ORDER BY completed_at DESC
+		LIMIT $2
+	`, channel, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]model.NotificationDelivery, 0)
+	for rows.Next() {
+		var d model.NotificationDelivery
+		err := rows.Scan(
+			&d.ID,
+			&d.Channel,
+			&d.ChannelType,
+			&d.Kind,
+			&d.TenantID,
+			&d.ReferenceID,
+			&d.Subject,
+			&d.Status,
+			&d.Attempts,
+			&d.Suppressed,
+			&d.Error,
+			&d.CreatedAt,
+			&d.CompletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DefaultLogPartition receives events outside every other partition
+const DefaultLogPartition = "logs_default"
+
+// LegacyLogPartition holds the rows of logs from before it was partitioned
+const LegacyLogPartition = "logs_legacy"
+
+// ErrPartitionOverlap is returned when a new partition overlaps an existing one
+var ErrPartitionOverlap = errors.New("partition overlaps an existing partition")
+
+// partitionLockTimeout bounds how long dropping a partition waits for its
+// locks. A DETACH queued behind a long reader would block every later
+// insert and query on logs, so maintenance gives up and retries instead.
+const partitionLockTimeout = "5s"
+
+// ErrPartitionHeld is returned when a partition to drop holds logs under legal hold
+var ErrPartitionHeld = errors.New("partition holds logs under legal hold")
+
+// IsLogsPartitioned reports whether the logs table is partitioned
+func (r *PostgresRepository) IsLogsPartitioned(ctx context.Context) (bool, error) {
+	var kind string
+	if err := r.pool.QueryRow(ctx, "SELECT relkind::text FROM pg_class WHERE oid = 'logs'::regclass").Scan(&kind); err != nil {
+		return false, fmt.Errorf("failed to inspect logs table: %w", err)
+	}
+	return kind == "p", nil
+}
+
+// ListLogPartitions returns the names of the partitions attached to logs
+func (r *PostgresRepository) ListLogPartitions(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'logs'::regclass
+		ORDER BY c.relname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log partitions: %w", err)
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan log partition: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// CreateLogPartition creates the partition for [from, to). Rows already in
+// the default partition for that range are moved into it before it is
+// attached, since attaching would fail otherwise.
+func (r *PostgresRepository) CreateLogPartition(ctx context.Context, name string, from, to time.Time) error {
+	table := pgx.Identifier{name}.Sanitize()
+	lower, upper := partitionBound(from), partitionBound(to)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s (LIKE logs INCLUDING DEFAULTS INCLUDING CONSTRAINTS INCLUDING GENERATED)", table),
+		fmt.Sprintf(`
+			WITH moved AS (
+				DELETE FROM %s WHERE timestamp >= %s AND timestamp < %s
+				RETURNING %s
+			)
+			INSERT INTO %s (%s) SELECT %s FROM moved
+		`, DefaultLogPartition, lower, upper, logColumns, table, logColumns, logColumns),
+		fmt.Sprintf("ALTER TABLE logs ATTACH PARTITION %s FOR VALUES FROM (%s) TO (%s)", table, lower, upper),
+	}
+
+	for _, stmt := range statements {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "42P17" { // invalid_object_definition
+				return ErrPartitionOverlap
+			}
+			return fmt.Errorf("failed to create partition %s: %w", name, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DropLogPartition detaches and drops a partition with all of its rows. A
+// partition holding a log matched by any of holds is left attached and
+// ErrPartitionHeld is returned. Waits for locks are bounded by
+// partitionLockTimeout.
+func (r *PostgresRepository) DropLogPartition(ctx context.Context, name string, holds []LogMatcher) error {
+	table := pgx.Identifier{name}.Sanitize()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SET LOCAL lock_timeout = '"+partitionLockTimeout+"'"); err != nil {
+		return fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+	// A share lock on the partition alone keeps held logs from being written
+	// to it between the check and the drop, without blocking the rest of
+	// logs during the scan. The parent is only locked to detach.
+	if _, err := tx.Exec(ctx, fmt.Sprintf("LOCK TABLE %s IN SHARE MODE", table)); err != nil {
+		return fmt.Errorf("failed to lock partition %s: %w", name, err)
+	}
+	held, err := partitionHasRows(ctx, tx, name, holds)
+	if err != nil {
+		return err
+	}
+	if held {
+		return ErrPartitionHeld
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE logs DETACH PARTITION %s", table)); err != nil {
+		return fmt.Errorf("failed to detach partition %s: %w", name, err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf("DROP TABLE %s", table)); err != nil {
+		return fmt.Errorf("failed to drop partition %s: %w", name, err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// PrunePartition deletes up to limit logs older than before from a single
+// partition, skipping logs matched by any of holds, and returns how many
+// were deleted
+func (r *PostgresRepository) PrunePartition(ctx context.Context, name string, before time.Time, holds []LogMatcher, limit int) (int64, error) {
+	table := pgx.Identifier{name}.Sanitize()
+	b := &sqlBuilder{}
+	query := fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE (id, timestamp) IN (
+			SELECT id, timestamp FROM %s
+			WHERE %s
+			LIMIT %s
+		)
+	`, table, table, b.selection(LogSelection{Before: before, Exclude: holds}), b.arg(limit))
+
+	tag, err := r.pool.Exec(ctx, query, b.args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune partition %s: %w", name, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// partitionBound formats a partition bound literal; DDL cannot take parameters
+func partitionBound(t time.Time) string {
+	return "'" + t.UTC().Format("2006-01-02 15:04:05") + "+00'"
+}
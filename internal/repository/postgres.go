@@ -2,14 +2,31 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/pkg/fulltext"
+	"github.com/Saumajitt/threatLog/pkg/searchql"
 )
 
+// logColumns is the column list selected for log events, in scanLog order
+const logColumns = "id, tenant_id, timestamp, severity, source, message, attributes, ingested_at, repeat_count, first_seen, last_seen"
+
+// insertColumns are the columns written by batch inserts, in logValues order
+var insertColumns = []string{"id", "tenant_id", "timestamp", "severity", "source", "message", "attributes", "ingested_at", "repeat_count", "first_seen", "last_seen"}
+
+// maxInsertRows keeps a multi-row insert well under the 65535 parameter limit
+const maxInsertRows = 1000
+
 type PostgresRepository struct {
 	pool *pgxpool.Pool
 }
@@ -21,109 +38,185 @@ func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
 // InsertLog inserts a single log event
 func (r *PostgresRepository) InsertLog(ctx context.Context, log *model.LogEvent) error {
 	query := `
-		INSERT INTO logs (id, timestamp, severity, source, message, ingested_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO logs (id, tenant_id, timestamp, severity, source, message, attributes, ingested_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 	
 	_, err := r.pool.Exec(ctx, query,
 		log.ID,
+		tenantOrDefault(log.TenantID),
 		log.Timestamp,
 		log.Severity,
 		log.Source,
 		log.Message,
+		attributesOrEmpty(log.Attributes),
 		time.Now(),
 	)
 	
 	return err
 }
 
-// BatchInsertLogs inserts multiple logs in a single transaction
+// BatchInsertLogs inserts multiple logs with the COPY protocol. COPY fails as
+// a whole when a row already exists, which happens when a retry or spool
+// replay resubmits stored rows; the batch is then written with multi-row
+// inserts that skip existing rows.
 func (r *PostgresRepository) BatchInsertLogs(ctx context.Context, logs []model.LogEvent) error {
 	if len(logs) == 0 {
 		return nil
 	}
 
+	ingestedAt := time.Now()
+	err := r.copyLogs(ctx, logs, ingestedAt)
+	if !isUniqueViolation(err) {
+		return err
+	}
+
+	return r.insertLogs(ctx, logs, ingestedAt)
+}
+
+// copyLogs writes logs with COPY in a single statement
+func (r *PostgresRepository) copyLogs(ctx context.Context, logs []model.LogEvent, ingestedAt time.Time) error {
+	rows := pgx.CopyFromSlice(len(logs), func(i int) ([]any, error) {
+		log := logs[i]
+		// The binary COPY format needs a parsed UUID
+		id, err := uuid.Parse(log.ID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log id %q: %w", log.ID, err)
+		}
+		return logValues(id, log, ingestedAt), nil
+	})
+
+	if _, err := r.pool.CopyFrom(ctx, pgx.Identifier{"logs"}, insertColumns, rows); err != nil {
+		return fmt.Errorf("failed to copy logs: %w", err)
+	}
+	return nil
+}
+
+// insertLogs writes logs with multi-row inserts in a single transaction,
+// skipping rows that already exist. The unique key is (id, timestamp) on the
+// partitioned table, so no conflict target is named.
+func (r *PostgresRepository) insertLogs(ctx context.Context, logs []model.LogEvent, ingestedAt time.Time) error {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	query := `
-		INSERT INTO logs (id, timestamp, severity, source, message, ingested_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
-
-	for _, log := range logs {
-		_, err := tx.Exec(ctx, query,
-			log.ID,
-			log.Timestamp,
-			log.Severity,
-			log.Source,
-			log.Message,
-			time.Now(),
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert log: %w", err)
+	for start := 0; start < len(logs); start += maxInsertRows {
+		end := min(start+maxInsertRows, len(logs))
+		query, args := multiRowInsert(logs[start:end], ingestedAt)
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to insert logs: %w", err)
 		}
 	}
 
 	return tx.Commit(ctx)
 }
 
-// QueryLogs queries logs with filters
-func (r *PostgresRepository) QueryLogs(ctx context.Context, req model.QueryRequest) ([]model.LogEvent, int, error) {
-	// Build WHERE clause
-	var conditions []string
-	var args []interface{}
-	argPos := 1
+// logValues returns the values of insertColumns for a log
+func logValues(id any, log model.LogEvent, ingestedAt time.Time) []any {
+	repeatCount := log.RepeatCount
+	if repeatCount < 1 {
+		repeatCount = 1
+	}
 
-	conditions = append(conditions, fmt.Sprintf("timestamp >= $%d", argPos))
-	args = append(args, req.StartTime)
-	argPos++
+	return []any{
+		id,
+		tenantOrDefault(log.TenantID),
+		log.Timestamp,
+		log.Severity,
+		log.Source,
+		log.Message,
+		attributesOrEmpty(log.Attributes),
+		ingestedAt,
+		repeatCount,
+		log.FirstSeen,
+		log.LastSeen,
+	}
+}
 
-	conditions = append(conditions, fmt.Sprintf("timestamp <= $%d", argPos))
-	args = append(args, req.EndTime)
-	argPos++
+// multiRowInsert builds a single INSERT for logs
+func multiRowInsert(logs []model.LogEvent, ingestedAt time.Time) (string, []interface{}) {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO logs (")
+	sb.WriteString(strings.Join(insertColumns, ", "))
+	sb.WriteString(") VALUES ")
 
-	if len(req.Severity) > 0 {
-		placeholders := make([]string, len(req.Severity))
-		for i, sev := range req.Severity {
-			placeholders[i] = fmt.Sprintf("$%d", argPos)
-			args = append(args, sev)
-			argPos++
+	args := make([]interface{}, 0, len(logs)*len(insertColumns))
+	for i, log := range logs {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for c := range insertColumns {
+			if c > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "$%d", len(args)+c+1)
 		}
-		conditions = append(conditions, fmt.Sprintf("severity IN (%s)", strings.Join(placeholders, ",")))
+		sb.WriteByte(')')
+
+		args = append(args, logValues(log.ID, log, ingestedAt)...)
 	}
+	sb.WriteString(" ON CONFLICT DO NOTHING")
 
-	if req.Source != "" {
-		conditions = append(conditions, fmt.Sprintf("source = $%d", argPos))
-		args = append(args, req.Source)
-		argPos++
+	return sb.String(), args
+}
+
+// QueryLogs queries the logs of req.TenantID with filters. The time range is
+// compared directly against the partition key, so only partitions that
+// overlap it are scanned.
+func (r *PostgresRepository) QueryLogs(ctx context.Context, req model.QueryRequest) ([]model.LogEvent, int, error) {
+	if req.TenantID == "" {
+		return nil, 0, ErrMissingTenant
 	}
 
-	whereClause := strings.Join(conditions, " AND ")
+	filter, err := buildLogFilter(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// id breaks ties between equal timestamps so keyset pagination is stable
+	orderBy := "timestamp DESC, id DESC"
+	if filter.search != nil && req.Sort == model.SortRelevance {
+		orderBy = filter.search.rankExpr() + " DESC, timestamp DESC, id DESC"
+	}
 
-	// Count total
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM logs WHERE %s", whereClause)
+	whereClause := filter.where()
+
+	// Count total, matching the filters regardless of the page position
 	var total int
-	err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total)
+	if req.Count == model.CountEstimate {
+		total, err = r.estimateCount(ctx, whereClause, filter.args)
+	} else {
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM logs WHERE %s", whereClause)
+		err = r.pool.QueryRow(ctx, countQuery, filter.args...).Scan(&total)
+	}
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count logs: %w", err)
 	}
 
+	// Seek past the cursor position instead of scanning skipped rows. The
+	// plain timestamp bound lets partitions after the cursor be pruned.
+	if req.Cursor != "" {
+		cursorTime, cursorID, err := model.DecodeCursor(req.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+		timeArg := filter.arg(cursorTime)
+		whereClause += fmt.Sprintf(" AND timestamp <= %s AND (timestamp, id) < (%s, %s)", timeArg, timeArg, filter.arg(cursorID))
+	}
+
 	// Query logs
 	query := fmt.Sprintf(`
-		SELECT id, timestamp, severity, source, message, ingested_at
+		SELECT %s
 		FROM logs
 		WHERE %s
-		ORDER BY timestamp DESC
-		LIMIT $%d OFFSET $%d
-	`, whereClause, argPos, argPos+1)
-
-	args = append(args, req.Limit, req.Offset)
+		ORDER BY %s
+		LIMIT %s OFFSET %s
+	`, logColumns, whereClause, orderBy, filter.arg(req.Limit), filter.arg(req.Offset))
 
-	rows, err := r.pool.Query(ctx, query, args...)
+	rows, err := r.pool.Query(ctx, query, filter.args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query logs: %w", err)
 	}
@@ -132,15 +225,7 @@ func (r *PostgresRepository) QueryLogs(ctx context.Context, req model.QueryReque
 	var logs []model.LogEvent
 	for rows.Next() {
 		var log model.LogEvent
-		err := rows.Scan(
-			&log.ID,
-			&log.Timestamp,
-			&log.Severity,
-			&log.Source,
-			&log.Message,
-			&log.IngestedAt,
-		)
-		if err != nil {
+		if err := scanLog(rows, &log); err != nil {
 			return nil, 0, fmt.Errorf("failed to scan log: %w", err)
 		}
 		logs = append(logs, log)
@@ -149,31 +234,276 @@ func (r *PostgresRepository) QueryLogs(ctx context.Context, req model.QueryReque
 	return logs, total, nil
 }
 
-// GetLogByID retrieves a log by ID
-func (r *PostgresRepository) GetLogByID(ctx context.Context, id string) (*model.LogEvent, error) {
+// logFilter is the parameterized WHERE clause selecting the logs of a query
+type logFilter struct {
+	conditions []string
+	args       []interface{}
+	// search is the compiled full-text query, nil without one
+	search *searchCompiler
+}
+
+// buildLogFilter builds the filter for the tenant, time range and filters of req
+func buildLogFilter(req model.QueryRequest) (*logFilter, error) {
+	f := &logFilter{}
+
+	f.add("tenant_id = " + f.arg(req.TenantID))
+	f.add("timestamp >= " + f.arg(req.StartTime))
+	f.add("timestamp <= " + f.arg(req.EndTime))
+
+	if len(req.Severity) > 0 {
+		placeholders := make([]string, len(req.Severity))
+		for i, sev := range req.Severity {
+			placeholders[i] = f.arg(sev)
+		}
+		f.add(fmt.Sprintf("severity IN (%s)", strings.Join(placeholders, ",")))
+	}
+
+	if req.Source != "" {
+		f.add("source = " + f.arg(req.Source))
+	}
+
+	// Attribute equality filters use JSONB containment so the GIN index applies.
+	// Values that also parse as JSON scalars match typed attributes too.
+	for key, value := range req.Attributes {
+		candidates := attributeCandidates(key, value)
+		alternatives := make([]string, len(candidates))
+		for i, candidate := range candidates {
+			alternatives[i] = "attributes @> " + f.arg(candidate)
+		}
+		f.add("(" + strings.Join(alternatives, " OR ") + ")")
+	}
+
+	for _, key := range req.AttributeExists {
+		f.add("attributes ? " + f.arg(key))
+	}
+
+	if req.Query != "" {
+		expr, err := fulltext.Parse(req.Query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid search query: %w", err)
+		}
+
+		f.search = &searchCompiler{argPos: len(f.args) + 1}
+		f.add(f.search.compile(expr, false))
+		f.args = append(f.args, f.search.args...)
+	}
+
+	if req.Search != "" {
+		expr, err := searchql.Parse(req.Search)
+		if err != nil {
+			return nil, fmt.Errorf("invalid search: %w", err)
+		}
+		f.add(f.searchPredicate(expr))
+	}
+
+	return f, nil
+}
+
+func (f *logFilter) add(condition string) {
+	f.conditions = append(f.conditions, condition)
+}
+
+func (f *logFilter) arg(v interface{}) string {
+	f.args = append(f.args, v)
+	return fmt.Sprintf("$%d", len(f.args))
+}
+
+// where returns the conditions joined for a WHERE clause
+func (f *logFilter) where() string {
+	return strings.Join(f.conditions, " AND ")
+}
+
+// estimateCount returns the planner's row estimate for the filters, which
+// avoids a full COUNT(*) over very large time ranges
+func (r *PostgresRepository) estimateCount(ctx context.Context, whereClause string, args []interface{}) (int, error) {
+	query := fmt.Sprintf("EXPLAIN (FORMAT JSON) SELECT 1 FROM logs WHERE %s", whereClause)
+
+	var plan []byte
+	if err := r.pool.QueryRow(ctx, query, args...).Scan(&plan); err != nil {
+		return 0, err
+	}
+
+	var explain []struct {
+		Plan struct {
+			PlanRows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explain); err != nil {
+		return 0, fmt.Errorf("failed to parse query plan: %w", err)
+	}
+	if len(explain) == 0 {
+		return 0, nil
+	}
+
+	return int(explain[0].Plan.PlanRows), nil
+}
+
+// GetLogByID retrieves a log of a tenant by ID
+func (r *PostgresRepository) GetLogByID(ctx context.Context, tenantID, id string) (*model.LogEvent, error) {
+	if tenantID == "" {
+		return nil, ErrMissingTenant
+	}
+
 	query := `
-		SELECT id, timestamp, severity, source, message, ingested_at
+		SELECT ` + logColumns + `
 		FROM logs
-		WHERE id = $1
+		WHERE tenant_id = $1 AND id = $2
 	`
 
 	var log model.LogEvent
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	if err := scanLog(r.pool.QueryRow(ctx, query, tenantID, id), &log); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get log: %w", err)
+	}
+
+	return &log, nil
+}
+
+// GetLogContext retrieves up to before and after logs from the same tenant
+// and source immediately preceding and following log, both oldest first
+func (r *PostgresRepository) GetLogContext(ctx context.Context, log *model.LogEvent, before, after int) ([]model.LogEvent, []model.LogEvent, error) {
+	if log.TenantID == "" {
+		return nil, nil, ErrMissingTenant
+	}
+
+	preceding, err := r.neighbourLogs(ctx, log, "<", "DESC", before)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Preceding logs are read nearest first; return them in time order
+	slices.Reverse(preceding)
+
+	following, err := r.neighbourLogs(ctx, log, ">", "ASC", after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return preceding, following, nil
+}
+
+// neighbourLogs reads up to limit logs of the same tenant and source as log
+// on one side of it. The (timestamp, id) comparison orders logs with equal
+// timestamps the same way keyset pagination does.
+func (r *PostgresRepository) neighbourLogs(ctx context.Context, log *model.LogEvent, cmp, direction string, limit int) ([]model.LogEvent, error) {
+	logs := []model.LogEvent{}
+	if limit <= 0 {
+		return logs, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM logs
+		WHERE tenant_id = $1 AND source = $2 AND (timestamp, id) %s ($3, $4)
+		ORDER BY timestamp %s, id %s
+		LIMIT $5
+	`, logColumns, cmp, direction, direction)
+
+	rows, err := r.pool.Query(ctx, query, log.TenantID, log.Source, log.Timestamp, log.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query log context: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var neighbour model.LogEvent
+		if err := scanLog(rows, &neighbour); err != nil {
+			return nil, fmt.Errorf("failed to scan log: %w", err)
+		}
+		logs = append(logs, neighbour)
+	}
+
+	return logs, rows.Err()
+}
+
+// UpdateLogRepeats adds collapsed repeats to stored logs and returns how many
+// logs were updated
+func (r *PostgresRepository) UpdateLogRepeats(ctx context.Context, updates []model.LogRepeats) (int64, error) {
+	if len(updates) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, len(updates))
+	timestamps := make([]time.Time, len(updates))
+	repeats := make([]int, len(updates))
+	lastSeen := make([]time.Time, len(updates))
+	for i, u := range updates {
+		ids[i] = u.ID
+		timestamps[i] = u.Timestamp
+		repeats[i] = u.Repeats
+		lastSeen[i] = u.LastSeen
+	}
+
+	// Matching on timestamp as well lets each update prune to one partition
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE logs AS l
+		SET repeat_count = l.repeat_count + u.repeats,
+			last_seen = GREATEST(COALESCE(l.last_seen, l.timestamp), u.last_seen)
+		FROM unnest($1::text[], $2::timestamptz[], $3::int[], $4::timestamptz[])
+			AS u(id, ts, repeats, last_seen)
+		WHERE l.id = u.id::uuid AND l.timestamp = u.ts
+	`, ids, timestamps, repeats, lastSeen)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update log repeats: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// HealthCheck checks if database is reachable
+func (r *PostgresRepository) HealthCheck(ctx context.Context) error {
+	return r.pool.Ping(ctx)
+}
+
+// scanLog scans a row selected with logColumns into a log event
+func scanLog(row pgx.Row, log *model.LogEvent) error {
+	return row.Scan(
 		&log.ID,
+		&log.TenantID,
 		&log.Timestamp,
 		&log.Severity,
 		&log.Source,
 		&log.Message,
+		&log.Attributes,
 		&log.IngestedAt,
+		&log.RepeatCount,
+		&log.FirstSeen,
+		&log.LastSeen,
 	)
-	if err != nil {
-		return nil, err
+}
+
+// tenantOrDefault assigns events without a tenant, such as ones spooled
+// before tenancy existed, to the default tenant
+func tenantOrDefault(tenantID string) string {
+	if tenantID == "" {
+		return model.DefaultTenant
 	}
+	return tenantID
+}
 
-	return &log, nil
+// attributesOrEmpty avoids writing SQL NULL into the NOT NULL attributes column
+func attributesOrEmpty(attrs map[string]any) map[string]any {
+	if attrs == nil {
+		return map[string]any{}
+	}
+	return attrs
 }
 
-// HealthCheck checks if database is reachable
-func (r *PostgresRepository) HealthCheck(ctx context.Context) error {
-	return r.pool.Ping(ctx)
-}
\ No newline at end of file
+// attributeCandidates returns the JSON documents an attribute equality filter
+// matches: the value as a string, plus as a number or boolean when it parses as one
+func attributeCandidates(key, value string) []string {
+	values := []any{value}
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		values = append(values, n)
+	}
+	if value == "true" || value == "false" {
+		values = append(values, value == "true")
+	}
+
+	candidates := make([]string, 0, len(values))
+	for _, v := range values {
+		data, _ := json.Marshal(map[string]any{key: v})
+		candidates = append(candidates, string(data))
+	}
+	return candidates
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+func TestMultiRowInsert(t *testing.T) {
+	ingestedAt := time.Now()
+	logs := []model.LogEvent{
+		{ID: "a", Severity: model.SeverityHigh, Source: "fw", Message: "one"},
+		{ID: "b", TenantID: "red", Severity: model.SeverityLow, Source: "fw", Message: "two"},
+	}
+
+	query, args := multiRowInsert(logs, ingestedAt)
+
+	assert.True(t, strings.HasPrefix(query, "INSERT INTO logs (id, tenant_id, timestamp,"))
+	assert.Contains(t, query, "VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11), ($12, $13,")
+	assert.True(t, strings.HasSuffix(query, "$22) ON CONFLICT DO NOTHING"))
+	assert.Len(t, args, 22)
+	assert.Equal(t, model.DefaultTenant, args[1])
+	assert.Equal(t, "red", args[12])
+	assert.Equal(t, map[string]any{}, args[6])
+	assert.Equal(t, ingestedAt, args[18])
+	// Events that were not deduplicated count once
+	assert.Equal(t, 1, args[8])
+}
@@ -55,15 +55,80 @@ func (r *RedisRepository) GetCachedQueryResult(ctx context.Context, req model.Qu
 	return &result, nil
 }
 
+// CacheAggregateResult caches aggregation results
+func (r *RedisRepository) CacheAggregateResult(ctx context.Context, req model.AggregateRequest, result model.AggregateResponse) error {
+	key := r.generateAggregateCacheKey(req)
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return r.client.Set(ctx, key, data, r.ttl).Err()
+}
+
+// GetCachedAggregateResult retrieves cached aggregation results
+func (r *RedisRepository) GetCachedAggregateResult(ctx context.Context, req model.AggregateRequest) (*model.AggregateResponse, error) {
+	key := r.generateAggregateCacheKey(req)
+
+	data, err := r.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, nil // Cache miss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached result: %w", err)
+	}
+
+	var result model.AggregateResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal result: %w", err)
+	}
+
+	return &result, nil
+}
+
 // InvalidateCache invalidates all cache entries
 func (r *RedisRepository) InvalidateCache(ctx context.Context) error {
-	iter := r.client.Scan(ctx, 0, "logs:query:*", 0).Iterator()
-	for iter.Next(ctx) {
-		if err := r.client.Del(ctx, iter.Val()).Err(); err != nil {
+	for _, pattern := range []string{"logs:query:*", "logs:aggregate:*"} {
+		iter := r.client.Scan(ctx, 0, pattern, 0).Iterator()
+		for iter.Next(ctx) {
+			if err := r.client.Del(ctx, iter.Val()).Err(); err != nil {
+				return err
+			}
+		}
+		if err := iter.Err(); err != nil {
 			return err
 		}
 	}
-	return iter.Err()
+	return nil
+}
+
+// ClaimEventIDs records event IDs as ingested for ttl and reports, for each
+// ID, whether it was not recorded before
+func (r *RedisRepository) ClaimEventIDs(ctx context.Context, ids []string, ttl time.Duration) ([]bool, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.BoolCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.SetNX(ctx, eventIDKey(id), 1, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to claim event ids: %w", err)
+	}
+
+	claimed := make([]bool, len(ids))
+	for i, cmd := range cmds {
+		claimed[i] = cmd.Val()
+	}
+	return claimed, nil
+}
+
+// ReleaseEventIDs forgets claimed event IDs
+func (r *RedisRepository) ReleaseEventIDs(ctx context.Context, ids []string) error {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = eventIDKey(id)
+	}
+	return r.client.Del(ctx, keys...).Err()
 }
 
 // HealthCheck checks if Redis is reachable
@@ -73,15 +138,35 @@ func (r *RedisRepository) HealthCheck(ctx context.Context) error {
 
 // generateCacheKey generates a unique cache key for query parameters
 func (r *RedisRepository) generateCacheKey(req model.QueryRequest) string {
-	data := fmt.Sprintf("%v-%v-%v-%v-%d-%d",
-		req.StartTime.Unix(),
-		req.EndTime.Unix(),
-		req.Severity,
-		req.Source,
-		req.Limit,
-		req.Offset,
-	)
-
-	hash := sha256.Sum256([]byte(data))
-	return fmt.Sprintf("logs:query:%x", hash)
+	return "logs:query:" + hashRequest(req.TenantID, req)
+}
+
+// generateAggregateCacheKey generates a unique cache key for aggregation parameters
+func (r *RedisRepository) generateAggregateCacheKey(req model.AggregateRequest) string {
+	return "logs:aggregate:" + hashRequest(req.Filter.TenantID, req)
+}
+
+// hashRequest hashes the JSON encoding of a request, which quotes strings
+// and sorts map keys, so distinct requests never share an encoding. The
+// tenant is not part of the encoding and is hashed alongside it.
+func hashRequest(tenantID string, req any) string {
+	// Requests hold only strings, numbers, times, slices and maps, which
+	// always encode
+	data, _ := json.Marshal([]any{tenantID, req})
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// eventIDKey is the key recording that an event was ingested
+func eventIDKey(id string) string {
+	return "logs:ingested:" + id
+}
+
+// Publish publishes a message on a pub/sub channel
+func (r *RedisRepository) Publish(ctx context.Context, channel string, data []byte) error {
+	return r.client.Publish(ctx, channel, data).Err()
+}
+
+// Subscribe subscribes to a pub/sub channel
+func (r *RedisRepository) Subscribe(ctx context.Context, channel string) *redis.PubSub {
+	return r.client.Subscribe(ctx, channel)
 }
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+func TestGenerateCacheKeyIsPerTenant(t *testing.T) {
+	r := &RedisRepository{}
+	req := model.QueryRequest{
+		TenantID:  "red",
+		StartTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Limit:     100,
+	}
+
+	other := req
+	other.TenantID = "blue"
+
+	assert.Equal(t, r.generateCacheKey(req), r.generateCacheKey(req))
+	assert.NotEqual(t, r.generateCacheKey(req), r.generateCacheKey(other))
+}
+
+func TestGenerateAggregateCacheKey(t *testing.T) {
+	r := &RedisRepository{}
+	req := model.AggregateRequest{
+		Filter: model.QueryRequest{
+			TenantID:  "red",
+			StartTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		Interval: model.IntervalAuto,
+		GroupBy:  []string{"source"},
+		Top:      10,
+	}
+
+	other := req
+	other.GroupBy = []string{"severity"}
+
+	assert.True(t, strings.HasPrefix(r.generateAggregateCacheKey(req), "logs:aggregate:"))
+	assert.Equal(t, r.generateAggregateCacheKey(req), r.generateAggregateCacheKey(req))
+	assert.NotEqual(t, r.generateAggregateCacheKey(req), r.generateAggregateCacheKey(other))
+}
+
+func TestGenerateCacheKeyHasNoCollisions(t *testing.T) {
+	r := &RedisRepository{}
+	base := model.QueryRequest{
+		TenantID:  "red",
+		StartTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Each pair printed the same with %v
+	a, b := base, base
+	a.Severity = []string{"HIGH LOW"}
+	b.Severity = []string{"HIGH", "LOW"}
+	assert.NotEqual(t, r.generateCacheKey(a), r.generateCacheKey(b))
+
+	a, b = base, base
+	a.Attributes = map[string]string{"a": "b c:d"}
+	b.Attributes = map[string]string{"a": "b", "c": "d"}
+	assert.NotEqual(t, r.generateCacheKey(a), r.generateCacheKey(b))
+
+	a, b = base, base
+	a.Attributes = map[string]string{"a": "1", "b": "2"}
+	b.Attributes = map[string]string{"b": "2", "a": "1"}
+	assert.Equal(t, r.generateCacheKey(a), r.generateCacheKey(b))
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// LogMatcher selects logs by severity, source and tenant. Source and Tenant
+// are globs where * matches any run of characters and ? a single one. Empty
+// fields match every log.
+type LogMatcher struct {
+	Severities []string
+	Source     string
+	Tenant     string
+}
+
+// LogSelection selects logs older than Before that match Match and none of Exclude
+type LogSelection struct {
+	Before  time.Time
+	Match   LogMatcher
+	Exclude []LogMatcher
+}
+
+// DeleteLogs deletes up to limit logs of a selection and returns how many
+// were deleted. Bounding each call keeps row locks short.
+func (r *PostgresRepository) DeleteLogs(ctx context.Context, sel LogSelection, limit int) (int64, error) {
+	b := &sqlBuilder{}
+	query := fmt.Sprintf(`
+		DELETE FROM logs
+		WHERE (id, timestamp) IN (
+			SELECT id, timestamp FROM logs
+			WHERE %s
+			LIMIT %s
+		)
+	`, b.selection(sel), b.arg(limit))
+
+	tag, err := r.pool.Exec(ctx, query, b.args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete logs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ArchiveLogs deletes up to limit logs of a selection and passes them to
+// write before the delete commits, so a failed write keeps the rows. It
+// returns how many logs were archived.
+func (r *PostgresRepository) ArchiveLogs(ctx context.Context, sel LogSelection, limit int, write func([]model.LogEvent) error) (int64, error) {
+	b := &sqlBuilder{}
+	query := fmt.Sprintf(`
+		DELETE FROM logs
+		WHERE (id, timestamp) IN (
+			SELECT id, timestamp FROM logs
+			WHERE %s
+			LIMIT %s
+		)
+		RETURNING %s
+	`, b.selection(sel), b.arg(limit), logColumns)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, b.args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete logs: %w", err)
+	}
+
+	logs := make([]model.LogEvent, 0)
+	for rows.Next() {
+		var log model.LogEvent
+		if err := scanLog(rows, &log); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan log: %w", err)
+		}
+		logs = append(logs, log)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to delete logs: %w", err)
+	}
+
+	if len(logs) == 0 {
+		return 0, nil
+	}
+	if err := write(logs); err != nil {
+		return 0, fmt.Errorf("failed to archive logs: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit archived logs: %w", err)
+	}
+	return int64(len(logs)), nil
+}
+
+// partitionHasRows reports whether a partition holds a log matching any of the matchers
+func partitionHasRows(ctx context.Context, tx pgx.Tx, name string, matchers []LogMatcher) (bool, error) {
+	if len(matchers) == 0 {
+		return false, nil
+	}
+
+	b := &sqlBuilder{}
+	alternatives := make([]string, len(matchers))
+	for i, m := range matchers {
+		alternatives[i] = b.matcher(m)
+	}
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE %s)",
+		pgx.Identifier{name}.Sanitize(), strings.Join(alternatives, " OR "))
+
+	var exists bool
+	if err := tx.QueryRow(ctx, query, b.args...).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check partition %s: %w", name, err)
+	}
+	return exists, nil
+}
+
+// InsertRetentionRun stores the report of a retention run
+func (r *PostgresRepository) InsertRetentionRun(ctx context.Context, run *model.RetentionRun) error {
+	rules, err := json.Marshal(run.Rules)
+	if err != nil {
+		return fmt.Errorf("failed to marshal retention report: %w", err)
+	}
+
+	err = r.pool.QueryRow(ctx, `
+		INSERT INTO retention_runs (started_at, finished_at, deleted, archived, rules)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, run.StartedAt, run.FinishedAt, run.Deleted, run.Archived, rules).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to insert retention run: %w", err)
+	}
+	return nil
+}
+
+// ListRetentionRuns lists retention runs, newest first
+func (r *PostgresRepository) ListRetentionRuns(ctx context.Context, limit int) ([]model.RetentionRun, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, started_at, finished_at, deleted, archived, rules
+		FROM retention_runs
+		ORDER BY started_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]model.RetentionRun, 0)
+	for rows.Next() {
+		var run model.RetentionRun
+		var rules []byte
+		if err := rows.Scan(&run.ID, &run.StartedAt, &run.FinishedAt, &run.Deleted, &run.Archived, &rules); err != nil {
+			return nil, fmt.Errorf("failed to scan retention run: %w", err)
+		}
+		if err := json.Unmarshal(rules, &run.Rules); err != nil {
+			return nil, fmt.Errorf("failed to decode retention report: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// sqlBuilder collects positional arguments while a predicate is built
+type sqlBuilder struct {
+	args []interface{}
+}
+
+func (b *sqlBuilder) arg(v interface{}) string {
+	b.args = append(b.args, v)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *sqlBuilder) selection(sel LogSelection) string {
+	conditions := []string{
+		"timestamp < " + b.arg(sel.Before),
+		b.matcher(sel.Match),
+	}
+	for _, m := range sel.Exclude {
+		conditions = append(conditions, "NOT ("+b.matcher(m)+")")
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+func (b *sqlBuilder) matcher(m LogMatcher) string {
+	var conditions []string
+	if len(m.Severities) > 0 {
+		conditions = append(conditions, "severity = ANY("+b.arg(m.Severities)+")")
+	}
+	if m.Source != "" && m.Source != "*" {
+		conditions = append(conditions, "source LIKE "+b.arg(GlobToLike(m.Source)))
+	}
+	if m.Tenant != "" && m.Tenant != "*" {
+		conditions = append(conditions, "tenant_id LIKE "+b.arg(GlobToLike(m.Tenant)))
+	}
+	if len(conditions) == 0 {
+		return "TRUE"
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")"
+}
+
+// GlobToLike converts a glob with * and ? wildcards into a LIKE pattern
+func GlobToLike(glob string) string {
+	var sb strings.Builder
+	for _, c := range glob {
+		switch c {
+		case '*':
+			sb.WriteByte('%')
+		case '?':
+			sb.WriteByte('_')
+		case '%', '_', '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(c)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
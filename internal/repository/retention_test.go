@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobToLike(t *testing.T) {
+	tests := []struct {
+		glob string
+		want string
+	}{
+		{"firewall-*", "firewall-%"},
+		{"host-?", "host-_"},
+		{"100%_done", `100\%\_done`},
+		{`a\b`, `a\\b`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, GlobToLike(tt.glob), tt.glob)
+	}
+}
+
+func TestSelectionPredicate(t *testing.T) {
+	b := &sqlBuilder{}
+	sel := LogSelection{
+		Match:   LogMatcher{Severities: []string{"info"}, Source: "fw-*"},
+		Exclude: []LogMatcher{{Tenant: "acme"}},
+	}
+
+	assert.Equal(t,
+		"timestamp < $1 AND (severity = ANY($2) AND source LIKE $3) AND NOT ((tenant_id LIKE $4))",
+		b.selection(sel))
+	assert.Len(t, b.args, 4)
+	assert.Equal(t, "TRUE", b.matcher(LogMatcher{Source: "*"}))
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// ruleColumns is the column list selected for rules, in scanRule order
+const ruleColumns = "id, name, description, enabled, severity, type, conditions, sigma, schedule, threshold, created_at, updated_at"
+
+// CreateRule inserts a detection rule
+func (r *PostgresRepository) CreateRule(ctx context.Context, rule *model.Rule) error {
+	query := `
+		INSERT INTO rules (id, name, description, enabled, severity, type, conditions, sigma, schedule, threshold, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	_, err := r.pool.Exec(ctx, query,
+		rule.ID,
+		rule.Name,
+		rule.Description,
+		rule.Enabled,
+		rule.Severity,
+		rule.Type,
+		rule.Conditions,
+		rule.Sigma,
+		rule.Schedule,
+		rule.Threshold,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert rule: %w", err)
+	}
+	return nil
+}
+
+// UpdateRule replaces a detection rule
+func (r *PostgresRepository) UpdateRule(ctx context.Context, rule *model.Rule) error {
+	query := `
+		UPDATE rules
+		SET name = $2, description = $3, enabled = $4, severity = $5, type = $6,
+			conditions = $7, sigma = $8, schedule = $9, threshold = $10, updated_at = $11
+		WHERE id = $1
+	`
+
+	tag, err := r.pool.Exec(ctx, query,
+		rule.ID,
+		rule.Name,
+		rule.Description,
+		rule.Enabled,
+		rule.Severity,
+		rule.Type,
+		rule.Conditions,
+		rule.Sigma,
+		rule.Schedule,
+		rule.Threshold,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update rule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteRule deletes a detection rule
+func (r *PostgresRepository) DeleteRule(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM rules WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetRule retrieves a detection rule by ID
+func (r *PostgresRepository) GetRule(ctx context.Context, id string) (*model.Rule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM rules WHERE id = $1`
+
+	var rule model.Rule
+	if err := scanRule(r.pool.QueryRow(ctx, query, id), &rule); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// ListRules lists detection rules, optionally only enabled ones
+func (r *PostgresRepository) ListRules(ctx context.Context, enabledOnly bool) ([]model.Rule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM rules`
+	if enabledOnly {
+		query += ` WHERE enabled`
+	}
+	query += ` ORDER BY name`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]model.Rule, 0)
+	for rows.Next() {
+		var rule model.Rule
+		if err := scanRule(rows, &rule); err != nil {
+			return nil, fmt.Errorf("failed to scan rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// scanRule scans a row selected with ruleColumns into a rule
+func scanRule(row pgx.Row, rule *model.Rule) error {
+	return row.Scan(
+		&rule.ID,
+		&rule.Name,
+		&rule.Description,
+		&rule.Enabled,
+		&rule.Severity,
+		&rule.Type,
+		&rule.Conditions,
+		&rule.Sigma,
+		&rule.Schedule,
+		&rule.Threshold,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+}
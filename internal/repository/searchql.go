@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/pkg/searchql"
+)
+
+// searchPredicate compiles a parsed search into a SQL predicate whose
+// values are all passed as arguments of the filter
+func (f *logFilter) searchPredicate(expr searchql.Expr) string {
+	switch e := expr.(type) {
+	case searchql.And:
+		return fmt.Sprintf("(%s AND %s)", f.searchPredicate(e.Left), f.searchPredicate(e.Right))
+	case searchql.Or:
+		return fmt.Sprintf("(%s OR %s)", f.searchPredicate(e.Left), f.searchPredicate(e.Right))
+	case searchql.Not:
+		// Missing attributes compare as NULL, which NOT would not turn true
+		return fmt.Sprintf("(%s) IS NOT TRUE", f.searchPredicate(e.Operand))
+	case searchql.Match:
+		return f.matchPredicate(e)
+	case searchql.Range:
+		return f.rangePredicate(e)
+	default:
+		return "TRUE"
+	}
+}
+
+func (f *logFilter) matchPredicate(m searchql.Match) string {
+	key, isAttribute := strings.CutPrefix(m.Field, model.AttributeFieldPrefix)
+
+	switch m.Op {
+	case searchql.OpEquals:
+		if isAttribute {
+			// Containment uses the GIN index and matches typed values
+			candidates := attributeCandidates(key, m.Value)
+			alternatives := make([]string, len(candidates))
+			for i, candidate := range candidates {
+				alternatives[i] = "attributes @> " + f.arg(candidate)
+			}
+			return "(" + strings.Join(alternatives, " OR ") + ")"
+		}
+		return fmt.Sprintf("%s = %s", f.fieldExpr(m.Field), f.arg(m.Value))
+	case searchql.OpContains:
+		return fmt.Sprintf("%s ILIKE %s", f.fieldExpr(m.Field), f.arg("%"+escapeLike(m.Value)+"%"))
+	case searchql.OpWildcard:
+		if m.Field == model.FieldMessage {
+			// Unanchored like wildcard terms of full-text search
+			return fmt.Sprintf("message ILIKE %s", f.arg(wildcardPattern(m.Value)))
+		}
+		return fmt.Sprintf("%s LIKE %s", f.fieldExpr(m.Field), f.arg(GlobToLike(m.Value)))
+	case searchql.OpRegex:
+		return fmt.Sprintf("%s ~ %s", f.fieldExpr(m.Field), f.arg(m.Value))
+	case searchql.OpIn:
+		return fmt.Sprintf("%s = ANY(%s::text[])", f.fieldExpr(m.Field), f.arg(m.Values))
+	case searchql.OpCIDR:
+		return fmt.Sprintf("safe_inet(%s) <<= %s::inet", f.fieldExpr(m.Field), f.arg(m.Value))
+	default:
+		return "FALSE"
+	}
+}
+
+func (f *logFilter) rangePredicate(r searchql.Range) string {
+	if r.Field == model.FieldSeverity {
+		return fmt.Sprintf("severity = ANY(%s::text[])", f.arg(searchql.SeveritiesInRange(r)))
+	}
+
+	// bound converts a bound to the type it is compared as
+	bound := func(value string) any { return value }
+	expr := ""
+	switch key, isAttribute := strings.CutPrefix(r.Field, model.AttributeFieldPrefix); {
+	case r.Field == searchql.FieldTimestamp:
+		expr = "timestamp"
+		bound = func(value string) any {
+			t, _ := time.Parse(time.RFC3339, value)
+			return t
+		}
+	case isAttribute && r.Numeric():
+		// Only numeric attributes are cast, so other values cannot fail the query
+		keyArg := f.arg(key)
+		expr = fmt.Sprintf("(CASE WHEN jsonb_typeof(attributes -> %s::text) = 'number' THEN (attributes ->> %s::text)::numeric END)", keyArg, keyArg)
+		bound = func(value string) any {
+			n, _ := strconv.ParseFloat(value, 64)
+			return n
+		}
+	default:
+		expr = f.fieldExpr(r.Field)
+	}
+
+	var conditions []string
+	if r.Lower != "" {
+		op := ">"
+		if r.IncludeLower {
+			op = ">="
+		}
+		conditions = append(conditions, fmt.Sprintf("%s %s %s", expr, op, f.arg(bound(r.Lower))))
+	}
+	if r.Upper != "" {
+		op := "<"
+		if r.IncludeUpper {
+			op = "<="
+		}
+		conditions = append(conditions, fmt.Sprintf("%s %s %s", expr, op, f.arg(bound(r.Upper))))
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")"
+}
+
+// fieldExpr returns the SQL expression for severity, source, message,
+// timestamp or attr.<key>; attributes are read as text from the top level
+func (f *logFilter) fieldExpr(field string) string {
+	if key, ok := strings.CutPrefix(field, model.AttributeFieldPrefix); ok {
+		return "(attributes ->> " + f.arg(key) + "::text)"
+	}
+	switch field {
+	case model.FieldSeverity, model.FieldSource, model.FieldMessage, searchql.FieldTimestamp:
+		return field
+	default:
+		return "NULL"
+	}
+}
+
+// escapeLike escapes the LIKE wildcards of a literal
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
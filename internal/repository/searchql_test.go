@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/pkg/searchql"
+)
+
+func TestSearchPredicate(t *testing.T) {
+	tests := []struct {
+		search string
+		want   string
+		args   []interface{}
+	}{
+		{
+			search: `severity:HIGH AND (source:fw-* OR message:"denied") NOT source:test`,
+			want:   "((severity = $1 AND (source LIKE $2 OR message ILIKE $3)) AND (source = $4) IS NOT TRUE)",
+			args:   []interface{}{"HIGH", "fw-%", "%denied%", "test"},
+		},
+		{
+			search: "user:root",
+			want:   "(attributes @> $1)",
+			args:   []interface{}{`{"user":"root"}`},
+		},
+		{
+			search: "src_ip:10.0.0.0/8",
+			want:   "safe_inet((attributes ->> $1::text)) <<= $2::inet",
+			args:   []interface{}{"src_ip", "10.0.0.0/8"},
+		},
+		{
+			search: "attr.port:[1024 TO *]",
+			want: "((CASE WHEN jsonb_typeof(attributes -> $1::text) = 'number' " +
+				"THEN (attributes ->> $1::text)::numeric END) >= $2)",
+			args: []interface{}{"port", float64(1024)},
+		},
+		{
+			search: "severity>=HIGH",
+			want:   "severity = ANY($1::text[])",
+			args:   []interface{}{[]string{"HIGH", "CRITICAL"}},
+		},
+		{
+			search: "timestamp<2026-01-29T00:00:00Z",
+			want:   "(timestamp < $1)",
+			args:   []interface{}{time.Date(2026, 1, 29, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			search: `source IN (fw-01, fw-02) user:/^adm/ "100%"`,
+			want:   "((source = ANY($1::text[]) AND (attributes ->> $2::text) ~ $3) AND message ILIKE $4)",
+			args:   []interface{}{[]string{"fw-01", "fw-02"}, "user", "^adm", `%100\%%`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.search, func(t *testing.T) {
+			expr, err := searchql.Parse(tt.search)
+			require.NoError(t, err)
+
+			f := &logFilter{}
+			assert.Equal(t, tt.want, f.searchPredicate(expr))
+			assert.Equal(t, tt.args, f.args)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/pkg/sigma"
+)
+
+// sigmaCompiler compiles a field-mapped Sigma expression into a parameterized SQL predicate
+type sigmaCompiler struct {
+	args   []interface{}
+	argPos int
+}
+
+// CompileSigma returns the SQL predicate for a Sigma expression whose fields
+// have been mapped onto rule fields. Placeholders start at $1.
+func CompileSigma(expr sigma.Expr) (string, []interface{}, error) {
+	c := &sigmaCompiler{argPos: 1}
+	where, err := c.compile(expr)
+	if err != nil {
+		return "", nil, err
+	}
+	return where, c.args, nil
+}
+
+// SigmaMatches holds the matches of a Sigma search within one tenant
+type SigmaMatches struct {
+	TenantID string
+	Count    int
+	LogIDs   []string
+}
+
+// SearchSigma runs a compiled Sigma expression over logs with timestamps in
+// [from, to). Matches are grouped by tenant, with the number of matches,
+// including the repeats collapsed into them, and the IDs of up to limit of
+// the most recent ones.
+func (r *PostgresRepository) SearchSigma(ctx context.Context, expr sigma.Expr, from, to time.Time, limit int) ([]SigmaMatches, error) {
+	c := &sigmaCompiler{argPos: 3}
+	where, err := c.compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT tenant_id, SUM(repeat_count), (array_agg(id::text ORDER BY timestamp DESC, id DESC))[1:$%d]
+		FROM logs
+		WHERE timestamp >= $1 AND timestamp < $2 AND %s
+		GROUP BY tenant_id
+	`, c.argPos, where)
+
+	args := append([]interface{}{from, to}, c.args...)
+	args = append(args, limit)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run sigma search: %w", err)
+	}
+	defer rows.Close()
+
+	matches := make([]SigmaMatches, 0)
+	for rows.Next() {
+		var m SigmaMatches
+		if err := rows.Scan(&m.TenantID, &m.Count, &m.LogIDs); err != nil {
+			return nil, fmt.Errorf("failed to scan sigma match: %w", err)
+		}
+		matches = append(matches, m)
+	}
+
+	return matches, rows.Err()
+}
+
+func (c *sigmaCompiler) compile(expr sigma.Expr) (string, error) {
+	switch e := expr.(type) {
+	case sigma.And:
+		return c.join(e, " AND ", "TRUE")
+	case sigma.Or:
+		return c.join(e, " OR ", "FALSE")
+	case sigma.Not:
+		operand, err := c.compile(e.Operand)
+		if err != nil {
+			return "", err
+		}
+		// Predicates on missing attributes are NULL; IS NOT TRUE makes them
+		// false under a negation too, as Eval does
+		return fmt.Sprintf("(%s) IS NOT TRUE", operand), nil
+	case sigma.Match:
+		return c.match(e)
+	default:
+		return "", fmt.Errorf("unsupported sigma expression %T", expr)
+	}
+}
+
+func (c *sigmaCompiler) join(operands []sigma.Expr, sep, empty string) (string, error) {
+	if len(operands) == 0 {
+		return empty, nil
+	}
+
+	parts := make([]string, len(operands))
+	for i, operand := range operands {
+		part, err := c.compile(operand)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = part
+	}
+	return "(" + strings.Join(parts, sep) + ")", nil
+}
+
+func (c *sigmaCompiler) match(m sigma.Match) (string, error) {
+	column, nullable, err := c.column(m.Field)
+	if err != nil {
+		return "", err
+	}
+
+	switch m.Op {
+	case sigma.OpExists:
+		if !nullable {
+			return "TRUE", nil
+		}
+		return fmt.Sprintf("%s IS NOT NULL", column), nil
+	case sigma.OpNull:
+		return fmt.Sprintf("COALESCE(%s, '') = ''", column), nil
+	case sigma.OpRegex:
+		// Postgres regexes are close to RE2 for the syntax Sigma rules use
+		return fmt.Sprintf("%s ~ %s", column, c.arg(m.Value)), nil
+	case sigma.OpEquals:
+		if m.Cased {
+			return fmt.Sprintf("%s = %s", column, c.arg(m.Value)), nil
+		}
+		return fmt.Sprintf("lower(%s) = lower(%s)", column, c.arg(m.Value)), nil
+	case sigma.OpContains, sigma.OpStartsWith, sigma.OpEndsWith, sigma.OpWildcard:
+		like := "ILIKE"
+		if m.Cased {
+			like = "LIKE"
+		}
+		return fmt.Sprintf("%s %s %s", column, like, c.arg(m.LikePattern())), nil
+	default:
+		return "", fmt.Errorf("unsupported sigma match %q", m.Op)
+	}
+}
+
+// column returns the SQL expression for a rule field and whether it can be NULL.
+// Attribute keys match a literal top-level key first and then a nested path,
+// as rules.FieldValue does.
+func (c *sigmaCompiler) column(field string) (string, bool, error) {
+	switch field {
+	case model.FieldSeverity, model.FieldSource, model.FieldMessage:
+		return field, false, nil
+	}
+
+	key, ok := strings.CutPrefix(field, model.AttributeFieldPrefix)
+	if !ok || key == "" {
+		return "", false, fmt.Errorf("unknown field %q", field)
+	}
+	return fmt.Sprintf("COALESCE(attributes->>%s::text, attributes #>> %s::text[])",
+		c.arg(key), c.arg(strings.Split(key, "."))), true, nil
+}
+
+func (c *sigmaCompiler) arg(value interface{}) string {
+	c.args = append(c.args, value)
+	placeholder := fmt.Sprintf("$%d", c.argPos)
+	c.argPos++
+	return placeholder
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/pkg/sigma"
+)
+
+func TestCompileSigma(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     sigma.Expr
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "column equals",
+			expr:     sigma.Match{Field: "source", Op: sigma.OpEquals, Value: "web-01"},
+			wantSQL:  "lower(source) = lower($1)",
+			wantArgs: []interface{}{"web-01"},
+		},
+		{
+			name: "attribute contains and not",
+			expr: sigma.And{
+				sigma.Match{Field: "attr.cmd", Op: sigma.OpContains, Value: "whoami"},
+				sigma.Not{Operand: sigma.Match{Field: "message", Op: sigma.OpStartsWith, Value: "test", Cased: true}},
+			},
+			wantSQL:  "(COALESCE(attributes->>$1::text, attributes #>> $2::text[]) ILIKE $3 AND (message LIKE $4) IS NOT TRUE)",
+			wantArgs: []interface{}{"cmd", []string{"cmd"}, "%whoami%", "test%"},
+		},
+		{
+			name: "nested attribute null or regex",
+			expr: sigma.Or{
+				sigma.Match{Field: "attr.process.parent", Op: sigma.OpNull},
+				sigma.Match{Field: "severity", Op: sigma.OpRegex, Value: "^(HIGH|CRITICAL)$"},
+			},
+			wantSQL:  "(COALESCE(COALESCE(attributes->>$1::text, attributes #>> $2::text[]), '') = '' OR severity ~ $3)",
+			wantArgs: []interface{}{"process.parent", []string{"process", "parent"}, "^(HIGH|CRITICAL)$"},
+		},
+		{
+			name:    "column exists",
+			expr:    sigma.Match{Field: "message", Op: sigma.OpExists},
+			wantSQL: "TRUE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := CompileSigma(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSQL, sql)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+// A predicate on a missing attribute is NULL in SQL, so a negation must
+// still hold, as it does when the streaming engine evaluates the rule
+func TestCompileSigmaNotMissingFieldMatchesEval(t *testing.T) {
+	expr := sigma.Not{Operand: sigma.Match{Field: "attr.user", Op: sigma.OpEquals, Value: "root"}}
+
+	missing := func(string) (string, bool) { return "", false }
+	require.True(t, expr.Eval(missing))
+
+	sql, _, err := CompileSigma(expr)
+	require.NoError(t, err)
+	// NULL IS NOT TRUE is TRUE, where NOT NULL would drop the row
+	assert.Equal(t, "(lower(COALESCE(attributes->>$1::text, attributes #>> $2::text[])) = lower($3)) IS NOT TRUE", sql)
+}
+
+func TestCompileSigmaUnknownField(t *testing.T) {
+	_, _, err := CompileSigma(sigma.Match{Field: "hostname", Op: sigma.OpEquals, Value: "x"})
+	assert.Error(t, err)
+}
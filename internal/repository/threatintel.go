@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ThreatFeed is an indicator feed uploaded through the API. Data is only
+// set by UpsertThreatFeed and GetThreatFeedData.
+type ThreatFeed struct {
+	Name       string
+	Format     string
+	Severity   string
+	Data       []byte
+	UploadedBy string
+	UploadedAt time.Time
+	ExpiresAt  *time.Time
+}
+
+// UpsertThreatFeed stores a feed, replacing a feed of the same name
+func (r *PostgresRepository) UpsertThreatFeed(ctx context.Context, feed ThreatFeed) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO threat_feeds (name, format, severity, data, uploaded_by, uploaded_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (name) DO UPDATE SET
+			format = EXCLUDED.format,
+			severity = EXCLUDED.severity,
+			data = EXCLUDED.data,
+			uploaded_by = EXCLUDED.uploaded_by,
+			uploaded_at = EXCLUDED.uploaded_at,
+			expires_at = EXCLUDED.expires_at
+	`, feed.Name, feed.Format, feed.Severity, feed.Data, feed.UploadedBy, feed.UploadedAt, feed.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to store threat feed: %w", err)
+	}
+	return nil
+}
+
+// ListThreatFeeds lists the feeds that have not expired at now, without their data
+func (r *PostgresRepository) ListThreatFeeds(ctx context.Context, now time.Time) ([]ThreatFeed, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT name, format, severity, uploaded_by, uploaded_at, expires_at
+		FROM threat_feeds
+		WHERE expires_at IS NULL OR expires_at > $1
+		ORDER BY name
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query threat feeds: %w", err)
+	}
+	defer rows.Close()
+
+	feeds := make([]ThreatFeed, 0)
+	for rows.Next() {
+		var feed ThreatFeed
+		if err := rows.Scan(&feed.Name, &feed.Format, &feed.Severity, &feed.UploadedBy, &feed.UploadedAt, &feed.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan threat feed: %w", err)
+		}
+		feeds = append(feeds, feed)
+	}
+
+	return feeds, rows.Err()
+}
+
+// GetThreatFeedData retrieves the uploaded content of a feed
+func (r *PostgresRepository) GetThreatFeedData(ctx context.Context, name string) ([]byte, error) {
+	var data []byte
+	if err := r.pool.QueryRow(ctx, `SELECT data FROM threat_feeds WHERE name = $1`, name).Scan(&data); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get threat feed: %w", err)
+	}
+	return data, nil
+}
+
+// ExpireThreatFeed expires a feed that is still active at the given time
+func (r *PostgresRepository) ExpireThreatFeed(ctx context.Context, name string, at time.Time) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE threat_feeds
+		SET expires_at = $2
+		WHERE name = $1 AND (expires_at IS NULL OR expires_at > $2)
+	`, name, at)
+	if err != nil {
+		return fmt.Errorf("failed to expire threat feed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
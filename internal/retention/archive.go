@@ -0,0 +1,65 @@
+package retention
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// unsafeFileChars matches characters not kept from rule names in file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// archiver writes archived logs as gzipped NDJSON files, one per chunk
+type archiver struct {
+	dir string
+	seq int
+}
+
+// write stores a chunk of logs under the archive directory. The file is
+// synced before write returns, so the logs may be deleted afterwards.
+func (a *archiver) write(rule string, logs []model.LogEvent) error {
+	if err := os.MkdirAll(a.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	a.seq++
+	name := fmt.Sprintf("%s-%s-%06d.ndjson.gz",
+		unsafeFileChars.ReplaceAllString(rule, "_"), time.Now().UTC().Format("20060102T150405Z"), a.seq)
+	path := filepath.Join(a.dir, name)
+	tmp := path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	enc := json.NewEncoder(gz)
+	for i := range logs {
+		if err := enc.Encode(&logs[i]); err != nil {
+			return fmt.Errorf("failed to write archive file: %w", err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync archive file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close archive file: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to rename archive file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package retention
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/tenant"
+)
+
+// Enforcer periodically applies tenant retentions and retention rules.
+// Logs are removed in chunks with a pause in between, so a run never holds
+// long locks on the logs table. Each run's report is stored.
+type Enforcer struct {
+	repo       *repository.PostgresRepository
+	quotas     tenant.Quotas
+	rules      []Rule
+	interval   time.Duration
+	batchSize  int
+	batchPause time.Duration
+	archiver   *archiver
+
+	trigger chan struct{}
+	wg      sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewEnforcer creates a new retention enforcer. Rules must have passed Validate.
+func NewEnforcer(
+	repo *repository.PostgresRepository,
+	quotas tenant.Quotas,
+	rules []Rule,
+	interval time.Duration,
+	batchSize int,
+	batchPause time.Duration,
+	archiveDir string,
+) *Enforcer {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Enforcer{
+		repo:       repo,
+		quotas:     quotas,
+		rules:      rules,
+		interval:   interval,
+		batchSize:  batchSize,
+		batchPause: batchPause,
+		archiver:   &archiver{dir: archiveDir},
+		trigger:    make(chan struct{}, 1),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start starts the enforcement loop; the first run starts immediately
+func (e *Enforcer) Start() {
+	e.wg.Add(1)
+	go e.loop()
+}
+
+// Stop stops the enforcement loop, interrupting a running run
+func (e *Enforcer) Stop() {
+	e.cancel()
+	e.wg.Wait()
+}
+
+// Trigger queues a run. Triggers received while a run is queued are merged.
+func (e *Enforcer) Trigger() {
+	select {
+	case e.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (e *Enforcer) loop() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		e.run()
+
+		select {
+		case <-ticker.C:
+		case <-e.trigger:
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// run executes every step of the retention plan and stores the report
+func (e *Enforcer) run() {
+	steps := plan(e.rules, e.quotas, time.Now())
+	if len(steps) == 0 {
+		return
+	}
+
+	report := model.RetentionRun{
+		StartedAt: time.Now(),
+		Rules:     make([]model.RetentionRuleReport, 0, len(steps)),
+	}
+	for _, s := range steps {
+		if e.ctx.Err() != nil {
+			break
+		}
+		r := e.execute(s)
+		report.Deleted += r.Deleted
+		report.Archived += r.Archived
+		report.Rules = append(report.Rules, r)
+	}
+	report.FinishedAt = time.Now()
+
+	// Interrupted runs are reported too
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.repo.InsertRetentionRun(ctx, &report); err != nil {
+		log.Error().Err(err).Msg("Failed to store retention report")
+	}
+
+	log.Info().
+		Int64("deleted", report.Deleted).
+		Int64("archived", report.Archived).
+		Dur("duration", report.FinishedAt.Sub(report.StartedAt)).
+		Msg("Retention run finished")
+}
+
+// execute removes the logs of a step chunk by chunk
+func (e *Enforcer) execute(s step) model.RetentionRuleReport {
+	report := model.RetentionRuleReport{Rule: s.name, Action: s.action, Before: s.sel.Before}
+
+	for e.ctx.Err() == nil {
+		var n int64
+		var err error
+		if s.action == model.RetentionArchive {
+			n, err = e.repo.ArchiveLogs(e.ctx, s.sel, e.batchSize, func(logs []model.LogEvent) error {
+				return e.archiver.write(s.name, logs)
+			})
+			report.Archived += n
+		} else {
+			n, err = e.repo.DeleteLogs(e.ctx, s.sel, e.batchSize)
+			report.Deleted += n
+		}
+		if err != nil {
+			if e.ctx.Err() == nil {
+				report.Error = err.Error()
+				log.Error().Err(err).Str("rule", s.name).Msg("Retention step failed")
+			}
+			break
+		}
+		if n < int64(e.batchSize) {
+			break
+		}
+
+		select {
+		case <-time.After(e.batchPause):
+		case <-e.ctx.Done():
+		}
+	}
+
+	if report.Deleted > 0 || report.Archived > 0 {
+		log.Info().
+			Str("rule", s.name).
+			Int64("deleted", report.Deleted).
+			Int64("archived", report.Archived).
+			Time("before", s.sel.Before).
+			Msg("Expired logs removed")
+	}
+	return report
+}
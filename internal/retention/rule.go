@@ -0,0 +1,134 @@
+package retention
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/tenant"
+)
+
+// Rule removes logs matching it once they are older than MaxAge. A legal
+// hold rule instead keeps matching logs forever, whatever other rules or
+// tenant retentions say.
+type Rule struct {
+	Name      string
+	Match     repository.LogMatcher
+	MaxAge    time.Duration
+	Action    string
+	LegalHold bool
+}
+
+// Validate checks a rule set. Archive rules need an archive directory.
+func Validate(rules []Rule, archiveDir string) error {
+	names := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("retention rule name is required")
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate retention rule %q", rule.Name)
+		}
+		names[rule.Name] = true
+
+		for _, sev := range rule.Match.Severities {
+			if !model.IsValidSeverity(sev) {
+				return fmt.Errorf("retention rule %q: invalid severity %q", rule.Name, sev)
+			}
+		}
+
+		if rule.LegalHold {
+			continue
+		}
+		if rule.MaxAge <= 0 {
+			return fmt.Errorf("retention rule %q: max_age must be positive", rule.Name)
+		}
+		if rule.Action != model.RetentionDelete && rule.Action != model.RetentionArchive {
+			return fmt.Errorf("retention rule %q: action must be delete or archive", rule.Name)
+		}
+		if rule.Action == model.RetentionArchive && archiveDir == "" {
+			return fmt.Errorf("retention rule %q: archiving requires an archive directory", rule.Name)
+		}
+	}
+	return nil
+}
+
+// step is a single selection removed by a retention run
+type step struct {
+	name   string
+	action string
+	sel    repository.LogSelection
+}
+
+// plan turns tenant retentions and rules into the steps of a run.
+//
+// Tenant retentions are an upper bound and run first. Rules then apply in
+// order, each to the logs that no earlier rule matches. No step touches a
+// log under legal hold.
+func plan(rules []Rule, quotas tenant.Quotas, now time.Time) []step {
+	holds := Holds(rules)
+
+	var steps []step
+
+	tenantIDs := make([]string, 0, len(quotas.Tenants))
+	for tenantID := range quotas.Tenants {
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+	sort.Strings(tenantIDs)
+
+	overridden := make([]repository.LogMatcher, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		match := repository.LogMatcher{Tenant: tenantID}
+		overridden = append(overridden, match)
+		if retention := quotas.Tenants[tenantID].Retention; retention > 0 {
+			steps = append(steps, step{
+				name:   "tenant:" + tenantID,
+				action: model.RetentionDelete,
+				sel:    repository.LogSelection{Before: now.Add(-retention), Match: match, Exclude: holds},
+			})
+		}
+	}
+
+	if retention := quotas.Default.Retention; retention > 0 {
+		steps = append(steps, step{
+			name:   "tenant:default_quota",
+			action: model.RetentionDelete,
+			sel: repository.LogSelection{
+				Before:  now.Add(-retention),
+				Exclude: append(append([]repository.LogMatcher(nil), holds...), overridden...),
+			},
+		})
+	}
+
+	var earlier []repository.LogMatcher
+	for _, rule := range rules {
+		if rule.LegalHold {
+			continue
+		}
+		steps = append(steps, step{
+			name:   rule.Name,
+			action: rule.Action,
+			sel: repository.LogSelection{
+				Before:  now.Add(-rule.MaxAge),
+				Match:   rule.Match,
+				Exclude: append(append([]repository.LogMatcher(nil), holds...), earlier...),
+			},
+		})
+		earlier = append(earlier, rule.Match)
+	}
+
+	return steps
+}
+
+// Holds returns the matchers of the legal hold rules
+func Holds(rules []Rule) []repository.LogMatcher {
+	var holds []repository.LogMatcher
+	for _, rule := range rules {
+		if rule.LegalHold {
+			holds = append(holds, rule.Match)
+		}
+	}
+	return holds
+}
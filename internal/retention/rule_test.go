@@ -0,0 +1,96 @@
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/tenant"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []Rule
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			rules: []Rule{
+				{Name: "hold", Match: repository.LogMatcher{Tenant: "acme"}, LegalHold: true},
+				{Name: "debug", Match: repository.LogMatcher{Severities: []string{model.SeverityInfo}}, MaxAge: time.Hour, Action: model.RetentionDelete},
+			},
+		},
+		{name: "missing name", rules: []Rule{{MaxAge: time.Hour, Action: model.RetentionDelete}}, wantErr: true},
+		{
+			name: "duplicate name",
+			rules: []Rule{
+				{Name: "a", MaxAge: time.Hour, Action: model.RetentionDelete},
+				{Name: "a", MaxAge: time.Hour, Action: model.RetentionDelete},
+			},
+			wantErr: true,
+		},
+		{name: "invalid severity", rules: []Rule{{Name: "a", Match: repository.LogMatcher{Severities: []string{"loud"}}, MaxAge: time.Hour, Action: model.RetentionDelete}}, wantErr: true},
+		{name: "missing max age", rules: []Rule{{Name: "a", Action: model.RetentionDelete}}, wantErr: true},
+		{name: "unknown action", rules: []Rule{{Name: "a", MaxAge: time.Hour, Action: "shred"}}, wantErr: true},
+		{name: "archive without directory", rules: []Rule{{Name: "a", MaxAge: time.Hour, Action: model.RetentionArchive}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.rules, "")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	hold := repository.LogMatcher{Tenant: "acme", Source: "case-*"}
+	debug := repository.LogMatcher{Severities: []string{model.SeverityInfo}}
+	rules := []Rule{
+		{Name: "debug", Match: debug, MaxAge: 24 * time.Hour, Action: model.RetentionDelete},
+		{Name: "hold", Match: hold, LegalHold: true},
+		{Name: "rest", MaxAge: 7 * 24 * time.Hour, Action: model.RetentionArchive},
+	}
+	quotas := tenant.Quotas{
+		Default: tenant.Quota{Retention: 30 * 24 * time.Hour},
+		Tenants: map[string]tenant.Quota{
+			"red":  {Retention: 48 * time.Hour},
+			"blue": {},
+		},
+	}
+
+	steps := plan(rules, quotas, now)
+	require.Len(t, steps, 4)
+
+	// Tenant retentions come first and never touch held logs
+	assert.Equal(t, "tenant:red", steps[0].name)
+	assert.Equal(t, now.Add(-48*time.Hour), steps[0].sel.Before)
+	assert.Equal(t, repository.LogMatcher{Tenant: "red"}, steps[0].sel.Match)
+	assert.Equal(t, []repository.LogMatcher{hold}, steps[0].sel.Exclude)
+
+	// The default retention skips tenants with a quota of their own
+	assert.Equal(t, "tenant:default_quota", steps[1].name)
+	assert.Equal(t, []repository.LogMatcher{hold, {Tenant: "blue"}, {Tenant: "red"}}, steps[1].sel.Exclude)
+
+	// Rules apply in order to logs no earlier rule matched
+	assert.Equal(t, "debug", steps[2].name)
+	assert.Equal(t, []repository.LogMatcher{hold}, steps[2].sel.Exclude)
+	assert.Equal(t, "rest", steps[3].name)
+	assert.Equal(t, model.RetentionArchive, steps[3].action)
+	assert.Equal(t, now.Add(-7*24*time.Hour), steps[3].sel.Before)
+	assert.Equal(t, []repository.LogMatcher{hold, debug}, steps[3].sel.Exclude)
+}
+
+func TestPlanWithoutRetention(t *testing.T) {
+	assert.Empty(t, plan(nil, tenant.Quotas{}, time.Now()))
+}
@@ -0,0 +1,531 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/pkg/sigma"
+)
+
+// maxAlertLogIDs caps how many triggering log IDs are kept on an alert
+const maxAlertLogIDs = 100
+
+// windowKey identifies a threshold window for a rule, tenant and group
+type windowKey struct {
+	ruleID   string
+	tenantID string
+	group    string
+}
+
+// window holds the matching events that may still fall within a threshold
+// window, ordered by timestamp. An event stands for weights of them, as for
+// collapsed repeats.
+type window struct {
+	times   []time.Time
+	logIDs  []string
+	weights []int
+}
+
+// busiest returns the span [from, to) of events within size of each other
+// that includes event i and stands for the most events, and that number
+func (w *window) busiest(i int, size time.Duration) (from, to, total int) {
+	start := sort.Search(i, func(j int) bool { return !w.times[j].Before(w.times[i].Add(-size)) })
+	end, sum := start, 0
+	for s := start; s <= i; s++ {
+		for end < len(w.times) && !w.times[end].After(w.times[s].Add(size)) {
+			sum += w.weights[end]
+			end++
+		}
+		if sum > total {
+			from, to, total = s, end, sum
+		}
+		sum -= w.weights[s]
+	}
+	return from, to, total
+}
+
+// scheduleState tracks the searches of a scheduled rule
+type scheduleState struct {
+	// from is the start of the interval not yet searched
+	from    time.Time
+	nextRun time.Time
+}
+
+// Engine evaluates detection rules against ingested events and raises alerts.
+// Rules are reloaded from the database on demand and periodically, so changes
+// take effect without a restart. Threshold windows use event timestamps;
+// events arriving more than maxLateness after their timestamp are not counted.
+// Scheduled sigma rules are run as searches against the logs table.
+// Rules apply to every tenant, but events of different tenants never share a
+// threshold window and each alert belongs to a single tenant.
+type Engine struct {
+	repo           *repository.PostgresRepository
+	reloadInterval time.Duration
+	scheduleLag    time.Duration
+	maxLateness    time.Duration
+	fieldMapping   sigma.FieldMapping
+
+	mu    sync.RWMutex
+	rules []*compiledRule
+
+	windowsMu sync.Mutex
+	windows   map[windowKey]*window
+
+	// scheduled tracks scheduled rules; only used by scheduleLoop
+	scheduled map[string]*scheduleState
+
+	alerts         chan model.Alert
+	alertObservers []AlertObserver
+	wg             sync.WaitGroup
+	ctx            context.Context
+	cancel         context.CancelFunc
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewEngine creates a new rule engine. Scheduled searches stop scheduleLag
+// before the current time, so events stored late are still searched.
+// Threshold rules count events up to maxLateness after their timestamp.
+func NewEngine(
+	repo *repository.PostgresRepository,
+	reloadInterval time.Duration,
+	scheduleLag time.Duration,
+	maxLateness time.Duration,
+	alertBufferSize int,
+	fieldMapping sigma.FieldMapping,
+) *Engine {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Engine{
+		repo:           repo,
+		reloadInterval: reloadInterval,
+		scheduleLag:    scheduleLag,
+		maxLateness:    maxLateness,
+		fieldMapping:   fieldMapping,
+		windows:        make(map[windowKey]*window),
+		scheduled:      make(map[string]*scheduleState),
+		alerts:         make(chan model.Alert, alertBufferSize),
+		ctx:            ctx,
+		cancel:         cancel,
+		now:            time.Now,
+	}
+}
+
+// AlertObserver is notified of alerts once they have been stored, except
+// those muted by a suppression. ObserveAlerts runs on the alert writer
+// goroutine and must not block.
+type AlertObserver interface {
+	ObserveAlerts(alerts []model.Alert)
+}
+
+// AddAlertObserver registers an alert observer; it must be called before Start
+func (e *Engine) AddAlertObserver(o AlertObserver) {
+	e.alertObservers = append(e.alertObservers, o)
+}
+
+// Start loads rules and starts the reload, schedule and alert writer loops
+func (e *Engine) Start() error {
+	if err := e.Reload(e.ctx); err != nil {
+		return err
+	}
+
+	e.wg.Add(3)
+	go e.reloadLoop()
+	go e.scheduleLoop()
+	go e.alertWriter()
+
+	return nil
+}
+
+// Stop stops background loops and flushes pending alerts
+func (e *Engine) Stop() {
+	log.Info().Msg("Stopping rule engine")
+	e.cancel()
+	e.wg.Wait()
+	log.Info().Msg("Rule engine stopped")
+}
+
+// Reload replaces the active rule set with the enabled rules in the database
+func (e *Engine) Reload(ctx context.Context) error {
+	rules, err := e.repo.ListRules(ctx, true)
+	if err != nil {
+		return fmt.Errorf("failed to load rules: %w", err)
+	}
+
+	e.SetRules(rules)
+	return nil
+}
+
+// SetRules compiles and activates a rule set. Rules that fail to compile are skipped.
+func (e *Engine) SetRules(rules []model.Rule) {
+	compiled := make([]*compiledRule, 0, len(rules))
+	active := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		cr, err := compile(rule, e.fieldMapping)
+		if err != nil {
+			log.Error().Err(err).Str("rule_id", rule.ID).Str("rule", rule.Name).Msg("Skipping invalid rule")
+			continue
+		}
+		compiled = append(compiled, cr)
+		active[rule.ID] = true
+	}
+
+	e.mu.Lock()
+	e.rules = compiled
+	e.mu.Unlock()
+
+	// Forget windows of rules that were removed or disabled
+	e.windowsMu.Lock()
+	for key := range e.windows {
+		if !active[key.ruleID] {
+			delete(e.windows, key)
+		}
+	}
+	e.windowsMu.Unlock()
+
+	log.Debug().Int("rules", len(compiled)).Msg("Rules loaded")
+}
+
+// Observe evaluates stored events against the active rules
+func (e *Engine) Observe(events []model.LogEvent) {
+	e.mu.RLock()
+	rules := e.rules
+	e.mu.RUnlock()
+
+	if len(rules) == 0 {
+		return
+	}
+
+	for _, event := range events {
+		for _, cr := range rules {
+			if cr.schedule > 0 || !cr.matches(event) {
+				continue
+			}
+
+			if cr.rule.Threshold == nil {
+				e.emit(newAlert(cr.rule, event.TenantID, "", []string{event.ID}, 1,
+					fmt.Sprintf("Rule %q matched event from %s: %s", cr.rule.Name, event.Source, event.Message)))
+				continue
+			}
+
+			e.observeThreshold(cr, event, 1)
+		}
+	}
+}
+
+// ObserveRepeats counts the repeats collapsed into stored events towards
+// threshold rules, at the time the last of them was seen. Rules without a
+// threshold already alerted on the stored event, so repeats raise no
+// further alerts.
+func (e *Engine) ObserveRepeats(events []model.LogEvent) {
+	e.mu.RLock()
+	rules := e.rules
+	e.mu.RUnlock()
+
+	for _, event := range events {
+		if event.LastSeen != nil {
+			event.Timestamp = *event.LastSeen
+		}
+		for _, cr := range rules {
+			if cr.schedule > 0 || cr.rule.Threshold == nil || !cr.matches(event) {
+				continue
+			}
+			e.observeThreshold(cr, event, event.RepeatCount)
+		}
+	}
+}
+
+// observeThreshold records a matching event standing for weight events and
+// alerts once the threshold is reached. Events are placed by timestamp;
+// timestamps in the future count as now, and events more than maxLateness
+// old are ignored.
+func (e *Engine) observeThreshold(cr *compiledRule, event model.LogEvent, weight int) {
+	threshold := cr.rule.Threshold
+
+	now := e.now()
+	at := event.Timestamp
+	if at.IsZero() || at.After(now) {
+		at = now
+	}
+	if now.Sub(at) > e.maxLateness {
+		return
+	}
+
+	group := ""
+	if threshold.GroupBy != "" {
+		group, _ = FieldValue(event, threshold.GroupBy)
+	}
+
+	key := windowKey{ruleID: cr.rule.ID, tenantID: event.TenantID, group: group}
+
+	e.windowsMu.Lock()
+	w, ok := e.windows[key]
+	if !ok {
+		w = &window{}
+		e.windows[key] = w
+	}
+
+	// Drop events that no event still to come can share a window with
+	cutoff := now.Add(-e.maxLateness - cr.window)
+	drop := 0
+	for drop < len(w.times) && w.times[drop].Before(cutoff) {
+		drop++
+	}
+	w.times = w.times[drop:]
+	w.logIDs = w.logIDs[drop:]
+	w.weights = w.weights[drop:]
+
+	i := sort.Search(len(w.times), func(j int) bool { return w.times[j].After(at) })
+	w.times = slices.Insert(w.times, i, at)
+	w.logIDs = slices.Insert(w.logIDs, i, event.ID)
+	w.weights = slices.Insert(w.weights, i, weight)
+
+	from, to, count := w.busiest(i, cr.window)
+	if count < threshold.Count {
+		e.windowsMu.Unlock()
+		return
+	}
+
+	logIDs := w.logIDs[from:to]
+	if len(logIDs) > maxAlertLogIDs {
+		logIDs = logIDs[len(logIDs)-maxAlertLogIDs:]
+	}
+	logIDs = append([]string(nil), logIDs...)
+	delete(e.windows, key)
+	e.windowsMu.Unlock()
+
+	message := fmt.Sprintf("Rule %q: %d matching events within %s", cr.rule.Name, count, threshold.Window)
+	if threshold.GroupBy != "" {
+		message += fmt.Sprintf(" for %s=%s", threshold.GroupBy, group)
+	}
+	e.emit(newAlert(cr.rule, event.TenantID, group, logIDs, count, message))
+}
+
+// emit queues an alert for persistence without blocking ingestion
+func (e *Engine) emit(alert model.Alert) {
+	select {
+	case e.alerts <- alert:
+	default:
+		log.Warn().Str("rule", alert.RuleName).Msg("Alert buffer full, dropping alert")
+	}
+}
+
+// reloadLoop periodically reloads rules and expires idle threshold windows
+func (e *Engine) reloadLoop() {
+	defer e.wg.Done()
+
+	if e.reloadInterval <= 0 {
+		<-e.ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(e.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := e.Reload(e.ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to reload rules")
+			}
+			e.expireWindows()
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// scheduleLoop runs scheduled rules when they are due
+func (e *Engine) scheduleLoop() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.runDueSchedules()
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// runDueSchedules searches each due scheduled rule over the interval since
+// its last successful search, up to scheduleLag before now. Events are
+// searched by timestamp, so the lag leaves time for delayed, spooled and
+// replayed events to be stored. A rule's first search covers one schedule.
+func (e *Engine) runDueSchedules() {
+	e.mu.RLock()
+	rules := e.rules
+	e.mu.RUnlock()
+
+	now := e.now()
+	to := now.Add(-e.scheduleLag)
+	active := make(map[string]bool)
+
+	for _, cr := range rules {
+		if cr.schedule <= 0 {
+			continue
+		}
+		active[cr.rule.ID] = true
+
+		state, ok := e.scheduled[cr.rule.ID]
+		if !ok {
+			state = &scheduleState{from: to.Add(-cr.schedule), nextRun: now}
+			e.scheduled[cr.rule.ID] = state
+		}
+		if now.Before(state.nextRun) {
+			continue
+		}
+
+		state.nextRun = now.Add(cr.schedule)
+		if e.runScheduled(cr, state.from, to) {
+			state.from = to
+		}
+	}
+
+	for id := range e.scheduled {
+		if !active[id] {
+			delete(e.scheduled, id)
+		}
+	}
+}
+
+// runScheduled searches logs in [from, to) and alerts for each tenant with
+// enough matching events
+func (e *Engine) runScheduled(cr *compiledRule, from, to time.Time) bool {
+	ctx, cancel := context.WithTimeout(e.ctx, cr.schedule)
+	defer cancel()
+
+	matches, err := e.repo.SearchSigma(ctx, cr.expr, from, to, maxAlertLogIDs)
+	if err != nil {
+		log.Error().Err(err).Str("rule_id", cr.rule.ID).Str("rule", cr.rule.Name).Msg("Scheduled search failed")
+		return false
+	}
+
+	minCount := 1
+	if cr.rule.Threshold != nil {
+		minCount = cr.rule.Threshold.Count
+	}
+
+	for _, m := range matches {
+		if m.Count < minCount {
+			continue
+		}
+		e.emit(newAlert(cr.rule, m.TenantID, "", m.LogIDs, m.Count,
+			fmt.Sprintf("Rule %q: %d matching events between %s and %s",
+				cr.rule.Name, m.Count, from.Format(time.RFC3339), to.Format(time.RFC3339))))
+	}
+	return true
+}
+
+// expireWindows removes windows whose newest event no event still to come
+// can share a window with
+func (e *Engine) expireWindows() {
+	e.mu.RLock()
+	windows := make(map[string]time.Duration, len(e.rules))
+	for _, cr := range e.rules {
+		windows[cr.rule.ID] = cr.window
+	}
+	e.mu.RUnlock()
+
+	now := e.now()
+
+	e.windowsMu.Lock()
+	defer e.windowsMu.Unlock()
+
+	for key, w := range e.windows {
+		if len(w.times) == 0 || now.Sub(w.times[len(w.times)-1]) > e.maxLateness+windows[key.ruleID] {
+			delete(e.windows, key)
+		}
+	}
+}
+
+// alertWriter persists queued alerts in batches
+func (e *Engine) alertWriter() {
+	defer e.wg.Done()
+
+	batch := make([]model.Alert, 0, 100)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := e.repo.InsertAlerts(ctx, batch); err != nil {
+			log.Error().Err(err).Int("alerts", len(batch)).Msg("Failed to store alerts")
+		} else {
+			// Observers only see alerts that no suppression muted. The
+			// batch is reused, so they get their own copy.
+			raised := make([]model.Alert, 0, len(batch))
+			for _, alert := range batch {
+				if alert.Status != model.AlertSuppressed {
+					raised = append(raised, alert)
+				}
+			}
+			log.Info().Int("alerts", len(raised)).Int("suppressed", len(batch)-len(raised)).Msg("Alerts raised")
+			if len(raised) > 0 {
+				for _, o := range e.alertObservers {
+					o.ObserveAlerts(raised)
+				}
+			}
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case alert := <-e.alerts:
+			batch = append(batch, alert)
+			if len(batch) >= cap(batch) {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.ctx.Done():
+			// Drain alerts that are already queued
+			for {
+				select {
+				case alert := <-e.alerts:
+					batch = append(batch, alert)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func newAlert(rule model.Rule, tenantID, group string, logIDs []string, count int, message string) model.Alert {
+	return model.Alert{
+		ID:          uuid.New().String(),
+		TenantID:    tenantID,
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		Severity:    rule.Severity,
+		GroupKey:    group,
+		EventCount:  count,
+		LogIDs:      logIDs,
+		Message:     message,
+		TriggeredAt: time.Now(),
+		Status:      model.AlertOpen,
+	}
+}
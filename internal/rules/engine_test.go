@@ -0,0 +1,322 @@
+package rules
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/pkg/sigma"
+)
+
+func newTestEngine(rules ...model.Rule) (*Engine, *time.Time) {
+	e := NewEngine(nil, 0, 0, 5*time.Minute, 100, nil)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	e.SetRules(rules)
+	return e, &now
+}
+
+func drainAlerts(e *Engine) []model.Alert {
+	var alerts []model.Alert
+	for {
+		select {
+		case a := <-e.alerts:
+			alerts = append(alerts, a)
+		default:
+			return alerts
+		}
+	}
+}
+
+func event(id, source, message string, attrs map[string]any) model.LogEvent {
+	return model.LogEvent{
+		ID:         id,
+		Severity:   "INFO",
+		Source:     source,
+		Message:    message,
+		Attributes: attrs,
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	ev := event("1", "fw-01", "blocked", map[string]any{
+		"port":    float64(443),
+		"dst.ip":  "10.0.0.1",
+		"network": map[string]any{"src": map[string]any{"ip": "1.2.3.4"}},
+	})
+
+	tests := []struct {
+		field string
+		want  string
+		found bool
+	}{
+		{"source", "fw-01", true},
+		{"attr.port", "443", true},
+		{"attr.dst.ip", "10.0.0.1", true},
+		{"attr.network.src.ip", "1.2.3.4", true},
+		{"attr.network.dst", "", false},
+		{"attr.missing", "", false},
+		{"unknown", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got, found := FieldValue(ev, tt.field)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConditions(t *testing.T) {
+	ev := event("1", "web-01", "Failed password for root", map[string]any{"user": "root"})
+
+	tests := []struct {
+		name string
+		cond model.RuleCondition
+		want bool
+	}{
+		{"equals", model.RuleCondition{Field: "source", Operator: model.OperatorEquals, Value: "web-01"}, true},
+		{"equals mismatch", model.RuleCondition{Field: "source", Operator: model.OperatorEquals, Value: "web-02"}, false},
+		{"not equals missing field", model.RuleCondition{Field: "attr.x", Operator: model.OperatorNotEquals, Value: "a"}, true},
+		{"in", model.RuleCondition{Field: "attr.user", Operator: model.OperatorIn, Values: []string{"admin", "root"}}, true},
+		{"contains is case-insensitive", model.RuleCondition{Field: "message", Operator: model.OperatorContains, Value: "failed PASSWORD"}, true},
+		{"regex", model.RuleCondition{Field: "message", Operator: model.OperatorRegex, Value: `for (root|admin)$`}, true},
+		{"exists", model.RuleCondition{Field: "attr.user", Operator: model.OperatorExists}, true},
+		{"exists missing", model.RuleCondition{Field: "attr.host", Operator: model.OperatorExists}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := compileCondition(tt.cond)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m(ev))
+		})
+	}
+}
+
+func TestObserveSingleEventRule(t *testing.T) {
+	e, _ := newTestEngine(model.Rule{
+		ID:       "r1",
+		Name:     "root login",
+		Severity: "HIGH",
+		Conditions: []model.RuleCondition{
+			{Field: "message", Operator: model.OperatorContains, Value: "root"},
+		},
+	})
+
+	e.Observe([]model.LogEvent{
+		event("1", "a", "login root", nil),
+		event("2", "a", "login alice", nil),
+	})
+
+	alerts := drainAlerts(e)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "r1", alerts[0].RuleID)
+	assert.Equal(t, "HIGH", alerts[0].Severity)
+	assert.Equal(t, []string{"1"}, alerts[0].LogIDs)
+}
+
+func TestObserveThreshold(t *testing.T) {
+	e, now := newTestEngine(model.Rule{
+		ID:       "r1",
+		Name:     "brute force",
+		Severity: "CRITICAL",
+		Conditions: []model.RuleCondition{
+			{Field: "message", Operator: model.OperatorContains, Value: "failed"},
+		},
+		Threshold: &model.RuleThreshold{Count: 3, Window: "1m", GroupBy: "attr.ip"},
+	})
+
+	failed := func(id, ip string) model.LogEvent {
+		return event(id, "sshd", "failed password", map[string]any{"ip": ip})
+	}
+
+	// Two events per group stay below the threshold
+	e.Observe([]model.LogEvent{failed("1", "1.1.1.1"), failed("2", "2.2.2.2"), failed("3", "1.1.1.1")})
+	assert.Empty(t, drainAlerts(e))
+
+	// Earlier events fall out of the window
+	*now = now.Add(61 * time.Second)
+	e.Observe([]model.LogEvent{failed("4", "1.1.1.1")})
+	*now = now.Add(10 * time.Second)
+	e.Observe([]model.LogEvent{failed("5", "1.1.1.1")})
+	assert.Empty(t, drainAlerts(e))
+
+	e.Observe([]model.LogEvent{failed("6", "1.1.1.1")})
+	alerts := drainAlerts(e)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "1.1.1.1", alerts[0].GroupKey)
+	assert.Equal(t, 3, alerts[0].EventCount)
+	assert.Equal(t, []string{"4", "5", "6"}, alerts[0].LogIDs)
+
+	// The window resets after an alert
+	e.Observe([]model.LogEvent{failed("7", "1.1.1.1")})
+	assert.Empty(t, drainAlerts(e))
+}
+
+func TestThresholdUsesEventTime(t *testing.T) {
+	e, now := newTestEngine(model.Rule{
+		ID:         "r1",
+		Name:       "brute force",
+		Conditions: []model.RuleCondition{{Field: "message", Operator: model.OperatorContains, Value: "failed"}},
+		Threshold:  &model.RuleThreshold{Count: 3, Window: "1m"},
+	})
+
+	failed := func(id string, age time.Duration) model.LogEvent {
+		ev := event(id, "sshd", "failed password", nil)
+		ev.Timestamp = now.Add(-age)
+		return ev
+	}
+
+	// Events arriving together but spread over more than the window do not alert
+	e.Observe([]model.LogEvent{failed("1", 4*time.Minute), failed("2", 2*time.Minute), failed("3", 0)})
+	assert.Empty(t, drainAlerts(e))
+
+	// Events past the allowed lateness are not counted
+	e.Observe([]model.LogEvent{failed("4", 10*time.Minute), failed("5", 10*time.Minute)})
+	assert.Empty(t, drainAlerts(e))
+
+	// A late event completes the window it belongs to
+	e.Observe([]model.LogEvent{failed("6", 4*time.Minute+30*time.Second)})
+	assert.Empty(t, drainAlerts(e))
+	e.Observe([]model.LogEvent{failed("7", 3*time.Minute+50*time.Second)})
+	alerts := drainAlerts(e)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, 3, alerts[0].EventCount)
+	assert.Equal(t, []string{"6", "1", "7"}, alerts[0].LogIDs)
+}
+
+func TestObserveRepeatsCountTowardsThreshold(t *testing.T) {
+	single, _ := newTestEngine(model.Rule{
+		ID:         "r1",
+		Name:       "any failure",
+		Conditions: []model.RuleCondition{{Field: "message", Operator: model.OperatorContains, Value: "failed"}},
+	})
+	e, _ := newTestEngine(model.Rule{
+		ID:         "r2",
+		Name:       "brute force",
+		Conditions: []model.RuleCondition{{Field: "message", Operator: model.OperatorContains, Value: "failed"}},
+		Threshold:  &model.RuleThreshold{Count: 5, Window: "1m"},
+	})
+
+	failed := event("1", "sshd", "failed password", nil)
+	repeats := failed
+	repeats.RepeatCount = 3
+
+	// Repeats do not alert again on rules matching single events
+	single.ObserveRepeats([]model.LogEvent{repeats})
+	assert.Empty(t, drainAlerts(single))
+
+	e.Observe([]model.LogEvent{failed})
+	e.ObserveRepeats([]model.LogEvent{repeats})
+	assert.Empty(t, drainAlerts(e))
+
+	repeats.RepeatCount = 1
+	e.ObserveRepeats([]model.LogEvent{repeats})
+	alerts := drainAlerts(e)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, 5, alerts[0].EventCount)
+}
+
+func TestThresholdWindowsArePerTenant(t *testing.T) {
+	e, _ := newTestEngine(model.Rule{
+		ID:         "r1",
+		Name:       "volume",
+		Conditions: []model.RuleCondition{{Field: "source", Operator: model.OperatorEquals, Value: "a"}},
+		Threshold:  &model.RuleThreshold{Count: 2, Window: "1m"},
+	})
+
+	inTenant := func(id, tenantID string) model.LogEvent {
+		ev := event(id, "a", "x", nil)
+		ev.TenantID = tenantID
+		return ev
+	}
+
+	// One event in each tenant does not reach the threshold
+	e.Observe([]model.LogEvent{inTenant("1", "red"), inTenant("2", "blue")})
+	assert.Empty(t, drainAlerts(e))
+
+	e.Observe([]model.LogEvent{inTenant("3", "blue")})
+	alerts := drainAlerts(e)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "blue", alerts[0].TenantID)
+	assert.Equal(t, []string{"2", "3"}, alerts[0].LogIDs)
+}
+
+func TestSetRulesSkipsInvalidAndDropsWindows(t *testing.T) {
+	threshold := model.Rule{
+		ID:         "r1",
+		Name:       "threshold",
+		Conditions: []model.RuleCondition{{Field: "source", Operator: model.OperatorExists}},
+		Threshold:  &model.RuleThreshold{Count: 2, Window: "1m"},
+	}
+	invalid := model.Rule{
+		ID:         "r2",
+		Name:       "bad regex",
+		Conditions: []model.RuleCondition{{Field: "message", Operator: model.OperatorRegex, Value: "("}},
+	}
+
+	e, _ := newTestEngine(threshold, invalid)
+	assert.Len(t, e.rules, 1)
+
+	e.Observe([]model.LogEvent{event("1", "a", "x", nil)})
+	assert.Len(t, e.windows, 1)
+
+	e.SetRules(nil)
+	assert.Empty(t, e.windows)
+}
+
+func TestAlertLogIDsAreCapped(t *testing.T) {
+	e, _ := newTestEngine(model.Rule{
+		ID:         "r1",
+		Name:       "volume",
+		Conditions: []model.RuleCondition{{Field: "source", Operator: model.OperatorEquals, Value: "a"}},
+		Threshold:  &model.RuleThreshold{Count: maxAlertLogIDs + 10, Window: "1h"},
+	})
+
+	events := make([]model.LogEvent, maxAlertLogIDs+10)
+	for i := range events {
+		events[i] = event(fmt.Sprint(i), "a", "x", nil)
+	}
+	e.Observe(events)
+
+	alerts := drainAlerts(e)
+	require.Len(t, alerts, 1)
+	assert.Len(t, alerts[0].LogIDs, maxAlertLogIDs)
+	assert.Equal(t, maxAlertLogIDs+10, alerts[0].EventCount)
+}
+
+func TestObserveSigmaRule(t *testing.T) {
+	e, _ := newTestEngine()
+	e.fieldMapping = sigma.NewFieldMapping(map[string]string{"Computer": "source"})
+
+	detection := `
+title: Whoami from web host
+detection:
+  selection:
+    Computer|startswith: web-
+    CommandLine|contains: whoami
+  condition: selection
+`
+	e.SetRules([]model.Rule{
+		{ID: "stream", Name: "stream", Severity: "HIGH", Type: model.RuleTypeSigma, Sigma: detection},
+		{ID: "scheduled", Name: "scheduled", Severity: "HIGH", Type: model.RuleTypeSigma, Sigma: detection, Schedule: "5m"},
+	})
+	require.Len(t, e.rules, 2)
+
+	e.Observe([]model.LogEvent{
+		event("1", "web-01", "exec", map[string]any{"CommandLine": "cmd /c WHOAMI"}),
+		event("2", "db-01", "exec", map[string]any{"CommandLine": "whoami"}),
+	})
+
+	// Only the stream rule evaluates ingested events
+	alerts := drainAlerts(e)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "stream", alerts[0].RuleID)
+	assert.Equal(t, []string{"1"}, alerts[0].LogIDs)
+}
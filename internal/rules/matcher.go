@@ -0,0 +1,227 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/pkg/sigma"
+)
+
+// matcher reports whether an event satisfies a condition
+type matcher func(event model.LogEvent) bool
+
+// compiledRule is a rule prepared for evaluation
+type compiledRule struct {
+	rule       model.Rule
+	conditions []matcher
+	// expr is the field-mapped detection of a sigma rule
+	expr   sigma.Expr
+	window time.Duration
+	// schedule is set for sigma rules that run as periodic searches
+	schedule time.Duration
+}
+
+// compile prepares a rule for evaluation
+func compile(rule model.Rule, mapping sigma.FieldMapping) (*compiledRule, error) {
+	cr := &compiledRule{rule: rule}
+
+	if rule.Type == model.RuleTypeSigma {
+		if err := cr.compileSigma(mapping); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, cond := range rule.Conditions {
+		m, err := compileCondition(cond)
+		if err != nil {
+			return nil, fmt.Errorf("condition %d: %w", i, err)
+		}
+		cr.conditions = append(cr.conditions, m)
+	}
+
+	if rule.Threshold != nil {
+		window, err := time.ParseDuration(rule.Threshold.Window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold window: %w", err)
+		}
+		cr.window = window
+	}
+
+	return cr, nil
+}
+
+// compileSigma parses the rule's Sigma detection and applies the field mapping
+func (cr *compiledRule) compileSigma(mapping sigma.FieldMapping) error {
+	parsed, err := sigma.Parse([]byte(cr.rule.Sigma))
+	if err != nil {
+		return fmt.Errorf("invalid sigma rule: %w", err)
+	}
+	if !parsed.Supported() {
+		return fmt.Errorf("unsupported sigma constructs: %s", strings.Join(parsed.Unsupported, "; "))
+	}
+	cr.expr = mapping.Apply(parsed.Condition)
+
+	if cr.rule.Schedule != "" {
+		schedule, err := time.ParseDuration(cr.rule.Schedule)
+		if err != nil || schedule <= 0 {
+			return fmt.Errorf("invalid schedule %q", cr.rule.Schedule)
+		}
+		// Fail now rather than on every scheduled run
+		if _, _, err := repository.CompileSigma(cr.expr); err != nil {
+			return err
+		}
+		cr.schedule = schedule
+	}
+
+	return nil
+}
+
+// matches reports whether the event satisfies the rule
+func (cr *compiledRule) matches(event model.LogEvent) bool {
+	if cr.expr != nil {
+		return cr.expr.Eval(func(field string) (string, bool) {
+			return FieldValue(event, field)
+		})
+	}
+
+	for _, m := range cr.conditions {
+		if !m(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// CompileConditions prepares conditions that must all hold, as in a
+// conditions rule
+func CompileConditions(conditions []model.RuleCondition) (func(event model.LogEvent) bool, error) {
+	matchers := make([]matcher, 0, len(conditions))
+	for i, cond := range conditions {
+		m, err := compileCondition(cond)
+		if err != nil {
+			return nil, fmt.Errorf("condition %d: %w", i, err)
+		}
+		matchers = append(matchers, m)
+	}
+
+	return func(event model.LogEvent) bool {
+		for _, m := range matchers {
+			if !m(event) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func compileCondition(cond model.RuleCondition) (matcher, error) {
+	field := cond.Field
+
+	switch cond.Operator {
+	case model.OperatorEquals:
+		return func(e model.LogEvent) bool {
+			v, ok := FieldValue(e, field)
+			return ok && v == cond.Value
+		}, nil
+	case model.OperatorNotEquals:
+		return func(e model.LogEvent) bool {
+			v, ok := FieldValue(e, field)
+			return !ok || v != cond.Value
+		}, nil
+	case model.OperatorIn:
+		set := make(map[string]struct{}, len(cond.Values))
+		for _, v := range cond.Values {
+			set[v] = struct{}{}
+		}
+		return func(e model.LogEvent) bool {
+			v, ok := FieldValue(e, field)
+			if !ok {
+				return false
+			}
+			_, found := set[v]
+			return found
+		}, nil
+	case model.OperatorContains:
+		needle := strings.ToLower(cond.Value)
+		return func(e model.LogEvent) bool {
+			v, ok := FieldValue(e, field)
+			return ok && strings.Contains(strings.ToLower(v), needle)
+		}, nil
+	case model.OperatorRegex:
+		re, err := regexp.Compile(cond.Value)
+		if err != nil {
+			return nil, err
+		}
+		return func(e model.LogEvent) bool {
+			v, ok := FieldValue(e, field)
+			return ok && re.MatchString(v)
+		}, nil
+	case model.OperatorExists:
+		return func(e model.LogEvent) bool {
+			_, ok := FieldValue(e, field)
+			return ok
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown operator %q", cond.Operator)
+	}
+}
+
+// FieldValue returns a field of an event as a string. Attributes are
+// addressed as "attr.<key>", where a dotted key also walks nested objects.
+func FieldValue(event model.LogEvent, field string) (string, bool) {
+	switch field {
+	case model.FieldSeverity:
+		return event.Severity, true
+	case model.FieldSource:
+		return event.Source, true
+	case model.FieldMessage:
+		return event.Message, true
+	}
+
+	key, ok := strings.CutPrefix(field, model.AttributeFieldPrefix)
+	if !ok || event.Attributes == nil {
+		return "", false
+	}
+
+	if value, ok := event.Attributes[key]; ok {
+		return stringify(value), true
+	}
+
+	// Walk nested objects for dotted keys
+	var current any = event.Attributes
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if current, ok = obj[part]; !ok {
+			return "", false
+		}
+	}
+	return stringify(current), true
+}
+
+func stringify(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	case bool, int, int64:
+		return fmt.Sprint(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
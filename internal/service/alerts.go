@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+)
+
+// AlertService triages alerts and manages alert suppressions. Every method
+// is scoped to a single tenant.
+type AlertService struct {
+	pgRepo *repository.PostgresRepository
+}
+
+// NewAlertService creates a new alert service
+func NewAlertService(pgRepo *repository.PostgresRepository) *AlertService {
+	return &AlertService{
+		pgRepo: pgRepo,
+	}
+}
+
+// ListAlerts returns a page of the alerts matching a filter
+func (s *AlertService) ListAlerts(ctx context.Context, filter model.AlertFilter) (*model.AlertListResponse, error) {
+	alerts, total, err := s.pgRepo.ListAlerts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.AlertListResponse{
+		Total:  total,
+		Count:  len(alerts),
+		Alerts: alerts,
+	}, nil
+}
+
+// GetAlert returns an alert with its linked logs, comments and audit trail
+func (s *AlertService) GetAlert(ctx context.Context, tenantID, id string) (*model.AlertDetail, error) {
+	alert, err := s.pgRepo.GetAlert(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &model.AlertDetail{
+		Alert: *alert,
+		Logs:  make([]model.LogEvent, 0, len(alert.LogIDs)),
+	}
+
+	for _, logID := range alert.LogIDs {
+		event, err := s.pgRepo.GetLogByID(ctx, tenantID, logID)
+		if errors.Is(err, repository.ErrNotFound) {
+			detail.MissingLogIDs = append(detail.MissingLogIDs, logID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		detail.Logs = append(detail.Logs, *event)
+	}
+
+	if detail.Comments, err = s.pgRepo.ListAlertComments(ctx, id); err != nil {
+		return nil, err
+	}
+	if detail.Audit, err = s.pgRepo.ListAlertAudit(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return detail, nil
+}
+
+// UpdateStatus moves an alert to another status on behalf of actor
+func (s *AlertService) UpdateStatus(ctx context.Context, tenantID, id, actor string, req model.AlertStatusRequest) (*model.Alert, error) {
+	return s.pgRepo.UpdateAlertStatus(ctx, tenantID, id, req.Status, actor, strings.TrimSpace(req.Comment))
+}
+
+// Assign sets the assignee of an alert on behalf of actor
+func (s *AlertService) Assign(ctx context.Context, tenantID, id, actor string, req model.AlertAssignRequest) (*model.Alert, error) {
+	return s.pgRepo.AssignAlert(ctx, tenantID, id, strings.TrimSpace(req.Assignee), actor)
+}
+
+// AddComment adds a comment of actor to an alert
+func (s *AlertService) AddComment(ctx context.Context, tenantID, id, actor string, req model.AlertCommentRequest) (*model.AlertComment, error) {
+	comment := &model.AlertComment{
+		ID:        uuid.New().String(),
+		AlertID:   id,
+		Author:    actor,
+		Body:      strings.TrimSpace(req.Body),
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.pgRepo.InsertAlertComment(ctx, tenantID, *comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// ListSuppressions returns the suppressions of a tenant; expired ones only when all is set
+func (s *AlertService) ListSuppressions(ctx context.Context, tenantID string, all bool) (*model.SuppressionListResponse, error) {
+	suppressions, err := s.pgRepo.ListAlertSuppressions(ctx, tenantID, all, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.SuppressionListResponse{
+		Count:        len(suppressions),
+		Suppressions: suppressions,
+	}, nil
+}
+
+// CreateSuppression creates a suppression of actor. The request must have
+// passed validator.ValidateSuppressionRequest.
+func (s *AlertService) CreateSuppression(ctx context.Context, tenantID, actor string, req model.SuppressionRequest) (*model.AlertSuppression, error) {
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+
+	suppression := &model.AlertSuppression{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		RuleID:    req.RuleID,
+		GroupKey:  req.GroupKey,
+		Severity:  req.Severity,
+		Reason:    strings.TrimSpace(req.Reason),
+		CreatedBy: actor,
+		StartsAt:  startsAt,
+		ExpiresAt: startsAt.Add(duration),
+		CreatedAt: now,
+	}
+
+	if err := s.pgRepo.InsertAlertSuppression(ctx, *suppression); err != nil {
+		return nil, err
+	}
+	return suppression, nil
+}
+
+// ExpireSuppression ends a suppression now
+func (s *AlertService) ExpireSuppression(ctx context.Context, tenantID, id string) error {
+	return s.pgRepo.ExpireAlertSuppression(ctx, tenantID, id, time.Now())
+}
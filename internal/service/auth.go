@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+)
+
+// ErrInvalidAPIKey is returned for unknown, revoked or expired keys
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+const (
+	// apiKeyPrefix marks ThreatLog keys so they are easy to recognise in secret scanners
+	apiKeyPrefix = "tl_"
+	// keyCacheTTL bounds how long a revoked key keeps working on other instances
+	keyCacheTTL = 30 * time.Second
+	// lastUsedFlushInterval is how often last-used times are written
+	lastUsedFlushInterval = 30 * time.Second
+	// tailTokenPrefix marks the short-lived tokens issued for live tail
+	tailTokenPrefix = "tlt_"
+	// tailTokenTTL is how long a live tail token can be used to open a stream
+	tailTokenTTL = time.Minute
+)
+
+// tailTokenClaims is the signed payload of a live tail token
+type tailTokenClaims struct {
+	model.Identity
+	ExpiresAt int64 `json:"exp"`
+}
+
+type cachedKey struct {
+	key     *model.APIKey
+	expires time.Time
+}
+
+// AuthService authenticates API keys and manages them. Verified keys are
+// cached briefly and last-used times are written in batches, so
+// authentication does not hit the database on every request.
+type AuthService struct {
+	pgRepo       *repository.PostgresRepository
+	bootstrapKey string
+	// tokenSecret signs live tail tokens
+	tokenSecret []byte
+
+	cacheMu sync.Mutex
+	cache   map[string]cachedKey
+
+	usageMu  sync.Mutex
+	lastUsed map[string]time.Time
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewAuthService creates a new auth service. A non-empty bootstrapKey is
+// accepted as an admin key so the first keys can be created. Live tail
+// tokens are signed with tokenSecret; when it is empty a random secret is
+// used, and tokens only work on the instance that issued them.
+func NewAuthService(pgRepo *repository.PostgresRepository, bootstrapKey, tokenSecret string) (*AuthService, error) {
+	secret := []byte(tokenSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &AuthService{
+		pgRepo:       pgRepo,
+		bootstrapKey: bootstrapKey,
+		tokenSecret:  secret,
+		cache:        make(map[string]cachedKey),
+		lastUsed:     make(map[string]time.Time),
+		ctx:          ctx,
+		cancel:       cancel,
+	}, nil
+}
+
+// Start starts the last-used flusher
+func (s *AuthService) Start() {
+	s.wg.Add(1)
+	go s.flushLoop()
+}
+
+// Stop stops the flusher and writes pending last-used times
+func (s *AuthService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// EnsureBootstrapKey generates a bootstrap key when none is configured and
+// no operator key exists, so a fresh install is not left without a way in.
+// It returns the generated key, or "" when none was needed. It must be
+// called before the service authenticates requests.
+func (s *AuthService) EnsureBootstrapKey(ctx context.Context) (string, error) {
+	if s.bootstrapKey != "" {
+		return "", nil
+	}
+
+	exists, err := s.pgRepo.HasOperatorKey(ctx)
+	if err != nil || exists {
+		return "", err
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		return "", err
+	}
+	s.bootstrapKey = key
+	return key, nil
+}
+
+// Authenticate resolves a bearer token to the identity of its key
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*model.Identity, error) {
+	if s.bootstrapKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.bootstrapKey)) == 1 {
+		return &model.Identity{
+			KeyID:    "bootstrap",
+			Name:     "bootstrap",
+			TenantID: model.DefaultTenant,
+			Roles:    []string{model.RoleAdmin},
+		}, nil
+	}
+	if !strings.HasPrefix(token, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	hash := hashAPIKey(token)
+	now := time.Now()
+
+	key, err := s.lookup(ctx, hash, now)
+	if err != nil {
+		return nil, err
+	}
+	if !key.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	s.usageMu.Lock()
+	s.lastUsed[key.ID] = now
+	s.usageMu.Unlock()
+
+	return &model.Identity{KeyID: key.ID, Name: key.Name, TenantID: key.TenantID, Roles: key.Roles}, nil
+}
+
+// IssueTailToken returns a short-lived token that opens a live tail for
+// the tenant of identity when passed as the access_token query parameter.
+// The token only grants the read role.
+func (s *AuthService) IssueTailToken(identity *model.Identity) (*model.TailTokenResponse, error) {
+	expiresAt := time.Now().Add(tailTokenTTL)
+	claims := tailTokenClaims{
+		Identity: model.Identity{
+			KeyID:    identity.KeyID,
+			Name:     identity.Name,
+			TenantID: identity.TenantID,
+			Roles:    []string{model.RoleRead},
+		},
+		ExpiresAt: expiresAt.Unix(),
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return &model.TailTokenResponse{
+		Token:     tailTokenPrefix + encoded + "." + s.signTailToken(encoded),
+		ExpiresAt: expiresAt.UTC().Truncate(time.Second),
+	}, nil
+}
+
+// AuthenticateTailToken resolves a live tail token to the identity it was
+// issued for. Invalid and expired tokens return ErrInvalidAPIKey.
+func (s *AuthService) AuthenticateTailToken(token string) (*model.Identity, error) {
+	encoded, signature, ok := strings.Cut(strings.TrimPrefix(token, tailTokenPrefix), ".")
+	if !ok || !strings.HasPrefix(token, tailTokenPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signTailToken(encoded))) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	var claims tailTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidAPIKey
+	}
+
+	return &claims.Identity, nil
+}
+
+func (s *AuthService) signTailToken(encoded string) string {
+	mac := hmac.New(sha256.New, s.tokenSecret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *AuthService) lookup(ctx context.Context, hash []byte, now time.Time) (*model.APIKey, error) {
+	cacheKey := string(hash)
+
+	s.cacheMu.Lock()
+	cached, ok := s.cache[cacheKey]
+	s.cacheMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.key, nil
+	}
+
+	key, err := s.pgRepo.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	s.cacheMu.Lock()
+	s.cache[cacheKey] = cachedKey{key: key, expires: now.Add(keyCacheTTL)}
+	s.cacheMu.Unlock()
+
+	return key, nil
+}
+
+// CreateKey creates an API key in req.TenantID and returns its secret
+func (s *AuthService) CreateKey(ctx context.Context, req model.APIKeyRequest) (*model.APIKeyCreateResponse, error) {
+	secret, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := model.APIKey{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		TenantID:  req.TenantID,
+		Prefix:    secret[:len(apiKeyPrefix)+8],
+		Roles:     req.Roles,
+		CreatedAt: time.Now(),
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if err := s.pgRepo.CreateAPIKey(ctx, &key, hashAPIKey(secret)); err != nil {
+		return nil, err
+	}
+
+	return &model.APIKeyCreateResponse{APIKey: key, Key: secret}, nil
+}
+
+// ListKeys lists the API keys of a tenant without their secrets. An empty
+// tenantID lists the keys of every tenant.
+func (s *AuthService) ListKeys(ctx context.Context, tenantID string) (*model.APIKeyListResponse, error) {
+	keys, err := s.pgRepo.ListAPIKeys(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.APIKeyListResponse{
+		Count: len(keys),
+		Keys:  keys,
+	}, nil
+}
+
+// RevokeKey revokes an API key of a tenant, or of any tenant when tenantID
+// is empty. It stops working on this instance at once and on others when
+// their cache entry expires.
+func (s *AuthService) RevokeKey(ctx context.Context, tenantID, id string) error {
+	if err := s.pgRepo.RevokeAPIKey(ctx, tenantID, id); err != nil {
+		return err
+	}
+
+	s.cacheMu.Lock()
+	for hash, cached := range s.cache {
+		if cached.key.ID == id {
+			delete(s.cache, hash)
+		}
+	}
+	s.cacheMu.Unlock()
+
+	return nil
+}
+
+// flushLoop periodically writes last-used times and prunes the key cache
+func (s *AuthService) flushLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(lastUsedFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flushLastUsed()
+			s.pruneCache()
+		case <-s.ctx.Done():
+			s.flushLastUsed()
+			return
+		}
+	}
+}
+
+func (s *AuthService) flushLastUsed() {
+	s.usageMu.Lock()
+	pending := s.lastUsed
+	s.lastUsed = make(map[string]time.Time)
+	s.usageMu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.pgRepo.TouchAPIKeys(ctx, pending); err != nil {
+		log.Error().Err(err).Int("keys", len(pending)).Msg("Failed to record api key usage")
+	}
+}
+
+func (s *AuthService) pruneCache() {
+	now := time.Now()
+
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	for hash, cached := range s.cache {
+		if !now.Before(cached.expires) {
+			delete(s.cache, hash)
+		}
+	}
+}
+
+// generateAPIKey returns a new random key: the tl_ prefix and 32 random bytes
+func generateAPIKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hashAPIKey hashes a key for storage. Keys are high-entropy random
+// strings, so a fast hash is sufficient.
+func hashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+func TestTailToken(t *testing.T) {
+	s, err := NewAuthService(nil, "", "secret")
+	require.NoError(t, err)
+
+	issued, err := s.IssueTailToken(&model.Identity{KeyID: "1", Name: "ops", TenantID: "red", Roles: []string{model.RoleAdmin}})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(issued.Token, tailTokenPrefix))
+	assert.WithinDuration(t, time.Now().Add(tailTokenTTL), issued.ExpiresAt, 2*time.Second)
+
+	identity, err := s.AuthenticateTailToken(issued.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "red", identity.TenantID)
+	assert.Equal(t, []string{model.RoleRead}, identity.Roles, "tokens only grant read")
+
+	// Tokens are bound to the secret and cannot be altered
+	other, err := NewAuthService(nil, "", "other")
+	require.NoError(t, err)
+	_, err = other.AuthenticateTailToken(issued.Token)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	encoded, signature, _ := strings.Cut(strings.TrimPrefix(issued.Token, tailTokenPrefix), ".")
+	_, err = s.AuthenticateTailToken(tailTokenPrefix + encoded + "x." + signature)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	_, err = s.AuthenticateTailToken(encoded + "." + signature)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	// Expired tokens are rejected
+	expired := tailTokenClaims{Identity: *identity, ExpiresAt: time.Now().Add(-time.Second).Unix()}
+	token := signedTestToken(t, s, expired)
+	_, err = s.AuthenticateTailToken(token)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
+
+func signedTestToken(t *testing.T, s *AuthService, claims tailTokenClaims) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return tailTokenPrefix + encoded + "." + s.signTailToken(encoded)
+}
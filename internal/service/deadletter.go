@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/worker"
+	"github.com/rs/zerolog/log"
+)
+
+// maxReplayBatch caps how many dead letters a single replay resubmits
+const maxReplayBatch = 1000
+
+// DeadLetterService lists and replays log events that failed to insert
+type DeadLetterService struct {
+	pgRepo *repository.PostgresRepository
+	pool   *worker.Pool
+}
+
+// NewDeadLetterService creates a new dead letter service
+func NewDeadLetterService(pgRepo *repository.PostgresRepository, pool *worker.Pool) *DeadLetterService {
+	return &DeadLetterService{
+		pgRepo: pgRepo,
+		pool:   pool,
+	}
+}
+
+// List returns a page of dead letters with the given status. An empty
+// tenantID lists the dead letters of every tenant.
+func (s *DeadLetterService) List(ctx context.Context, tenantID, status string, limit, offset int) (*model.DeadLetterListResponse, error) {
+	deadLetters, total, err := s.pgRepo.ListDeadLetters(ctx, tenantID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.DeadLetterListResponse{
+		Total:       total,
+		Count:       len(deadLetters),
+		DeadLetters: deadLetters,
+	}, nil
+}
+
+// Replay resubmits pending dead letters to the worker pool. An empty
+// tenantID replays the dead letters of every tenant.
+func (s *DeadLetterService) Replay(ctx context.Context, tenantID string, req model.ReplayRequest) (*model.ReplayResponse, error) {
+	deadLetters, err := s.pgRepo.GetPendingDeadLetters(ctx, tenantID, req.IDs, maxReplayBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.ReplayResponse{
+		Errors: make([]map[string]string, 0),
+	}
+
+	replayed := make([]int64, 0, len(deadLetters))
+	for _, dl := range deadLetters {
+		if _, err := s.pool.Submit(dl.Log); err != nil {
+			response.Failed++
+			response.Errors = append(response.Errors, map[string]string{
+				"id":    strconv.FormatInt(dl.ID, 10),
+				"error": err.Error(),
+			})
+			continue
+		}
+		replayed = append(replayed, dl.ID)
+	}
+
+	if err := s.pgRepo.MarkDeadLettersReplayed(ctx, replayed); err != nil {
+		return nil, err
+	}
+	response.Replayed = len(replayed)
+
+	log.Info().
+		Int("replayed", response.Replayed).
+		Int("failed", response.Failed).
+		Msg("Dead letters replayed")
+
+	return response, nil
+}
@@ -1,77 +1,238 @@
 package service
 
 import (
+	"context"
+	"maps"
+	"strconv"
+	"time"
+
 	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/tenant"
 	"github.com/Saumajitt/threatLog/internal/worker"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
+// eventIDNamespace scopes the event IDs derived from client-supplied keys
+var eventIDNamespace = uuid.MustParse("86bdb99c-fe2e-4bd1-b270-8f7df1339766")
+
 // IngestionService handles log ingestion
 type IngestionService struct {
-	pool *worker.Pool
+	pool           *worker.Pool
+	limiter        *tenant.Limiter
+	redisRepo      *repository.RedisRepository
+	idempotencyTTL time.Duration
 }
 
-// NewIngestionService creates a new ingestion service
-func NewIngestionService(pool *worker.Pool) *IngestionService {
+// NewIngestionService creates a new ingestion service. Client-supplied keys
+// are remembered for idempotencyTTL; the insert itself stays idempotent
+// after that.
+func NewIngestionService(
+	pool *worker.Pool,
+	limiter *tenant.Limiter,
+	redisRepo *repository.RedisRepository,
+	idempotencyTTL time.Duration,
+) *IngestionService {
 	return &IngestionService{
-		pool: pool,
+		pool:           pool,
+		limiter:        limiter,
+		redisRepo:      redisRepo,
+		idempotencyTTL: idempotencyTTL,
 	}
 }
 
-// IngestLog ingests a single log event
-func (s *IngestionService) IngestLog(req model.IngestRequest) (*model.IngestResponse, error) {
-	// Generate ID if not provided
-	logEvent := model.LogEvent{
-		ID:        uuid.New().String(),
-		Timestamp: req.Timestamp,
-		Severity:  req.Severity,
-		Source:    req.Source,
-		Message:   req.Message,
+// IngestLog ingests a single log event for a tenant. The event is keyed by
+// req.ID, else by idempotencyKey when set; an event whose key was already
+// ingested is reported as a duplicate. It returns a *tenant.QuotaError when
+// the tenant is over its ingestion rate.
+func (s *IngestionService) IngestLog(ctx context.Context, tenantID, idempotencyKey string, req model.IngestRequest) (*model.IngestResponse, error) {
+	if err := s.limiter.Allow(tenantID, 1); err != nil {
+		return nil, err
+	}
+
+	key := req.ID
+	if key == "" {
+		key = idempotencyKey
 	}
+	logEvent := newLogEvent(tenantID, key, req)
 
-	// Submit to worker pool
-	if err := s.pool.Submit(logEvent); err != nil {
+	if key != "" && !s.claim(ctx, []string{logEvent.ID})[0] {
+		return &model.IngestResponse{
+			ID:        logEvent.ID,
+			ClientID:  req.ID,
+			Status:    model.IngestStatusDuplicate,
+			Timestamp: logEvent.Timestamp,
+		}, nil
+	}
+
+	// Submit to worker pool; with the spool enabled this returns only once
+	// the event is on disk. A collapsed repeat is reported under the ID of
+	// the stored event it repeats.
+	id, err := s.pool.Submit(logEvent)
+	if err != nil {
 		log.Error().Err(err).Msg("Failed to submit log to worker pool")
+		if key != "" {
+			s.release(ctx, []string{logEvent.ID})
+		}
 		return nil, err
 	}
 
 	return &model.IngestResponse{
-		ID:        logEvent.ID,
-		Status:    "ingested",
+		ID:        id,
+		ClientID:  req.ID,
+		Status:    model.IngestStatusIngested,
 		Timestamp: logEvent.Timestamp,
 	}, nil
 }
 
-// IngestBatch ingests multiple log events
-func (s *IngestionService) IngestBatch(req model.BatchIngestRequest) (*model.BatchIngestResponse, error) {
+// IngestBatch ingests multiple log events for a tenant. Each event is keyed
+// by its ID, else by idempotencyKey and its index when set; events whose key
+// was already ingested are reported as duplicates. The batch is rejected as
+// a whole with a *tenant.QuotaError when it exceeds the tenant's ingestion
+// rate.
+func (s *IngestionService) IngestBatch(ctx context.Context, tenantID, idempotencyKey string, req model.BatchIngestRequest) (*model.BatchIngestResponse, error) {
+	if err := s.limiter.Allow(tenantID, len(req.Logs)); err != nil {
+		return nil, err
+	}
+
 	response := &model.BatchIngestResponse{
 		Accepted: 0,
 		Rejected: 0,
 		Errors:   make([]map[string]string, 0),
 	}
 
+	events := make([]model.LogEvent, len(req.Logs))
+	keyed := make([]bool, len(req.Logs))
+	var claimIDs []string
 	for i, logReq := range req.Logs {
-		logEvent := model.LogEvent{
-			ID:        uuid.New().String(),
-			Timestamp: logReq.Timestamp,
-			Severity:  logReq.Severity,
-			Source:    logReq.Source,
-			Message:   logReq.Message,
+		key := logReq.ID
+		if key == "" && idempotencyKey != "" {
+			key = idempotencyKey + "/" + strconv.Itoa(i)
+		}
+		events[i] = newLogEvent(tenantID, key, logReq)
+		if key != "" {
+			keyed[i] = true
+			claimIDs = append(claimIDs, events[i].ID)
+		}
+	}
+
+	claimed := s.claim(ctx, claimIDs)
+
+	var failed []string
+	for i, logEvent := range events {
+		if keyed[i] {
+			isNew := claimed[0]
+			claimed = claimed[1:]
+			if !isNew {
+				response.Duplicates = append(response.Duplicates, model.BatchDuplicate{
+					Index:    i,
+					ID:       logEvent.ID,
+					ClientID: req.Logs[i].ID,
+				})
+				continue
+			}
 		}
 
-		if err := s.pool.Submit(logEvent); err != nil {
+		if _, err := s.pool.Submit(logEvent); err != nil {
 			response.Rejected++
 			response.Errors = append(response.Errors, map[string]string{
-				"index":  string(rune(i)),
+				"index":  strconv.Itoa(i),
 				"error":  err.Error(),
 				"log_id": logEvent.ID,
 			})
+			if keyed[i] {
+				failed = append(failed, logEvent.ID)
+			}
 			continue
 		}
 
 		response.Accepted++
 	}
 
+	if len(failed) > 0 {
+		s.release(ctx, failed)
+	}
+
 	return response, nil
 }
+
+// claim records event IDs as ingested and reports which were new. Without
+// Redis every event counts as new; inserts skip stored events either way.
+func (s *IngestionService) claim(ctx context.Context, ids []string) []bool {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	claimed, err := s.redisRepo.ClaimEventIDs(ctx, ids, s.idempotencyTTL)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to check idempotency keys, accepting events")
+		claimed = make([]bool, len(ids))
+		for i := range claimed {
+			claimed[i] = true
+		}
+	}
+	return claimed
+}
+
+// releaseTimeout bounds the release of the keys of dead-lettered events
+const releaseTimeout = 5 * time.Second
+
+// ObserveDeadLetters releases the keys of dead-lettered events, so a retry
+// of one is ingested again instead of being reported as a duplicate. Only
+// IDs derived from a key are released.
+func (s *IngestionService) ObserveDeadLetters(deadLetters []model.DeadLetter) {
+	var ids []string
+	for _, dl := range deadLetters {
+		if id, err := uuid.Parse(dl.Log.ID); err == nil && id.Version() == 5 {
+			ids = append(ids, dl.Log.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	// Observers must not block the worker
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+		defer cancel()
+		s.release(ctx, ids)
+	}()
+}
+
+// release forgets event IDs that were claimed but not ingested, so a retry is accepted
+func (s *IngestionService) release(ctx context.Context, ids []string) {
+	if err := s.redisRepo.ReleaseEventIDs(ctx, ids); err != nil {
+		log.Warn().Err(err).Msg("Failed to release idempotency keys")
+	}
+}
+
+// newLogEvent creates the event for a request. A client-supplied key maps
+// to the same event ID on every retry; the ID is derived from the tenant
+// too, so tenants cannot collide. The id of the request is kept as the
+// client_id attribute, so events can be found by it.
+func newLogEvent(tenantID, key string, req model.IngestRequest) model.LogEvent {
+	id := uuid.New()
+	if key != "" {
+		id = uuid.NewSHA1(eventIDNamespace, []byte(tenantID+"\x00"+key))
+	}
+
+	attributes := req.Attributes
+	if req.ID != "" {
+		attributes = maps.Clone(req.Attributes)
+		if attributes == nil {
+			attributes = make(map[string]any, 1)
+		}
+		attributes[model.ClientIDAttribute] = req.ID
+	}
+
+	return model.LogEvent{
+		ID:         id.String(),
+		TenantID:   tenantID,
+		Timestamp:  req.Timestamp,
+		Severity:   req.Severity,
+		Source:     req.Source,
+		Message:    req.Message,
+		Attributes: attributes,
+	}
+}
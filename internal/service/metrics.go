@@ -4,6 +4,11 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/Saumajitt/threatLog/internal/dedup"
+	"github.com/Saumajitt/threatLog/internal/geoip"
+	"github.com/Saumajitt/threatLog/internal/pipeline"
+	"github.com/Saumajitt/threatLog/internal/syslog"
 )
 
 // MetricsService tracks system metrics
@@ -17,13 +22,23 @@ type MetricsService struct {
 	cacheHits         atomic.Int64
 	cacheMisses       atomic.Int64
 	maxLatencyEntries int
+	deduper           *dedup.Deduper
+	geoEnricher       *geoip.Enricher
+	pipelines         *pipeline.Pipelines
+	syslogServer      *syslog.Server
 }
 
-// NewMetricsService creates a new metrics service
-func NewMetricsService() *MetricsService {
+// NewMetricsService creates a new metrics service. deduper, geoEnricher,
+// pipelines and syslogServer may be nil when deduplication, GeoIP
+// enrichment, processing pipelines or syslog are disabled.
+func NewMetricsService(deduper *dedup.Deduper, geoEnricher *geoip.Enricher, pipelines *pipeline.Pipelines, syslogServer *syslog.Server) *MetricsService {
 	return &MetricsService{
 		startTime:         time.Now(),
 		maxLatencyEntries: 1000,
+		deduper:           deduper,
+		geoEnricher:       geoEnricher,
+		pipelines:         pipelines,
+		syslogServer:      syslogServer,
 	}
 }
 
@@ -59,8 +74,10 @@ func (m *MetricsService) RecordQuery(latency time.Duration, cacheHit bool) {
 	}
 }
 
-// GetMetrics returns current metrics
-func (m *MetricsService) GetMetrics() map[string]interface{} {
+// GetMetrics returns current metrics. Deduplication statistics cover
+// tenantID, or every tenant when it is empty; syslog statistics are only
+// shown to the syslog tenant and operators.
+func (m *MetricsService) GetMetrics(tenantID string) map[string]interface{} {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -73,7 +90,7 @@ func (m *MetricsService) GetMetrics() map[string]interface{} {
 		cacheHitRatio = float64(m.cacheHits.Load()) / float64(totalCache)
 	}
 
-	return map[string]interface{}{
+	metrics := map[string]interface{}{
 		"ingestion_rate":          ingestionRate,
 		"total_logs_ingested":     m.totalIngested.Load(),
 		"total_queries":           m.totalQueries.Load(),
@@ -87,6 +104,23 @@ func (m *MetricsService) GetMetrics() map[string]interface{} {
 		"cache_misses":             m.cacheMisses.Load(),
 		"uptime_seconds":           uptime,
 	}
+
+	if m.deduper != nil {
+		metrics["dedup"] = m.deduper.Stats(tenantID)
+	}
+	if m.geoEnricher != nil {
+		metrics["geoip"] = m.geoEnricher.Stats()
+	}
+	if m.pipelines != nil {
+		metrics["pipelines"] = m.pipelines.Stats()
+	}
+	if m.syslogServer != nil {
+		if stats := m.syslogServer.Stats(); tenantID == "" || tenantID == stats.TenantID {
+			metrics["syslog"] = stats
+		}
+	}
+
+	return metrics
 }
 
 func (m *MetricsService) calculateAvg(latencies []time.Duration) float64 {
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/notify"
+	"github.com/Saumajitt/threatLog/internal/repository"
+)
+
+// ErrNotificationsDisabled is returned when a test is requested while notifications are disabled
+var ErrNotificationsDisabled = errors.New("notifications are disabled")
+
+// NotificationService reports notification channels and deliveries and
+// sends test notifications
+type NotificationService struct {
+	pgRepo   *repository.PostgresRepository
+	notifier *notify.Notifier
+}
+
+// NewNotificationService creates a new notification service. notifier may be
+// nil when notifications are disabled.
+func NewNotificationService(pgRepo *repository.PostgresRepository, notifier *notify.Notifier) *NotificationService {
+	return &NotificationService{
+		pgRepo:   pgRepo,
+		notifier: notifier,
+	}
+}
+
+// ListChannels returns the configured channels
+func (s *NotificationService) ListChannels() *model.NotificationChannelListResponse {
+	channels := []model.NotificationChannelInfo{}
+	if s.notifier != nil {
+		channels = s.notifier.Channels()
+	}
+
+	return &model.NotificationChannelListResponse{
+		Count:    len(channels),
+		Channels: channels,
+	}
+}
+
+// ListDeliveries returns the latest deliveries, optionally of a single channel
+func (s *NotificationService) ListDeliveries(ctx context.Context, channel string, limit int) (*model.NotificationDeliveryListResponse, error) {
+	deliveries, err := s.pgRepo.ListNotificationDeliveries(ctx, channel, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.NotificationDeliveryListResponse{
+		Count:      len(deliveries),
+		Deliveries: deliveries,
+	}, nil
+}
+
+// Test sends a test notification through a channel
+func (s *NotificationService) Test(ctx context.Context, channel string) (*model.NotificationDelivery, error) {
+	if s.notifier == nil {
+		return nil, ErrNotificationsDisabled
+	}
+	return s.notifier.Test(ctx, channel)
+}
@@ -49,9 +49,17 @@ func (s *QueryService) QueryLogs(ctx context.Context, req model.QueryRequest) (*
 	}
 
 	response := &model.QueryResponse{
-		Total: total,
-		Count: len(logs),
-		Logs:  logs,
+		Total:          total,
+		TotalEstimated: req.Count == model.CountEstimate,
+		Count:          len(logs),
+		Logs:           logs,
+	}
+
+	// A full page means there may be more rows; hand out a cursor to the next one.
+	// Relevance ordering has no stable keyset, so it pages by offset only.
+	if len(logs) > 0 && len(logs) == req.Limit && req.Sort != model.SortRelevance {
+		last := logs[len(logs)-1]
+		response.NextCursor = model.EncodeCursor(last.Timestamp, last.ID)
 	}
 
 	// Cache the result if enabled
@@ -62,4 +70,69 @@ func (s *QueryService) QueryLogs(ctx context.Context, req model.QueryRequest) (*
 	}
 
 	return response, nil
-}
\ No newline at end of file
+}
+// AggregateLogs computes time-bucketed counts, top values and cardinality
+// estimates with optional caching
+func (s *QueryService) AggregateLogs(ctx context.Context, req model.AggregateRequest) (*model.AggregateResponse, error) {
+	interval, err := req.BucketInterval()
+	if err != nil {
+		return nil, err
+	}
+
+	// Try cache first if enabled
+	if s.cacheEnabled {
+		cached, err := s.redisRepo.GetCachedAggregateResult(ctx, req)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to get cached aggregation")
+		} else if cached != nil {
+			log.Debug().Msg("Cache hit")
+			return cached, nil
+		}
+	}
+
+	response, err := s.pgRepo.AggregateLogs(ctx, req, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the result if enabled
+	if s.cacheEnabled {
+		if err := s.redisRepo.CacheAggregateResult(ctx, req, *response); err != nil {
+			log.Warn().Err(err).Msg("Failed to cache aggregation")
+		}
+	}
+
+	return response, nil
+}
+
+// ExportLogs streams every log matching the query filters to fn. Exports
+// can be arbitrarily large, so they always read the database and are never
+// cached.
+func (s *QueryService) ExportLogs(ctx context.Context, req model.QueryRequest, fn func(*model.LogEvent) error) error {
+	return s.pgRepo.StreamLogs(ctx, req, fn)
+}
+
+// GetLog retrieves a log of a tenant by ID
+func (s *QueryService) GetLog(ctx context.Context, tenantID, id string) (*model.LogEvent, error) {
+	return s.pgRepo.GetLogByID(ctx, tenantID, id)
+}
+
+// GetLogContext retrieves a log of a tenant with up to before and after
+// events from the same source around it
+func (s *QueryService) GetLogContext(ctx context.Context, tenantID, id string, before, after int) (*model.LogContextResponse, error) {
+	event, err := s.pgRepo.GetLogByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	preceding, following, err := s.pgRepo.GetLogContext(ctx, event, before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LogContextResponse{
+		Log:    *event,
+		Before: preceding,
+		After:  following,
+	}, nil
+}
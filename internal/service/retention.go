@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/retention"
+)
+
+// ErrRetentionDisabled is returned when a run is requested while retention is disabled
+var ErrRetentionDisabled = errors.New("retention is disabled")
+
+// RetentionService reports and triggers retention runs
+type RetentionService struct {
+	pgRepo   *repository.PostgresRepository
+	enforcer *retention.Enforcer
+}
+
+// NewRetentionService creates a new retention service. enforcer may be nil
+// when retention is disabled.
+func NewRetentionService(pgRepo *repository.PostgresRepository, enforcer *retention.Enforcer) *RetentionService {
+	return &RetentionService{
+		pgRepo:   pgRepo,
+		enforcer: enforcer,
+	}
+}
+
+// ListRuns returns the latest retention runs
+func (s *RetentionService) ListRuns(ctx context.Context, limit int) (*model.RetentionRunListResponse, error) {
+	runs, err := s.pgRepo.ListRetentionRuns(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.RetentionRunListResponse{
+		Count: len(runs),
+		Runs:  runs,
+	}, nil
+}
+
+// Trigger queues a retention run
+func (s *RetentionService) Trigger() error {
+	if s.enforcer == nil {
+		return ErrRetentionDisabled
+	}
+	s.enforcer.Trigger()
+	return nil
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/rules"
+	"github.com/Saumajitt/threatLog/pkg/sigma"
+	"github.com/Saumajitt/threatLog/pkg/validator"
+)
+
+// ErrInvalidSigmaPayload is returned when a Sigma payload is not valid YAML
+var ErrInvalidSigmaPayload = errors.New("invalid sigma payload")
+
+// RuleService manages detection rules
+type RuleService struct {
+	pgRepo       *repository.PostgresRepository
+	engine       *rules.Engine
+	fieldMapping sigma.FieldMapping
+}
+
+// NewRuleService creates a new rule service. engine may be nil when rule
+// evaluation is disabled.
+func NewRuleService(pgRepo *repository.PostgresRepository, engine *rules.Engine, fieldMapping sigma.FieldMapping) *RuleService {
+	return &RuleService{
+		pgRepo:       pgRepo,
+		engine:       engine,
+		fieldMapping: fieldMapping,
+	}
+}
+
+// ListRules lists all rules
+func (s *RuleService) ListRules(ctx context.Context) (*model.RuleListResponse, error) {
+	ruleList, err := s.pgRepo.ListRules(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.RuleListResponse{
+		Count: len(ruleList),
+		Rules: ruleList,
+	}, nil
+}
+
+// GetRule retrieves a rule by ID
+func (s *RuleService) GetRule(ctx context.Context, id string) (*model.Rule, error) {
+	return s.pgRepo.GetRule(ctx, id)
+}
+
+// CreateRule creates a rule and activates it
+func (s *RuleService) CreateRule(ctx context.Context, req model.RuleRequest) (*model.Rule, error) {
+	rule, err := s.createRule(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.reload(ctx)
+	return rule, nil
+}
+
+func (s *RuleService) createRule(ctx context.Context, req model.RuleRequest) (*model.Rule, error) {
+	now := time.Now()
+	rule := &model.Rule{
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Description: req.Description,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		Severity:    req.Severity,
+		Type:        ruleType(req.Type),
+		Conditions:  req.Conditions,
+		Sigma:       req.Sigma,
+		Schedule:    req.Schedule,
+		Threshold:   req.Threshold,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.pgRepo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateRule replaces a rule and reloads the active rule set
+func (s *RuleService) UpdateRule(ctx context.Context, id string, req model.RuleRequest) (*model.Rule, error) {
+	existing, err := s.pgRepo.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	existing.Name = req.Name
+	existing.Description = req.Description
+	if req.Enabled != nil {
+		existing.Enabled = *req.Enabled
+	}
+	existing.Severity = req.Severity
+	existing.Type = ruleType(req.Type)
+	existing.Conditions = req.Conditions
+	existing.Sigma = req.Sigma
+	existing.Schedule = req.Schedule
+	existing.Threshold = req.Threshold
+	existing.UpdatedAt = time.Now()
+
+	if err := s.pgRepo.UpdateRule(ctx, existing); err != nil {
+		return nil, err
+	}
+
+	s.reload(ctx)
+	return existing, nil
+}
+
+// DeleteRule deletes a rule and reloads the active rule set
+func (s *RuleService) DeleteRule(ctx context.Context, id string) error {
+	if err := s.pgRepo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+
+	s.reload(ctx)
+	return nil
+}
+
+// TranslateSigma parses Sigma rules and reports, per rule, whether it is
+// supported, the fields it uses after mapping and the SQL it compiles to
+func (s *RuleService) TranslateSigma(data []byte) (*model.SigmaTranslateResponse, error) {
+	docs, err := sigma.Split(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSigmaPayload, err)
+	}
+
+	response := &model.SigmaTranslateResponse{
+		Rules: make([]model.SigmaTranslation, len(docs)),
+	}
+	for i, doc := range docs {
+		translation, _ := s.translate(i, doc)
+		response.Rules[i] = translation
+	}
+	return response, nil
+}
+
+// ImportSigma creates a sigma rule for each supported Sigma rule. Rules that
+// are malformed, unsupported or fail validation are reported as rejected.
+func (s *RuleService) ImportSigma(ctx context.Context, data []byte, enabled bool, schedule string) (*model.SigmaImportResponse, error) {
+	docs, err := sigma.Split(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSigmaPayload, err)
+	}
+
+	response := &model.SigmaImportResponse{
+		Imported: make([]model.Rule, 0, len(docs)),
+		Rejected: make([]model.SigmaTranslation, 0),
+	}
+
+	for i, doc := range docs {
+		translation, parsed := s.translate(i, doc)
+		if !translation.Supported {
+			response.Rejected = append(response.Rejected, translation)
+			continue
+		}
+
+		severity, ok := sigma.SeverityFromLevel(parsed.Level)
+		if !ok {
+			severity = model.SeverityMedium
+		}
+
+		req := model.RuleRequest{
+			Name:        parsed.Title,
+			Description: parsed.Description,
+			Enabled:     &enabled,
+			Severity:    severity,
+			Type:        model.RuleTypeSigma,
+			Sigma:       string(doc),
+			Schedule:    schedule,
+		}
+		if err := validator.ValidateRuleRequest(req); err != nil {
+			translation.Supported = false
+			translation.Errors = append(translation.Errors, err.Error())
+			response.Rejected = append(response.Rejected, translation)
+			continue
+		}
+
+		rule, err := s.createRule(ctx, req)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if !errors.As(err, &pgErr) {
+				return nil, err
+			}
+			translation.Supported = false
+			translation.Errors = append(translation.Errors, pgErr.Message)
+			response.Rejected = append(response.Rejected, translation)
+			continue
+		}
+		response.Imported = append(response.Imported, *rule)
+	}
+
+	if len(response.Imported) > 0 {
+		s.reload(ctx)
+	}
+	return response, nil
+}
+
+// translate parses one Sigma document and compiles it with the field mapping
+func (s *RuleService) translate(index int, doc []byte) (model.SigmaTranslation, *sigma.Rule) {
+	translation := model.SigmaTranslation{Index: index}
+
+	parsed, err := sigma.Parse(doc)
+	if err != nil {
+		translation.Errors = []string{err.Error()}
+		return translation, nil
+	}
+
+	translation.Title = parsed.Title
+	translation.Unsupported = parsed.Unsupported
+	translation.Warnings = parsed.Warnings
+	if !parsed.Supported() {
+		return translation, parsed
+	}
+
+	expr := s.fieldMapping.Apply(parsed.Condition)
+	translation.Fields = sigma.Fields(expr)
+
+	sql, args, err := repository.CompileSigma(expr)
+	if err != nil {
+		translation.Errors = []string{err.Error()}
+		return translation, parsed
+	}
+
+	translation.Supported = true
+	translation.SQL = sql
+	translation.Args = args
+	return translation, parsed
+}
+
+// ruleType defaults an empty rule type to conditions
+func ruleType(t string) string {
+	if t == "" {
+		return model.RuleTypeConditions
+	}
+	return t
+}
+
+// reload hot-reloads the engine; periodic reloads retry on failure
+func (s *RuleService) reload(ctx context.Context) {
+	if s.engine == nil {
+		return
+	}
+	if err := s.engine.Reload(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload rules after change")
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/threatintel"
+)
+
+var (
+	// ErrThreatIntelDisabled is returned when feeds are changed while threat intel is disabled
+	ErrThreatIntelDisabled = errors.New("threat intel is disabled")
+	// ErrFeedConfigured is returned when an upload or expiry targets a feed read from a file
+	ErrFeedConfigured = errors.New("feed is read from a configured file")
+	// ErrInvalidFeed is returned when an uploaded feed has no usable indicators
+	ErrInvalidFeed = errors.New("invalid threat feed")
+)
+
+// ThreatIntelService reports loaded indicator feeds and manages uploaded ones
+type ThreatIntelService struct {
+	pgRepo *repository.PostgresRepository
+	store  *threatintel.Store
+}
+
+// NewThreatIntelService creates a new threat intel service. store may be nil
+// when threat intel is disabled.
+func NewThreatIntelService(pgRepo *repository.PostgresRepository, store *threatintel.Store) *ThreatIntelService {
+	return &ThreatIntelService{
+		pgRepo: pgRepo,
+		store:  store,
+	}
+}
+
+// ListFeeds returns the loaded feeds
+func (s *ThreatIntelService) ListFeeds() *model.ThreatFeedListResponse {
+	feeds := []model.ThreatFeed{}
+	if s.store != nil {
+		feeds = s.store.Feeds()
+	}
+
+	return &model.ThreatFeedListResponse{
+		Count: len(feeds),
+		Feeds: feeds,
+	}
+}
+
+// UploadFeed stores a feed on behalf of actor, replacing an uploaded feed of
+// the same name, and loads it right away
+func (s *ThreatIntelService) UploadFeed(ctx context.Context, upload model.ThreatFeedUpload, data []byte, actor string) (*model.ThreatFeed, error) {
+	if s.store == nil {
+		return nil, ErrThreatIntelDisabled
+	}
+	if s.store.IsConfigured(upload.Name) {
+		return nil, ErrFeedConfigured
+	}
+
+	now := time.Now().UTC()
+	indicators, _, err := threatintel.ParseFeed(upload.Format, data, now)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFeed, err)
+	}
+	if len(indicators) == 0 {
+		return nil, fmt.Errorf("%w: no usable indicators", ErrInvalidFeed)
+	}
+
+	feed := repository.ThreatFeed{
+		Name:       upload.Name,
+		Format:     upload.Format,
+		Severity:   upload.Severity,
+		Data:       data,
+		UploadedBy: actor,
+		UploadedAt: now,
+	}
+	if upload.ExpiresIn != "" {
+		expiresIn, err := time.ParseDuration(upload.ExpiresIn)
+		if err != nil {
+			return nil, err
+		}
+		expiresAt := now.Add(expiresIn)
+		feed.ExpiresAt = &expiresAt
+	}
+
+	if err := s.pgRepo.UpsertThreatFeed(ctx, feed); err != nil {
+		return nil, err
+	}
+	if err := s.store.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, info := range s.store.Feeds() {
+		if info.Name == upload.Name {
+			return &info, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// ExpireFeed unloads an uploaded feed now
+func (s *ThreatIntelService) ExpireFeed(ctx context.Context, name string) error {
+	if s.store == nil {
+		return ErrThreatIntelDisabled
+	}
+	if s.store.IsConfigured(name) {
+		return ErrFeedConfigured
+	}
+
+	if err := s.pgRepo.ExpireThreatFeed(ctx, name, time.Now().UTC()); err != nil {
+		return err
+	}
+	return s.store.Reload(ctx)
+}
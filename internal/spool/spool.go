@@ -0,0 +1,596 @@
+package spool
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Fsync policies
+const (
+	FsyncAlways   = "always"
+	FsyncInterval = "interval"
+	FsyncNever    = "never"
+)
+
+var (
+	ErrSpoolFull   = errors.New("spool disk usage limit reached")
+	ErrSpoolClosed = errors.New("spool is closed")
+)
+
+const (
+	segmentExt     = ".seg"
+	checkpointFile = "checkpoint.json"
+	// headerSize is the per-record header: 4-byte length + 4-byte CRC32
+	headerSize = 8
+)
+
+// Options configures a spool
+type Options struct {
+	Dir           string
+	SegmentSize   int64
+	Fsync         string
+	FsyncInterval time.Duration
+	MaxDiskUsage  int64
+}
+
+// Record is a record read from the spool
+type Record struct {
+	Seq  uint64
+	Data []byte
+}
+
+// position locates a record within the spool
+type position struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+}
+
+// segment tracks the written size of a segment file
+type segment struct {
+	id   uint64
+	size int64
+}
+
+// Spool is an append-only, segment-file write-ahead log. Records are read back
+// in order and committed once processed; fully committed segments are deleted
+// and the commit position is checkpointed so uncommitted records are replayed
+// after a restart.
+type Spool struct {
+	opts Options
+
+	mu        sync.Mutex
+	segments  []*segment
+	writer    *os.File
+	totalSize int64
+	dirty     bool
+	closed    bool
+
+	// Reader state
+	readPos    position
+	readFile   *os.File
+	readFileID uint64
+	notify     chan struct{}
+
+	// Commit tracking
+	nextSeq      uint64
+	nextUnacked  uint64
+	inflight     map[uint64]position
+	acked        map[uint64]bool
+	checkpointed position
+	// requeued holds read records to deliver again, ahead of unread ones
+	requeued []uint64
+
+	stopSync chan struct{}
+	syncDone chan struct{}
+}
+
+// Open opens or creates a spool in opts.Dir, resuming from the last checkpoint
+func Open(opts Options) (*Spool, error) {
+	switch opts.Fsync {
+	case FsyncAlways, FsyncInterval, FsyncNever:
+	default:
+		return nil, fmt.Errorf("unknown spool fsync policy: %q", opts.Fsync)
+	}
+	if opts.SegmentSize <= 0 {
+		return nil, errors.New("spool segment size must be positive")
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &Spool{
+		opts:     opts,
+		notify:   make(chan struct{}, 1),
+		inflight: make(map[uint64]position),
+		acked:    make(map[uint64]bool),
+	}
+
+	if err := s.loadSegments(); err != nil {
+		return nil, err
+	}
+
+	// Always append to a fresh segment so replayed segments are never modified
+	nextID := uint64(1)
+	if len(s.segments) > 0 {
+		nextID = s.segments[len(s.segments)-1].id + 1
+	}
+	if err := s.openSegment(nextID); err != nil {
+		return nil, err
+	}
+
+	if s.readPos.Segment == 0 {
+		s.readPos = position{Segment: s.segments[0].id}
+	}
+	s.checkpointed = s.readPos
+
+	if opts.Fsync == FsyncInterval && opts.FsyncInterval > 0 {
+		s.stopSync = make(chan struct{})
+		s.syncDone = make(chan struct{})
+		go s.syncLoop()
+	}
+
+	pending := int64(0)
+	for _, seg := range s.segments {
+		pending += seg.size
+	}
+	log.Info().
+		Str("dir", opts.Dir).
+		Int("segments", len(s.segments)).
+		Int64("pending_bytes", pending).
+		Msg("Spool opened")
+
+	return s, nil
+}
+
+// loadSegments discovers existing segments and the last checkpoint
+func (s *Spool) loadSegments() error {
+	entries, err := os.ReadDir(s.opts.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	var checkpoint position
+	if data, err := os.ReadFile(filepath.Join(s.opts.Dir, checkpointFile)); err == nil {
+		if err := json.Unmarshal(data, &checkpoint); err != nil {
+			log.Warn().Err(err).Msg("Ignoring corrupt spool checkpoint")
+			checkpoint = position{}
+		}
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		// Segments before the checkpoint are fully committed
+		if id < checkpoint.Segment {
+			os.Remove(filepath.Join(s.opts.Dir, name))
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat segment %s: %w", name, err)
+		}
+		s.segments = append(s.segments, &segment{id: id, size: info.Size()})
+		s.totalSize += info.Size()
+	}
+
+	sort.Slice(s.segments, func(i, j int) bool {
+		return s.segments[i].id < s.segments[j].id
+	})
+
+	if len(s.segments) > 0 {
+		s.readPos = position{Segment: s.segments[0].id}
+		if checkpoint.Segment == s.segments[0].id {
+			s.readPos = checkpoint
+		}
+	}
+
+	return nil
+}
+
+// openSegment creates a new segment and makes it the write target
+func (s *Spool) openSegment(id uint64) error {
+	f, err := os.OpenFile(s.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create spool segment: %w", err)
+	}
+
+	if s.writer != nil {
+		if err := s.writer.Sync(); err != nil {
+			log.Warn().Err(err).Msg("Failed to sync spool segment")
+		}
+		s.writer.Close()
+	}
+
+	s.writer = f
+	s.segments = append(s.segments, &segment{id: id})
+	return nil
+}
+
+// Append durably appends a record according to the fsync policy
+func (s *Spool) Append(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSpoolClosed
+	}
+
+	recordSize := int64(headerSize + len(data))
+	if s.opts.MaxDiskUsage > 0 && s.totalSize+recordSize > s.opts.MaxDiskUsage {
+		return ErrSpoolFull
+	}
+
+	current := s.segments[len(s.segments)-1]
+	if current.size > 0 && current.size+recordSize > s.opts.SegmentSize {
+		if err := s.openSegment(current.id + 1); err != nil {
+			return err
+		}
+		current = s.segments[len(s.segments)-1]
+	}
+
+	buf := make([]byte, recordSize)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(data))
+	copy(buf[headerSize:], data)
+
+	if _, err := s.writer.Write(buf); err != nil {
+		return fmt.Errorf("failed to write spool record: %w", err)
+	}
+	if s.opts.Fsync == FsyncAlways {
+		if err := s.writer.Sync(); err != nil {
+			return fmt.Errorf("failed to sync spool record: %w", err)
+		}
+	} else {
+		s.dirty = true
+	}
+
+	current.size += recordSize
+	s.totalSize += recordSize
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Next blocks until the next unread record is available or ctx is done
+func (s *Spool) Next(ctx context.Context) (Record, error) {
+	for {
+		rec, ok, err := s.tryNext()
+		if err != nil {
+			return Record{}, err
+		}
+		if ok {
+			return rec, nil
+		}
+
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return Record{}, ctx.Err()
+		}
+	}
+}
+
+// tryNext reads the next record if one has been written
+func (s *Spool) tryNext() (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if s.closed {
+			return Record{}, false, ErrSpoolClosed
+		}
+
+		if rec, ok := s.nextRequeued(); ok {
+			return rec, true, nil
+		}
+
+		seg := s.findSegment(s.readPos.Segment)
+		if seg == nil {
+			return Record{}, false, nil
+		}
+
+		if s.readPos.Offset >= seg.size {
+			next := s.segmentAfter(seg.id)
+			if next == nil {
+				return Record{}, false, nil
+			}
+			s.readPos = position{Segment: next.id}
+			continue
+		}
+
+		data, n, err := s.readRecord(seg, s.readPos.Offset)
+		if err != nil {
+			// A torn or corrupt tail (e.g. crash mid-write) ends the segment
+			log.Warn().
+				Err(err).
+				Uint64("segment", seg.id).
+				Int64("offset", s.readPos.Offset).
+				Msg("Skipping corrupt spool segment tail")
+			s.totalSize -= seg.size - s.readPos.Offset
+			seg.size = s.readPos.Offset
+			continue
+		}
+
+		seq := s.nextSeq
+		s.nextSeq++
+		s.inflight[seq] = s.readPos
+		s.readPos.Offset += n
+
+		return Record{Seq: seq, Data: data}, true, nil
+	}
+}
+
+// nextRequeued rereads the oldest requeued record
+func (s *Spool) nextRequeued() (Record, bool) {
+	for len(s.requeued) > 0 {
+		seq := s.requeued[0]
+		s.requeued = s.requeued[1:]
+
+		pos, ok := s.inflight[seq]
+		if !ok || s.acked[seq] {
+			continue
+		}
+		seg := s.findSegment(pos.Segment)
+		if seg == nil {
+			continue
+		}
+
+		data, _, err := s.readRecord(seg, pos.Offset)
+		if err != nil {
+			// The record was read before, so it cannot be torn; skip it rather
+			// than hold back the commit position forever
+			log.Error().Err(err).Uint64("seq", seq).Msg("Dropping unreadable requeued spool record")
+			s.acked[seq] = true
+			continue
+		}
+		return Record{Seq: seq, Data: data}, true
+	}
+	return Record{}, false
+}
+
+// readRecord reads the record at offset in seg, returning it and its total size
+func (s *Spool) readRecord(seg *segment, offset int64) ([]byte, int64, error) {
+	if s.readFile == nil || s.readFileID != seg.id {
+		if s.readFile != nil {
+			s.readFile.Close()
+		}
+		f, err := os.Open(s.segmentPath(seg.id))
+		if err != nil {
+			return nil, 0, err
+		}
+		s.readFile = f
+		s.readFileID = seg.id
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := s.readFile.ReadAt(header, offset); err != nil {
+		return nil, 0, err
+	}
+
+	length := int64(binary.BigEndian.Uint32(header[0:4]))
+	if offset+headerSize+length > seg.size {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+
+	data := make([]byte, length)
+	if _, err := s.readFile.ReadAt(data, offset+headerSize); err != nil {
+		return nil, 0, err
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, 0, errors.New("spool record checksum mismatch")
+	}
+
+	return data, headerSize + length, nil
+}
+
+// Commit marks records as processed, checkpoints the oldest uncommitted
+// position and deletes segments that are no longer needed
+func (s *Spool) Commit(seqs []uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSpoolClosed
+	}
+
+	for _, seq := range seqs {
+		if _, ok := s.inflight[seq]; ok {
+			s.acked[seq] = true
+		}
+	}
+
+	for s.acked[s.nextUnacked] {
+		delete(s.acked, s.nextUnacked)
+		delete(s.inflight, s.nextUnacked)
+		s.nextUnacked++
+	}
+
+	watermark := s.readPos
+	if pos, ok := s.inflight[s.nextUnacked]; ok {
+		watermark = pos
+	}
+	if watermark == s.checkpointed {
+		return nil
+	}
+
+	if err := s.writeCheckpoint(watermark); err != nil {
+		return err
+	}
+	s.checkpointed = watermark
+
+	// Drop segments entirely before the watermark
+	for len(s.segments) > 1 && s.segments[0].id < watermark.Segment {
+		seg := s.segments[0]
+		if s.readFile != nil && s.readFileID == seg.id {
+			s.readFile.Close()
+			s.readFile = nil
+		}
+		if err := os.Remove(s.segmentPath(seg.id)); err != nil && !os.IsNotExist(err) {
+			log.Warn().Err(err).Uint64("segment", seg.id).Msg("Failed to remove spool segment")
+		}
+		s.totalSize -= seg.size
+		s.segments = s.segments[1:]
+	}
+
+	return nil
+}
+
+// Requeue returns read records that could not be processed to the read
+// path. Next delivers them again, with the same sequence numbers, before any
+// unread record, so a failure does not hold back the commit position until
+// a restart.
+func (s *Spool) Requeue(seqs []uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSpoolClosed
+	}
+
+	for _, seq := range seqs {
+		if _, ok := s.inflight[seq]; ok && !s.acked[seq] {
+			s.requeued = append(s.requeued, seq)
+		}
+	}
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// writeCheckpoint atomically persists the commit position
+func (s *Spool) writeCheckpoint(pos position) error {
+	data, err := json.Marshal(pos)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(s.opts.Dir, checkpointFile+".tmp")
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to write spool checkpoint: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write spool checkpoint: %w", err)
+	}
+	if s.opts.Fsync != FsyncNever {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to sync spool checkpoint: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filepath.Join(s.opts.Dir, checkpointFile))
+}
+
+// DiskUsage returns the number of bytes held in segment files
+func (s *Spool) DiskUsage() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.totalSize
+}
+
+// Close syncs and closes the spool; uncommitted records are replayed on the next Open
+func (s *Spool) Close() error {
+	if s.stopSync != nil {
+		close(s.stopSync)
+		<-s.syncDone
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if s.readFile != nil {
+		s.readFile.Close()
+	}
+
+	if err := s.writer.Sync(); err != nil {
+		s.writer.Close()
+		return err
+	}
+	return s.writer.Close()
+}
+
+// syncLoop periodically fsyncs the active segment for the interval policy
+func (s *Spool) syncLoop() {
+	defer close(s.syncDone)
+
+	ticker := time.NewTicker(s.opts.FsyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.dirty && !s.closed {
+				if err := s.writer.Sync(); err != nil {
+					log.Warn().Err(err).Msg("Failed to sync spool segment")
+				}
+				s.dirty = false
+			}
+			s.mu.Unlock()
+		case <-s.stopSync:
+			return
+		}
+	}
+}
+
+func (s *Spool) findSegment(id uint64) *segment {
+	for _, seg := range s.segments {
+		if seg.id == id {
+			return seg
+		}
+	}
+	return nil
+}
+
+func (s *Spool) segmentAfter(id uint64) *segment {
+	for _, seg := range s.segments {
+		if seg.id > id {
+			return seg
+		}
+	}
+	return nil
+}
+
+func (s *Spool) segmentPath(id uint64) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%020d%s", id, segmentExt))
+}
@@ -0,0 +1,205 @@
+package spool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOptions(dir string) Options {
+	return Options{
+		Dir:          dir,
+		SegmentSize:  64,
+		Fsync:        FsyncNever,
+		MaxDiskUsage: 1 << 20,
+	}
+}
+
+func readN(t *testing.T, s *Spool, n int) []Record {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	records := make([]Record, 0, n)
+	for i := 0; i < n; i++ {
+		rec, err := s.Next(ctx)
+		require.NoError(t, err)
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestAppendAndRead(t *testing.T) {
+	s, err := Open(testOptions(t.TempDir()))
+	require.NoError(t, err)
+	defer s.Close()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, s.Append([]byte(fmt.Sprintf("record-%d", i))))
+	}
+
+	records := readN(t, s, 10)
+	for i, rec := range records {
+		assert.Equal(t, fmt.Sprintf("record-%d", i), string(rec.Data))
+	}
+
+	// Nothing more to read
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = s.Next(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestNextWaitsForAppend(t *testing.T) {
+	s, err := Open(testOptions(t.TempDir()))
+	require.NoError(t, err)
+	defer s.Close()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.Append([]byte("late"))
+	}()
+
+	records := readN(t, s, 1)
+	assert.Equal(t, "late", string(records[0].Data))
+}
+
+func TestReplayUncommitted(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(testOptions(dir))
+	require.NoError(t, err)
+	for i := 0; i < 6; i++ {
+		require.NoError(t, s.Append([]byte(fmt.Sprintf("record-%d", i))))
+	}
+
+	records := readN(t, s, 6)
+	// Commit out of order: record 3 is committed but 2 is not
+	require.NoError(t, s.Commit([]uint64{records[0].Seq, records[1].Seq, records[3].Seq}))
+	require.NoError(t, s.Close())
+
+	s, err = Open(testOptions(dir))
+	require.NoError(t, err)
+	defer s.Close()
+
+	replayed := readN(t, s, 4)
+	assert.Equal(t, "record-2", string(replayed[0].Data))
+	assert.Equal(t, "record-5", string(replayed[3].Data))
+}
+
+func TestCommitDeletesSegments(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(testOptions(dir))
+	require.NoError(t, err)
+	defer s.Close()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, s.Append([]byte(fmt.Sprintf("record-%02d", i))))
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.Greater(t, len(segments), 2)
+
+	records := readN(t, s, 20)
+	seqs := make([]uint64, len(records))
+	for i, rec := range records {
+		seqs[i] = rec.Seq
+	}
+	require.NoError(t, s.Commit(seqs))
+
+	segments, _ = filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	assert.Len(t, segments, 1)
+}
+
+func TestRequeueLetsWatermarkAdvance(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(testOptions(dir))
+	require.NoError(t, err)
+	defer s.Close()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, s.Append([]byte(fmt.Sprintf("record-%02d", i))))
+	}
+	records := readN(t, s, 20)
+	seqs := func(records []Record) []uint64 {
+		out := make([]uint64, len(records))
+		for i, rec := range records {
+			out[i] = rec.Seq
+		}
+		return out
+	}
+
+	// The first batch fails and a later one is stored
+	require.NoError(t, s.Requeue(seqs(records[:5])))
+	require.NoError(t, s.Commit(seqs(records[5:])))
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.Greater(t, len(segments), 1, "the failed batch holds back the watermark")
+
+	// The failed batch is delivered again, without a restart
+	retried := readN(t, s, 5)
+	for i, rec := range retried {
+		assert.Equal(t, records[i].Seq, rec.Seq)
+		assert.Equal(t, fmt.Sprintf("record-%02d", i), string(rec.Data))
+	}
+	require.NoError(t, s.Commit(seqs(retried)))
+
+	segments, _ = filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	assert.Len(t, segments, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = s.Next(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestDiskUsageLimit(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	opts.MaxDiskUsage = 40
+
+	s, err := Open(opts)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Append(make([]byte, 20)))
+	assert.ErrorIs(t, s.Append(make([]byte, 20)), ErrSpoolFull)
+}
+
+func TestCorruptTailIsSkipped(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(testOptions(dir))
+	require.NoError(t, err)
+	require.NoError(t, s.Append([]byte("good")))
+	require.NoError(t, s.Close())
+
+	// Simulate a torn write at the end of the segment
+	f, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("%020d%s", 1, segmentExt)), os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	f.Write([]byte{0, 0, 0, 50, 1, 2})
+	f.Close()
+
+	s, err = Open(testOptions(dir))
+	require.NoError(t, err)
+	defer s.Close()
+	require.NoError(t, s.Append([]byte("after")))
+
+	records := readN(t, s, 2)
+	assert.Equal(t, "good", string(records[0].Data))
+	assert.Equal(t, "after", string(records[1].Data))
+}
+
+func TestOpenRejectsUnknownFsync(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	opts.Fsync = "sometimes"
+
+	_, err := Open(opts)
+	assert.Error(t, err)
+}
@@ -0,0 +1,374 @@
+package syslog
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+var (
+	ErrEmptyMessage   = errors.New("empty syslog message")
+	ErrInvalidPRI     = errors.New("invalid syslog PRI")
+	ErrInvalidHeader  = errors.New("invalid syslog header")
+	ErrInvalidSDBlock = errors.New("invalid structured data")
+)
+
+// nilValue is the RFC 5424 NILVALUE placeholder
+const nilValue = "-"
+
+// Message represents a parsed syslog message
+type Message struct {
+	Facility       int
+	Severity       int
+	Timestamp      time.Time
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData map[string]map[string]string
+	Message        string
+}
+
+// Parse parses a syslog message, detecting RFC 5424 or RFC 3164 format
+func Parse(data []byte) (*Message, error) {
+	line := strings.TrimRight(string(data), "\r\n\x00")
+	if line == "" {
+		return nil, ErrEmptyMessage
+	}
+
+	pri, rest, err := parsePRI(line)
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC 5424 messages carry a version number right after PRI
+	if strings.HasPrefix(rest, "1 ") {
+		return parseRFC5424(pri, rest[2:])
+	}
+
+	return parseRFC3164(pri, rest, time.Now()), nil
+}
+
+// SeverityFromSyslog maps a syslog severity (0-7) onto a model severity
+func SeverityFromSyslog(severity int) string {
+	switch {
+	case severity <= 2: // emergency, alert, critical
+		return model.SeverityCritical
+	case severity == 3: // error
+		return model.SeverityHigh
+	case severity == 4: // warning
+		return model.SeverityMedium
+	case severity == 5: // notice
+		return model.SeverityLow
+	default: // informational, debug
+		return model.SeverityInfo
+	}
+}
+
+// LogEvent converts the syslog message into a log event
+func (m *Message) LogEvent(remoteAddr string) model.LogEvent {
+	source := m.Hostname
+	if source == "" {
+		source = m.AppName
+	}
+	if source == "" {
+		source = remoteAddr
+	}
+	source = truncate(source, 255)
+
+	message := m.Message
+	if message == "" {
+		message = "(empty)"
+	}
+	message = truncate(message, 4096)
+
+	timestamp := m.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	return model.LogEvent{
+		ID:         uuid.New().String(),
+		Timestamp:  timestamp,
+		Severity:   SeverityFromSyslog(m.Severity),
+		Source:     source,
+		Message:    message,
+		Attributes: m.attributes(),
+	}
+}
+
+// truncate shortens s to at most max bytes without splitting a UTF-8 sequence
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
+
+// attributes returns the syslog header fields and structured data as event attributes
+func (m *Message) attributes() map[string]any {
+	attrs := map[string]any{
+		"syslog_facility": m.Facility,
+	}
+	if m.Hostname != "" {
+		attrs["host"] = m.Hostname
+	}
+	if m.AppName != "" {
+		attrs["app_name"] = m.AppName
+	}
+	if m.ProcID != "" {
+		attrs["proc_id"] = m.ProcID
+	}
+	if m.MsgID != "" {
+		attrs["msg_id"] = m.MsgID
+	}
+	if len(m.StructuredData) > 0 {
+		sd := make(map[string]any, len(m.StructuredData))
+		for id, params := range m.StructuredData {
+			values := make(map[string]any, len(params))
+			for name, value := range params {
+				values[name] = value
+			}
+			sd[id] = values
+		}
+		attrs["structured_data"] = sd
+	}
+	return attrs
+}
+
+// parsePRI parses the leading <PRI> part of a message
+func parsePRI(line string) (int, string, error) {
+	if len(line) < 3 || line[0] != '<' {
+		return 0, "", ErrInvalidPRI
+	}
+
+	end := strings.IndexByte(line, '>')
+	if end < 2 || end > 4 {
+		return 0, "", ErrInvalidPRI
+	}
+
+	pri, err := strconv.Atoi(line[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, "", ErrInvalidPRI
+	}
+
+	return pri, line[end+1:], nil
+}
+
+// parseRFC5424 parses the part of an RFC 5424 message following "<PRI>1 "
+func parseRFC5424(pri int, rest string) (*Message, error) {
+	msg := &Message{
+		Facility: pri / 8,
+		Severity: pri % 8,
+	}
+
+	fields := make([]string, 5)
+	for i := range fields {
+		sp := strings.IndexByte(rest, ' ')
+		if sp < 0 {
+			return nil, ErrInvalidHeader
+		}
+		fields[i] = rest[:sp]
+		rest = rest[sp+1:]
+	}
+
+	if fields[0] != nilValue {
+		ts, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return nil, ErrInvalidHeader
+		}
+		msg.Timestamp = ts
+	}
+
+	msg.Hostname = nilToEmpty(fields[1])
+	msg.AppName = nilToEmpty(fields[2])
+	msg.ProcID = nilToEmpty(fields[3])
+	msg.MsgID = nilToEmpty(fields[4])
+
+	sd, rest, err := parseStructuredData(rest)
+	if err != nil {
+		return nil, err
+	}
+	msg.StructuredData = sd
+
+	rest = strings.TrimPrefix(rest, " ")
+	rest = strings.TrimPrefix(rest, "\ufeff")
+	msg.Message = rest
+
+	return msg, nil
+}
+
+// parseStructuredData parses RFC 5424 STRUCTURED-DATA and returns the remainder
+func parseStructuredData(s string) (map[string]map[string]string, string, error) {
+	if strings.HasPrefix(s, nilValue) {
+		return nil, s[1:], nil
+	}
+	if !strings.HasPrefix(s, "[") {
+		return nil, "", ErrInvalidSDBlock
+	}
+
+	sd := make(map[string]map[string]string)
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+
+		end := strings.IndexAny(s, " ]")
+		if end <= 0 {
+			return nil, "", ErrInvalidSDBlock
+		}
+		id := s[:end]
+		s = s[end:]
+		params := make(map[string]string)
+
+		for {
+			s = strings.TrimLeft(s, " ")
+			if s == "" {
+				return nil, "", ErrInvalidSDBlock
+			}
+			if s[0] == ']' {
+				s = s[1:]
+				break
+			}
+
+			eq := strings.Index(s, "=\"")
+			if eq <= 0 {
+				return nil, "", ErrInvalidSDBlock
+			}
+			name := s[:eq]
+			s = s[eq+2:]
+
+			var value strings.Builder
+			closed := false
+			for i := 0; i < len(s); i++ {
+				c := s[i]
+				if c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\' || s[i+1] == ']') {
+					value.WriteByte(s[i+1])
+					i++
+					continue
+				}
+				if c == '"' {
+					s = s[i+1:]
+					closed = true
+					break
+				}
+				value.WriteByte(c)
+			}
+			if !closed {
+				return nil, "", ErrInvalidSDBlock
+			}
+			params[name] = value.String()
+		}
+
+		sd[id] = params
+	}
+
+	return sd, s, nil
+}
+
+// rfc3164Layouts are the timestamp layouts accepted in BSD syslog headers
+var rfc3164Layouts = []string{
+	time.StampMicro,
+	time.Stamp,
+	time.RFC3339Nano,
+}
+
+// parseRFC3164 parses the part of an RFC 3164 message following "<PRI>".
+// BSD syslog is loosely specified, so parsing is best-effort and never fails.
+func parseRFC3164(pri int, rest string, now time.Time) *Message {
+	msg := &Message{
+		Facility: pri / 8,
+		Severity: pri % 8,
+	}
+
+	rest, msg.Timestamp = parseRFC3164Timestamp(rest, now)
+
+	// HOSTNAME is present only when a timestamp was found and the next
+	// token does not look like a TAG
+	if !msg.Timestamp.IsZero() {
+		if sp := strings.IndexByte(rest, ' '); sp > 0 {
+			token := rest[:sp]
+			if !strings.HasSuffix(token, ":") && !strings.ContainsRune(token, '[') {
+				msg.Hostname = token
+				rest = rest[sp+1:]
+			}
+		}
+	}
+
+	msg.AppName, msg.ProcID, rest = parseTag(rest)
+	msg.Message = strings.TrimPrefix(rest, " ")
+
+	return msg
+}
+
+// parseRFC3164Timestamp extracts the leading BSD timestamp, if any
+func parseRFC3164Timestamp(s string, now time.Time) (string, time.Time) {
+	for _, layout := range rfc3164Layouts {
+		n := len(layout)
+		if layout == time.RFC3339Nano {
+			n = strings.IndexByte(s, ' ')
+		}
+		if n <= 0 || len(s) < n {
+			continue
+		}
+
+		ts, err := time.ParseInLocation(layout, s[:n], now.Location())
+		if err != nil {
+			continue
+		}
+
+		if layout != time.RFC3339Nano {
+			// BSD timestamps carry no year; assume the most recent one
+			ts = ts.AddDate(now.Year(), 0, 0)
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+		}
+
+		return strings.TrimPrefix(s[n:], " "), ts
+	}
+
+	return s, time.Time{}
+}
+
+// parseTag extracts "TAG[PID]:" from the start of the content
+func parseTag(s string) (string, string, string) {
+	end := strings.IndexAny(s, ":[ ")
+	if end <= 0 || end > 48 {
+		return "", "", s
+	}
+
+	tag := s[:end]
+	rest := s[end:]
+	procID := ""
+
+	if rest[0] == '[' {
+		closeIdx := strings.IndexByte(rest, ']')
+		if closeIdx < 0 {
+			return "", "", s
+		}
+		procID = rest[1:closeIdx]
+		rest = rest[closeIdx+1:]
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return "", "", s
+	}
+
+	return tag, procID, rest[1:]
+}
+
+func nilToEmpty(s string) string {
+	if s == nilValue {
+		return ""
+	}
+	return s
+}
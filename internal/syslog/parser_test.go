@@ -0,0 +1,180 @@
+package syslog
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRFC5424(t *testing.T) {
+	raw := `<165>1 2026-01-29T12:00:00.003Z fw-01 sshd 1234 ID47 [exampleSDID@32473 iut="3" eventSource="App\"lication"][meta seq="1"] Failed password for root`
+
+	msg, err := Parse([]byte(raw))
+	require.NoError(t, err)
+
+	assert.Equal(t, 20, msg.Facility)
+	assert.Equal(t, 5, msg.Severity)
+	assert.Equal(t, time.Date(2026, 1, 29, 12, 0, 0, 3000000, time.UTC), msg.Timestamp)
+	assert.Equal(t, "fw-01", msg.Hostname)
+	assert.Equal(t, "sshd", msg.AppName)
+	assert.Equal(t, "1234", msg.ProcID)
+	assert.Equal(t, "ID47", msg.MsgID)
+	assert.Equal(t, `App"lication`, msg.StructuredData["exampleSDID@32473"]["eventSource"])
+	assert.Equal(t, "1", msg.StructuredData["meta"]["seq"])
+	assert.Equal(t, "Failed password for root", msg.Message)
+}
+
+func TestParseRFC5424NilValues(t *testing.T) {
+	msg, err := Parse([]byte("<11>1 - - app - - - \ufeffdisk failure"))
+	require.NoError(t, err)
+
+	assert.True(t, msg.Timestamp.IsZero())
+	assert.Empty(t, msg.Hostname)
+	assert.Equal(t, "app", msg.AppName)
+	assert.Nil(t, msg.StructuredData)
+	assert.Equal(t, "disk failure", msg.Message)
+}
+
+func TestParseRFC3164(t *testing.T) {
+	now := time.Date(2026, 1, 29, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		input    string
+		hostname string
+		appName  string
+		procID   string
+		message  string
+	}{
+		{
+			name:     "full header",
+			input:    "Jan 29 11:59:58 host1 sshd[42]: Accepted publickey",
+			hostname: "host1",
+			appName:  "sshd",
+			procID:   "42",
+			message:  "Accepted publickey",
+		},
+		{
+			name:    "missing hostname",
+			input:   "Jan  5 10:00:00 kernel: link down",
+			appName: "kernel",
+			message: "link down",
+		},
+		{
+			name:    "no header",
+			input:   "just some text",
+			message: "just some text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := parseRFC3164(34, tt.input, now)
+
+			assert.Equal(t, 4, msg.Facility)
+			assert.Equal(t, 2, msg.Severity)
+			assert.Equal(t, tt.hostname, msg.Hostname)
+			assert.Equal(t, tt.appName, msg.AppName)
+			assert.Equal(t, tt.procID, msg.ProcID)
+			assert.Equal(t, tt.message, msg.Message)
+		})
+	}
+}
+
+func TestParseRFC3164YearRollover(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 5, 0, time.UTC)
+
+	msg := parseRFC3164(13, "Dec 31 23:59:59 host1 app: msg", now)
+	assert.Equal(t, 2025, msg.Timestamp.Year())
+}
+
+func TestParseInvalidPRI(t *testing.T) {
+	for _, input := range []string{"", "no pri", "<>msg", "<999>msg", "<abc>msg"} {
+		_, err := Parse([]byte(input))
+		assert.Error(t, err, input)
+	}
+}
+
+func TestSeverityFromSyslog(t *testing.T) {
+	expected := []string{
+		model.SeverityCritical,
+		model.SeverityCritical,
+		model.SeverityCritical,
+		model.SeverityHigh,
+		model.SeverityMedium,
+		model.SeverityLow,
+		model.SeverityInfo,
+		model.SeverityInfo,
+	}
+
+	for sev, want := range expected {
+		assert.Equal(t, want, SeverityFromSyslog(sev))
+	}
+}
+
+func TestMessageLogEvent(t *testing.T) {
+	msg := &Message{Severity: 3, AppName: "nginx", Message: "upstream timed out"}
+
+	event := msg.LogEvent("10.0.0.1")
+	assert.NotEmpty(t, event.ID)
+	assert.Equal(t, "nginx", event.Source)
+	assert.Equal(t, model.SeverityHigh, event.Severity)
+	assert.False(t, event.Timestamp.IsZero())
+
+	assert.Equal(t, "nginx", event.Attributes["app_name"])
+	assert.Equal(t, 0, event.Attributes["syslog_facility"])
+
+	event = (&Message{Message: "x"}).LogEvent("10.0.0.1")
+	assert.Equal(t, "10.0.0.1", event.Source)
+
+	// Long fields are cut on rune boundaries
+	event = (&Message{Hostname: strings.Repeat("é", 200), Message: strings.Repeat("€", 2000)}).LogEvent("10.0.0.1")
+	assert.Len(t, event.Source, 254)
+	assert.True(t, utf8.ValidString(event.Source))
+	assert.Len(t, event.Message, 4095)
+	assert.True(t, utf8.ValidString(event.Message))
+}
+
+func TestReadFrame(t *testing.T) {
+	stream := "9 <13>hello<13>line one\n\n<13>line two\r\n"
+	r := bufio.NewReader(strings.NewReader(stream))
+
+	frame, err := ReadFrame(r, FramingAuto, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "<13>hello", string(frame))
+
+	frame, err = ReadFrame(r, FramingAuto, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "<13>line one", string(frame))
+
+	frame, err = ReadFrame(r, FramingNewline, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "<13>line two", string(frame))
+}
+
+func TestReadFrameTooLarge(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("100 <13>msg"))
+
+	_, err := ReadFrame(r, FramingOctetCounting, 10)
+	assert.ErrorIs(t, err, ErrMessageTooLarge)
+}
+
+func TestReadFrameRejectsBadOctetCounts(t *testing.T) {
+	for _, stream := range []string{"12a <13>msg", "0 <13>msg", " <13>msg", "05 <13>msg"} {
+		r := bufio.NewReader(strings.NewReader(stream))
+		_, err := ReadFrame(r, FramingOctetCounting, 1024)
+		assert.Error(t, err, stream)
+	}
+
+	// Digits beyond the width of the max size are rejected without reading on
+	src := strings.NewReader(strings.Repeat("9", 1<<20))
+	_, err := ReadFrame(bufio.NewReader(src), FramingOctetCounting, 1024)
+	assert.ErrorIs(t, err, ErrMessageTooLarge)
+	assert.Greater(t, src.Len(), 1<<19)
+}
@@ -0,0 +1,335 @@
+package syslog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/config"
+	"github.com/Saumajitt/threatLog/internal/tenant"
+	"github.com/Saumajitt/threatLog/internal/worker"
+	"github.com/Saumajitt/threatLog/pkg/validator"
+)
+
+// TCP framing modes (RFC 6587)
+const (
+	FramingAuto          = "auto"
+	FramingOctetCounting = "octet_counting"
+	FramingNewline       = "newline"
+)
+
+var ErrMessageTooLarge = errors.New("syslog message exceeds max size")
+
+// Stats counts the syslog messages received and dropped
+type Stats struct {
+	TenantID  string `json:"tenant_id"`
+	Received  int64  `json:"received"`
+	Throttled int64  `json:"throttled"`
+}
+
+// Server receives syslog messages over UDP and TCP and submits them to the worker pool
+type Server struct {
+	cfg     config.SyslogConfig
+	pool    *worker.Pool
+	limiter *tenant.Limiter
+	udpConn net.PacketConn
+	tcpLn   net.Listener
+	conns   map[net.Conn]struct{}
+	connsMu sync.Mutex
+	wg      sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	received  atomic.Int64
+	throttled atomic.Int64
+}
+
+// NewServer creates a new syslog server. Messages over the ingestion rate
+// of the syslog tenant are dropped, since syslog senders cannot be asked
+// to retry.
+func NewServer(cfg config.SyslogConfig, pool *worker.Pool, limiter *tenant.Limiter) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Server{
+		cfg:     cfg,
+		pool:    pool,
+		limiter: limiter,
+		conns:   make(map[net.Conn]struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Stats returns the message counters
+func (s *Server) Stats() Stats {
+	return Stats{
+		TenantID:  s.cfg.TenantID,
+		Received:  s.received.Load(),
+		Throttled: s.throttled.Load(),
+	}
+}
+
+// Start opens the configured listeners
+func (s *Server) Start() error {
+	switch s.cfg.Framing {
+	case FramingAuto, FramingOctetCounting, FramingNewline:
+	default:
+		return fmt.Errorf("unknown syslog framing: %q", s.cfg.Framing)
+	}
+
+	if s.cfg.UDPPort > 0 {
+		conn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", s.cfg.UDPPort))
+		if err != nil {
+			return fmt.Errorf("failed to listen on udp port %d: %w", s.cfg.UDPPort, err)
+		}
+		s.udpConn = conn
+
+		s.wg.Add(1)
+		go s.serveUDP()
+
+		log.Info().Int("port", s.cfg.UDPPort).Msg("Syslog UDP listener started")
+	}
+
+	if s.cfg.TCPPort > 0 {
+		ln, err := net.Listen("tcp", fmt.Sprintf(":%d", s.cfg.TCPPort))
+		if err != nil {
+			if s.udpConn != nil {
+				s.udpConn.Close()
+			}
+			return fmt.Errorf("failed to listen on tcp port %d: %w", s.cfg.TCPPort, err)
+		}
+		s.tcpLn = ln
+
+		s.wg.Add(1)
+		go s.serveTCP()
+
+		log.Info().
+			Int("port", s.cfg.TCPPort).
+			Str("framing", s.cfg.Framing).
+			Msg("Syslog TCP listener started")
+	}
+
+	return nil
+}
+
+// Stop closes all listeners and open connections
+func (s *Server) Stop() {
+	log.Info().Msg("Stopping syslog listeners")
+	s.cancel()
+
+	if s.udpConn != nil {
+		s.udpConn.Close()
+	}
+	if s.tcpLn != nil {
+		s.tcpLn.Close()
+	}
+
+	s.connsMu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.connsMu.Unlock()
+
+	s.wg.Wait()
+	log.Info().Msg("Syslog listeners stopped")
+}
+
+// serveUDP reads one message per datagram
+func (s *Server) serveUDP() {
+	defer s.wg.Done()
+
+	buf := make([]byte, s.cfg.MaxMessageSize)
+	for {
+		n, addr, err := s.udpConn.ReadFrom(buf)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+			log.Warn().Err(err).Msg("Syslog UDP read failed")
+			continue
+		}
+
+		s.handleMessage(buf[:n], hostOf(addr))
+	}
+}
+
+// serveTCP accepts TCP connections
+func (s *Server) serveTCP() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.tcpLn.Accept()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+			log.Warn().Err(err).Msg("Syslog TCP accept failed")
+			continue
+		}
+
+		s.connsMu.Lock()
+		s.conns[conn] = struct{}{}
+		s.connsMu.Unlock()
+
+		s.wg.Add(1)
+		go s.handleConn(conn)
+	}
+}
+
+// handleConn reads framed messages from a TCP connection
+func (s *Server) handleConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.connsMu.Lock()
+		delete(s.conns, conn)
+		s.connsMu.Unlock()
+		conn.Close()
+	}()
+
+	remote := hostOf(conn.RemoteAddr())
+	reader := bufio.NewReaderSize(conn, 64*1024)
+
+	for {
+		if s.cfg.ReadTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.cfg.ReadTimeout))
+		}
+
+		frame, err := ReadFrame(reader, s.cfg.Framing, s.cfg.MaxMessageSize)
+		if err != nil {
+			if err != io.EOF && s.ctx.Err() == nil {
+				log.Debug().Err(err).Str("remote_addr", remote).Msg("Syslog TCP connection closed")
+			}
+			return
+		}
+
+		s.handleMessage(frame, remote)
+	}
+}
+
+// handleMessage parses a message and submits it to the worker pool
+func (s *Server) handleMessage(data []byte, remote string) {
+	s.received.Add(1)
+	if err := s.limiter.Allow(s.cfg.TenantID, 1); err != nil {
+		s.throttled.Add(1)
+		log.Debug().Err(err).Str("remote_addr", remote).Msg("Dropping throttled syslog message")
+		return
+	}
+
+	msg, err := Parse(data)
+	if err != nil {
+		log.Debug().Err(err).Str("remote_addr", remote).Msg("Failed to parse syslog message")
+		return
+	}
+
+	event := msg.LogEvent(remote)
+	event.TenantID = s.cfg.TenantID
+	if err := validator.ValidateAttributes(event.Attributes); err != nil {
+		// Keep the event but drop oversized structured data
+		log.Debug().Err(err).Str("remote_addr", remote).Msg("Dropping syslog structured data")
+		delete(event.Attributes, "structured_data")
+	}
+
+	if _, err := s.pool.Submit(event); err != nil {
+		log.Error().Err(err).Msg("Failed to submit syslog message to worker pool")
+	}
+}
+
+// readOctetCount reads the length prefix of an octet-counted frame and the
+// space after it. The prefix is read a byte at a time and rejected once it
+// has more digits than maxSize, so a stream of digits cannot grow the buffer.
+func readOctetCount(r *bufio.Reader, maxSize int) (int, error) {
+	maxDigits := len(strconv.Itoa(maxSize))
+
+	n, digits := 0, 0
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if c == ' ' && digits > 0 {
+			break
+		}
+		if c < '0' || c > '9' || (digits == 0 && c == '0') {
+			return 0, fmt.Errorf("invalid octet count: unexpected %q", c)
+		}
+		if digits++; digits > maxDigits {
+			return 0, ErrMessageTooLarge
+		}
+		n = n*10 + int(c-'0')
+	}
+
+	if n > maxSize {
+		return 0, ErrMessageTooLarge
+	}
+	return n, nil
+}
+
+// ReadFrame reads a single message from a TCP stream using the given framing.
+// In auto mode a leading digit selects octet counting, otherwise newline framing.
+func ReadFrame(r *bufio.Reader, framing string, maxSize int) ([]byte, error) {
+	if framing == FramingAuto {
+		b, err := r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		framing = FramingNewline
+		if b[0] >= '0' && b[0] <= '9' {
+			framing = FramingOctetCounting
+		}
+	}
+
+	if framing == FramingOctetCounting {
+		n, err := readOctetCount(r, maxSize)
+		if err != nil {
+			return nil, err
+		}
+
+		frame := make([]byte, n)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return nil, err
+		}
+		return frame, nil
+	}
+
+	for {
+		var frame []byte
+		for {
+			chunk, isPrefix, err := r.ReadLine()
+			if err != nil {
+				return nil, err
+			}
+			frame = append(frame, chunk...)
+			if len(frame) > maxSize {
+				return nil, ErrMessageTooLarge
+			}
+			if !isPrefix {
+				break
+			}
+		}
+
+		// Skip blank lines between messages
+		if len(frame) > 0 {
+			return frame, nil
+		}
+	}
+}
+
+func hostOf(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
@@ -0,0 +1,23 @@
+package syslog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/internal/config"
+	"github.com/Saumajitt/threatLog/internal/tenant"
+)
+
+func TestHandleMessageThrottled(t *testing.T) {
+	limiter := tenant.NewLimiter(tenant.Quotas{Default: tenant.Quota{IngestRate: 1, IngestBurst: 1}})
+	s := NewServer(config.SyslogConfig{TenantID: "red"}, nil, limiter)
+
+	// The tenant's burst is used up, so the message is dropped before it
+	// reaches the worker pool
+	require.NoError(t, limiter.Allow("red", 1))
+	s.handleMessage([]byte("<34>1 2024-01-01T00:00:00Z host app - - - hello"), "10.0.0.1")
+
+	assert.Equal(t, Stats{TenantID: "red", Received: 1, Throttled: 1}, s.Stats())
+}
@@ -0,0 +1,98 @@
+package tail
+
+import (
+	"strconv"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/pkg/fulltext"
+)
+
+// Filter selects the events delivered to a tail subscription
+type Filter struct {
+	tenantID        string
+	severities      map[string]bool
+	source          string
+	attributes      map[string]string
+	attributeExists []string
+	query           fulltext.Expr
+}
+
+// NewFilter builds a filter from a validated tail request
+func NewFilter(req model.TailRequest) (*Filter, error) {
+	f := &Filter{
+		tenantID:        req.TenantID,
+		source:          req.Source,
+		attributes:      req.Attributes,
+		attributeExists: req.AttributeExists,
+	}
+
+	if len(req.Severity) > 0 {
+		f.severities = make(map[string]bool, len(req.Severity))
+		for _, sev := range req.Severity {
+			f.severities[sev] = true
+		}
+	}
+
+	if req.Query != "" {
+		expr, err := fulltext.Parse(req.Query)
+		if err != nil {
+			return nil, err
+		}
+		f.query = expr
+	}
+
+	if f.tenantID == "" {
+		f.tenantID = model.DefaultTenant
+	}
+
+	return f, nil
+}
+
+// Match reports whether an event passes the filter. Events of other
+// tenants never match.
+func (f *Filter) Match(event model.LogEvent) bool {
+	tenantID := event.TenantID
+	if tenantID == "" {
+		tenantID = model.DefaultTenant
+	}
+	if tenantID != f.tenantID {
+		return false
+	}
+	if f.severities != nil && !f.severities[event.Severity] {
+		return false
+	}
+	if f.source != "" && event.Source != f.source {
+		return false
+	}
+
+	for key, want := range f.attributes {
+		got, ok := attributeString(event.Attributes[key])
+		if !ok || got != want {
+			return false
+		}
+	}
+	for _, key := range f.attributeExists {
+		if _, ok := event.Attributes[key]; !ok {
+			return false
+		}
+	}
+
+	if f.query != nil && !fulltext.Match(f.query, event.Message) {
+		return false
+	}
+	return true
+}
+
+// attributeString formats a scalar attribute the way query filters compare it
+func attributeString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
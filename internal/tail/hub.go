@@ -0,0 +1,270 @@
+// Package tail streams newly stored log events to live subscribers.
+package tail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+)
+
+var (
+	ErrSlowConsumer   = errors.New("tail client is too slow and was disconnected")
+	ErrTooManyClients = errors.New("too many tail clients")
+	ErrHubStopped     = errors.New("tail hub stopped")
+)
+
+// Subscription receives the events that pass its filter
+type Subscription struct {
+	hub    *Hub
+	filter *Filter
+	events chan model.LogEvent
+	done   chan struct{}
+	once   sync.Once
+	err    error
+}
+
+// Events returns the channel of matching events
+func (s *Subscription) Events() <-chan model.LogEvent {
+	return s.events
+}
+
+// Done is closed when the subscription ends
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the subscription ended, or nil if it was closed by the client
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.end(nil)
+	s.hub.unsubscribe(s)
+}
+
+// end closes the subscription once, recording the reason
+func (s *Subscription) end(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+func (s *Subscription) ended() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Hub fans stored events out to tail subscriptions. It observes the worker
+// pool; with Redis, events are published to a channel and every instance
+// delivers what it receives from that channel, so clients see events
+// ingested by any instance.
+type Hub struct {
+	clientBuffer int
+	maxClients   int
+
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+
+	redis    *repository.RedisRepository
+	channel  string
+	outbound chan []model.LogEvent
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewHub creates a tail hub. redisRepo may be nil for single-instance fan-out.
+func NewHub(clientBuffer, maxClients int, redisRepo *repository.RedisRepository, channel string) *Hub {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Hub{
+		clientBuffer: clientBuffer,
+		maxClients:   maxClients,
+		subs:         make(map[*Subscription]struct{}),
+		redis:        redisRepo,
+		channel:      channel,
+		outbound:     make(chan []model.LogEvent, 1024),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start starts the Redis publisher and subscriber
+func (h *Hub) Start() {
+	if h.redis == nil {
+		return
+	}
+
+	h.wg.Add(2)
+	go h.publish()
+	go h.receive()
+}
+
+// Stop ends all subscriptions and stops background loops
+func (h *Hub) Stop() {
+	h.cancel()
+	h.wg.Wait()
+
+	h.mu.Lock()
+	for sub := range h.subs {
+		sub.end(ErrHubStopped)
+		delete(h.subs, sub)
+	}
+	h.mu.Unlock()
+}
+
+// Subscribe registers a subscription for events matching filter
+func (h *Hub) Subscribe(filter *Filter) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.ctx.Err() != nil {
+		return nil, ErrHubStopped
+	}
+	if len(h.subs) >= h.maxClients {
+		return nil, ErrTooManyClients
+	}
+
+	sub := &Subscription{
+		hub:    h,
+		filter: filter,
+		events: make(chan model.LogEvent, h.clientBuffer),
+		done:   make(chan struct{}),
+	}
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Clients returns the number of local subscriptions
+func (h *Hub) Clients() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
+
+func (h *Hub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	delete(h.subs, sub)
+	h.mu.Unlock()
+}
+
+// Observe receives stored events from the worker pool. It never blocks:
+// events are dropped rather than slowing ingestion.
+func (h *Hub) Observe(events []model.LogEvent) {
+	if h.ctx.Err() != nil {
+		return
+	}
+	if h.redis == nil {
+		h.dispatch(events)
+		return
+	}
+
+	select {
+	case h.outbound <- events:
+	default:
+		log.Warn().Int("events", len(events)).Msg("Tail publish buffer full, dropping events")
+	}
+}
+
+// ObserveRepeats delivers the repeats collapsed into stored events, as the
+// stored event with the number of new repeats in its repeat count
+func (h *Hub) ObserveRepeats(events []model.LogEvent) {
+	h.Observe(events)
+}
+
+// dispatch delivers events to matching local subscriptions. A subscription
+// whose buffer is full is ended with ErrSlowConsumer.
+func (h *Hub) dispatch(events []model.LogEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subs {
+		if sub.ended() {
+			continue
+		}
+		for _, event := range events {
+			if !sub.filter.Match(event) {
+				continue
+			}
+			select {
+			case sub.events <- event:
+			default:
+				sub.end(ErrSlowConsumer)
+			}
+			if sub.ended() {
+				break
+			}
+		}
+	}
+}
+
+// publish sends observed events to the Redis channel
+func (h *Hub) publish() {
+	defer h.wg.Done()
+
+	for {
+		select {
+		case events := <-h.outbound:
+			data, err := json.Marshal(events)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to encode tail events")
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(h.ctx, 2*time.Second)
+			if err := h.redis.Publish(ctx, h.channel, data); err != nil && h.ctx.Err() == nil {
+				log.Error().Err(err).Msg("Failed to publish tail events")
+			}
+			cancel()
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
+
+// receive delivers events published by any instance to local subscriptions
+func (h *Hub) receive() {
+	defer h.wg.Done()
+
+	pubsub := h.redis.Subscribe(h.ctx, h.channel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var events []model.LogEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &events); err != nil {
+				log.Error().Err(err).Msg("Discarding undecodable tail message")
+				continue
+			}
+			h.dispatch(events)
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
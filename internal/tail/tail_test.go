@@ -0,0 +1,194 @@
+package tail
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+func testEvent(id, severity, source, message string, attrs map[string]any) model.LogEvent {
+	return model.LogEvent{ID: id, Severity: severity, Source: source, Message: message, Attributes: attrs}
+}
+
+func TestFilterMatch(t *testing.T) {
+	event := testEvent("1", "HIGH", "fw-01", "Blocked connection to evil.com", map[string]any{"port": float64(443), "user": "root"})
+
+	tests := []struct {
+		name string
+		req  model.TailRequest
+		want bool
+	}{
+		{"no filters", model.TailRequest{}, true},
+		{"severity list", model.TailRequest{Severity: []string{"CRITICAL", "HIGH"}}, true},
+		{"severity mismatch", model.TailRequest{Severity: []string{"LOW"}}, false},
+		{"source", model.TailRequest{Source: "fw-02"}, false},
+		{"numeric attribute", model.TailRequest{Attributes: map[string]string{"port": "443"}}, true},
+		{"attribute exists", model.TailRequest{AttributeExists: []string{"user", "host"}}, false},
+		{"text", model.TailRequest{Query: "blocked evil*"}, true},
+		{"negated text", model.TailRequest{Query: "-blocked"}, false},
+		{"default tenant", model.TailRequest{TenantID: model.DefaultTenant}, true},
+		{"other tenant", model.TailRequest{TenantID: "blue"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, f.Match(event))
+		})
+	}
+}
+
+func TestHubDispatch(t *testing.T) {
+	h := NewHub(4, 10, nil, "")
+	defer h.Stop()
+
+	high, err := NewFilter(model.TailRequest{Severity: []string{"HIGH"}})
+	require.NoError(t, err)
+	sub, err := h.Subscribe(high)
+	require.NoError(t, err)
+
+	h.Observe([]model.LogEvent{
+		testEvent("1", "LOW", "a", "x", nil),
+		testEvent("2", "HIGH", "a", "y", nil),
+	})
+
+	select {
+	case event := <-sub.Events():
+		assert.Equal(t, "2", event.ID)
+	case <-time.After(time.Second):
+		t.Fatal("no event delivered")
+	}
+	assert.Empty(t, sub.Events())
+
+	sub.Close()
+	assert.Equal(t, 0, h.Clients())
+	assert.NoError(t, sub.Err())
+}
+
+func TestHubDisconnectsSlowConsumer(t *testing.T) {
+	h := NewHub(2, 10, nil, "")
+	defer h.Stop()
+
+	all, _ := NewFilter(model.TailRequest{})
+	slow, err := h.Subscribe(all)
+	require.NoError(t, err)
+
+	events := make([]model.LogEvent, 5)
+	for i := range events {
+		events[i] = testEvent(fmt.Sprint(i), "INFO", "a", "x", nil)
+	}
+	h.Observe(events)
+
+	select {
+	case <-slow.Done():
+	default:
+		t.Fatal("slow consumer not disconnected")
+	}
+	assert.ErrorIs(t, slow.Err(), ErrSlowConsumer)
+}
+
+func TestHubMaxClients(t *testing.T) {
+	h := NewHub(1, 1, nil, "")
+	all, _ := NewFilter(model.TailRequest{})
+
+	sub, err := h.Subscribe(all)
+	require.NoError(t, err)
+	_, err = h.Subscribe(all)
+	assert.ErrorIs(t, err, ErrTooManyClients)
+
+	h.Stop()
+	assert.ErrorIs(t, sub.Err(), ErrHubStopped)
+	_, err = h.Subscribe(all)
+	assert.ErrorIs(t, err, ErrHubStopped)
+}
+
+func TestWebSocket(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteText([]byte(`{"id":"1"}`), time.Second)
+		conn.ReadLoop()
+	}))
+	defer srv.Close()
+
+	c, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	require.NoError(t, err)
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(5 * time.Second))
+
+	fmt.Fprintf(c, "GET / HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+
+	reader := bufio.NewReader(c)
+	resp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	// Example key and accept value from RFC 6455
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+
+	// Text frame from the server
+	head := make([]byte, 2)
+	_, err = io.ReadFull(reader, head)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x81), head[0])
+	payload := make([]byte, head[1])
+	_, err = io.ReadFull(reader, payload)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"1"}`, string(payload))
+
+	// A masked ping is answered with a pong carrying the same payload
+	mask := []byte{1, 2, 3, 4}
+	ping := []byte("hi")
+	frame := []byte{0x80 | opPing, 0x80 | byte(len(ping))}
+	frame = append(frame, mask...)
+	for i, b := range ping {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err = c.Write(frame)
+	require.NoError(t, err)
+
+	_, err = io.ReadFull(reader, head)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x80|opPong), head[0])
+	pong := make([]byte, head[1])
+	io.ReadFull(reader, pong)
+	assert.Equal(t, "hi", string(pong))
+
+	// Close handshake
+	closePayload := binary.BigEndian.AppendUint16(nil, CloseNormal)
+	frame = []byte{0x80 | opClose, 0x80 | byte(len(closePayload))}
+	frame = append(frame, mask...)
+	for i, b := range closePayload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err = c.Write(frame)
+	require.NoError(t, err)
+
+	_, err = io.ReadFull(reader, head)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x80|opClose), head[0])
+}
+
+func TestUpgradeRejectsPlainRequest(t *testing.T) {
+	rec := httptest.NewRecorder()
+	_, err := Upgrade(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.ErrorIs(t, err, ErrNotWebSocket)
+}
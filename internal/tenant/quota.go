@@ -0,0 +1,110 @@
+package tenant
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Quota holds the limits of a tenant; zero values mean unlimited
+type Quota struct {
+	// IngestRate is the sustained number of events per second accepted over
+	// the API and syslog
+	IngestRate float64
+	// IngestBurst is how many events can be accepted at once; it defaults
+	// to one second of IngestRate
+	IngestBurst int
+	// Retention is how long events are kept
+	Retention time.Duration
+}
+
+// Quotas resolves the quota of each tenant
+type Quotas struct {
+	Default Quota
+	Tenants map[string]Quota
+}
+
+// For returns the quota of a tenant
+func (q Quotas) For(tenantID string) Quota {
+	if quota, ok := q.Tenants[tenantID]; ok {
+		return quota
+	}
+	return q.Default
+}
+
+// QuotaError is returned when a tenant exceeds its ingestion rate
+type QuotaError struct {
+	TenantID   string
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("tenant %s exceeded its ingestion rate, retry after %s", e.TenantID, e.RetryAfter)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter enforces per-tenant ingestion rates with token buckets
+type Limiter struct {
+	quotas Quotas
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewLimiter creates a new limiter
+func NewLimiter(quotas Quotas) *Limiter {
+	return &Limiter{
+		quotas:  quotas,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes n events from the tenant's bucket or returns a *QuotaError.
+// A request larger than the burst is accepted from a full bucket and leaves
+// it in debt, so large batches are slowed down rather than rejected forever.
+func (l *Limiter) Allow(tenantID string, n int) error {
+	quota := l.quotas.For(tenantID)
+	if quota.IngestRate <= 0 {
+		return nil
+	}
+
+	burst := float64(quota.IngestBurst)
+	if burst <= 0 {
+		burst = math.Max(quota.IngestRate, 1)
+	}
+
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[tenantID]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[tenantID] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*quota.IngestRate)
+	b.last = now
+
+	need := math.Min(float64(n), burst)
+	if b.tokens < need {
+		wait := (need - b.tokens) / quota.IngestRate
+		return &QuotaError{
+			TenantID:   tenantID,
+			RetryAfter: time.Duration(math.Ceil(wait * float64(time.Second))),
+		}
+	}
+
+	b.tokens -= float64(n)
+	return nil
+}
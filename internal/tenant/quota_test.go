@@ -0,0 +1,69 @@
+package tenant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(quotas Quotas) (*Limiter, *time.Time) {
+	l := NewLimiter(quotas)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestQuotasFor(t *testing.T) {
+	quotas := Quotas{
+		Default: Quota{IngestRate: 10},
+		Tenants: map[string]Quota{"red": {IngestRate: 100, Retention: time.Hour}},
+	}
+
+	assert.Equal(t, 100.0, quotas.For("red").IngestRate)
+	assert.Equal(t, time.Hour, quotas.For("red").Retention)
+	assert.Equal(t, 10.0, quotas.For("blue").IngestRate)
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	l, _ := newTestLimiter(Quotas{})
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, l.Allow("red", 1000))
+	}
+}
+
+func TestLimiterRefills(t *testing.T) {
+	l, now := newTestLimiter(Quotas{Default: Quota{IngestRate: 10, IngestBurst: 20}})
+
+	require.NoError(t, l.Allow("red", 20))
+
+	err := l.Allow("red", 5)
+	var quotaErr *QuotaError
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, "red", quotaErr.TenantID)
+	assert.Equal(t, 500*time.Millisecond, quotaErr.RetryAfter)
+
+	// Tenants have separate buckets
+	assert.NoError(t, l.Allow("blue", 20))
+
+	*now = now.Add(500 * time.Millisecond)
+	assert.NoError(t, l.Allow("red", 5))
+	assert.Error(t, l.Allow("red", 1))
+}
+
+func TestLimiterLargeBatchGoesIntoDebt(t *testing.T) {
+	l, now := newTestLimiter(Quotas{Default: Quota{IngestRate: 10}})
+
+	// Larger than the burst, but accepted from a full bucket
+	require.NoError(t, l.Allow("red", 30))
+
+	// The bucket is 20 events in debt, so one more event waits 2.1s
+	var quotaErr *QuotaError
+	require.ErrorAs(t, l.Allow("red", 1), &quotaErr)
+	assert.Equal(t, 2100*time.Millisecond, quotaErr.RetryAfter)
+
+	*now = now.Add(3 * time.Second)
+	assert.NoError(t, l.Allow("red", 1))
+}
@@ -0,0 +1,83 @@
+package threatintel
+
+// automaton is an Aho-Corasick automaton finding every occurrence of a set
+// of byte patterns in a single pass over a text
+type automaton struct {
+	nodes   []acNode
+	lengths []int
+}
+
+type acNode struct {
+	next map[byte]int32
+	fail int32
+	// out holds the patterns ending at this node, including through fail links
+	out []int32
+}
+
+func newAutomaton(patterns []string) *automaton {
+	a := &automaton{
+		nodes:   []acNode{{next: make(map[byte]int32)}},
+		lengths: make([]int, len(patterns)),
+	}
+
+	for i, pattern := range patterns {
+		a.lengths[i] = len(pattern)
+		state := int32(0)
+		for j := 0; j < len(pattern); j++ {
+			next, ok := a.nodes[state].next[pattern[j]]
+			if !ok {
+				next = int32(len(a.nodes))
+				a.nodes = append(a.nodes, acNode{next: make(map[byte]int32)})
+				a.nodes[state].next[pattern[j]] = next
+			}
+			state = next
+		}
+		a.nodes[state].out = append(a.nodes[state].out, int32(i))
+	}
+
+	// Breadth-first, so fail links always point at finished nodes
+	queue := make([]int32, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for c, child := range a.nodes[state].next {
+			fail := a.nodes[state].fail
+			for fail != 0 && !a.has(fail, c) {
+				fail = a.nodes[fail].fail
+			}
+			if next, ok := a.nodes[fail].next[c]; ok && next != child {
+				a.nodes[child].fail = next
+			}
+			a.nodes[child].out = append(a.nodes[child].out, a.nodes[a.nodes[child].fail].out...)
+			queue = append(queue, child)
+		}
+	}
+
+	return a
+}
+
+func (a *automaton) has(state int32, c byte) bool {
+	_, ok := a.nodes[state].next[c]
+	return ok
+}
+
+// scan calls fn with the pattern index and start offset of every match in text
+func (a *automaton) scan(text string, fn func(pattern, start, end int)) {
+	state := int32(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		for state != 0 && !a.has(state, c) {
+			state = a.nodes[state].fail
+		}
+		if next, ok := a.nodes[state].next[c]; ok {
+			state = next
+		}
+		for _, p := range a.nodes[state].out {
+			fn(int(p), i+1-a.lengths[p], i+1)
+		}
+	}
+}
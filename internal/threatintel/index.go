@@ -0,0 +1,219 @@
+package threatintel
+
+import (
+	"net/netip"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/pkg/ipscan"
+	"github.com/Saumajitt/threatLog/pkg/validator"
+)
+
+// feedRef is a feed listing an indicator
+type feedRef struct {
+	name     string
+	severity string
+}
+
+// entry is an indicator with the feeds listing it
+type entry struct {
+	indicator Indicator
+	feeds     []*feedRef
+}
+
+// loadedFeed is a feed with its parsed indicators
+type loadedFeed struct {
+	info       model.ThreatFeed
+	indicators []Indicator
+}
+
+// index looks up the indicators of every loaded feed. Whole attribute
+// values are checked against hash sets and a CIDR trie; message text is
+// scanned with an Aho-Corasick automaton for domains, URLs and hashes and
+// for IP addresses, which are looked up in the trie.
+type index struct {
+	exact    map[string]*entry
+	ips      cidrTrie
+	ac       *automaton
+	patterns []*entry
+	size     int
+}
+
+// match is an indicator found in a field of an event
+type match struct {
+	entry *entry
+	field string
+}
+
+func newIndex(feeds []loadedFeed) *index {
+	ix := &index{exact: make(map[string]*entry)}
+
+	entries := make(map[Indicator]*entry)
+	var order []*entry
+	for _, feed := range feeds {
+		ref := &feedRef{name: feed.info.Name, severity: feed.info.Severity}
+		for _, indicator := range feed.indicators {
+			e, ok := entries[indicator]
+			if !ok {
+				e = &entry{indicator: indicator}
+				entries[indicator] = e
+				order = append(order, e)
+			}
+			e.feeds = append(e.feeds, ref)
+		}
+	}
+
+	var patterns []string
+	for _, e := range order {
+		if e.indicator.Type == model.IndicatorIP {
+			prefix, err := netip.ParsePrefix(e.indicator.Value)
+			if err != nil {
+				addr := netip.MustParseAddr(e.indicator.Value)
+				prefix = netip.PrefixFrom(addr, addr.BitLen())
+			}
+			ix.ips.insert(prefix, e)
+			continue
+		}
+
+		ix.exact[e.indicator.Value] = e
+		patterns = append(patterns, e.indicator.Value)
+		ix.patterns = append(ix.patterns, e)
+	}
+	ix.ac = newAutomaton(patterns)
+	ix.size = len(order)
+
+	return ix
+}
+
+// match returns up to max distinct indicators found in an event, in the
+// order they were found: the message first, then attributes by key
+func (ix *index) match(event *model.LogEvent, max int) []match {
+	m := &matcher{index: ix, max: max, seen: make(map[*entry]bool)}
+
+	m.text(event.Message, model.FieldMessage)
+
+	keys := make([]string, 0, len(event.Attributes))
+	for key := range event.Attributes {
+		if key != model.ThreatIntelAttribute {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		m.attribute(event.Attributes[key], model.AttributeFieldPrefix+key, 0)
+	}
+
+	return m.matches
+}
+
+// matcher collects the matches of an event
+type matcher struct {
+	*index
+	max     int
+	seen    map[*entry]bool
+	matches []match
+}
+
+func (m *matcher) full() bool {
+	return len(m.matches) >= m.max
+}
+
+func (m *matcher) add(e *entry, field string) {
+	if e != nil && !m.seen[e] && !m.full() {
+		m.seen[e] = true
+		m.matches = append(m.matches, match{entry: e, field: field})
+	}
+}
+
+// text scans free text for indicators
+func (m *matcher) text(text, field string) {
+	if text == "" {
+		return
+	}
+
+	lower := strings.ToLower(text)
+	m.ac.scan(lower, func(pattern, start, end int) {
+		if (start == 0 || !isIndicatorChar(lower[start-1])) && (end == len(lower) || !isIndicatorChar(lower[end])) {
+			m.add(m.patterns[pattern], field)
+		}
+	})
+
+	for _, addr := range ipscan.Find(text) {
+		m.add(m.ips.lookup(addr), field)
+	}
+}
+
+// attribute checks attribute values, walking nested objects and arrays
+func (m *matcher) attribute(value any, field string, depth int) {
+	if m.full() || depth > validator.MaxAttributeDepth {
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		m.value(v, field)
+	case []any:
+		for _, item := range v {
+			m.attribute(item, field, depth+1)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			m.attribute(v[key], field+"."+key, depth+1)
+		}
+	}
+}
+
+// value checks a whole attribute value: an address, an exact hash, domain
+// or URL, a subdomain of a listed domain, or a URL on a listed host
+func (m *matcher) value(value, field string) {
+	if addr, ok := ipscan.Parse(value); ok {
+		m.add(m.ips.lookup(addr), field)
+		return
+	}
+
+	lower := strings.ToLower(strings.TrimSpace(value))
+	if e, ok := m.exact[lower]; ok {
+		m.add(e, field)
+		return
+	}
+
+	host := lower
+	if strings.Contains(lower, "://") {
+		if u, err := url.Parse(lower); err == nil && u.Host != "" {
+			host = u.Hostname()
+			if addr, ok := ipscan.Parse(host); ok {
+				m.add(m.ips.lookup(addr), field)
+				return
+			}
+		}
+	}
+	m.domain(host, field)
+}
+
+// domain matches a host or any of its parent domains
+func (m *matcher) domain(host, field string) {
+	for host != "" {
+		if e, ok := m.exact[host]; ok && e.indicator.Type == model.IndicatorDomain {
+			m.add(e, field)
+			return
+		}
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok || !strings.Contains(parent, ".") {
+			return
+		}
+		host = parent
+	}
+}
+
+// isIndicatorChar reports characters that continue a domain, URL or hash,
+// so matches inside longer tokens are ignored
+func isIndicatorChar(c byte) bool {
+	return c == '-' || c == '_' || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,117 @@
+package threatintel
+
+import (
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// Indicator is an indicator of compromise with a normalized value: IPs and
+// CIDRs in canonical form, lowercase domains, URLs and hex hashes
+type Indicator struct {
+	Type  string
+	Value string
+}
+
+// refang undoes the usual defanging of indicators in feeds, such as
+// hxxp://evil[.]com
+var refang = strings.NewReplacer("[.]", ".", "(.)", ".", "[:]", ":", "hxxp", "http", "[://]", "://")
+
+// Normalize returns the canonical value of an indicator of a type, and
+// false when value is not a valid indicator of that type
+func Normalize(typ, value string) (string, bool) {
+	value = refang.Replace(strings.TrimSpace(value))
+	if value == "" {
+		return "", false
+	}
+
+	switch typ {
+	case model.IndicatorIP:
+		return normalizeIP(value)
+	case model.IndicatorDomain:
+		return normalizeDomain(value)
+	case model.IndicatorURL:
+		return normalizeURL(value)
+	case model.IndicatorHash:
+		return normalizeHash(value)
+	default:
+		return "", false
+	}
+}
+
+// Infer determines the type of an untyped indicator value
+func Infer(value string) (Indicator, bool) {
+	for _, typ := range []string{model.IndicatorIP, model.IndicatorHash, model.IndicatorURL, model.IndicatorDomain} {
+		if normalized, ok := Normalize(typ, value); ok {
+			return Indicator{Type: typ, Value: normalized}, true
+		}
+	}
+	return Indicator{}, false
+}
+
+func normalizeIP(value string) (string, bool) {
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return addr.Unmap().String(), true
+	}
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return "", false
+	}
+	prefix = prefix.Masked()
+	if prefix.IsSingleIP() {
+		return prefix.Addr().String(), true
+	}
+	return prefix.String(), true
+}
+
+func normalizeDomain(value string) (string, bool) {
+	value = strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(value), "*."), ".")
+	if !strings.Contains(value, ".") || len(value) > 253 {
+		return "", false
+	}
+	if _, err := netip.ParseAddr(value); err == nil {
+		return "", false
+	}
+
+	for _, label := range strings.Split(value, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !('a' <= c && c <= 'z') && !('0' <= c && c <= '9') && c != '-' && c != '_' {
+				return "", false
+			}
+		}
+	}
+	return value, true
+}
+
+func normalizeURL(value string) (string, bool) {
+	value = strings.ToLower(value)
+	if !strings.Contains(value, "://") {
+		return "", false
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", false
+	}
+	return strings.TrimSuffix(value, "/"), true
+}
+
+func normalizeHash(value string) (string, bool) {
+	switch len(value) {
+	case 32, 40, 64, 128: // MD5, SHA-1, SHA-256, SHA-512
+	default:
+		return "", false
+	}
+	value = strings.ToLower(value)
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; !('0' <= c && c <= '9') && !('a' <= c && c <= 'f') {
+			return "", false
+		}
+	}
+	return value, true
+}
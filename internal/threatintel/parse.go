@@ -0,0 +1,294 @@
+package threatintel
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// ErrUnknownFormat is returned for feed formats other than stix, misp and csv
+var ErrUnknownFormat = errors.New("feed format must be stix, misp or csv")
+
+// ParseFeed reads the indicators of a feed. Entries that are not usable
+// indicators, such as unsupported types, expired or revoked STIX indicators
+// and malformed values, are skipped and counted. Duplicates are dropped.
+func ParseFeed(format string, data []byte, now time.Time) ([]Indicator, int, error) {
+	p := &parser{seen: make(map[Indicator]bool)}
+
+	var err error
+	switch format {
+	case model.FeedFormatSTIX:
+		err = p.stix(data, now)
+	case model.FeedFormatMISP:
+		err = p.misp(data)
+	case model.FeedFormatCSV:
+		err = p.csv(data)
+	default:
+		return nil, 0, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return p.indicators, p.skipped, nil
+}
+
+// parser collects the distinct indicators of a feed
+type parser struct {
+	indicators []Indicator
+	seen       map[Indicator]bool
+	skipped    int
+}
+
+// add adds a value of a type, counting it as skipped when it is not valid
+func (p *parser) add(typ, value string) {
+	normalized, ok := Normalize(typ, value)
+	if !ok {
+		p.skipped++
+		return
+	}
+
+	indicator := Indicator{Type: typ, Value: normalized}
+	if !p.seen[indicator] {
+		p.seen[indicator] = true
+		p.indicators = append(p.indicators, indicator)
+	}
+}
+
+// stixComparison matches the comparisons of a STIX pattern, such as
+// [ipv4-addr:value = '198.51.100.1'] or [file:hashes.'SHA-256' = '...']
+var stixComparison = regexp.MustCompile(`([a-z0-9-]+):([A-Za-z0-9_.'-]+)\s*(=|ISSUBSET)\s*'((?:[^'\\]|\\.)*)'`)
+
+type stixObject struct {
+	Type        string     `json:"type"`
+	Pattern     string     `json:"pattern"`
+	PatternType string     `json:"pattern_type"`
+	ValidUntil  *time.Time `json:"valid_until"`
+	Revoked     bool       `json:"revoked"`
+}
+
+// stix reads the indicators of a STIX 2.1 bundle. Patterns whose
+// comparisons must all hold together (AND, FOLLOWEDBY) cannot be matched one
+// value at a time and are skipped.
+func (p *parser) stix(data []byte, now time.Time) error {
+	var bundle struct {
+		Type    string       `json:"type"`
+		Objects []stixObject `json:"objects"`
+	}
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("invalid STIX bundle: %w", err)
+	}
+	if bundle.Type != "bundle" {
+		return errors.New("invalid STIX bundle: type must be bundle")
+	}
+
+	for _, obj := range bundle.Objects {
+		if obj.Type != "indicator" {
+			continue
+		}
+		if obj.Revoked || (obj.ValidUntil != nil && !obj.ValidUntil.After(now)) ||
+			(obj.PatternType != "" && obj.PatternType != "stix") ||
+			strings.Contains(obj.Pattern, " AND ") || strings.Contains(obj.Pattern, "FOLLOWEDBY") {
+			p.skipped++
+			continue
+		}
+
+		comparisons := stixComparison.FindAllStringSubmatch(obj.Pattern, -1)
+		if len(comparisons) == 0 {
+			p.skipped++
+			continue
+		}
+		for _, c := range comparisons {
+			objectType, path, value := c[1], c[2], strings.ReplaceAll(c[4], `\'`, "'")
+			switch {
+			case (objectType == "ipv4-addr" || objectType == "ipv6-addr") && path == "value":
+				p.add(model.IndicatorIP, value)
+			case objectType == "domain-name" && path == "value":
+				p.add(model.IndicatorDomain, value)
+			case objectType == "url" && path == "value":
+				p.add(model.IndicatorURL, value)
+			case objectType == "file" && strings.HasPrefix(path, "hashes."):
+				p.add(model.IndicatorHash, value)
+			default:
+				p.skipped++
+			}
+		}
+	}
+	return nil
+}
+
+type mispAttribute struct {
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	ToIDS   *bool  `json:"to_ids"`
+	Deleted bool   `json:"deleted"`
+}
+
+type mispEvent struct {
+	Attribute []mispAttribute `json:"Attribute"`
+	Object    []struct {
+		Attribute []mispAttribute `json:"Attribute"`
+	} `json:"Object"`
+}
+
+// mispDocument is any of the shapes MISP exports: an event, a list of
+// events, or a search response of events or attributes
+type mispDocument struct {
+	Event     *mispEvent      `json:"Event"`
+	Attribute []mispAttribute `json:"Attribute"`
+	Response  json.RawMessage `json:"response"`
+}
+
+// misp reads the attributes of MISP JSON exports. Attributes explicitly
+// marked as not for detection (to_ids false) are skipped.
+func (p *parser) misp(data []byte) error {
+	if err := p.mispDocuments(data); err != nil {
+		return fmt.Errorf("invalid MISP export: %w", err)
+	}
+	return nil
+}
+
+func (p *parser) mispDocuments(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	var docs []mispDocument
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &docs); err != nil {
+			return err
+		}
+	} else {
+		var doc mispDocument
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+	}
+
+	for _, doc := range docs {
+		if doc.Event != nil {
+			p.mispAttributes(doc.Event.Attribute)
+			for _, obj := range doc.Event.Object {
+				p.mispAttributes(obj.Attribute)
+			}
+		}
+		p.mispAttributes(doc.Attribute)
+		if len(doc.Response) > 0 {
+			if err := p.mispDocuments(doc.Response); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *parser) mispAttributes(attributes []mispAttribute) {
+	for _, attr := range attributes {
+		if attr.Deleted || (attr.ToIDS != nil && !*attr.ToIDS) {
+			p.skipped++
+			continue
+		}
+
+		// Composite types such as ip-dst|port or filename|sha256 hold one
+		// value per part
+		types := strings.Split(attr.Type, "|")
+		values := strings.Split(attr.Value, "|")
+		if len(types) != len(values) {
+			p.skipped++
+			continue
+		}
+
+		added := false
+		for i, typ := range types {
+			if indicatorType, ok := mispTypes[typ]; ok {
+				p.add(indicatorType, values[i])
+				added = true
+			}
+		}
+		if !added {
+			p.skipped++
+		}
+	}
+}
+
+// mispTypes maps MISP attribute types onto indicator types
+var mispTypes = map[string]string{
+	"ip-src":   model.IndicatorIP,
+	"ip-dst":   model.IndicatorIP,
+	"domain":   model.IndicatorDomain,
+	"hostname": model.IndicatorDomain,
+	"url":      model.IndicatorURL,
+	"md5":      model.IndicatorHash,
+	"sha1":     model.IndicatorHash,
+	"sha256":   model.IndicatorHash,
+	"sha512":   model.IndicatorHash,
+}
+
+// csvTypes maps the type column of CSV feeds onto indicator types
+var csvTypes = map[string]string{
+	"ip":       model.IndicatorIP,
+	"ipv4":     model.IndicatorIP,
+	"ipv6":     model.IndicatorIP,
+	"cidr":     model.IndicatorIP,
+	"domain":   model.IndicatorDomain,
+	"hostname": model.IndicatorDomain,
+	"url":      model.IndicatorURL,
+	"hash":     model.IndicatorHash,
+	"md5":      model.IndicatorHash,
+	"sha1":     model.IndicatorHash,
+	"sha256":   model.IndicatorHash,
+	"sha512":   model.IndicatorHash,
+}
+
+// csv reads feeds with a value and an optional type per line. Lines
+// starting with # and a value or indicator header are ignored; values
+// without a type are typed by their form.
+func (p *parser) csv(data []byte) error {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	for line := 0; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid CSV feed: %w", err)
+		}
+
+		value := strings.TrimSpace(record[0])
+		if line == 0 && (strings.EqualFold(value, "value") || strings.EqualFold(value, "indicator")) {
+			continue
+		}
+		if value == "" {
+			continue
+		}
+
+		typ := ""
+		if len(record) > 1 {
+			typ = strings.ToLower(strings.TrimSpace(record[1]))
+		}
+		if typ == "" {
+			indicator, ok := Infer(value)
+			if !ok {
+				p.skipped++
+				continue
+			}
+			typ = indicator.Type
+		} else if typ = csvTypes[typ]; typ == "" {
+			p.skipped++
+			continue
+		}
+
+		p.add(typ, value)
+	}
+}
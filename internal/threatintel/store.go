@@ -0,0 +1,352 @@
+// Package threatintel matches ingested events against indicator of
+// compromise feeds
+package threatintel
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/pkg/validator"
+)
+
+// Feed is a feed read from a local file
+type Feed struct {
+	Name   string
+	Path   string
+	Format string
+	// Severity is the minimum severity of matching events; empty leaves it
+	Severity string
+}
+
+// Validate checks a set of file feeds
+func Validate(feeds []Feed) error {
+	names := make(map[string]bool, len(feeds))
+	for _, feed := range feeds {
+		if err := validator.ValidateFeedName(feed.Name); err != nil {
+			return fmt.Errorf("threat feed %q: %w", feed.Name, err)
+		}
+		if names[feed.Name] {
+			return fmt.Errorf("duplicate threat feed %q", feed.Name)
+		}
+		names[feed.Name] = true
+
+		if err := validator.ValidateFeedFormat(feed.Format); err != nil {
+			return fmt.Errorf("threat feed %q: %w", feed.Name, err)
+		}
+		if feed.Severity != "" && !model.IsValidSeverity(feed.Severity) {
+			return fmt.Errorf("threat feed %q: invalid severity %q", feed.Name, feed.Severity)
+		}
+		if feed.Path == "" {
+			return fmt.Errorf("threat feed %q: path is required", feed.Name)
+		}
+	}
+	return nil
+}
+
+// cachedFeed is a loaded feed with the version of its source: the
+// modification time and size of a file, or the upload time of an upload
+type cachedFeed struct {
+	version string
+	feed    loadedFeed
+}
+
+// Store holds the indicators of file and uploaded feeds and tags matching
+// events. Feeds are reloaded on demand and periodically; a source is only
+// parsed again when it changed, and a feed that fails to load keeps its
+// previous indicators. Uploaded feeds stop matching at the first reload
+// after they expire.
+type Store struct {
+	repo           *repository.PostgresRepository
+	feeds          []Feed
+	reloadInterval time.Duration
+	maxMatches     int
+
+	index atomic.Pointer[index]
+
+	// mu serializes reloads and guards cache and infos
+	mu    sync.Mutex
+	cache map[string]cachedFeed
+	infos []model.ThreatFeed
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewStore creates a new indicator store. Events are tagged with at most
+// maxMatches indicators.
+func NewStore(
+	repo *repository.PostgresRepository,
+	feeds []Feed,
+	reloadInterval time.Duration,
+	maxMatches int,
+) *Store {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &Store{
+		repo:           repo,
+		feeds:          feeds,
+		reloadInterval: reloadInterval,
+		maxMatches:     maxMatches,
+		cache:          make(map[string]cachedFeed),
+		ctx:            ctx,
+		cancel:         cancel,
+		now:            time.Now,
+	}
+	s.index.Store(newIndex(nil))
+	return s
+}
+
+// Start loads feeds and starts the reload loop
+func (s *Store) Start() error {
+	if err := s.Reload(s.ctx); err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go s.reloadLoop()
+
+	return nil
+}
+
+// Stop stops the reload loop
+func (s *Store) Stop() {
+	log.Info().Msg("Stopping threat intel store")
+	s.cancel()
+	s.wg.Wait()
+	log.Info().Msg("Threat intel store stopped")
+}
+
+// IsConfigured reports whether a feed of that name is read from a file
+func (s *Store) IsConfigured(name string) bool {
+	for _, feed := range s.feeds {
+		if feed.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Feeds returns the loaded feeds, by name
+func (s *Store) Feeds() []model.ThreatFeed {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]model.ThreatFeed(nil), s.infos...)
+}
+
+// Reload loads changed feeds and activates the indicators of every feed.
+// Feeds that fail to load are logged and keep their previous indicators;
+// an error is only returned when uploaded feeds cannot be listed.
+func (s *Store) Reload(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	cache := make(map[string]cachedFeed, len(s.cache))
+
+	for _, feed := range s.feeds {
+		cache[feed.Name] = s.loadFile(feed, now)
+	}
+
+	uploads, listErr := s.repo.ListThreatFeeds(ctx, now)
+	if listErr != nil {
+		// Keep the uploaded feeds that were loaded until they expire
+		for name, cached := range s.cache {
+			info := cached.feed.info
+			if info.Origin == model.FeedOriginUpload && (info.ExpiresAt == nil || info.ExpiresAt.After(now)) {
+				cache[name] = cached
+			}
+		}
+	}
+	for _, upload := range uploads {
+		if s.IsConfigured(upload.Name) {
+			log.Warn().Str("feed", upload.Name).Msg("Ignoring uploaded threat feed named like a configured feed")
+			continue
+		}
+		cache[upload.Name] = s.loadUpload(ctx, upload, now)
+	}
+
+	feeds := make([]loadedFeed, 0, len(cache))
+	infos := make([]model.ThreatFeed, 0, len(cache))
+	for _, cached := range cache {
+		feeds = append(feeds, cached.feed)
+		infos = append(infos, cached.feed.info)
+	}
+	sort.Slice(feeds, func(i, j int) bool { return feeds[i].info.Name < feeds[j].info.Name })
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	s.cache = cache
+	s.infos = infos
+	s.index.Store(newIndex(feeds))
+
+	if listErr != nil {
+		return fmt.Errorf("failed to load uploaded threat feeds: %w", listErr)
+	}
+	return nil
+}
+
+// loadFile loads a file feed unless its file is unchanged
+func (s *Store) loadFile(feed Feed, now time.Time) cachedFeed {
+	info := model.ThreatFeed{
+		Name:     feed.Name,
+		Format:   feed.Format,
+		Origin:   model.FeedOriginConfig,
+		Severity: feed.Severity,
+		Path:     feed.Path,
+	}
+
+	stat, err := os.Stat(feed.Path)
+	if err != nil {
+		return s.failed(info, err)
+	}
+	version := strconv.FormatInt(stat.ModTime().UnixNano(), 10) + "-" + strconv.FormatInt(stat.Size(), 10)
+	if cached, ok := s.cache[feed.Name]; ok && cached.version == version && cached.feed.info.Error == "" {
+		return cached
+	}
+
+	data, err := os.ReadFile(feed.Path)
+	if err != nil {
+		return s.failed(info, err)
+	}
+	return s.parse(info, version, data, now)
+}
+
+// loadUpload loads an uploaded feed unless it was not uploaded again
+func (s *Store) loadUpload(ctx context.Context, upload repository.ThreatFeed, now time.Time) cachedFeed {
+	uploadedAt := upload.UploadedAt
+	info := model.ThreatFeed{
+		Name:       upload.Name,
+		Format:     upload.Format,
+		Origin:     model.FeedOriginUpload,
+		Severity:   upload.Severity,
+		UploadedBy: upload.UploadedBy,
+		UploadedAt: &uploadedAt,
+		ExpiresAt:  upload.ExpiresAt,
+	}
+
+	version := strconv.FormatInt(uploadedAt.UnixNano(), 10)
+	if cached, ok := s.cache[upload.Name]; ok && cached.version == version && cached.feed.info.Error == "" {
+		cached.feed.info.ExpiresAt = upload.ExpiresAt
+		return cached
+	}
+
+	data, err := s.repo.GetThreatFeedData(ctx, upload.Name)
+	if err != nil {
+		return s.failed(info, err)
+	}
+	return s.parse(info, version, data, now)
+}
+
+func (s *Store) parse(info model.ThreatFeed, version string, data []byte, now time.Time) cachedFeed {
+	indicators, skipped, err := ParseFeed(info.Format, data, now)
+	if err != nil {
+		return s.failed(info, err)
+	}
+
+	info.Indicators = len(indicators)
+	info.Skipped = skipped
+	info.LoadedAt = now
+
+	log.Info().
+		Str("feed", info.Name).
+		Str("origin", info.Origin).
+		Int("indicators", info.Indicators).
+		Int("skipped", info.Skipped).
+		Msg("Loaded threat feed")
+
+	return cachedFeed{version: version, feed: loadedFeed{info: info, indicators: indicators}}
+}
+
+// failed records a load failure, keeping the indicators previously loaded
+// for the feed
+func (s *Store) failed(info model.ThreatFeed, err error) cachedFeed {
+	log.Error().Err(err).Str("feed", info.Name).Str("origin", info.Origin).Msg("Failed to load threat feed")
+
+	cached, ok := s.cache[info.Name]
+	if !ok || cached.feed.info.Origin != info.Origin {
+		info.Error = err.Error()
+		return cachedFeed{feed: loadedFeed{info: info}}
+	}
+
+	cached.feed.info.Error = err.Error()
+	return cached
+}
+
+// Enrich tags an event with the indicators it matches and the feeds listing
+// them, and raises its severity to the highest severity of those feeds
+func (s *Store) Enrich(event *model.LogEvent) {
+	ix := s.index.Load()
+	if ix.size == 0 {
+		return
+	}
+
+	matches := ix.match(event, s.maxMatches)
+	if len(matches) == 0 {
+		return
+	}
+
+	tags := make([]any, 0, len(matches))
+	severity := ""
+	for _, m := range matches {
+		for _, feed := range m.entry.feeds {
+			tags = append(tags, map[string]any{
+				"indicator": m.entry.indicator.Value,
+				"type":      m.entry.indicator.Type,
+				"feed":      feed.name,
+				"field":     m.field,
+			})
+			if model.SeverityRank(feed.severity) > model.SeverityRank(severity) {
+				severity = feed.severity
+			}
+		}
+	}
+
+	// Attributes may be shared with the caller, so they are copied
+	attributes := make(map[string]any, len(event.Attributes)+2)
+	maps.Copy(attributes, event.Attributes)
+	attributes[model.ThreatIntelAttribute] = tags
+	if model.SeverityRank(severity) > model.SeverityRank(event.Severity) {
+		attributes[model.OriginalSeverityAttribute] = event.Severity
+		event.Severity = severity
+	}
+	event.Attributes = attributes
+}
+
+// reloadLoop periodically reloads feeds
+func (s *Store) reloadLoop() {
+	defer s.wg.Done()
+
+	if s.reloadInterval <= 0 {
+		<-s.ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(s.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Reload(s.ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to reload threat feeds")
+			}
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package threatintel
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+var testNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestParseSTIX(t *testing.T) {
+	bundle := `{
+		"type": "bundle",
+		"objects": [
+			{"type": "indicator", "pattern_type": "stix", "pattern": "[ipv4-addr:value = '198.51.100.0/24']"},
+			{"type": "indicator", "pattern": "[domain-name:value = 'Evil.example.com'] OR [url:value = 'http://bad.example.net/x/']"},
+			{"type": "indicator", "pattern": "[file:hashes.'SHA-256' = 'AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA']"},
+			{"type": "indicator", "pattern": "[ipv4-addr:value = '203.0.113.9'] AND [domain-name:value = 'x.example.org']"},
+			{"type": "indicator", "pattern": "[ipv4-addr:value = '203.0.113.10']", "revoked": true},
+			{"type": "indicator", "pattern": "[ipv4-addr:value = '203.0.113.11']", "valid_until": "2023-01-01T00:00:00Z"},
+			{"type": "indicator", "pattern": "[email-addr:value = 'a@example.com']"},
+			{"type": "malware", "name": "not an indicator"}
+		]
+	}`
+
+	indicators, skipped, err := ParseFeed(model.FeedFormatSTIX, []byte(bundle), testNow)
+	require.NoError(t, err)
+	assert.Equal(t, []Indicator{
+		{Type: model.IndicatorIP, Value: "198.51.100.0/24"},
+		{Type: model.IndicatorDomain, Value: "evil.example.com"},
+		{Type: model.IndicatorURL, Value: "http://bad.example.net/x"},
+		{Type: model.IndicatorHash, Value: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+	}, indicators)
+	assert.Equal(t, 4, skipped)
+
+	_, _, err = ParseFeed(model.FeedFormatSTIX, []byte(`{"type": "report"}`), testNow)
+	assert.Error(t, err)
+}
+
+func TestParseMISP(t *testing.T) {
+	export := `{"response": [{"Event": {
+		"Attribute": [
+			{"type": "ip-dst|port", "value": "192.0.2.1|443", "to_ids": true},
+			{"type": "domain", "value": "evil[.]example.com"},
+			{"type": "md5", "value": "d41d8cd98f00b204e9800998ecf8427e", "to_ids": false},
+			{"type": "comment", "value": "free text"}
+		],
+		"Object": [{"Attribute": [{"type": "url", "value": "hxxp://bad.example.net/a"}]}]
+	}}]}`
+
+	indicators, skipped, err := ParseFeed(model.FeedFormatMISP, []byte(export), testNow)
+	require.NoError(t, err)
+	assert.Equal(t, []Indicator{
+		{Type: model.IndicatorIP, Value: "192.0.2.1"},
+		{Type: model.IndicatorDomain, Value: "evil.example.com"},
+		{Type: model.IndicatorURL, Value: "http://bad.example.net/a"},
+	}, indicators)
+	assert.Equal(t, 2, skipped)
+}
+
+func TestParseCSV(t *testing.T) {
+	feed := "value,type\n" +
+		"# comment\n" +
+		"10.1.0.0/16,cidr\n" +
+		"evil.example.com\n" +
+		"EVIL.example.com,domain\n" +
+		"not an indicator\n" +
+		"x,unknown\n"
+
+	indicators, skipped, err := ParseFeed(model.FeedFormatCSV, []byte(feed), testNow)
+	require.NoError(t, err)
+	assert.Equal(t, []Indicator{
+		{Type: model.IndicatorIP, Value: "10.1.0.0/16"},
+		{Type: model.IndicatorDomain, Value: "evil.example.com"},
+	}, indicators)
+	assert.Equal(t, 2, skipped)
+
+	_, _, err = ParseFeed("yaml", []byte(feed), testNow)
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestCIDRTrieLongestPrefix(t *testing.T) {
+	wide := &entry{indicator: Indicator{Type: model.IndicatorIP, Value: "10.0.0.0/8"}}
+	narrow := &entry{indicator: Indicator{Type: model.IndicatorIP, Value: "10.1.0.0/16"}}
+	v6 := &entry{indicator: Indicator{Type: model.IndicatorIP, Value: "2001:db8::/32"}}
+
+	var trie cidrTrie
+	trie.insert(netip.MustParsePrefix("10.0.0.0/8"), wide)
+	trie.insert(netip.MustParsePrefix("10.1.0.0/16"), narrow)
+	trie.insert(netip.MustParsePrefix("2001:db8::/32"), v6)
+
+	assert.Equal(t, narrow, trie.lookup(netip.MustParseAddr("10.1.2.3")))
+	assert.Equal(t, wide, trie.lookup(netip.MustParseAddr("10.2.2.3")))
+	assert.Equal(t, v6, trie.lookup(netip.MustParseAddr("2001:db8::1")))
+	assert.Nil(t, trie.lookup(netip.MustParseAddr("11.0.0.1")))
+}
+
+func TestAutomatonFindsOverlappingPatterns(t *testing.T) {
+	a := newAutomaton([]string{"he", "she", "hers"})
+
+	var found []string
+	text := "ushers"
+	a.scan(text, func(pattern, start, end int) {
+		found = append(found, text[start:end])
+	})
+	assert.ElementsMatch(t, []string{"she", "he", "hers"}, found)
+}
+
+func newTestStore(feeds ...loadedFeed) *Store {
+	s := NewStore(nil, nil, 0, 10)
+	s.index.Store(newIndex(feeds))
+	return s
+}
+
+func testFeed(name, severity string, indicators ...Indicator) loadedFeed {
+	return loadedFeed{info: model.ThreatFeed{Name: name, Severity: severity}, indicators: indicators}
+}
+
+func TestEnrichTagsMatches(t *testing.T) {
+	s := newTestStore(
+		testFeed("botnet", model.SeverityHigh,
+			Indicator{Type: model.IndicatorIP, Value: "198.51.100.0/24"},
+			Indicator{Type: model.IndicatorDomain, Value: "evil.example.com"}),
+		testFeed("phishing", "",
+			Indicator{Type: model.IndicatorDomain, Value: "evil.example.com"}),
+	)
+
+	event := &model.LogEvent{
+		Severity: model.SeverityLow,
+		Message:  "Connection from 198.51.100.7:443 refused",
+		Attributes: map[string]any{
+			"dns": map[string]any{"query": "cdn.EVIL.example.com"},
+		},
+	}
+	s.Enrich(event)
+
+	assert.Equal(t, model.SeverityHigh, event.Severity)
+	assert.Equal(t, model.SeverityLow, event.Attributes[model.OriginalSeverityAttribute])
+	assert.Equal(t, []any{
+		map[string]any{"indicator": "198.51.100.0/24", "type": model.IndicatorIP, "feed": "botnet", "field": model.FieldMessage},
+		map[string]any{"indicator": "evil.example.com", "type": model.IndicatorDomain, "feed": "botnet", "field": "attr.dns.query"},
+		map[string]any{"indicator": "evil.example.com", "type": model.IndicatorDomain, "feed": "phishing", "field": "attr.dns.query"},
+	}, event.Attributes[model.ThreatIntelAttribute])
+}
+
+func TestEnrichMessageBoundaries(t *testing.T) {
+	s := newTestStore(testFeed("feed", "",
+		Indicator{Type: model.IndicatorDomain, Value: "evil.com"},
+		Indicator{Type: model.IndicatorURL, Value: "http://bad.example.net/x"}))
+
+	event := &model.LogEvent{Severity: model.SeverityInfo, Message: "Lookup of notevil.com and evil.community"}
+	s.Enrich(event)
+	assert.Nil(t, event.Attributes)
+
+	event = &model.LogEvent{Severity: model.SeverityInfo, Message: "GET HTTP://BAD.example.net/x from client"}
+	s.Enrich(event)
+	require.Contains(t, event.Attributes, model.ThreatIntelAttribute)
+	assert.Equal(t, model.SeverityInfo, event.Severity)
+	assert.NotContains(t, event.Attributes, model.OriginalSeverityAttribute)
+}
+
+func TestEnrichKeepsHigherSeverity(t *testing.T) {
+	s := newTestStore(testFeed("feed", model.SeverityMedium,
+		Indicator{Type: model.IndicatorIP, Value: "192.0.2.1"}))
+
+	attributes := map[string]any{"src_ip": "192.0.2.1"}
+	event := &model.LogEvent{Severity: model.SeverityCritical, Attributes: attributes}
+	s.Enrich(event)
+
+	assert.Equal(t, model.SeverityCritical, event.Severity)
+	assert.NotContains(t, event.Attributes, model.OriginalSeverityAttribute)
+	assert.Contains(t, event.Attributes, model.ThreatIntelAttribute)
+	assert.NotContains(t, attributes, model.ThreatIntelAttribute, "caller's attributes are not modified")
+}
+
+func TestEnrichCapsMatches(t *testing.T) {
+	s := newTestStore(testFeed("feed", "",
+		Indicator{Type: model.IndicatorIP, Value: "192.0.2.1"},
+		Indicator{Type: model.IndicatorIP, Value: "192.0.2.2"},
+		Indicator{Type: model.IndicatorIP, Value: "192.0.2.3"}))
+	s.maxMatches = 2
+
+	event := &model.LogEvent{Message: "192.0.2.1 192.0.2.2 192.0.2.3"}
+	s.Enrich(event)
+	assert.Len(t, event.Attributes[model.ThreatIntelAttribute], 2)
+}
+
+func TestLoadFileKeepsIndicatorsOnFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.csv")
+	require.NoError(t, os.WriteFile(path, []byte("192.0.2.1\n"), 0o644))
+
+	s := NewStore(nil, nil, 0, 10)
+	feed := Feed{Name: "local", Path: path, Format: model.FeedFormatCSV}
+
+	cached := s.loadFile(feed, testNow)
+	require.Empty(t, cached.feed.info.Error)
+	assert.Equal(t, 1, cached.feed.info.Indicators)
+	s.cache[feed.Name] = cached
+
+	require.NoError(t, os.Remove(path))
+	cached = s.loadFile(feed, testNow)
+	assert.NotEmpty(t, cached.feed.info.Error)
+	assert.Len(t, cached.feed.indicators, 1)
+}
+
+func TestValidate(t *testing.T) {
+	valid := Feed{Name: "local", Path: "/feeds/local.csv", Format: model.FeedFormatCSV, Severity: model.SeverityHigh}
+	require.NoError(t, Validate([]Feed{valid}))
+
+	assert.Error(t, Validate([]Feed{valid, valid}))
+	assert.Error(t, Validate([]Feed{{Name: "Bad Name", Path: "x", Format: model.FeedFormatCSV}}))
+	assert.Error(t, Validate([]Feed{{Name: "x", Path: "x", Format: "yaml"}}))
+	assert.Error(t, Validate([]Feed{{Name: "x", Path: "x", Format: model.FeedFormatCSV, Severity: "URGENT"}}))
+	assert.Error(t, Validate([]Feed{{Name: "x", Format: model.FeedFormatCSV}}))
+}
@@ -0,0 +1,59 @@
+package threatintel
+
+import "net/netip"
+
+// cidrTrie is a binary trie of IPv4 and IPv6 prefixes for longest prefix
+// lookups of addresses
+type cidrTrie struct {
+	v4, v6 *trieNode
+}
+
+type trieNode struct {
+	child [2]*trieNode
+	entry *entry
+}
+
+func (t *cidrTrie) root(addr netip.Addr) **trieNode {
+	if addr.Is4() {
+		return &t.v4
+	}
+	return &t.v6
+}
+
+// insert adds a prefix; an existing entry for the prefix is replaced
+func (t *cidrTrie) insert(prefix netip.Prefix, e *entry) {
+	root := t.root(prefix.Addr())
+	if *root == nil {
+		*root = &trieNode{}
+	}
+
+	node := *root
+	bytes := prefix.Addr().AsSlice()
+	for i := 0; i < prefix.Bits(); i++ {
+		bit := bytes[i/8] >> (7 - i%8) & 1
+		if node.child[bit] == nil {
+			node.child[bit] = &trieNode{}
+		}
+		node = node.child[bit]
+	}
+	node.entry = e
+}
+
+// lookup returns the entry of the longest prefix containing addr
+func (t *cidrTrie) lookup(addr netip.Addr) *entry {
+	addr = addr.Unmap()
+	node := *t.root(addr)
+
+	var found *entry
+	bytes := addr.AsSlice()
+	for i := 0; node != nil; i++ {
+		if node.entry != nil {
+			found = node.entry
+		}
+		if i == len(bytes)*8 {
+			break
+		}
+		node = node.child[bytes[i/8]>>(7-i%8)&1]
+	}
+	return found
+}
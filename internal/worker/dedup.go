@@ -0,0 +1,17 @@
+package worker
+
+import "github.com/Saumajitt/threatLog/internal/model"
+
+// Deduplicator drops repeated events before they are queued
+type Deduplicator interface {
+	// Collapse reports whether an event repeats a stored one and is dropped,
+	// setting its ID to that of the stored event
+	Collapse(event *model.LogEvent) bool
+	// Forget is called when an event that was not collapsed fails to queue
+	Forget(event model.LogEvent)
+}
+
+// SetDeduplicator sets the deduplicator; it must be called before Start
+func (p *Pool) SetDeduplicator(d Deduplicator) {
+	p.dedup = d
+}
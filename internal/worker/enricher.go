@@ -0,0 +1,23 @@
+package worker
+
+import "github.com/Saumajitt/threatLog/internal/model"
+
+// Enricher adds context to log events before they are stored.
+// Enrich runs on the worker goroutine and must not block.
+type Enricher interface {
+	Enrich(event *model.LogEvent)
+}
+
+// AddEnricher registers an enricher; it must be called before Start
+func (p *Pool) AddEnricher(e Enricher) {
+	p.enrichers = append(p.enrichers, e)
+}
+
+// enrich passes every event of a batch to all enrichers, in order
+func (p *Pool) enrich(batch []model.LogEvent) {
+	for _, e := range p.enrichers {
+		for i := range batch {
+			e.Enrich(&batch[i])
+		}
+	}
+}
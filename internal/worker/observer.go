@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"slices"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// Observer is notified of log events once they have been stored.
+// Observe runs on the worker goroutine and must not block. The events are a
+// copy, so observers may keep them after Observe returns.
+type Observer interface {
+	Observe(events []model.LogEvent)
+}
+
+// AddObserver registers an observer; it must be called before Start
+func (p *Pool) AddObserver(o Observer) {
+	p.observers = append(p.observers, o)
+}
+
+// DeadLetterObserver is notified of log events that will not be stored,
+// once they have been written to the dead-letter table or dropped.
+// ObserveDeadLetters runs on the worker goroutine and must not block.
+type DeadLetterObserver interface {
+	ObserveDeadLetters(deadLetters []model.DeadLetter)
+}
+
+// AddDeadLetterObserver registers a dead-letter observer; it must be called
+// before Start
+func (p *Pool) AddDeadLetterObserver(o DeadLetterObserver) {
+	p.deadLetterObservers = append(p.deadLetterObservers, o)
+}
+
+// notifyObservers passes the stored events of a batch to all observers
+func (p *Pool) notifyObservers(batch []model.LogEvent, deadLetters []model.DeadLetter) {
+	if len(p.observers) == 0 {
+		return
+	}
+
+	// Workers reuse their batch, so observers that hand events to another
+	// goroutine get their own copy
+	var stored []model.LogEvent
+	if len(deadLetters) == 0 {
+		stored = slices.Clone(batch)
+	} else {
+		failed := make(map[string]bool, len(deadLetters))
+		for _, dl := range deadLetters {
+			failed[dl.Log.ID] = true
+		}
+
+		stored = make([]model.LogEvent, 0, len(batch)-len(deadLetters))
+		for _, event := range batch {
+			if !failed[event.ID] {
+				stored = append(stored, event)
+			}
+		}
+	}
+
+	if len(stored) == 0 {
+		return
+	}
+	for _, o := range p.observers {
+		o.Observe(stored)
+	}
+}
+
+// notifyDeadLetterObservers passes the dead letters of a batch to all
+// dead-letter observers
+func (p *Pool) notifyDeadLetterObservers(deadLetters []model.DeadLetter) {
+	for _, o := range p.deadLetterObservers {
+		o.ObserveDeadLetters(deadLetters)
+	}
+}
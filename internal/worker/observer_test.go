@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// asyncObserver hands events to another goroutine, as the tail hub does
+// when publishing to Redis
+type asyncObserver struct {
+	events chan []model.LogEvent
+}
+
+func (o asyncObserver) Observe(events []model.LogEvent) {
+	o.events <- events
+}
+
+func TestNotifyObserversCopiesBatch(t *testing.T) {
+	o := asyncObserver{events: make(chan []model.LogEvent, 2)}
+	p := &Pool{}
+	p.AddObserver(o)
+
+	batch := []model.LogEvent{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	p.notifyObservers(batch, nil)
+	p.notifyObservers(batch, []model.DeadLetter{{Log: model.LogEvent{ID: "2"}}})
+
+	ids := make(chan []string)
+	go func() {
+		var seen []string
+		for range 2 {
+			for _, event := range <-o.events {
+				seen = append(seen, event.ID)
+			}
+		}
+		ids <- seen
+	}()
+
+	// The worker reuses its batch for the next events
+	for i := range batch {
+		batch[i] = model.LogEvent{ID: "next"}
+	}
+
+	assert.Equal(t, []string{"1", "2", "3", "1", "3"}, <-ids)
+}
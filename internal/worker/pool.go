@@ -2,43 +2,66 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
 	"sync"
 	"time"
 
 	
 	"github.com/Saumajitt/threatLog/internal/model"
 	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/Saumajitt/threatLog/internal/spool"
 	"github.com/rs/zerolog/log"
 )
 
+// job is a log event queued for a worker
+type job struct {
+	event model.LogEvent
+	// seq is the spool sequence number, committed once the event is stored
+	seq uint64
+}
+
 // Pool represents a worker pool for processing log events
 type Pool struct {
 	workers      int
-	logChannel   chan model.LogEvent
+	logChannel   chan job
 	batchSize    int
 	batchTimeout time.Duration
 	repo         *repository.PostgresRepository
+	spool        *spool.Spool
+	retry        RetryPolicy
+	observers    []Observer
+	enrichers    []Enricher
+	processor    Processor
+	dedup        Deduplicator
 	wg           sync.WaitGroup
+	feederWg     sync.WaitGroup
 	ctx          context.Context
 	cancel       context.CancelFunc
+
+	deadLetterObservers []DeadLetterObserver
 }
 
-// NewPool creates a new worker pool
+// NewPool creates a new worker pool. When sp is non-nil, submitted events are
+// appended to the spool and workers are fed from it.
 func NewPool(
 	workers int,
 	bufferSize int,
 	batchSize int,
 	batchTimeout time.Duration,
 	repo *repository.PostgresRepository,
+	sp *spool.Spool,
+	retry RetryPolicy,
 ) *Pool {
 	ctx, cancel := context.WithCancel(context.Background())
 	
 	return &Pool{
 		workers:      workers,
-		logChannel:   make(chan model.LogEvent, bufferSize),
+		logChannel:   make(chan job, bufferSize),
 		batchSize:    batchSize,
 		batchTimeout: batchTimeout,
 		repo:         repo,
+		spool:        sp,
+		retry:        retry,
 		ctx:          ctx,
 		cancel:       cancel,
 	}
@@ -56,12 +79,43 @@ func (p *Pool) Start() {
 		p.wg.Add(1)
 		go p.worker(i)
 	}
+
+	if p.spool != nil {
+		p.feederWg.Add(1)
+		go p.feed()
+	}
+}
+
+// Submit submits a log event to the worker pool and returns the ID it is
+// stored under. With a spool, the event is durably written before Submit
+// returns. Repeats dropped by the deduplicator count as submitted, under the
+// ID of the event they repeat.
+func (p *Pool) Submit(log model.LogEvent) (string, error) {
+	if p.dedup == nil {
+		return log.ID, p.submit(log)
+	}
+
+	if p.dedup.Collapse(&log) {
+		return log.ID, nil
+	}
+	err := p.submit(log)
+	if err != nil {
+		p.dedup.Forget(log)
+	}
+	return log.ID, err
 }
 
-// Submit submits a log event to the worker pool
-func (p *Pool) Submit(log model.LogEvent) error {
+func (p *Pool) submit(log model.LogEvent) error {
+	if p.spool != nil {
+		data, err := json.Marshal(log)
+		if err != nil {
+			return err
+		}
+		return p.spool.Append(data)
+	}
+
 	select {
-	case p.logChannel <- log:
+	case p.logChannel <- job{event: log}:
 		return nil
 	case <-p.ctx.Done():
 		return p.ctx.Err()
@@ -74,16 +128,98 @@ func (p *Pool) Submit(log model.LogEvent) error {
 func (p *Pool) Stop() {
 	log.Info().Msg("Stopping worker pool")
 	p.cancel()
+	p.feederWg.Wait()
 	close(p.logChannel)
 	p.wg.Wait()
 	log.Info().Msg("Worker pool stopped")
 }
 
+// feed reads spooled events and hands them to workers. Events still queued
+// at shutdown stay uncommitted in the spool and are replayed on restart.
+func (p *Pool) feed() {
+	defer p.feederWg.Done()
+
+	for {
+		rec, err := p.spool.Next(p.ctx)
+		if err != nil {
+			if p.ctx.Err() == nil {
+				log.Error().Err(err).Msg("Failed to read from spool")
+			}
+			return
+		}
+
+		var event model.LogEvent
+		if err := json.Unmarshal(rec.Data, &event); err != nil {
+			log.Error().Err(err).Uint64("seq", rec.Seq).Msg("Discarding undecodable spool record")
+			p.commit([]uint64{rec.Seq})
+			continue
+		}
+
+		select {
+		case p.logChannel <- job{event: event, seq: rec.Seq}:
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// commit marks spooled events as stored
+func (p *Pool) commit(seqs []uint64) {
+	if p.spool == nil || len(seqs) == 0 {
+		return
+	}
+	if err := p.spool.Commit(seqs); err != nil {
+		log.Error().Err(err).Msg("Failed to commit spool records")
+	}
+}
+
+// requeueFailed commits the spooled events of a batch that were stored or
+// dropped and returns those in failed to the spool to be processed again.
+// ids holds the event IDs of the batch as submitted, in the order of seqs.
+func (p *Pool) requeueFailed(seqs []uint64, ids []string, failed []model.DeadLetter) {
+	if p.spool == nil {
+		return
+	}
+
+	failedIDs := make(map[string]bool, len(failed))
+	for _, dl := range failed {
+		failedIDs[dl.Log.ID] = true
+	}
+
+	var done, retry []uint64
+	for i, seq := range seqs {
+		if failedIDs[ids[i]] {
+			retry = append(retry, seq)
+		} else {
+			done = append(done, seq)
+		}
+	}
+
+	p.commit(done)
+	if err := p.spool.Requeue(retry); err != nil {
+		// Left uncommitted, they are replayed on restart
+		log.Error().Err(err).Msg("Failed to requeue spool records")
+	}
+}
+
+// eventIDs returns the IDs of the events of a batch when they are spooled
+func (p *Pool) eventIDs(batch []model.LogEvent) []string {
+	if p.spool == nil {
+		return nil
+	}
+	ids := make([]string, len(batch))
+	for i, event := range batch {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
 // worker processes log events in batches
 func (p *Pool) worker(id int) {
 	defer p.wg.Done()
 
 	batch := make([]model.LogEvent, 0, p.batchSize)
+	seqs := make([]uint64, 0, p.batchSize)
 	ticker := time.NewTicker(p.batchTimeout)
 	defer ticker.Stop()
 
@@ -91,34 +227,37 @@ func (p *Pool) worker(id int) {
 
 	for {
 		select {
-		case logEvent, ok := <-p.logChannel:
+		case j, ok := <-p.logChannel:
 			if !ok {
 				// Channel closed, flush remaining batch
 				if len(batch) > 0 {
-					p.flushBatch(id, batch)
+					p.flushBatch(id, batch, seqs)
 				}
 				log.Info().Int("worker_id", id).Msg("Worker stopped")
 				return
 			}
 
-			batch = append(batch, logEvent)
+			batch = append(batch, j.event)
+			seqs = append(seqs, j.seq)
 
 			if len(batch) >= p.batchSize {
-				p.flushBatch(id, batch)
+				p.flushBatch(id, batch, seqs)
 				batch = batch[:0] // Reset batch
+				seqs = seqs[:0]
 				ticker.Reset(p.batchTimeout)
 			}
 
 		case <-ticker.C:
 			if len(batch) > 0 {
-				p.flushBatch(id, batch)
+				p.flushBatch(id, batch, seqs)
 				batch = batch[:0] // Reset batch
+				seqs = seqs[:0]
 			}
 
 		case <-p.ctx.Done():
 			// Graceful shutdown, flush remaining batch
 			if len(batch) > 0 {
-				p.flushBatch(id, batch)
+				p.flushBatch(id, batch, seqs)
 			}
 			log.Info().Int("worker_id", id).Msg("Worker stopped")
 			return
@@ -126,28 +265,75 @@ func (p *Pool) worker(id int) {
 	}
 }
 
-// flushBatch writes a batch of logs to the database
-func (p *Pool) flushBatch(workerID int, batch []model.LogEvent) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// flushBatch processes and enriches a batch of logs and writes it to the database. Transient failures are
+// retried, and rows that still cannot be stored go to the dead-letter table, as
+// do events the processor rejects.
+// Spooled events are committed only once every row is stored or dead-lettered;
+// those that could not be dead-lettered are requeued.
+func (p *Pool) flushBatch(workerID int, batch []model.LogEvent, seqs []uint64) {
+	// The processor may reuse the batch, so spooled events are matched to
+	// their sequence numbers by ID
+	ids := p.eventIDs(batch)
+	batch, rejected := p.process(batch)
+	p.enrich(batch)
 
 	start := time.Now()
-	err := p.repo.BatchInsertLogs(ctx, batch)
+	var deadLetters []model.DeadLetter
+	if len(batch) > 0 {
+		deadLetters = p.insertOrIsolate(workerID, batch)
+	}
 	duration := time.Since(start)
 
-	if err != nil {
+	p.notifyObservers(batch, deadLetters)
+
+	if len(deadLetters) > 0 {
 		log.Error().
-			Err(err).
+			Str("error", deadLetters[0].Error).
 			Int("worker_id", workerID).
 			Int("batch_size", len(batch)).
+			Int("dead_letters", len(deadLetters)).
 			Dur("duration", duration).
-			Msg("Failed to insert batch")
-		return
+			Msg("Failed to insert logs, writing to dead-letter table")
+	}
+	if len(rejected) > 0 {
+		log.Warn().
+			Str("error", rejected[0].Error).
+			Int("worker_id", workerID).
+			Int("rejected", len(rejected)).
+			Msg("Processor rejected logs, writing to dead-letter table")
+		deadLetters = append(deadLetters, rejected...)
+	}
+
+	if len(deadLetters) > 0 {
+		if err := p.insertDeadLetters(workerID, deadLetters); err != nil {
+			if p.spool == nil {
+				log.Error().
+					Err(err).
+					Int("worker_id", workerID).
+					Int("dead_letters", len(deadLetters)).
+					Msg("Failed to write dead letters, dropping them")
+				p.notifyDeadLetterObservers(deadLetters)
+				return
+			}
+			// Spooled events are processed again rather than holding back
+			// the commit of every later batch
+			log.Error().
+				Err(err).
+				Int("worker_id", workerID).
+				Int("dead_letters", len(deadLetters)).
+				Msg("Failed to write dead letters, requeueing them")
+			p.requeueFailed(seqs, ids, deadLetters)
+			return
+		}
+		p.notifyDeadLetterObservers(deadLetters)
 	}
 
+	p.commit(seqs)
+
 	log.Debug().
 		Int("worker_id", workerID).
 		Int("batch_size", len(batch)).
+		Int("dead_letters", len(deadLetters)).
 		Dur("duration", duration).
 		Msg("Batch inserted successfully")
 }
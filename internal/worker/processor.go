@@ -0,0 +1,24 @@
+package worker
+
+import "github.com/Saumajitt/threatLog/internal/model"
+
+// Processor transforms, filters and rejects log events before they are
+// enriched and stored. Process returns the events to store and those to
+// dead-letter; events in neither are dropped. It runs on the worker
+// goroutine and may reuse the batch for its result.
+type Processor interface {
+	Process(batch []model.LogEvent) ([]model.LogEvent, []model.DeadLetter)
+}
+
+// SetProcessor sets the processor; it must be called before Start
+func (p *Pool) SetProcessor(proc Processor) {
+	p.processor = proc
+}
+
+// process runs a batch through the processor, if any
+func (p *Pool) process(batch []model.LogEvent) ([]model.LogEvent, []model.DeadLetter) {
+	if p.processor == nil {
+		return batch, nil
+	}
+	return p.processor.Process(batch)
+}
@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// RetryPolicy controls how failed batch inserts are retried
+type RetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// backoff returns the delay before the given retry attempt (1-based) using
+// exponential backoff with full jitter
+func (rp RetryPolicy) backoff(attempt int) time.Duration {
+	delay := rp.InitialBackoff << (attempt - 1)
+	if delay <= 0 || delay > rp.MaxBackoff {
+		delay = rp.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// insertWithRetry inserts a batch, retrying transient errors with backoff
+func (p *Pool) insertWithRetry(workerID int, batch []model.LogEvent) error {
+	return p.withRetry(workerID, "batch insert", len(batch), func(ctx context.Context) error {
+		return p.repo.BatchInsertLogs(ctx, batch)
+	})
+}
+
+// insertDeadLetters writes dead letters, retrying transient errors with backoff
+func (p *Pool) insertDeadLetters(workerID int, deadLetters []model.DeadLetter) error {
+	return p.withRetry(workerID, "dead letter insert", len(deadLetters), func(ctx context.Context) error {
+		return p.repo.InsertDeadLetters(ctx, deadLetters)
+	})
+}
+
+// withRetry runs a write of size rows, retrying transient errors with
+// backoff. Retries stop early when the pool is shutting down.
+func (p *Pool) withRetry(workerID int, op string, size int, write func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt <= p.retry.MaxRetries; attempt++ {
+		if attempt > 0 {
+			delay := p.retry.backoff(attempt)
+			log.Warn().
+				Err(err).
+				Int("worker_id", workerID).
+				Int("batch_size", size).
+				Int("attempt", attempt).
+				Dur("backoff", delay).
+				Msg("Retrying " + op)
+
+			select {
+			case <-time.After(delay):
+			case <-p.ctx.Done():
+				return err
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = write(ctx)
+		cancel()
+
+		if err == nil || !repository.IsTransientError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// insertOrIsolate inserts a batch, bisecting it on permanent errors until the
+// offending rows are isolated. Rows that cannot be stored are returned as dead letters.
+func (p *Pool) insertOrIsolate(workerID int, batch []model.LogEvent) []model.DeadLetter {
+	err := p.insertWithRetry(workerID, batch)
+	if err == nil {
+		return nil
+	}
+
+	// Transient errors that outlived the retries, and single poison rows,
+	// cannot be narrowed down further
+	if repository.IsTransientError(err) || len(batch) == 1 {
+		now := time.Now()
+		deadLetters := make([]model.DeadLetter, len(batch))
+		for i, event := range batch {
+			deadLetters[i] = model.DeadLetter{
+				Log:      event,
+				Error:    err.Error(),
+				FailedAt: now,
+			}
+		}
+		return deadLetters
+	}
+
+	mid := len(batch) / 2
+	deadLetters := p.insertOrIsolate(workerID, batch[:mid])
+	return append(deadLetters, p.insertOrIsolate(workerID, batch[mid:])...)
+}
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetry(t *testing.T) {
+	p := NewPool(1, 1, 1, 0, nil, nil, RetryPolicy{MaxRetries: 3})
+
+	// Transient errors are retried until the write succeeds
+	attempts := 0
+	err := p.withRetry(0, "write", 1, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return syscall.ECONNRESET
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	// Permanent errors are returned right away
+	attempts = 0
+	permanent := &pgconn.PgError{Code: "23502"}
+	err = p.withRetry(0, "write", 1, func(ctx context.Context) error {
+		attempts++
+		return permanent
+	})
+	assert.ErrorIs(t, err, permanent)
+	assert.Equal(t, 1, attempts)
+
+	// Retries give up after MaxRetries
+	attempts = 0
+	err = p.withRetry(0, "write", 1, func(ctx context.Context) error {
+		attempts++
+		return syscall.ECONNRESET
+	})
+	assert.True(t, errors.Is(err, syscall.ECONNRESET))
+	assert.Equal(t, 4, attempts)
+}
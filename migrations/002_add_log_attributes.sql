@@ -0,0 +1,5 @@
+-- Add structured attributes to logs
+ALTER TABLE logs ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- GIN index supports containment (@>) and key existence (?) filters
+CREATE INDEX IF NOT EXISTS idx_logs_attributes ON logs USING GIN (attributes);
@@ -0,0 +1,11 @@
+-- Full-text search over log messages
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- "simple" avoids stemming so IOCs and identifiers match exactly
+ALTER TABLE logs ADD COLUMN IF NOT EXISTS message_tsv TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('simple'::regconfig, message)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_logs_message_tsv ON logs USING GIN (message_tsv);
+
+-- Trigram index for substring and wildcard (ILIKE) matching
+CREATE INDEX IF NOT EXISTS idx_logs_message_trgm ON logs USING GIN (message gin_trgm_ops);
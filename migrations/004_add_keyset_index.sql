@@ -0,0 +1,2 @@
+-- Keyset pagination seeks on (timestamp, id) in descending order
+CREATE INDEX IF NOT EXISTS idx_logs_timestamp_id ON logs(timestamp DESC, id DESC);
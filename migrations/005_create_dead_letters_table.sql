@@ -0,0 +1,12 @@
+-- Log events that could not be inserted, kept for inspection and replay
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+    log_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    error TEXT NOT NULL,
+    failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    replayed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letters_failed_at ON dead_letters(failed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_dead_letters_pending ON dead_letters(id) WHERE replayed_at IS NULL;
@@ -0,0 +1,28 @@
+-- Detection rules evaluated on the ingestion stream
+CREATE TABLE IF NOT EXISTS rules (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    severity VARCHAR(20) NOT NULL,
+    conditions JSONB NOT NULL,
+    threshold JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Alerts raised by detection rules
+CREATE TABLE IF NOT EXISTS alerts (
+    id UUID PRIMARY KEY,
+    rule_id UUID NOT NULL,
+    rule_name VARCHAR(255) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    group_key TEXT NOT NULL DEFAULT '',
+    event_count INT NOT NULL,
+    log_ids UUID[] NOT NULL,
+    message TEXT NOT NULL,
+    triggered_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_triggered_at ON alerts(triggered_at DESC);
+CREATE INDEX IF NOT EXISTS idx_alerts_rule_id ON alerts(rule_id);
@@ -0,0 +1,6 @@
+-- Sigma rules store their source YAML; scheduled rules run as periodic searches
+ALTER TABLE rules
+    ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'conditions',
+    ADD COLUMN IF NOT EXISTS sigma TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS schedule VARCHAR(32) NOT NULL DEFAULT '',
+    ALTER COLUMN conditions DROP NOT NULL;
@@ -0,0 +1,11 @@
+-- Tenant isolation: every log, dead letter, alert and API key belongs to a tenant
+ALTER TABLE logs ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE dead_letters ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+
+-- Queries always filter on tenant first, so lead the time indexes with it
+CREATE INDEX IF NOT EXISTS idx_logs_tenant_timestamp_id ON logs(tenant_id, timestamp DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_dead_letters_tenant ON dead_letters(tenant_id, failed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_alerts_tenant_triggered_at ON alerts(tenant_id, triggered_at DESC);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant ON api_keys(tenant_id);
@@ -0,0 +1,79 @@
+-- Convert logs into a table range-partitioned on timestamp.
+--
+-- The existing table is kept as the logs_legacy partition covering everything
+-- before today (UTC), so no historical rows are copied. Rows from today on are
+-- moved into logs_default, from where the partition maintainer moves them into
+-- the daily or hourly partitions it creates. logs_legacy is not dropped
+-- automatically; drop it with
+--   ALTER TABLE logs DETACH PARTITION logs_legacy; DROP TABLE logs_legacy;
+-- once its data has passed retention.
+DO $$
+DECLARE
+    cutoff TIMESTAMPTZ := date_trunc('day', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+    idx RECORD;
+BEGIN
+    IF (SELECT relkind FROM pg_class WHERE oid = 'logs'::regclass) = 'p' THEN
+        RETURN;
+    END IF;
+
+    ALTER TABLE logs RENAME TO logs_legacy;
+    ALTER TABLE logs_legacy RENAME CONSTRAINT logs_pkey TO logs_legacy_pkey;
+    -- The parent's trigger is cloned onto every partition
+    DROP TRIGGER IF EXISTS check_severity ON logs_legacy;
+
+    -- Free the index names for the parent
+    FOR idx IN
+        SELECT indexname FROM pg_indexes
+        WHERE tablename = 'logs_legacy' AND indexname LIKE 'idx_logs_%'
+    LOOP
+        EXECUTE format('ALTER INDEX %I RENAME TO %I',
+            idx.indexname, replace(idx.indexname, 'idx_logs_', 'idx_logs_legacy_'));
+    END LOOP;
+
+    -- The primary key must include the partition key
+    CREATE TABLE logs (
+        id UUID NOT NULL,
+        tenant_id VARCHAR(63) NOT NULL DEFAULT 'default',
+        timestamp TIMESTAMPTZ NOT NULL,
+        severity VARCHAR(20) NOT NULL,
+        source VARCHAR(255) NOT NULL,
+        message TEXT NOT NULL,
+        attributes JSONB NOT NULL DEFAULT '{}'::jsonb,
+        ingested_at TIMESTAMPTZ DEFAULT NOW(),
+        message_tsv TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple'::regconfig, message)) STORED,
+        PRIMARY KEY (id, timestamp)
+    ) PARTITION BY RANGE (timestamp);
+
+    CREATE INDEX idx_logs_timestamp ON logs(timestamp DESC);
+    CREATE INDEX idx_logs_severity ON logs(severity);
+    CREATE INDEX idx_logs_source ON logs(source);
+    CREATE INDEX idx_logs_timestamp_severity ON logs(timestamp DESC, severity);
+    CREATE INDEX idx_logs_attributes ON logs USING GIN (attributes);
+    CREATE INDEX idx_logs_message_tsv ON logs USING GIN (message_tsv);
+    CREATE INDEX idx_logs_message_trgm ON logs USING GIN (message gin_trgm_ops);
+    CREATE INDEX idx_logs_timestamp_id ON logs(timestamp DESC, id DESC);
+    CREATE INDEX idx_logs_tenant_timestamp_id ON logs(tenant_id, timestamp DESC, id DESC);
+
+    CREATE TRIGGER check_severity
+        BEFORE INSERT OR UPDATE ON logs
+        FOR EACH ROW
+        EXECUTE FUNCTION validate_severity();
+
+    -- Catches events outside every managed partition
+    CREATE TABLE logs_default PARTITION OF logs DEFAULT;
+
+    IF NOT EXISTS (SELECT 1 FROM logs_legacy) THEN
+        DROP TABLE logs_legacy;
+        RETURN;
+    END IF;
+
+    INSERT INTO logs (id, tenant_id, timestamp, severity, source, message, attributes, ingested_at)
+    SELECT id, tenant_id, timestamp, severity, source, message, attributes, ingested_at
+    FROM logs_legacy
+    WHERE timestamp >= cutoff;
+
+    DELETE FROM logs_legacy WHERE timestamp >= cutoff;
+
+    ALTER TABLE logs ATTACH PARTITION logs_legacy FOR VALUES FROM (MINVALUE) TO (cutoff);
+END
+$$;
@@ -0,0 +1,11 @@
+-- Reports of retention runs: what each retention rule deleted or archived
+CREATE TABLE IF NOT EXISTS retention_runs (
+    id BIGSERIAL PRIMARY KEY,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    deleted BIGINT NOT NULL,
+    archived BIGINT NOT NULL,
+    rules JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_runs_started_at ON retention_runs(started_at DESC);
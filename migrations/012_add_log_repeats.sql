@@ -0,0 +1,6 @@
+-- Repeats of an event collapsed by ingestion deduplication. first_seen and
+-- last_seen are NULL for events that were not deduplicated.
+ALTER TABLE logs
+    ADD COLUMN IF NOT EXISTS repeat_count INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS first_seen TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS last_seen TIMESTAMPTZ;
@@ -0,0 +1,9 @@
+-- safe_inet casts text to inet, returning NULL instead of failing for values
+-- that are not IP addresses, so CIDR searches can cast any attribute
+CREATE OR REPLACE FUNCTION safe_inet(value TEXT) RETURNS INET AS $$
+BEGIN
+    RETURN value::inet;
+EXCEPTION WHEN invalid_text_representation THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE STRICT;
@@ -0,0 +1,3 @@
+-- Serves the context endpoint, which reads the events of one source
+-- immediately before and after a given event
+CREATE INDEX IF NOT EXISTS idx_logs_tenant_source_timestamp_id ON logs(tenant_id, source, timestamp DESC, id DESC);
@@ -0,0 +1,49 @@
+-- Alert triage: status, assignee, comments, suppression and an audit trail
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'open';
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS assignee TEXT NOT NULL DEFAULT '';
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS suppression_id UUID;
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_alerts_tenant_status ON alerts(tenant_id, status, triggered_at DESC);
+
+-- Comments left on alerts during triage
+CREATE TABLE IF NOT EXISTS alert_comments (
+    id UUID PRIMARY KEY,
+    alert_id UUID NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    author TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_comments_alert ON alert_comments(alert_id, created_at);
+
+-- Every change of an alert's status or assignee, including its creation
+CREATE TABLE IF NOT EXISTS alert_audit (
+    id BIGSERIAL PRIMARY KEY,
+    alert_id UUID NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    actor TEXT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    from_status VARCHAR(20) NOT NULL DEFAULT '',
+    to_status VARCHAR(20) NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_audit_alert ON alert_audit(alert_id, id);
+
+-- Time-boxed suppressions; alerts matching an active suppression are stored
+-- as suppressed and not notified. Empty matchers match anything.
+CREATE TABLE IF NOT EXISTS alert_suppressions (
+    id UUID PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    rule_id UUID,
+    group_key TEXT,
+    severity VARCHAR(20),
+    reason TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_suppressions_tenant ON alert_suppressions(tenant_id, expires_at DESC);
@@ -0,0 +1,11 @@
+-- Indicator feeds uploaded through the API. Expired feeds are kept with
+-- their expiry until uploaded again.
+CREATE TABLE IF NOT EXISTS threat_feeds (
+    name VARCHAR(64) PRIMARY KEY,
+    format VARCHAR(10) NOT NULL,
+    severity VARCHAR(20) NOT NULL DEFAULT '',
+    data BYTEA NOT NULL,
+    uploaded_by TEXT NOT NULL,
+    uploaded_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ
+);
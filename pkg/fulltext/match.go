@@ -0,0 +1,117 @@
+package fulltext
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Match evaluates a parsed query against text in memory. It approximates the
+// database's 'simple' text search: words and phrases match case-insensitive
+// alphanumeric tokens, and wildcards match substrings.
+func Match(expr Expr, text string) bool {
+	return newMatcher(text).match(expr)
+}
+
+type matcher struct {
+	lower  string
+	tokens []string
+}
+
+func newMatcher(text string) *matcher {
+	lower := strings.ToLower(text)
+	return &matcher{lower: lower, tokens: words(lower)}
+}
+
+func (m *matcher) match(expr Expr) bool {
+	switch e := expr.(type) {
+	case And:
+		return m.match(e.Left) && m.match(e.Right)
+	case Or:
+		return m.match(e.Left) || m.match(e.Right)
+	case Not:
+		return !m.match(e.Operand)
+	case Phrase:
+		return m.containsSequence(words(strings.ToLower(e.Text)))
+	case Term:
+		switch e.Kind {
+		case TermPrefix:
+			return m.hasPrefixAtWordStart(strings.ToLower(e.Text))
+		case TermWildcard:
+			return wildcardRegexp(e.Text).MatchString(m.lower)
+		default:
+			// Terms with punctuation ("10.0.0.5") match their words in sequence
+			return m.containsSequence(words(strings.ToLower(e.Text)))
+		}
+	default:
+		return false
+	}
+}
+
+// containsSequence reports whether seq occurs as consecutive tokens
+func (m *matcher) containsSequence(seq []string) bool {
+	if len(seq) == 0 {
+		return false
+	}
+	for i := 0; i+len(seq) <= len(m.tokens); i++ {
+		found := true
+		for j, word := range seq {
+			if m.tokens[i+j] != word {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPrefixAtWordStart reports whether prefix occurs at the start of a word
+func (m *matcher) hasPrefixAtWordStart(prefix string) bool {
+	if prefix == "" {
+		return false
+	}
+	for offset := 0; ; {
+		i := strings.Index(m.lower[offset:], prefix)
+		if i < 0 {
+			return false
+		}
+		i += offset
+		if before, _ := utf8.DecodeLastRuneInString(m.lower[:i]); i == 0 || !isWordRune(before) {
+			return true
+		}
+		offset = i + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// words splits text into alphanumeric tokens
+func words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+// wildcardRegexp compiles an unanchored, case-insensitive pattern where
+// * matches any run of characters and ? a single character
+func wildcardRegexp(term string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?is)")
+	for _, r := range term {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteByte('.')
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return regexp.MustCompile(b.String())
+}
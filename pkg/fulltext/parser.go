@@ -0,0 +1,289 @@
+package fulltext
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrEmptyQuery     = errors.New("search query is empty")
+	ErrQueryTooLong   = errors.New("search query exceeds 512 characters")
+	ErrUnbalancedExpr = errors.New("unbalanced parentheses or quotes in search query")
+)
+
+// MaxQueryLength is the maximum length of a search query
+const MaxQueryLength = 512
+
+// Expr is a node of a parsed search query
+type Expr interface {
+	expr()
+}
+
+// TermKind describes how a term is matched
+type TermKind int
+
+const (
+	// TermWord matches a whole word
+	TermWord TermKind = iota
+	// TermPrefix matches words starting with the term ("pass*")
+	TermPrefix
+	// TermWildcard matches a substring pattern ("*.evil.com", "adm?n")
+	TermWildcard
+)
+
+// Term is a single search word
+type Term struct {
+	Text string
+	Kind TermKind
+}
+
+// Phrase matches consecutive words ("failed password")
+type Phrase struct {
+	Text string
+}
+
+// And matches when both sides match
+type And struct {
+	Left, Right Expr
+}
+
+// Or matches when either side matches
+type Or struct {
+	Left, Right Expr
+}
+
+// Not matches when the operand does not match
+type Not struct {
+	Operand Expr
+}
+
+func (Term) expr()   {}
+func (Phrase) expr() {}
+func (And) expr()    {}
+func (Or) expr()     {}
+func (Not) expr()    {}
+
+// Parse parses a search query. Supported syntax:
+//
+//	failed password      both words (implicit AND)
+//	"failed password"    exact phrase
+//	pass*                prefix match
+//	*.evil.com           substring/wildcard match (* and ?)
+//	a OR b, a | b        either word
+//	NOT a, -a, !a        exclude word
+//	(a OR b) AND c       grouping
+func Parse(query string) (Expr, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+	if len(query) > MaxQueryLength {
+		return nil, ErrQueryTooLong
+	}
+
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in search query", p.tokens[p.pos].text)
+	}
+
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokPhrase
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits a query into words, phrases, operators and parentheses
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")"})
+			i++
+		case c == '"':
+			end := strings.IndexByte(query[i+1:], '"')
+			if end < 0 {
+				return nil, ErrUnbalancedExpr
+			}
+			tokens = append(tokens, token{tokPhrase, query[i+1 : i+1+end]})
+			i += end + 2
+		case c == '|':
+			tokens = append(tokens, token{tokOr, "|"})
+			i++
+		case c == '&':
+			tokens = append(tokens, token{tokAnd, "&"})
+			i++
+		case c == '-' || c == '!':
+			tokens = append(tokens, token{tokNot, string(c)})
+			i++
+		default:
+			end := i
+			for end < len(query) && !strings.ContainsRune(" \t\n()\"|&", rune(query[end])) {
+				end++
+			}
+			word := query[i:end]
+			switch word {
+			case "AND":
+				tokens = append(tokens, token{tokAnd, word})
+			case "OR":
+				tokens = append(tokens, token{tokOr, word})
+			case "NOT":
+				tokens = append(tokens, token{tokNot, word})
+			default:
+				tokens = append(tokens, token{tokWord, word})
+			}
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokOr {
+			return left, nil
+		}
+		p.pos++
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokOr || tok.kind == tokRParen {
+			return left, nil
+		}
+		if tok.kind == tokAnd {
+			p.pos++
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, errors.New("search query ends unexpectedly")
+	}
+
+	if tok.kind == tokNot {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok, _ := p.peek()
+	p.pos++
+
+	switch tok.kind {
+	case tokLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if next, ok := p.peek(); !ok || next.kind != tokRParen {
+			return nil, ErrUnbalancedExpr
+		}
+		p.pos++
+		return expr, nil
+	case tokPhrase:
+		text := strings.TrimSpace(tok.text)
+		if text == "" {
+			return nil, errors.New("empty phrase in search query")
+		}
+		return Phrase{Text: text}, nil
+	case tokWord:
+		return newTerm(tok.text)
+	default:
+		return nil, fmt.Errorf("unexpected %q in search query", tok.text)
+	}
+}
+
+// newTerm classifies a word as a plain, prefix or wildcard term
+func newTerm(word string) (Expr, error) {
+	if strings.Trim(word, "*?") == "" {
+		return nil, fmt.Errorf("term %q has no literal characters", word)
+	}
+
+	body := strings.TrimSuffix(word, "*")
+	if strings.ContainsAny(body, "*?") {
+		return Term{Text: word, Kind: TermWildcard}, nil
+	}
+	if body != word {
+		return Term{Text: body, Kind: TermPrefix}, nil
+	}
+	return Term{Text: word, Kind: TermWord}, nil
+}
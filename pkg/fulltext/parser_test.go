@@ -0,0 +1,134 @@
+package fulltext
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  Expr
+	}{
+		{
+			name:  "single word",
+			query: "denied",
+			want:  Term{Text: "denied", Kind: TermWord},
+		},
+		{
+			name:  "implicit and",
+			query: "failed password",
+			want: And{
+				Left:  Term{Text: "failed", Kind: TermWord},
+				Right: Term{Text: "password", Kind: TermWord},
+			},
+		},
+		{
+			name:  "phrase",
+			query: `"failed password"`,
+			want:  Phrase{Text: "failed password"},
+		},
+		{
+			name:  "prefix",
+			query: "pass*",
+			want:  Term{Text: "pass", Kind: TermPrefix},
+		},
+		{
+			name:  "wildcard",
+			query: "*.evil.com",
+			want:  Term{Text: "*.evil.com", Kind: TermWildcard},
+		},
+		{
+			name:  "or binds looser than and",
+			query: "a b OR c",
+			want: Or{
+				Left: And{
+					Left:  Term{Text: "a", Kind: TermWord},
+					Right: Term{Text: "b", Kind: TermWord},
+				},
+				Right: Term{Text: "c", Kind: TermWord},
+			},
+		},
+		{
+			name:  "grouping and negation",
+			query: "(ssh | rdp) AND -test",
+			want: And{
+				Left: Or{
+					Left:  Term{Text: "ssh", Kind: TermWord},
+					Right: Term{Text: "rdp", Kind: TermWord},
+				},
+				Right: Not{Operand: Term{Text: "test", Kind: TermWord}},
+			},
+		},
+		{
+			name:  "not keyword",
+			query: "NOT root",
+			want:  Not{Operand: Term{Text: "root", Kind: TermWord}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, expr)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"empty", "   "},
+		{"too long", strings.Repeat("a", MaxQueryLength+1)},
+		{"unclosed quote", `"failed password`},
+		{"unclosed paren", "(a OR b"},
+		{"stray paren", "a)"},
+		{"dangling operator", "a OR"},
+		{"only wildcards", "**"},
+		{"empty phrase", `""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	message := "Failed password for root from 10.0.0.5 port 22 ssh2"
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"failed", true},
+		{"FAILED root", true},
+		{"failed admin", false},
+		{`"password for root"`, true},
+		{`"root for password"`, false},
+		{"pass*", true},
+		{"10.0.0.*", true},
+		{"ssh2 OR rdp", true},
+		{"ssh", false},
+		{"10.0.0.5", true},
+		{"-root", false},
+		{"(admin | root) AND NOT telnet", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			expr, err := Parse(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, Match(expr, message))
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Package ipscan finds IPv4 and IPv6 addresses in log text
+package ipscan
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// Parse parses a value that is a single IP address, optionally in brackets
+// or followed by a port. IPv4-mapped IPv6 addresses are returned as IPv4.
+func Parse(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return addr.Unmap(), true
+	}
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		if addr, err := netip.ParseAddr(value[1 : len(value)-1]); err == nil {
+			return addr.Unmap(), true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// Find returns the distinct IP addresses in text, in order of appearance.
+// Candidates are runs of hex digits, dots and colons that are not part of a
+// longer word; an IPv4 address followed by a port is found too.
+func Find(text string) []netip.Addr {
+	var addrs []netip.Addr
+	seen := make(map[netip.Addr]bool)
+	add := func(addr netip.Addr) {
+		addr = addr.Unmap()
+		if !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+
+	for i := 0; i < len(text); {
+		if !isAddrChar(text[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(text) && isAddrChar(text[i]) {
+			i++
+		}
+		if (start > 0 && isWordChar(text[start-1])) || (i < len(text) && isWordChar(text[i])) {
+			continue
+		}
+
+		run := strings.TrimRight(text[start:i], ".")
+		if len(run) < 2 || !strings.ContainsAny(run, ".:") {
+			continue
+		}
+		if addr, err := netip.ParseAddr(run); err == nil {
+			add(addr)
+			continue
+		}
+		// 10.0.0.1:443 and similar
+		if host, _, ok := strings.Cut(run, ":"); ok && strings.Contains(host, ".") {
+			if addr, err := netip.ParseAddr(host); err == nil {
+				add(addr)
+			}
+		}
+	}
+
+	return addrs
+}
+
+func isAddrChar(c byte) bool {
+	return c == '.' || c == ':' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// isWordChar reports characters that cannot border an address
+func isWordChar(c byte) bool {
+	return c == '_' || ('g' <= c && c <= 'z') || ('G' <= c && c <= 'Z')
+}
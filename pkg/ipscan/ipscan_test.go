@@ -0,0 +1,45 @@
+package ipscan
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"10.0.0.1", "10.0.0.1"},
+		{" 2001:db8::1 ", "2001:db8::1"},
+		{"10.0.0.1:443", "10.0.0.1"},
+		{"[2001:db8::1]:8443", "2001:db8::1"},
+		{"[2001:db8::1]", "2001:db8::1"},
+		{"::ffff:192.0.2.7", "192.0.2.7"},
+	}
+	for _, tt := range tests {
+		addr, ok := Parse(tt.value)
+		assert.True(t, ok, tt.value)
+		assert.Equal(t, netip.MustParseAddr(tt.want), addr, tt.value)
+	}
+
+	for _, value := range []string{"", "example.com", "10.0.0", "1.2.3.4.5", "cafe"} {
+		_, ok := Parse(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestFind(t *testing.T) {
+	text := "Blocked 203.0.113.9:4444 -> 10.0.0.1, then 2001:db8::7 (again 10.0.0.1). " +
+		"Version 1.2.3.4.5, host abc10.0.0.2 and id deadbeef are not addresses."
+
+	var got []string
+	for _, addr := range Find(text) {
+		got = append(got, addr.String())
+	}
+	assert.Equal(t, []string{"203.0.113.9", "10.0.0.1", "2001:db8::7"}, got)
+
+	assert.Empty(t, Find("no addresses here, only 12:30 and 3.5"))
+}
@@ -0,0 +1,72 @@
+package searchql
+
+// FieldTimestamp can be searched with ranges besides the severity, source
+// and message fields of rules. Any other field name addresses an attribute,
+// with or without the "attr." prefix.
+const FieldTimestamp = "timestamp"
+
+// Expr is a node of a parsed search
+type Expr interface {
+	expr()
+}
+
+// Op is the operator of a Match
+type Op int
+
+const (
+	// OpEquals matches the whole value
+	OpEquals Op = iota
+	// OpContains matches a substring, case-insensitively
+	OpContains
+	// OpWildcard matches a pattern where * is any run of characters and ? a single one
+	OpWildcard
+	// OpRegex matches a POSIX regular expression
+	OpRegex
+	// OpIn matches any of Values
+	OpIn
+	// OpCIDR matches IP addresses inside the network in Value
+	OpCIDR
+)
+
+// Match compares a field against a value. Field is severity, source,
+// message, timestamp or attr.<key>.
+type Match struct {
+	Field string
+	Op    Op
+	Value string
+	// Values holds the alternatives of OpIn
+	Values []string
+	// Pos is the byte offset of the field in the search
+	Pos int
+}
+
+// Range matches field values between Lower and Upper; an empty bound is
+// unbounded. Numeric bounds compare attributes as numbers.
+type Range struct {
+	Field        string
+	Lower, Upper string
+	IncludeLower bool
+	IncludeUpper bool
+	Pos          int
+}
+
+// And matches when both sides match
+type And struct {
+	Left, Right Expr
+}
+
+// Or matches when either side matches
+type Or struct {
+	Left, Right Expr
+}
+
+// Not matches when the operand does not match
+type Not struct {
+	Operand Expr
+}
+
+func (Match) expr() {}
+func (Range) expr() {}
+func (And) expr()   {}
+func (Or) expr()    {}
+func (Not) expr()   {}
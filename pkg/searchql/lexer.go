@@ -0,0 +1,196 @@
+package searchql
+
+import (
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokRegex
+	tokColon
+	tokEq
+	tokNeq
+	tokGt
+	tokGte
+	tokLt
+	tokLte
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokLBrace
+	tokRBrace
+	tokComma
+	tokAnd
+	tokOr
+	tokNot
+	tokIn
+	tokTo
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// describe names a token for error messages
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of search"
+	case tokString:
+		return `"` + t.text + `"`
+	case tokRegex:
+		return "/" + t.text + "/"
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+// punctuation maps single-character tokens to their kind
+var punctuation = map[byte]tokenKind{
+	':': tokColon,
+	'=': tokEq,
+	'(': tokLParen,
+	')': tokRParen,
+	'[': tokLBracket,
+	']': tokRBracket,
+	'{': tokLBrace,
+	'}': tokRBrace,
+	',': tokComma,
+}
+
+var keywords = map[string]tokenKind{
+	"AND": tokAnd,
+	"OR":  tokOr,
+	"NOT": tokNot,
+	"IN":  tokIn,
+	"TO":  tokTo,
+}
+
+// lex splits a search into tokens. Words in value position, after an
+// operator or inside a range or IN list, may contain ':' so timestamps and
+// IPv6 addresses need no quoting, and are never keywords.
+func lex(search string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(search); {
+		c := search[i]
+		value := inValuePosition(tokens)
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			text, end, err := scanQuoted(search, i, '"')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokString, text, i})
+			i = end
+		case c == '/' && value:
+			text, end, err := scanQuoted(search, i, '/')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokRegex, text, i})
+			i = end
+		case c == '!' || c == '>' || c == '<':
+			kind, text := comparison(search[i:])
+			if kind == tokEOF {
+				return nil, &ParseError{Pos: i, Token: "'!'", Message: "expected '!='"}
+			}
+			tokens = append(tokens, token{kind, text, i})
+			i += len(text)
+		case punctuation[c] != tokEOF && !(value && c == ':'):
+			// In value position a colon starts a word, as in the IPv6 address ::1
+			tokens = append(tokens, token{punctuation[c], string(c), i})
+			i++
+		default:
+			end := i
+			for end < len(search) && !endsWord(search[end], value) {
+				end++
+			}
+			word := search[i:end]
+			kind := tokWord
+			if k, ok := keywords[word]; ok && !value {
+				kind = k
+			}
+			tokens = append(tokens, token{kind, word, i})
+			i = end
+		}
+	}
+
+	return append(tokens, token{tokEOF, "", len(search)}), nil
+}
+
+// inValuePosition reports whether the next token is a value
+func inValuePosition(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	switch tokens[len(tokens)-1].kind {
+	case tokColon, tokEq, tokNeq, tokGt, tokGte, tokLt, tokLte, tokLBracket, tokLBrace, tokTo, tokComma:
+		return true
+	case tokLParen:
+		return len(tokens) > 1 && tokens[len(tokens)-2].kind == tokIn
+	default:
+		return false
+	}
+}
+
+// endsWord reports whether c ends a bare word
+func endsWord(c byte, value bool) bool {
+	if value {
+		return strings.IndexByte(" \t\n\r\"()[]{},", c) >= 0
+	}
+	return strings.IndexByte(" \t\n\r\"():=!<>[]{},", c) >= 0
+}
+
+// comparison reads !=, >, >=, < or <=
+func comparison(s string) (tokenKind, string) {
+	switch {
+	case strings.HasPrefix(s, "!="):
+		return tokNeq, "!="
+	case strings.HasPrefix(s, ">="):
+		return tokGte, ">="
+	case strings.HasPrefix(s, "<="):
+		return tokLte, "<="
+	case s[0] == '>':
+		return tokGt, ">"
+	case s[0] == '<':
+		return tokLt, "<"
+	default:
+		return tokEOF, ""
+	}
+}
+
+// scanQuoted reads text enclosed in quote starting at start. A backslash
+// escapes the quote; in strings it also escapes itself, in regular
+// expressions other escapes are kept for the regex engine.
+func scanQuoted(search string, start int, quote byte) (string, int, error) {
+	var sb strings.Builder
+	for i := start + 1; i < len(search); i++ {
+		c := search[i]
+		switch {
+		case c == quote:
+			return sb.String(), i + 1, nil
+		case c == '\\' && i+1 < len(search) && (search[i+1] == quote || (quote == '"' && search[i+1] == '\\')):
+			sb.WriteByte(search[i+1])
+			i++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	what := "string"
+	if quote == '/' {
+		what = "regular expression"
+	}
+	return "", 0, &ParseError{Pos: start, Token: string(quote), Message: "unterminated " + what}
+}
@@ -0,0 +1,460 @@
+package searchql
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+var (
+	ErrEmptySearch   = errors.New("search is empty")
+	ErrSearchTooLong = errors.New("search exceeds 1024 characters")
+)
+
+// MaxSearchLength is the maximum length of a search
+const MaxSearchLength = 1024
+
+// maxAttributeKeyLen matches the attribute key limit of ingestion
+const maxAttributeKeyLen = 128
+
+// severityOrder ranks severities for range comparisons
+var severityOrder = []string{
+	model.SeverityInfo,
+	model.SeverityLow,
+	model.SeverityMedium,
+	model.SeverityHigh,
+	model.SeverityCritical,
+}
+
+var attributeKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// ParseError reports where a search is invalid
+type ParseError struct {
+	// Pos is the byte offset of the offending token
+	Pos     int
+	Token   string
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Pos)
+}
+
+// Parse parses a search. Supported syntax:
+//
+//	severity:HIGH                  equality (field=value works too)
+//	source!=test                   inequality
+//	source:fw-*                    wildcard (* and ?)
+//	message:"denied"               message substring, as are bare terms
+//	attr.user:/^adm(in)?$/         POSIX regular expression
+//	attr.port>=1024                range (>, >=, <, <=)
+//	timestamp:[2026-01-29T00:00:00Z TO *]  inclusive range, {} for exclusive
+//	severity IN (HIGH, CRITICAL)   any of the values
+//	src_ip:10.0.0.0/8              CIDR match on source or attributes
+//	a AND b, a b, a OR b, NOT a    boolean logic, with (a OR b) grouping
+//
+// Fields other than severity, source, message and timestamp are attributes,
+// with or without the attr. prefix.
+func Parse(search string) (Expr, error) {
+	if strings.TrimSpace(search) == "" {
+		return nil, ErrEmptySearch
+	}
+	if len(search) > MaxSearchLength {
+		return nil, ErrSearchTooLong
+	}
+
+	tokens, err := lex(search)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.unexpected(tok, "AND, OR or end of search")
+	}
+
+	return expr, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes a token; the final EOF token is never consumed
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(kind tokenKind, expected string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, p.unexpected(tok, expected)
+	}
+	return tok, nil
+}
+
+func (p *parser) unexpected(tok token, expected string) error {
+	return &ParseError{
+		Pos:     tok.pos,
+		Token:   tok.describe(),
+		Message: fmt.Sprintf("expected %s, found %s", expected, tok.describe()),
+	}
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().kind {
+		case tokEOF, tokOr, tokRParen:
+			return left, nil
+		case tokAnd:
+			p.next()
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case tokString:
+		return Match{Field: model.FieldMessage, Op: OpContains, Value: tok.text, Pos: tok.pos}, nil
+	case tokWord:
+		switch p.peek().kind {
+		case tokColon, tokEq, tokNeq, tokGt, tokGte, tokLt, tokLte, tokIn:
+			return p.parseComparison(tok)
+		}
+		// A bare term searches the message
+		if strings.ContainsAny(tok.text, "*?") {
+			return Match{Field: model.FieldMessage, Op: OpWildcard, Value: tok.text, Pos: tok.pos}, nil
+		}
+		return Match{Field: model.FieldMessage, Op: OpContains, Value: tok.text, Pos: tok.pos}, nil
+	default:
+		return nil, p.unexpected(tok, "a term, field or '('")
+	}
+}
+
+// parseComparison parses the operator and value following a field name
+func (p *parser) parseComparison(fieldTok token) (Expr, error) {
+	field, err := fieldName(fieldTok)
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.next()
+	switch op.kind {
+	case tokIn:
+		return p.parseIn(field, fieldTok.pos)
+	case tokGt, tokGte, tokLt, tokLte:
+		value, err := p.value(false)
+		if err != nil {
+			return nil, err
+		}
+		r := Range{Field: field, Pos: fieldTok.pos}
+		switch op.kind {
+		case tokGt, tokGte:
+			r.Lower, r.IncludeLower = value.text, op.kind == tokGte
+		default:
+			r.Upper, r.IncludeUpper = value.text, op.kind == tokLte
+		}
+		return r, checkRange(r, value)
+	case tokNeq:
+		match, err := p.parseMatch(field, fieldTok.pos)
+		if err != nil {
+			return nil, err
+		}
+		return Not{Operand: match}, nil
+	default:
+		if next := p.peek().kind; next == tokLBracket || next == tokLBrace {
+			return p.parseRange(field, fieldTok.pos)
+		}
+		return p.parseMatch(field, fieldTok.pos)
+	}
+}
+
+// parseMatch parses the value of an equality, wildcard, regex or CIDR match
+func (p *parser) parseMatch(field string, pos int) (Expr, error) {
+	value, err := p.value(true)
+	if err != nil {
+		return nil, err
+	}
+	match := Match{Field: field, Op: OpEquals, Value: value.text, Pos: pos}
+
+	switch {
+	case field == FieldTimestamp:
+		return nil, &ParseError{Pos: pos, Token: "'timestamp'", Message: "timestamp can only be compared with ranges"}
+	case value.kind == tokRegex:
+		if field == model.FieldSeverity {
+			return nil, valueError(value, "severity cannot be matched with a regular expression")
+		}
+		if _, err := regexp.Compile(value.text); err != nil {
+			return nil, valueError(value, "invalid regular expression")
+		}
+		match.Op = OpRegex
+	case field == model.FieldSeverity:
+		match.Value = strings.ToUpper(value.text)
+		if !model.IsValidSeverity(match.Value) {
+			return nil, valueError(value, "invalid severity")
+		}
+	case value.kind == tokString:
+		// Quoted values are literal
+		if field == model.FieldMessage {
+			match.Op = OpContains
+		}
+	case strings.ContainsAny(value.text, "*?"):
+		match.Op = OpWildcard
+	case field == model.FieldMessage:
+		match.Op = OpContains
+	case isCIDR(value.text):
+		match.Op = OpCIDR
+	}
+
+	return match, nil
+}
+
+// parseIn parses a parenthesized, comma-separated list of values
+func (p *parser) parseIn(field string, pos int) (Expr, error) {
+	if field == FieldTimestamp {
+		return nil, &ParseError{Pos: pos, Token: "'timestamp'", Message: "timestamp can only be compared with ranges"}
+	}
+	if _, err := p.expect(tokLParen, "'('"); err != nil {
+		return nil, err
+	}
+
+	match := Match{Field: field, Op: OpIn, Pos: pos}
+	for {
+		value, err := p.value(false)
+		if err != nil {
+			return nil, err
+		}
+		text := value.text
+		if field == model.FieldSeverity {
+			text = strings.ToUpper(text)
+			if !model.IsValidSeverity(text) {
+				return nil, valueError(value, "invalid severity")
+			}
+		}
+		match.Values = append(match.Values, text)
+
+		sep := p.next()
+		if sep.kind == tokRParen {
+			return match, nil
+		}
+		if sep.kind != tokComma {
+			return nil, p.unexpected(sep, "',' or ')'")
+		}
+	}
+}
+
+// parseRange parses [lower TO upper]; braces make a bound exclusive and *
+// leaves it open
+func (p *parser) parseRange(field string, pos int) (Expr, error) {
+	open := p.next()
+	lower, err := p.value(false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokTo, "TO"); err != nil {
+		return nil, err
+	}
+	upper, err := p.value(false)
+	if err != nil {
+		return nil, err
+	}
+	closing := p.next()
+	if closing.kind != tokRBracket && closing.kind != tokRBrace {
+		return nil, p.unexpected(closing, "']' or '}'")
+	}
+
+	r := Range{
+		Field:        field,
+		IncludeLower: open.kind == tokLBracket,
+		IncludeUpper: closing.kind == tokRBracket,
+		Pos:          pos,
+	}
+	if !(lower.kind == tokWord && lower.text == "*") {
+		r.Lower = lower.text
+	}
+	if !(upper.kind == tokWord && upper.text == "*") {
+		r.Upper = upper.text
+	}
+	if r.Lower == "" && r.Upper == "" {
+		return nil, valueError(lower, "range needs at least one bound")
+	}
+
+	if err := checkRange(r, lower); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// value consumes a word or quoted string, or a regular expression if allowed
+func (p *parser) value(allowRegex bool) (token, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokWord, tok.kind == tokString:
+		return tok, nil
+	case tok.kind == tokRegex && allowRegex:
+		return tok, nil
+	default:
+		return tok, p.unexpected(tok, "a value")
+	}
+}
+
+// checkRange validates range bounds for the field; errors point at at
+func checkRange(r Range, at token) error {
+	switch r.Field {
+	case model.FieldMessage:
+		return valueError(at, "message cannot be compared with ranges")
+	case model.FieldSeverity:
+		for _, bound := range []string{r.Lower, r.Upper} {
+			if bound != "" && !model.IsValidSeverity(strings.ToUpper(bound)) {
+				return valueError(at, "invalid severity")
+			}
+		}
+	case FieldTimestamp:
+		for _, bound := range []string{r.Lower, r.Upper} {
+			if bound == "" {
+				continue
+			}
+			if _, err := time.Parse(time.RFC3339, bound); err != nil {
+				return valueError(at, "timestamps must be RFC3339")
+			}
+		}
+	default:
+		if r.Lower != "" && r.Upper != "" && isNumber(r.Lower) != isNumber(r.Upper) {
+			return valueError(at, "range bounds must both be numbers or both be text")
+		}
+	}
+	return nil
+}
+
+// fieldName returns the canonical name of a field: severity, source,
+// message, timestamp or attr.<key>
+func fieldName(tok token) (string, error) {
+	switch tok.text {
+	case model.FieldSeverity, model.FieldSource, model.FieldMessage, FieldTimestamp:
+		return tok.text, nil
+	}
+
+	key := strings.TrimPrefix(tok.text, model.AttributeFieldPrefix)
+	if len(key) > maxAttributeKeyLen || !attributeKeyPattern.MatchString(key) {
+		return "", &ParseError{Pos: tok.pos, Token: tok.describe(), Message: "invalid field name " + tok.describe()}
+	}
+	return model.AttributeFieldPrefix + key, nil
+}
+
+func valueError(tok token, message string) error {
+	return &ParseError{Pos: tok.pos, Token: tok.describe(), Message: message + " " + tok.describe()}
+}
+
+// SeveritiesInRange returns the severities within the bounds of a severity range
+func SeveritiesInRange(r Range) []string {
+	lower, upper := 0, len(severityOrder)-1
+	for i, sev := range severityOrder {
+		if strings.EqualFold(sev, r.Lower) {
+			lower = i
+			if !r.IncludeLower {
+				lower++
+			}
+		}
+		if strings.EqualFold(sev, r.Upper) {
+			upper = i
+			if !r.IncludeUpper {
+				upper--
+			}
+		}
+	}
+
+	if lower > upper {
+		return []string{}
+	}
+	return append([]string{}, severityOrder[lower:upper+1]...)
+}
+
+// Numeric reports whether the bounds of a range are numbers
+func (r Range) Numeric() bool {
+	for _, bound := range []string{r.Lower, r.Upper} {
+		if bound != "" && !isNumber(bound) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func isCIDR(s string) bool {
+	_, _, err := net.ParseCIDR(s)
+	return err == nil
+}
@@ -0,0 +1,166 @@
+package searchql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		search string
+		want   Expr
+	}{
+		{
+			name:   "equality",
+			search: "severity:high",
+			want:   Match{Field: "severity", Op: OpEquals, Value: "HIGH"},
+		},
+		{
+			name:   "bare attribute field",
+			search: "user=root",
+			want:   Match{Field: "attr.user", Op: OpEquals, Value: "root"},
+		},
+		{
+			name:   "wildcard",
+			search: "source:fw-*",
+			want:   Match{Field: "source", Op: OpWildcard, Value: "fw-*"},
+		},
+		{
+			name:   "quoted values are literal",
+			search: `source:"fw-*"`,
+			want:   Match{Field: "source", Op: OpEquals, Value: "fw-*"},
+		},
+		{
+			name:   "message substring",
+			search: `message:"access denied"`,
+			want:   Match{Field: "message", Op: OpContains, Value: "access denied"},
+		},
+		{
+			name:   "bare term",
+			search: "denied",
+			want:   Match{Field: "message", Op: OpContains, Value: "denied"},
+		},
+		{
+			name:   "regex",
+			search: `attr.user:/^adm\/in$/`,
+			want:   Match{Field: "attr.user", Op: OpRegex, Value: "^adm/in$"},
+		},
+		{
+			name:   "cidr",
+			search: "attr.src_ip:10.0.0.0/8",
+			want:   Match{Field: "attr.src_ip", Op: OpCIDR, Value: "10.0.0.0/8"},
+		},
+		{
+			name:   "ipv6 cidr needs no quoting",
+			search: "src_ip:2001:db8::/32",
+			want:   Match{Field: "attr.src_ip", Op: OpCIDR, Value: "2001:db8::/32"},
+		},
+		{
+			name:   "not equals",
+			search: "source!=test",
+			want:   Not{Operand: Match{Field: "source", Op: OpEquals, Value: "test"}},
+		},
+		{
+			name:   "in",
+			search: "severity IN (high, CRITICAL)",
+			want:   Match{Field: "severity", Op: OpIn, Values: []string{"HIGH", "CRITICAL"}},
+		},
+		{
+			name:   "comparison",
+			search: "attr.port>=1024",
+			want:   Range{Field: "attr.port", Lower: "1024", IncludeLower: true},
+		},
+		{
+			name:   "range",
+			search: "timestamp:[2026-01-29T00:00:00Z TO *}",
+			want:   Range{Field: "timestamp", Lower: "2026-01-29T00:00:00Z", IncludeLower: true},
+		},
+		{
+			name:   "boolean logic and grouping",
+			search: `severity:HIGH AND (source:fw-* OR message:"denied") NOT source:test`,
+			want: And{
+				Left: And{
+					Left: Match{Field: "severity", Op: OpEquals, Value: "HIGH"},
+					Right: Or{
+						Left:  Match{Field: "source", Op: OpWildcard, Value: "fw-*"},
+						Right: Match{Field: "message", Op: OpContains, Value: "denied"},
+					},
+				},
+				Right: Not{Operand: Match{Field: "source", Op: OpEquals, Value: "test"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.search)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, clearPositions(got))
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		search string
+		pos    int
+		msg    string
+	}{
+		{"severity:HIGH AND", 17, "expected a term, field or '(', found end of search"},
+		{"(source:fw OR denied", 20, "expected ')'"},
+		{`message:"denied`, 8, "unterminated string"},
+		{"severity:urgent", 9, "invalid severity 'urgent'"},
+		{"user:/[a-/", 5, "invalid regular expression"},
+		{"attr.port:[1 TO abc]", 11, "range bounds must both be numbers or both be text"},
+		{"timestamp:yesterday", 0, "timestamp can only be compared with ranges"},
+		{"timestamp>yesterday", 10, "timestamps must be RFC3339"},
+		{"source IN (a b)", 13, "expected ',' or ')'"},
+		{"source ! test", 7, "expected '!='"},
+		{"a OR )", 5, "expected a term, field or '('"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.search, func(t *testing.T) {
+			_, err := Parse(tt.search)
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tt.pos, parseErr.Pos)
+			assert.Contains(t, parseErr.Message, tt.msg)
+		})
+	}
+
+	_, err := Parse("  ")
+	assert.ErrorIs(t, err, ErrEmptySearch)
+	_, err = Parse(strings.Repeat("a ", MaxSearchLength))
+	assert.ErrorIs(t, err, ErrSearchTooLong)
+}
+
+func TestSeveritiesInRange(t *testing.T) {
+	assert.Equal(t, []string{"HIGH", "CRITICAL"}, SeveritiesInRange(Range{Lower: "HIGH", IncludeLower: true}))
+	assert.Equal(t, []string{"INFO", "LOW"}, SeveritiesInRange(Range{Upper: "medium"}))
+	assert.Equal(t, []string{}, SeveritiesInRange(Range{Lower: "CRITICAL"}))
+}
+
+// clearPositions zeroes node positions so expected trees stay readable
+func clearPositions(expr Expr) Expr {
+	switch e := expr.(type) {
+	case Match:
+		e.Pos = 0
+		return e
+	case Range:
+		e.Pos = 0
+		return e
+	case And:
+		return And{Left: clearPositions(e.Left), Right: clearPositions(e.Right)}
+	case Or:
+		return Or{Left: clearPositions(e.Left), Right: clearPositions(e.Right)}
+	case Not:
+		return Not{Operand: clearPositions(e.Operand)}
+	default:
+		return expr
+	}
+}
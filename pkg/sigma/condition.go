@@ -0,0 +1,181 @@
+package sigma
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// conditionParser parses a Sigma condition expression:
+//
+//	or      := and ("or" and)*
+//	and     := not ("and" not)*
+//	not     := "not" not | primary
+//	primary := "(" or ")" | ("1" | "all") "of" (pattern | "them") | name
+type conditionParser struct {
+	tokens     []string
+	pos        int
+	selections map[string]Expr
+	// names lists selections in declaration order
+	names []string
+}
+
+// parseCondition resolves a condition against the rule's selections
+func parseCondition(condition string, selections map[string]Expr, names []string) (Expr, error) {
+	p := &conditionParser{
+		tokens:     tokenizeCondition(condition),
+		selections: selections,
+		names:      names,
+	}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in condition", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+func tokenizeCondition(condition string) []string {
+	condition = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(condition)
+	return strings.Fields(condition)
+}
+
+func (p *conditionParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return strings.ToLower(p.tokens[p.pos])
+}
+
+func (p *conditionParser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := Or{left}
+	for p.peek() == "or" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, right)
+	}
+
+	if len(operands) == 1 {
+		return left, nil
+	}
+	return operands, nil
+}
+
+func (p *conditionParser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := And{left}
+	for p.peek() == "and" {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, right)
+	}
+
+	if len(operands) == 1 {
+		return left, nil
+	}
+	return operands, nil
+}
+
+func (p *conditionParser) parseNot() (Expr, error) {
+	if p.peek() == "not" {
+		p.pos++
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *conditionParser) parsePrimary() (Expr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of condition")
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+
+	switch strings.ToLower(token) {
+	case "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis in condition")
+		}
+		p.pos++
+		return expr, nil
+	case ")", "and", "or", "of", "them":
+		return nil, fmt.Errorf("unexpected %q in condition", token)
+	case "1", "all":
+		return p.parseQuantifier(strings.ToLower(token))
+	}
+
+	expr, ok := p.selections[token]
+	if !ok {
+		return nil, fmt.Errorf("condition references unknown selection %q", token)
+	}
+	return expr, nil
+}
+
+// parseQuantifier parses the remainder of "1 of x" or "all of x"
+func (p *conditionParser) parseQuantifier(quantifier string) (Expr, error) {
+	if p.peek() != "of" {
+		return nil, fmt.Errorf("expected \"of\" after %q in condition", quantifier)
+	}
+	p.pos++
+
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("expected selection pattern after \"of\"")
+	}
+	pattern := p.tokens[p.pos]
+	p.pos++
+
+	var matched []Expr
+	for _, name := range p.names {
+		if strings.ToLower(pattern) == "them" {
+			// Selections prefixed with an underscore are excluded from "them"
+			if !strings.HasPrefix(name, "_") {
+				matched = append(matched, p.selections[name])
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			matched = append(matched, p.selections[name])
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("%q matches no selections", pattern)
+	}
+
+	if len(matched) == 1 {
+		return matched[0], nil
+	}
+	if quantifier == "all" {
+		return And(matched), nil
+	}
+	return Or(matched), nil
+}
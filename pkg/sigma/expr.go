@@ -0,0 +1,227 @@
+package sigma
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// MatchOp is the comparison a Match performs
+type MatchOp string
+
+const (
+	OpEquals     MatchOp = "equals"
+	OpContains   MatchOp = "contains"
+	OpStartsWith MatchOp = "startswith"
+	OpEndsWith   MatchOp = "endswith"
+	// OpWildcard matches Value as a glob where * and ? are wildcards and
+	// backslash escapes them
+	OpWildcard MatchOp = "wildcard"
+	OpRegex    MatchOp = "regex"
+	OpExists   MatchOp = "exists"
+	// OpNull matches a missing or empty field
+	OpNull MatchOp = "null"
+)
+
+// FieldGetter returns the string value of a field and whether it is present
+type FieldGetter func(field string) (string, bool)
+
+// Expr is a compiled Sigma detection expression
+type Expr interface {
+	Eval(get FieldGetter) bool
+}
+
+// And matches when all operands match
+type And []Expr
+
+// Or matches when any operand matches
+type Or []Expr
+
+// Not negates its operand
+type Not struct {
+	Operand Expr
+}
+
+// Match compares a single field. An empty Field is a keyword match against
+// the whole event, which the field mapping directs at the message.
+type Match struct {
+	Field string
+	Op    MatchOp
+	Value string
+	// Cased makes string comparisons case-sensitive (regexes always are)
+	Cased bool
+
+	// re evaluates OpWildcard and OpRegex
+	re *regexp.Regexp
+}
+
+func (e And) Eval(get FieldGetter) bool {
+	for _, operand := range e {
+		if !operand.Eval(get) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e Or) Eval(get FieldGetter) bool {
+	for _, operand := range e {
+		if operand.Eval(get) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e Not) Eval(get FieldGetter) bool {
+	return !e.Operand.Eval(get)
+}
+
+func (m Match) Eval(get FieldGetter) bool {
+	value, ok := get(m.Field)
+
+	switch m.Op {
+	case OpNull:
+		return !ok || value == ""
+	case OpExists:
+		return ok
+	}
+	if !ok {
+		return false
+	}
+
+	switch m.Op {
+	case OpWildcard, OpRegex:
+		return m.re.MatchString(value)
+	}
+
+	target := m.Value
+	if !m.Cased {
+		value = strings.ToLower(value)
+		target = strings.ToLower(target)
+	}
+
+	switch m.Op {
+	case OpEquals:
+		return value == target
+	case OpContains:
+		return strings.Contains(value, target)
+	case OpStartsWith:
+		return strings.HasPrefix(value, target)
+	case OpEndsWith:
+		return strings.HasSuffix(value, target)
+	default:
+		return false
+	}
+}
+
+// LikePattern returns the LIKE pattern equivalent to a string match, with
+// backslash as the escape character
+func (m Match) LikePattern() string {
+	escape := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+	switch m.Op {
+	case OpContains:
+		return "%" + escape.Replace(m.Value) + "%"
+	case OpStartsWith:
+		return escape.Replace(m.Value) + "%"
+	case OpEndsWith:
+		return "%" + escape.Replace(m.Value)
+	case OpWildcard:
+		var b strings.Builder
+		for _, tok := range parseGlob(m.Value) {
+			switch tok.wildcard {
+			case '*':
+				b.WriteByte('%')
+			case '?':
+				b.WriteByte('_')
+			default:
+				b.WriteString(escape.Replace(tok.text))
+			}
+		}
+		return b.String()
+	default:
+		return escape.Replace(m.Value)
+	}
+}
+
+// FieldMapping maps Sigma field names onto rule fields (severity, source,
+// message or attr.<key>). Lookups are case-insensitive; unmapped fields map
+// to attr.<field> and keywords map to the message.
+type FieldMapping map[string]string
+
+// NewFieldMapping builds a mapping from configuration
+func NewFieldMapping(fields map[string]string) FieldMapping {
+	m := make(FieldMapping, len(fields))
+	for k, v := range fields {
+		m[strings.ToLower(k)] = v
+	}
+	return m
+}
+
+// Field returns the rule field for a Sigma field
+func (m FieldMapping) Field(name string) string {
+	if name == "" {
+		return model.FieldMessage
+	}
+	if target, ok := m[strings.ToLower(name)]; ok {
+		return target
+	}
+	return model.AttributeFieldPrefix + name
+}
+
+// Apply returns a copy of expr with Sigma fields replaced by rule fields
+func (m FieldMapping) Apply(expr Expr) Expr {
+	switch e := expr.(type) {
+	case And:
+		mapped := make(And, len(e))
+		for i, operand := range e {
+			mapped[i] = m.Apply(operand)
+		}
+		return mapped
+	case Or:
+		mapped := make(Or, len(e))
+		for i, operand := range e {
+			mapped[i] = m.Apply(operand)
+		}
+		return mapped
+	case Not:
+		return Not{Operand: m.Apply(e.Operand)}
+	case Match:
+		e.Field = m.Field(e.Field)
+		return e
+	default:
+		return expr
+	}
+}
+
+// Fields returns the distinct fields referenced by expr
+func Fields(expr Expr) []string {
+	seen := make(map[string]bool)
+	var fields []string
+
+	var walk func(Expr)
+	walk = func(expr Expr) {
+		switch e := expr.(type) {
+		case And:
+			for _, operand := range e {
+				walk(operand)
+			}
+		case Or:
+			for _, operand := range e {
+				walk(operand)
+			}
+		case Not:
+			walk(e.Operand)
+		case Match:
+			if !seen[e.Field] {
+				seen[e.Field] = true
+				fields = append(fields, e.Field)
+			}
+		}
+	}
+	walk(expr)
+
+	return fields
+}
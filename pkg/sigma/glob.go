@@ -0,0 +1,93 @@
+package sigma
+
+import (
+	"regexp"
+	"strings"
+)
+
+// globToken is either a literal run of text or a single * or ? wildcard
+type globToken struct {
+	wildcard byte
+	text     string
+}
+
+// parseGlob splits a Sigma value into literals and wildcards. A backslash
+// escapes *, ? and itself; any other backslash is literal.
+func parseGlob(value string) []globToken {
+	var tokens []globToken
+	var lit strings.Builder
+
+	flush := func() {
+		if lit.Len() > 0 {
+			tokens = append(tokens, globToken{text: lit.String()})
+			lit.Reset()
+		}
+	}
+
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\':
+			if i+1 < len(value) && strings.IndexByte(`*?\`, value[i+1]) >= 0 {
+				i++
+				lit.WriteByte(value[i])
+			} else {
+				lit.WriteByte(c)
+			}
+		case '*', '?':
+			flush()
+			tokens = append(tokens, globToken{wildcard: c})
+		default:
+			lit.WriteByte(c)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// hasWildcard reports whether tokens contain a wildcard
+func hasWildcard(tokens []globToken) bool {
+	for _, tok := range tokens {
+		if tok.wildcard != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// joinLiterals concatenates the literal tokens
+func joinLiterals(tokens []globToken) string {
+	var b strings.Builder
+	for _, tok := range tokens {
+		b.WriteString(tok.text)
+	}
+	return b.String()
+}
+
+// escapeGlob escapes a literal so it can be embedded in a glob
+func escapeGlob(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(s)
+}
+
+// globRegexp compiles a glob into an anchored regular expression
+func globRegexp(pattern string, cased bool) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?s)")
+	if !cased {
+		b.WriteString("(?i)")
+	}
+	b.WriteByte('^')
+	for _, tok := range parseGlob(pattern) {
+		switch tok.wildcard {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteByte('.')
+		default:
+			b.WriteString(regexp.QuoteMeta(tok.text))
+		}
+	}
+	b.WriteByte('$')
+
+	return regexp.MustCompile(b.String())
+}
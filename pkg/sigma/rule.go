@@ -0,0 +1,427 @@
+// Package sigma parses Sigma detection rules into expressions that can be
+// evaluated against events or compiled into SQL.
+package sigma
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+// Rule is a parsed Sigma rule
+type Rule struct {
+	Title       string
+	ID          string
+	Status      string
+	Description string
+	Level       string
+	Tags        []string
+	LogSource   LogSource
+	// Condition is the detection expression over Sigma field names. It is
+	// nil when the rule uses unsupported constructs.
+	Condition Expr
+	// Unsupported lists constructs that prevent the rule from being used
+	Unsupported []string
+	// Warnings lists constructs that are accepted but not enforced
+	Warnings []string
+}
+
+// LogSource is the Sigma logsource section
+type LogSource struct {
+	Category string `yaml:"category"`
+	Product  string `yaml:"product"`
+	Service  string `yaml:"service"`
+}
+
+// Supported reports whether the rule can be evaluated
+func (r *Rule) Supported() bool {
+	return r.Condition != nil && len(r.Unsupported) == 0
+}
+
+type rawRule struct {
+	Title       string    `yaml:"title"`
+	ID          string    `yaml:"id"`
+	Status      string    `yaml:"status"`
+	Description string    `yaml:"description"`
+	Level       string    `yaml:"level"`
+	Tags        []string  `yaml:"tags"`
+	LogSource   LogSource `yaml:"logsource"`
+	Action      string    `yaml:"action"`
+	Correlation yaml.Node `yaml:"correlation"`
+	Detection   yaml.Node `yaml:"detection"`
+}
+
+// Split splits a multi-document YAML stream into single rule documents
+func Split(data []byte) ([][]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	var docs [][]byte
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		if len(node.Content) == 0 || node.Content[0].Tag == "!!null" {
+			continue
+		}
+
+		doc, err := yaml.Marshal(&node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode rule: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+// Parse parses a single Sigma rule. Malformed rules return an error, while
+// valid rules that use unsupported constructs are returned with those
+// constructs listed in Unsupported.
+func Parse(data []byte) (*Rule, error) {
+	var raw rawRule
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	rule := &Rule{
+		Title:       raw.Title,
+		ID:          raw.ID,
+		Status:      raw.Status,
+		Description: raw.Description,
+		Level:       raw.Level,
+		Tags:        raw.Tags,
+		LogSource:   raw.LogSource,
+	}
+
+	if raw.Action != "" {
+		rule.Unsupported = append(rule.Unsupported, fmt.Sprintf("rule collection action %q", raw.Action))
+		return rule, nil
+	}
+	if raw.Correlation.Kind != 0 {
+		rule.Unsupported = append(rule.Unsupported, "correlation rules")
+		return rule, nil
+	}
+
+	if strings.TrimSpace(raw.Title) == "" {
+		return nil, errors.New("rule has no title")
+	}
+	if raw.Detection.Kind != yaml.MappingNode {
+		return nil, errors.New("rule has no detection section")
+	}
+
+	if raw.LogSource != (LogSource{}) {
+		rule.Warnings = append(rule.Warnings, "logsource is not enforced; the rule applies to all events")
+	}
+
+	b := &builder{}
+	selections := make(map[string]Expr)
+	var names []string
+	var conditions []string
+
+	detection := raw.Detection.Content
+	for i := 0; i+1 < len(detection); i += 2 {
+		key, value := detection[i].Value, detection[i+1]
+
+		switch key {
+		case "condition":
+			switch value.Kind {
+			case yaml.ScalarNode:
+				conditions = append(conditions, value.Value)
+			case yaml.SequenceNode:
+				for _, item := range value.Content {
+					conditions = append(conditions, item.Value)
+				}
+			default:
+				return nil, errors.New("condition must be a string or a list of strings")
+			}
+		case "timeframe":
+			b.unsupported("timeframe")
+		default:
+			expr, err := b.selection(key, value)
+			if err != nil {
+				return nil, fmt.Errorf("selection %q: %w", key, err)
+			}
+			selections[key] = expr
+			names = append(names, key)
+		}
+	}
+
+	if len(conditions) == 0 {
+		return nil, errors.New("detection has no condition")
+	}
+
+	var exprs Or
+	for _, condition := range conditions {
+		if strings.Contains(condition, "|") {
+			b.unsupported(fmt.Sprintf("aggregation in condition %q", condition))
+			continue
+		}
+		if strings.Contains(strings.ToLower(condition), " near ") {
+			b.unsupported(fmt.Sprintf("near in condition %q", condition))
+			continue
+		}
+
+		expr, err := parseCondition(condition, selections, names)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+
+	rule.Unsupported = append(rule.Unsupported, b.problems...)
+	if len(rule.Unsupported) > 0 {
+		return rule, nil
+	}
+
+	if len(exprs) == 1 {
+		rule.Condition = exprs[0]
+	} else {
+		rule.Condition = exprs
+	}
+	return rule, nil
+}
+
+// SeverityFromLevel maps a Sigma level onto a log severity
+func SeverityFromLevel(level string) (string, bool) {
+	switch strings.ToLower(level) {
+	case "informational":
+		return model.SeverityInfo, true
+	case "low":
+		return model.SeverityLow, true
+	case "medium":
+		return model.SeverityMedium, true
+	case "high":
+		return model.SeverityHigh, true
+	case "critical":
+		return model.SeverityCritical, true
+	default:
+		return "", false
+	}
+}
+
+// builder converts detection selections into expressions, collecting the
+// unsupported constructs it encounters
+type builder struct {
+	problems []string
+}
+
+func (b *builder) unsupported(construct string) {
+	b.problems = append(b.problems, construct)
+}
+
+// selection builds a named selection. Maps AND their fields, lists of maps OR
+// them, and lists of plain values are keyword searches.
+func (b *builder) selection(name string, node *yaml.Node) (Expr, error) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return b.fieldMap(name, node)
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			return nil, errors.New("empty selection")
+		}
+
+		var alternatives Or
+		for _, item := range node.Content {
+			switch item.Kind {
+			case yaml.MappingNode:
+				expr, err := b.fieldMap(name, item)
+				if err != nil {
+					return nil, err
+				}
+				alternatives = append(alternatives, expr)
+			case yaml.ScalarNode:
+				alternatives = append(alternatives, b.value(name, "", OpContains, false, item)...)
+			default:
+				return nil, errors.New("selection lists must contain maps or values")
+			}
+		}
+		if len(alternatives) == 1 {
+			return alternatives[0], nil
+		}
+		return alternatives, nil
+	case yaml.ScalarNode:
+		return Or(b.value(name, "", OpContains, false, node)), nil
+	default:
+		return nil, errors.New("selection must be a map or a list")
+	}
+}
+
+// fieldMap builds the conjunction of the fields in a selection map
+func (b *builder) fieldMap(name string, node *yaml.Node) (Expr, error) {
+	if len(node.Content) == 0 {
+		return nil, errors.New("empty selection")
+	}
+
+	var fields And
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		expr, err := b.field(name, node.Content[i].Value, node.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, expr)
+	}
+
+	if len(fields) == 1 {
+		return fields[0], nil
+	}
+	return fields, nil
+}
+
+// field builds the match for a "field|modifier|..." key and its values
+func (b *builder) field(selection, key string, node *yaml.Node) (Expr, error) {
+	parts := strings.Split(key, "|")
+	field := parts[0]
+	where := fmt.Sprintf("%s.%s", selection, key)
+
+	op := OpEquals
+	all, cased := false, false
+	reFlags := ""
+
+	setOp := func(next MatchOp, mod string) {
+		if op != OpEquals {
+			b.unsupported(fmt.Sprintf("%s: modifier %q combined with %q", where, mod, op))
+			return
+		}
+		op = next
+	}
+
+	for _, mod := range parts[1:] {
+		switch strings.ToLower(mod) {
+		case "contains":
+			setOp(OpContains, mod)
+		case "startswith":
+			setOp(OpStartsWith, mod)
+		case "endswith":
+			setOp(OpEndsWith, mod)
+		case "re":
+			setOp(OpRegex, mod)
+		case "exists":
+			setOp(OpExists, mod)
+		case "all":
+			all = true
+		case "cased":
+			cased = true
+		case "i", "m", "s":
+			if op != OpRegex {
+				b.unsupported(fmt.Sprintf("%s: modifier %q without re", where, mod))
+				continue
+			}
+			reFlags += strings.ToLower(mod)
+		default:
+			b.unsupported(fmt.Sprintf("%s: modifier %q", where, mod))
+		}
+	}
+
+	var values []*yaml.Node
+	switch node.Kind {
+	case yaml.ScalarNode:
+		values = []*yaml.Node{node}
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			return nil, fmt.Errorf("field %q has an empty value list", key)
+		}
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				b.unsupported(fmt.Sprintf("%s: nested value", where))
+				return And{}, nil
+			}
+			values = append(values, item)
+		}
+	default:
+		b.unsupported(fmt.Sprintf("%s: map value", where))
+		return And{}, nil
+	}
+
+	if op == OpExists {
+		if len(values) != 1 || values[0].Tag != "!!bool" {
+			return nil, fmt.Errorf("field %q: exists requires true or false", key)
+		}
+		if values[0].Value == "true" {
+			return Match{Field: field, Op: OpExists}, nil
+		}
+		return Not{Operand: Match{Field: field, Op: OpExists}}, nil
+	}
+
+	if op == OpRegex && reFlags != "" {
+		reFlags = "(?" + reFlags + ")"
+	}
+
+	var matches []Expr
+	for _, value := range values {
+		if op == OpRegex {
+			re, err := regexp.Compile(reFlags + value.Value)
+			if err != nil {
+				b.unsupported(fmt.Sprintf("%s: regex not supported: %v", where, err))
+				continue
+			}
+			matches = append(matches, Match{Field: field, Op: OpRegex, Value: reFlags + value.Value, re: re})
+			continue
+		}
+		matches = append(matches, b.value(where, field, op, cased, value)...)
+	}
+
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	if all {
+		return And(matches), nil
+	}
+	return Or(matches), nil
+}
+
+// value builds the match for a single string value, expanding wildcards
+func (b *builder) value(where, field string, op MatchOp, cased bool, node *yaml.Node) []Expr {
+	if node.Tag == "!!null" {
+		if op != OpEquals {
+			b.unsupported(fmt.Sprintf("%s: null with modifier %q", where, op))
+			return nil
+		}
+		return []Expr{Match{Field: field, Op: OpNull}}
+	}
+
+	// Only strings carry wildcards; numbers and booleans match literally
+	var tokens []globToken
+	if node.Tag == "!!str" {
+		tokens = parseGlob(node.Value)
+	} else {
+		tokens = []globToken{{text: node.Value}}
+	}
+
+	if !hasWildcard(tokens) {
+		return []Expr{Match{Field: field, Op: op, Value: joinLiterals(tokens), Cased: cased}}
+	}
+
+	var pattern strings.Builder
+	if op == OpContains || op == OpEndsWith {
+		pattern.WriteByte('*')
+	}
+	for _, tok := range tokens {
+		if tok.wildcard != 0 {
+			pattern.WriteByte(tok.wildcard)
+		} else {
+			pattern.WriteString(escapeGlob(tok.text))
+		}
+	}
+	if op == OpContains || op == OpStartsWith {
+		pattern.WriteByte('*')
+	}
+
+	return []Expr{Match{
+		Field: field,
+		Op:    OpWildcard,
+		Value: pattern.String(),
+		Cased: cased,
+		re:    globRegexp(pattern.String(), cased),
+	}}
+}
@@ -0,0 +1,233 @@
+package sigma
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const processRule = `
+title: Suspicious PowerShell Download
+id: 3b6ab547-8ec2-4991-b9d2-2b06702a48d7
+level: high
+logsource:
+  category: process_creation
+  product: windows
+detection:
+  selection_img:
+    Image|endswith: '\powershell.exe'
+  selection_cli:
+    CommandLine|contains:
+      - 'DownloadString'
+      - 'DownloadFile'
+  filter:
+    User: SYSTEM
+  condition: all of selection_* and not filter
+`
+
+func getter(fields map[string]string) FieldGetter {
+	return func(field string) (string, bool) {
+		v, ok := fields[field]
+		return v, ok
+	}
+}
+
+func TestParseAndEval(t *testing.T) {
+	rule, err := Parse([]byte(processRule))
+	require.NoError(t, err)
+	require.True(t, rule.Supported())
+	assert.Equal(t, "Suspicious PowerShell Download", rule.Title)
+	assert.Equal(t, "high", rule.Level)
+	assert.NotEmpty(t, rule.Warnings)
+
+	tests := []struct {
+		name   string
+		fields map[string]string
+		want   bool
+	}{
+		{
+			name:   "match is case-insensitive",
+			fields: map[string]string{"Image": `C:\Windows\POWERSHELL.EXE`, "CommandLine": "iex (downloadstring 'x')", "User": "alice"},
+			want:   true,
+		},
+		{
+			name:   "filtered user",
+			fields: map[string]string{"Image": `C:\powershell.exe`, "CommandLine": "DownloadFile", "User": "SYSTEM"},
+			want:   false,
+		},
+		{
+			name:   "missing selection",
+			fields: map[string]string{"Image": `C:\powershell.exe`, "CommandLine": "Get-Process"},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rule.Condition.Eval(getter(tt.fields)))
+		})
+	}
+}
+
+func TestConditionsAndValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		detection string
+		fields    map[string]string
+		want      bool
+	}{
+		{
+			name:      "one of them",
+			detection: "a: {x: 1}\n  b: {y: 2}\n  condition: 1 of them",
+			fields:    map[string]string{"y": "2"},
+			want:      true,
+		},
+		{
+			name:      "parentheses and or",
+			detection: "a: {x: 1}\n  b: {y: 2}\n  c: {z: 3}\n  condition: a and (b or c)",
+			fields:    map[string]string{"x": "1", "z": "3"},
+			want:      true,
+		},
+		{
+			name:      "list of maps is or",
+			detection: "sel:\n    - {x: 1}\n    - {y: 2}\n  condition: sel",
+			fields:    map[string]string{"y": "2"},
+			want:      true,
+		},
+		{
+			name:      "all modifier",
+			detection: "sel:\n    cmd|contains|all: [foo, bar]\n  condition: sel",
+			fields:    map[string]string{"cmd": "foo only"},
+			want:      false,
+		},
+		{
+			name:      "wildcard value",
+			detection: "sel:\n    path: 'C:\\\\*\\cmd.ex?'\n  condition: sel",
+			fields:    map[string]string{"path": `c:\windows\system32\cmd.exe`},
+			want:      true,
+		},
+		{
+			name:      "escaped wildcard is literal",
+			detection: "sel:\n    q: 'what\\?'\n  condition: sel",
+			fields:    map[string]string{"q": "whats"},
+			want:      false,
+		},
+		{
+			name:      "regex is case-sensitive",
+			detection: "sel:\n    user|re: '^adm.n$'\n  condition: sel",
+			fields:    map[string]string{"user": "ADMIN"},
+			want:      false,
+		},
+		{
+			name:      "regex i flag",
+			detection: "sel:\n    user|re|i: '^adm.n$'\n  condition: sel",
+			fields:    map[string]string{"user": "ADMIN"},
+			want:      true,
+		},
+		{
+			name:      "null matches missing field",
+			detection: "sel:\n    parent: null\n  condition: sel",
+			fields:    map[string]string{},
+			want:      true,
+		},
+		{
+			name:      "keywords",
+			detection: "keywords:\n    - 'mimikatz'\n    - 'sekurlsa::*'\n  condition: keywords",
+			fields:    map[string]string{"": "running SEKURLSA::logonpasswords"},
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse([]byte("title: t\ndetection:\n  " + tt.detection))
+			require.NoError(t, err)
+			require.True(t, rule.Supported(), rule.Unsupported)
+			assert.Equal(t, tt.want, rule.Condition.Eval(getter(tt.fields)))
+		})
+	}
+}
+
+func TestUnsupportedConstructs(t *testing.T) {
+	tests := []struct {
+		name      string
+		detection string
+		want      string
+	}{
+		{"modifier", "sel:\n    cmd|base64offset|contains: x\n  condition: sel", `sel.cmd|base64offset|contains: modifier "base64offset"`},
+		{"aggregation", "sel: {x: 1}\n  condition: sel | count() > 5", `aggregation in condition "sel | count() > 5"`},
+		{"timeframe", "sel: {x: 1}\n  timeframe: 5m\n  condition: sel", "timeframe"},
+		{"regex lookahead", "sel:\n    x|re: '(?=a)'\n  condition: sel", "regex not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse([]byte("title: t\ndetection:\n  " + tt.detection))
+			require.NoError(t, err)
+			assert.False(t, rule.Supported())
+			require.NotEmpty(t, rule.Unsupported)
+			assert.Contains(t, rule.Unsupported[0], tt.want)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{"no title", "detection:\n  sel: {x: 1}\n  condition: sel"},
+		{"no detection", "title: t"},
+		{"no condition", "title: t\ndetection:\n  sel: {x: 1}"},
+		{"unknown selection", "title: t\ndetection:\n  sel: {x: 1}\n  condition: other"},
+		{"unbalanced parentheses", "title: t\ndetection:\n  sel: {x: 1}\n  condition: (sel"},
+		{"pattern matches nothing", "title: t\ndetection:\n  sel: {x: 1}\n  condition: 1 of foo*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.rule))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFieldMapping(t *testing.T) {
+	rule, err := Parse([]byte(processRule))
+	require.NoError(t, err)
+
+	mapping := NewFieldMapping(map[string]string{"image": "attr.process.image", "User": "attr.user.name"})
+	expr := mapping.Apply(rule.Condition)
+
+	assert.ElementsMatch(t, []string{"attr.process.image", "attr.CommandLine", "attr.user.name"}, Fields(expr))
+	assert.Equal(t, "message", mapping.Field(""))
+}
+
+func TestLikePattern(t *testing.T) {
+	tests := []struct {
+		match Match
+		want  string
+	}{
+		{Match{Op: OpContains, Value: "50%_off"}, `%50\%\_off%`},
+		{Match{Op: OpStartsWith, Value: "cmd"}, "cmd%"},
+		{Match{Op: OpEndsWith, Value: `\cmd.exe`}, `%\\cmd.exe`},
+		{Match{Op: OpWildcard, Value: `C:\\*\\cmd.ex?`}, `C:\\%\\cmd.ex_`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.match.LikePattern())
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	docs, err := Split([]byte(processRule + "\n---\ntitle: second\n---\n"))
+	require.NoError(t, err)
+	assert.Len(t, docs, 2)
+
+	rule, err := Parse(docs[0])
+	require.NoError(t, err)
+	assert.True(t, rule.Supported())
+}
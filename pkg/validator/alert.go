@@ -0,0 +1,80 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+var (
+	ErrInvalidAlertStatus   = errors.New("status must be open, acknowledged or resolved")
+	ErrEmptyComment         = errors.New("comment cannot be empty")
+	ErrCommentTooLong       = errors.New("comment exceeds 4096 characters")
+	ErrAssigneeTooLong      = errors.New("assignee exceeds 255 characters")
+	ErrEmptyReason          = errors.New("suppression reason cannot be empty")
+	ErrNoSuppressionMatcher = errors.New("suppression must match a rule_id, group_key or severity")
+	ErrInvalidRuleID        = errors.New("rule_id must be a UUID")
+	ErrInvalidSuppression   = errors.New("suppression duration must be between 1m and 720h")
+)
+
+// ValidateAlertStatusRequest validates an alert status change
+func ValidateAlertStatusRequest(req model.AlertStatusRequest) error {
+	switch req.Status {
+	case model.AlertOpen, model.AlertAcknowledged, model.AlertResolved:
+	default:
+		return ErrInvalidAlertStatus
+	}
+	if len(req.Comment) > 4096 {
+		return ErrCommentTooLong
+	}
+	return nil
+}
+
+// ValidateAlertAssignRequest validates an alert assignment
+func ValidateAlertAssignRequest(req model.AlertAssignRequest) error {
+	if len(req.Assignee) > 255 {
+		return ErrAssigneeTooLong
+	}
+	return nil
+}
+
+// ValidateAlertComment validates an alert comment
+func ValidateAlertComment(req model.AlertCommentRequest) error {
+	if strings.TrimSpace(req.Body) == "" {
+		return ErrEmptyComment
+	}
+	if len(req.Body) > 4096 {
+		return ErrCommentTooLong
+	}
+	return nil
+}
+
+// ValidateSuppressionRequest validates a suppression. Suppressions must
+// match on something, so a single request cannot mute every alert of a
+// tenant, and must expire.
+func ValidateSuppressionRequest(req model.SuppressionRequest) error {
+	if strings.TrimSpace(req.Reason) == "" {
+		return ErrEmptyReason
+	}
+	if req.RuleID == "" && req.GroupKey == "" && req.Severity == "" {
+		return ErrNoSuppressionMatcher
+	}
+	if req.RuleID != "" {
+		if _, err := uuid.Parse(req.RuleID); err != nil {
+			return ErrInvalidRuleID
+		}
+	}
+	if req.Severity != "" && !model.IsValidSeverity(req.Severity) {
+		return ErrInvalidSeverity
+	}
+
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration < time.Minute || duration > model.MaxSuppressionDuration {
+		return ErrInvalidSuppression
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+func TestValidateAlertStatusRequest(t *testing.T) {
+	assert.NoError(t, ValidateAlertStatusRequest(model.AlertStatusRequest{Status: model.AlertAcknowledged}))
+	assert.NoError(t, ValidateAlertStatusRequest(model.AlertStatusRequest{Status: model.AlertResolved, Comment: "false positive"}))
+
+	assert.Equal(t, ErrInvalidAlertStatus, ValidateAlertStatusRequest(model.AlertStatusRequest{Status: model.AlertSuppressed}))
+	assert.Equal(t, ErrInvalidAlertStatus, ValidateAlertStatusRequest(model.AlertStatusRequest{}))
+	assert.Equal(t, ErrCommentTooLong, ValidateAlertStatusRequest(model.AlertStatusRequest{
+		Status:  model.AlertResolved,
+		Comment: strings.Repeat("a", 4097),
+	}))
+}
+
+func TestValidateSuppressionRequest(t *testing.T) {
+	valid := model.SuppressionRequest{
+		RuleID:   "6f1c2a9e-3b7d-4c5e-9a8f-0d1e2f3a4b5c",
+		Reason:   "Scheduled pentest",
+		Duration: "4h",
+	}
+	assert.NoError(t, ValidateSuppressionRequest(valid))
+
+	tests := []struct {
+		name    string
+		modify  func(req *model.SuppressionRequest)
+		wantErr error
+	}{
+		{"missing reason", func(req *model.SuppressionRequest) { req.Reason = " " }, ErrEmptyReason},
+		{"no matcher", func(req *model.SuppressionRequest) { req.RuleID = "" }, ErrNoSuppressionMatcher},
+		{"bad rule id", func(req *model.SuppressionRequest) { req.RuleID = "brute-force" }, ErrInvalidRuleID},
+		{"bad severity", func(req *model.SuppressionRequest) { req.Severity = "SEVERE" }, ErrInvalidSeverity},
+		{"missing duration", func(req *model.SuppressionRequest) { req.Duration = "" }, ErrInvalidSuppression},
+		{"too short", func(req *model.SuppressionRequest) { req.Duration = "30s" }, ErrInvalidSuppression},
+		{"too long", func(req *model.SuppressionRequest) { req.Duration = "721h" }, ErrInvalidSuppression},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			assert.Equal(t, tt.wantErr, ValidateSuppressionRequest(req))
+		})
+	}
+
+	severityOnly := model.SuppressionRequest{Severity: model.SeverityLow, Reason: "Noise", Duration: "720h"}
+	assert.NoError(t, ValidateSuppressionRequest(severityOnly))
+}
@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+var (
+	ErrEmptyKeyName    = errors.New("api key name cannot be empty")
+	ErrKeyNameTooLong  = errors.New("api key name exceeds 255 characters")
+	ErrNoKeyRoles      = errors.New("api key must have at least one role")
+	ErrInvalidRole     = errors.New("role must be ingest, read or admin")
+	ErrKeyExpiryInPast = errors.New("expires_at must be in the future")
+)
+
+// ValidateAPIKeyRequest validates an API key creation request
+func ValidateAPIKeyRequest(req model.APIKeyRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return ErrEmptyKeyName
+	}
+	if len(req.Name) > 255 {
+		return ErrKeyNameTooLong
+	}
+
+	if req.TenantID != "" {
+		if err := ValidateTenantID(req.TenantID); err != nil {
+			return err
+		}
+	}
+
+	if len(req.Roles) == 0 {
+		return ErrNoKeyRoles
+	}
+	for _, role := range req.Roles {
+		if !model.IsValidRole(role) {
+			return ErrInvalidRole
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return ErrKeyExpiryInPast
+	}
+
+	return nil
+}
@@ -1,23 +1,63 @@
 package validator
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/pkg/fulltext"
+	"github.com/Saumajitt/threatLog/pkg/searchql"
 )
 
 var (
+	ErrInvalidEventID   = errors.New("id must be 1-128 letters, digits, '.', '_', ':' or '-'")
 	ErrInvalidTimestamp = errors.New("invalid timestamp")
 	ErrInvalidSeverity  = errors.New("invalid severity level")
 	ErrEmptySource      = errors.New("source cannot be empty")
 	ErrEmptyMessage     = errors.New("message cannot be empty")
 	ErrSourceTooLong    = errors.New("source exceeds 255 characters")
 	ErrMessageTooLong   = errors.New("message exceeds 4096 characters")
+
+	ErrTooManyAttributes   = errors.New("attributes exceed 64 keys")
+	ErrAttributesTooDeep   = errors.New("attributes exceed nesting depth of 4")
+	ErrAttributesTooLarge  = errors.New("attributes exceed 16384 bytes")
+	ErrInvalidAttributeKey = errors.New("attribute keys must be 1-128 characters")
+	ErrTooManyAttrFilters  = errors.New("query cannot have more than 16 attribute filters")
+
+	ErrInvalidAggregateField  = errors.New("aggregation fields must be severity, source or attr.<key>")
+	ErrTooManyAggregateFields = errors.New("group_by and cardinality cannot have more than 5 fields each")
+)
+
+var eventIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Attribute limits
+const (
+	MaxAttributeKeys    = 64
+	MaxAttributeDepth   = 4
+	MaxAttributesSize   = 16384
+	MaxAttributeKeyLen  = 128
+	MaxAttributeFilters = 16
+)
+
+// Aggregation limits
+const (
+	MaxAggregateFields = 5
+	MaxAggregateTop    = 100
 )
 
 // ValidateIngestRequest validates a single log ingest request
 func ValidateIngestRequest(req model.IngestRequest) error {
+	// Validate client-supplied ID
+	if req.ID != "" {
+		if err := ValidateEventID(req.ID); err != nil {
+			return err
+		}
+	}
+
 	// Validate timestamp
 	if req.Timestamp.IsZero() {
 		return ErrInvalidTimestamp
@@ -44,18 +84,81 @@ func ValidateIngestRequest(req model.IngestRequest) error {
 		return ErrMessageTooLong
 	}
 
+	// Validate attributes
+	if err := ValidateAttributes(req.Attributes); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// ValidateQueryRequest validates a query request
-func ValidateQueryRequest(req model.QueryRequest) error {
-	// Validate time range
-	if req.StartTime.IsZero() || req.EndTime.IsZero() {
-		return errors.New("start_time and end_time are required")
+// ValidateEventID validates a client-supplied event ID or idempotency key
+func ValidateEventID(id string) error {
+	if !eventIDPattern.MatchString(id) {
+		return ErrInvalidEventID
 	}
+	return nil
+}
 
-	if req.EndTime.Before(req.StartTime) {
-		return errors.New("end_time must be after start_time")
+// ValidateAttributes enforces key count, nesting depth and size limits on attributes
+func ValidateAttributes(attrs map[string]any) error {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	keys := 0
+	if err := walkAttributes(attrs, 1, &keys); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(attrs)
+	if err != nil {
+		return err
+	}
+	if len(data) > MaxAttributesSize {
+		return ErrAttributesTooLarge
+	}
+
+	return nil
+}
+
+// walkAttributes counts keys and checks depth of nested attribute values
+func walkAttributes(value any, depth int, keys *int) error {
+	switch v := value.(type) {
+	case map[string]any:
+		if depth > MaxAttributeDepth {
+			return ErrAttributesTooDeep
+		}
+		for key, child := range v {
+			if key == "" || len(key) > MaxAttributeKeyLen {
+				return ErrInvalidAttributeKey
+			}
+			*keys++
+			if *keys > MaxAttributeKeys {
+				return ErrTooManyAttributes
+			}
+			if err := walkAttributes(child, depth+1, keys); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if depth > MaxAttributeDepth {
+			return ErrAttributesTooDeep
+		}
+		for _, child := range v {
+			if err := walkAttributes(child, depth+1, keys); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateQueryRequest validates a query request
+func ValidateQueryRequest(req model.QueryRequest) error {
+	if err := validateQueryFilters(req); err != nil {
+		return err
 	}
 
 	// Validate limit
@@ -71,6 +174,110 @@ func ValidateQueryRequest(req model.QueryRequest) error {
 		return errors.New("offset cannot be negative")
 	}
 
+	// Validate sort order
+	switch req.Sort {
+	case "", model.SortTimestamp:
+	case model.SortRelevance:
+		if req.Query == "" {
+			return errors.New("sort=relevance requires q")
+		}
+	default:
+		return errors.New("sort must be timestamp or relevance")
+	}
+
+	// Validate pagination cursor
+	if req.Cursor != "" {
+		if _, _, err := model.DecodeCursor(req.Cursor); err != nil {
+			return err
+		}
+		if req.Offset > 0 {
+			return errors.New("cursor and offset cannot be combined")
+		}
+		if req.Sort == model.SortRelevance {
+			return errors.New("cursor cannot be used with sort=relevance")
+		}
+	}
+
+	// Validate count mode
+	switch req.Count {
+	case "", model.CountExact, model.CountEstimate:
+	default:
+		return errors.New("count must be exact or estimate")
+	}
+
+	return nil
+}
+
+// ValidateExportRequest validates the filters of an export. Exports have
+// no row limit, so only the filters are checked.
+func ValidateExportRequest(req model.QueryRequest) error {
+	return validateQueryFilters(req)
+}
+
+// ValidateLogContext validates the number of events requested before and
+// after a log
+func ValidateLogContext(before, after int) error {
+	if before < 0 || after < 0 {
+		return errors.New("before and after cannot be negative")
+	}
+	if before > model.MaxContextEvents || after > model.MaxContextEvents {
+		return fmt.Errorf("before and after cannot exceed %d", model.MaxContextEvents)
+	}
+	return nil
+}
+
+// ValidateAggregateRequest validates an aggregation request
+func ValidateAggregateRequest(req model.AggregateRequest) error {
+	if err := validateQueryFilters(req.Filter); err != nil {
+		return err
+	}
+
+	if _, err := req.BucketInterval(); err != nil {
+		return err
+	}
+
+	if len(req.GroupBy) > MaxAggregateFields || len(req.Cardinality) > MaxAggregateFields {
+		return ErrTooManyAggregateFields
+	}
+	for _, field := range append(append([]string{}, req.GroupBy...), req.Cardinality...) {
+		if err := validateAggregateField(field); err != nil {
+			return err
+		}
+	}
+
+	if req.Top < 1 || req.Top > MaxAggregateTop {
+		return errors.New("top must be between 1 and 100")
+	}
+
+	return nil
+}
+
+// validateAggregateField accepts severity, source and attr.<key>
+func validateAggregateField(field string) error {
+	if field == model.FieldSeverity || field == model.FieldSource {
+		return nil
+	}
+	key, ok := strings.CutPrefix(field, model.AttributeFieldPrefix)
+	if !ok {
+		return ErrInvalidAggregateField
+	}
+	if key == "" || len(key) > MaxAttributeKeyLen {
+		return ErrInvalidAttributeKey
+	}
+	return nil
+}
+
+// validateQueryFilters validates the time range and filters shared by queries and aggregations
+func validateQueryFilters(req model.QueryRequest) error {
+	// Validate time range
+	if req.StartTime.IsZero() || req.EndTime.IsZero() {
+		return errors.New("start_time and end_time are required")
+	}
+
+	if req.EndTime.Before(req.StartTime) {
+		return errors.New("end_time must be after start_time")
+	}
+
 	// Validate severity values
 	for _, sev := range req.Severity {
 		if !model.IsValidSeverity(sev) {
@@ -78,6 +285,63 @@ func ValidateQueryRequest(req model.QueryRequest) error {
 		}
 	}
 
+	// Validate attribute filters
+	if err := validateAttributeFilters(req.Attributes, req.AttributeExists); err != nil {
+		return err
+	}
+
+	// Validate search query
+	if req.Query != "" {
+		if _, err := fulltext.Parse(req.Query); err != nil {
+			return err
+		}
+	}
+
+	// Validate search language expression
+	if req.Search != "" {
+		if _, err := searchql.Parse(req.Search); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateTailRequest validates live tail filters
+func ValidateTailRequest(req model.TailRequest) error {
+	for _, sev := range req.Severity {
+		if !model.IsValidSeverity(sev) {
+			return ErrInvalidSeverity
+		}
+	}
+
+	if err := validateAttributeFilters(req.Attributes, req.AttributeExists); err != nil {
+		return err
+	}
+
+	if req.Query != "" {
+		if _, err := fulltext.Parse(req.Query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateAttributeFilters(attributes map[string]string, exists []string) error {
+	if len(attributes)+len(exists) > MaxAttributeFilters {
+		return ErrTooManyAttrFilters
+	}
+	for key := range attributes {
+		if key == "" || len(key) > MaxAttributeKeyLen {
+			return ErrInvalidAttributeKey
+		}
+	}
+	for _, key := range exists {
+		if key == "" || len(key) > MaxAttributeKeyLen {
+			return ErrInvalidAttributeKey
+		}
+	}
 	return nil
 }
 
@@ -93,4 +357,4 @@ func ParseTimestamp(s string) (time.Time, error) {
 	}
 
 	return t, nil
-}
\ No newline at end of file
+}
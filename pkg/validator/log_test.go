@@ -85,6 +85,39 @@ func TestValidateIngestRequest(t *testing.T) {
 			},
 			wantErr: ErrMessageTooLong,
 		},
+		{
+			name: "client id",
+			request: model.IngestRequest{
+				ID:        "fw-01:2024-01-01T00:00:00Z.42",
+				Timestamp: time.Now(),
+				Severity:  model.SeverityHigh,
+				Source:    "192.168.1.1",
+				Message:   "Test message",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "invalid client id",
+			request: model.IngestRequest{
+				ID:        "event 42",
+				Timestamp: time.Now(),
+				Severity:  model.SeverityHigh,
+				Source:    "192.168.1.1",
+				Message:   "Test message",
+			},
+			wantErr: ErrInvalidEventID,
+		},
+		{
+			name: "client id too long",
+			request: model.IngestRequest{
+				ID:        strings.Repeat("a", 129),
+				Timestamp: time.Now(),
+				Severity:  model.SeverityHigh,
+				Source:    "192.168.1.1",
+				Message:   "Test message",
+			},
+			wantErr: ErrInvalidEventID,
+		},
 	}
 
 	for _, tt := range tests {
@@ -138,3 +171,130 @@ func TestParseTimestamp(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateAttributes(t *testing.T) {
+	tooMany := make(map[string]any)
+	for i := 0; i <= MaxAttributeKeys; i++ {
+		tooMany[strings.Repeat("k", i+1)] = i
+	}
+
+	tests := []struct {
+		name    string
+		attrs   map[string]any
+		wantErr error
+	}{
+		{
+			name:    "nil attributes",
+			attrs:   nil,
+			wantErr: nil,
+		},
+		{
+			name: "valid attributes",
+			attrs: map[string]any{
+				"src_ip": "10.0.0.5",
+				"port":   22,
+				"user":   map[string]any{"name": "root"},
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "too many keys",
+			attrs:   tooMany,
+			wantErr: ErrTooManyAttributes,
+		},
+		{
+			name: "too deep",
+			attrs: map[string]any{
+				"a": map[string]any{"b": map[string]any{"c": map[string]any{"d": map[string]any{"e": 1}}}},
+			},
+			wantErr: ErrAttributesTooDeep,
+		},
+		{
+			name:    "too large",
+			attrs:   map[string]any{"blob": strings.Repeat("a", MaxAttributesSize)},
+			wantErr: ErrAttributesTooLarge,
+		},
+		{
+			name:    "empty key",
+			attrs:   map[string]any{"": "value"},
+			wantErr: ErrInvalidAttributeKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAttributes(tt.attrs)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateAggregateRequest(t *testing.T) {
+	valid := model.AggregateRequest{
+		Filter: model.QueryRequest{
+			StartTime: time.Date(2026, 1, 29, 0, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC),
+		},
+		Interval:    model.IntervalAuto,
+		GroupBy:     []string{"severity", "attr.src_ip"},
+		Top:         10,
+		Cardinality: []string{"source"},
+	}
+	assert.NoError(t, ValidateAggregateRequest(valid))
+
+	tests := []struct {
+		name    string
+		modify  func(req *model.AggregateRequest)
+		wantErr error
+	}{
+		{
+			name:    "message group",
+			modify:  func(req *model.AggregateRequest) { req.GroupBy = []string{"message"} },
+			wantErr: ErrInvalidAggregateField,
+		},
+		{
+			name:    "empty attribute key",
+			modify:  func(req *model.AggregateRequest) { req.Cardinality = []string{"attr."} },
+			wantErr: ErrInvalidAttributeKey,
+		},
+		{
+			name: "too many fields",
+			modify: func(req *model.AggregateRequest) {
+				req.GroupBy = []string{"severity", "source", "attr.a", "attr.b", "attr.c", "attr.d"}
+			},
+			wantErr: ErrTooManyAggregateFields,
+		},
+		{
+			name:    "too many buckets",
+			modify:  func(req *model.AggregateRequest) { req.Interval = "1s" },
+			wantErr: model.ErrTooManyBuckets,
+		},
+		{
+			name:    "invalid severity",
+			modify:  func(req *model.AggregateRequest) { req.Filter.Severity = []string{"high"} },
+			wantErr: ErrInvalidSeverity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			assert.ErrorIs(t, ValidateAggregateRequest(req), tt.wantErr)
+		})
+	}
+
+	req := valid
+	req.Top = 0
+	assert.Error(t, ValidateAggregateRequest(req))
+}
+
+func TestValidateLogContext(t *testing.T) {
+	assert.NoError(t, ValidateLogContext(0, model.MaxContextEvents))
+	assert.Error(t, ValidateLogContext(-1, 10))
+	assert.Error(t, ValidateLogContext(10, model.MaxContextEvents+1))
+}
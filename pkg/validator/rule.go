@@ -0,0 +1,164 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+	"github.com/Saumajitt/threatLog/pkg/sigma"
+)
+
+var (
+	ErrEmptyRuleName     = errors.New("rule name cannot be empty")
+	ErrRuleNameTooLong   = errors.New("rule name exceeds 255 characters")
+	ErrNoRuleConditions  = errors.New("rule must have at least one condition")
+	ErrTooManyConditions = errors.New("rule cannot have more than 32 conditions")
+	ErrInvalidRuleField  = errors.New("condition field must be severity, source, message or attr.<key>")
+	ErrInvalidOperator   = errors.New("invalid condition operator")
+	ErrInvalidThreshold  = errors.New("threshold count must be at least 1")
+	ErrInvalidWindow     = errors.New("threshold window must be a duration between 1s and 24h")
+	ErrInvalidRuleType   = errors.New("rule type must be conditions or sigma")
+	ErrEmptySigma        = errors.New("sigma rule requires the sigma YAML")
+	ErrSigmaConditions   = errors.New("sigma rules cannot have conditions")
+	ErrInvalidSchedule   = errors.New("schedule must be a duration between 10s and 24h")
+	ErrScheduleNotSigma  = errors.New("only sigma rules can be scheduled")
+	ErrScheduledGroupBy  = errors.New("scheduled rules cannot group thresholds")
+)
+
+// ValidateRuleRequest validates a rule create/update request
+func ValidateRuleRequest(req model.RuleRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return ErrEmptyRuleName
+	}
+	if len(req.Name) > 255 {
+		return ErrRuleNameTooLong
+	}
+
+	if !model.IsValidSeverity(req.Severity) {
+		return ErrInvalidSeverity
+	}
+
+	switch req.Type {
+	case "", model.RuleTypeConditions:
+		if req.Schedule != "" {
+			return ErrScheduleNotSigma
+		}
+		if err := validateConditions(req.Conditions); err != nil {
+			return err
+		}
+	case model.RuleTypeSigma:
+		if err := validateSigma(req); err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidRuleType
+	}
+
+	if req.Threshold != nil {
+		if req.Threshold.Count < 1 {
+			return ErrInvalidThreshold
+		}
+		window, err := time.ParseDuration(req.Threshold.Window)
+		if err != nil || window < time.Second || window > 24*time.Hour {
+			return ErrInvalidWindow
+		}
+		if req.Threshold.GroupBy != "" && !IsValidRuleField(req.Threshold.GroupBy) {
+			return fmt.Errorf("threshold group_by: %w", ErrInvalidRuleField)
+		}
+	}
+
+	return nil
+}
+
+func validateConditions(conditions []model.RuleCondition) error {
+	if len(conditions) == 0 {
+		return ErrNoRuleConditions
+	}
+	if len(conditions) > 32 {
+		return ErrTooManyConditions
+	}
+	for i, cond := range conditions {
+		if err := ValidateCondition(cond); err != nil {
+			return fmt.Errorf("condition %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateSigma(req model.RuleRequest) error {
+	if strings.TrimSpace(req.Sigma) == "" {
+		return ErrEmptySigma
+	}
+	if len(req.Conditions) > 0 {
+		return ErrSigmaConditions
+	}
+
+	rule, err := sigma.Parse([]byte(req.Sigma))
+	if err != nil {
+		return fmt.Errorf("invalid sigma rule: %w", err)
+	}
+	if !rule.Supported() {
+		return fmt.Errorf("unsupported sigma constructs: %s", strings.Join(rule.Unsupported, "; "))
+	}
+
+	if req.Schedule != "" {
+		schedule, err := time.ParseDuration(req.Schedule)
+		if err != nil || schedule < 10*time.Second || schedule > 24*time.Hour {
+			return ErrInvalidSchedule
+		}
+		if req.Threshold != nil && req.Threshold.GroupBy != "" {
+			return ErrScheduledGroupBy
+		}
+	}
+	return nil
+}
+
+// ValidateFieldMapping checks that Sigma field mapping targets are valid rule fields
+func ValidateFieldMapping(mapping map[string]string) error {
+	for field, target := range mapping {
+		if !IsValidRuleField(target) {
+			return fmt.Errorf("sigma field %q: %w", field, ErrInvalidRuleField)
+		}
+	}
+	return nil
+}
+
+// IsValidRuleField checks if a field can be referenced by a rule
+func IsValidRuleField(field string) bool {
+	switch field {
+	case model.FieldSeverity, model.FieldSource, model.FieldMessage:
+		return true
+	}
+	key, ok := strings.CutPrefix(field, model.AttributeFieldPrefix)
+	return ok && key != "" && len(key) <= MaxAttributeKeyLen
+}
+
+// ValidateCondition validates a single event condition
+func ValidateCondition(cond model.RuleCondition) error {
+	if !IsValidRuleField(cond.Field) {
+		return ErrInvalidRuleField
+	}
+
+	switch cond.Operator {
+	case model.OperatorEquals, model.OperatorNotEquals, model.OperatorContains:
+		if cond.Value == "" {
+			return fmt.Errorf("operator %s requires a value", cond.Operator)
+		}
+	case model.OperatorIn:
+		if len(cond.Values) == 0 {
+			return errors.New("operator in requires values")
+		}
+	case model.OperatorRegex:
+		if _, err := regexp.Compile(cond.Value); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	case model.OperatorExists:
+	default:
+		return ErrInvalidOperator
+	}
+
+	return nil
+}
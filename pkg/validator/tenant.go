@@ -0,0 +1,18 @@
+package validator
+
+import (
+	"errors"
+	"regexp"
+)
+
+var ErrInvalidTenantID = errors.New("tenant_id must be 1-63 lowercase letters, digits, '-' or '_', starting with a letter or digit")
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidateTenantID validates a tenant identifier
+func ValidateTenantID(tenantID string) error {
+	if !tenantIDPattern.MatchString(tenantID) {
+		return ErrInvalidTenantID
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package validator
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+var (
+	ErrInvalidFeedName   = errors.New("feed name must be 1-64 lowercase letters, digits, '-' or '_'")
+	ErrInvalidFeedFormat = errors.New("feed format must be stix, misp or csv")
+	ErrInvalidFeedExpiry = errors.New("expires_in must be a duration of at least 1m")
+)
+
+var feedNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// ValidateFeedName validates a threat feed name
+func ValidateFeedName(name string) error {
+	if !feedNamePattern.MatchString(name) {
+		return ErrInvalidFeedName
+	}
+	return nil
+}
+
+// ValidateFeedFormat validates a threat feed format
+func ValidateFeedFormat(format string) error {
+	switch format {
+	case model.FeedFormatSTIX, model.FeedFormatMISP, model.FeedFormatCSV:
+		return nil
+	default:
+		return ErrInvalidFeedFormat
+	}
+}
+
+// ValidateThreatFeedUpload validates the parameters of an uploaded feed
+func ValidateThreatFeedUpload(upload model.ThreatFeedUpload) error {
+	if err := ValidateFeedName(upload.Name); err != nil {
+		return err
+	}
+	if err := ValidateFeedFormat(upload.Format); err != nil {
+		return err
+	}
+	if upload.Severity != "" && !model.IsValidSeverity(upload.Severity) {
+		return ErrInvalidSeverity
+	}
+	if upload.ExpiresIn != "" {
+		expiresIn, err := time.ParseDuration(upload.ExpiresIn)
+		if err != nil || expiresIn < time.Minute {
+			return ErrInvalidFeedExpiry
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Saumajitt/threatLog/internal/model"
+)
+
+func TestValidateThreatFeedUpload(t *testing.T) {
+	valid := model.ThreatFeedUpload{Name: "misp-daily", Format: model.FeedFormatMISP}
+	assert.NoError(t, ValidateThreatFeedUpload(valid))
+
+	tests := []struct {
+		name    string
+		modify  func(upload *model.ThreatFeedUpload)
+		wantErr error
+	}{
+		{"uppercase name", func(upload *model.ThreatFeedUpload) { upload.Name = "MISP" }, ErrInvalidFeedName},
+		{"empty name", func(upload *model.ThreatFeedUpload) { upload.Name = "" }, ErrInvalidFeedName},
+		{"bad format", func(upload *model.ThreatFeedUpload) { upload.Format = "json" }, ErrInvalidFeedFormat},
+		{"bad severity", func(upload *model.ThreatFeedUpload) { upload.Severity = "SEVERE" }, ErrInvalidSeverity},
+		{"bad expiry", func(upload *model.ThreatFeedUpload) { upload.ExpiresIn = "week" }, ErrInvalidFeedExpiry},
+		{"short expiry", func(upload *model.ThreatFeedUpload) { upload.ExpiresIn = "30s" }, ErrInvalidFeedExpiry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upload := valid
+			tt.modify(&upload)
+			assert.Equal(t, tt.wantErr, ValidateThreatFeedUpload(upload))
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"flag"
 	"fmt"
 	"net/http"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -18,8 +19,13 @@ var (
 	concurrency = flag.Int("concurrency", 100, "Number of concurrent workers")
 	duration    = flag.Int("duration", 60, "Test duration in seconds")
 	rps         = flag.Int("rps", 1000, "Target requests per second")
+	apiKey      = flag.String("key", os.Getenv("THREATLOG_API_KEY"), "API key with the ingest role")
+	batchSize   = flag.Int("batch", 1, "Logs per request; above 1 uses the batch endpoint")
 )
 
+// statsInterval is how often throughput is sampled
+const statsInterval = 5 * time.Second
+
 type Stats struct {
 	totalRequests   atomic.Int64
 	successRequests atomic.Int64
@@ -27,6 +33,19 @@ type Stats struct {
 	totalLatency    atomic.Int64
 	minLatency      atomic.Int64
 	maxLatency      atomic.Int64
+	acceptedRows    atomic.Int64
+
+	// intervalRates holds rows/sec of each full sampling interval
+	mu            sync.Mutex
+	intervalRates []float64
+}
+
+type BatchRequest struct {
+	Logs []LogRequest `json:"logs"`
+}
+
+type BatchResponse struct {
+	Accepted int `json:"accepted"`
 }
 
 type LogRequest struct {
@@ -44,6 +63,7 @@ func main() {
 	fmt.Printf("  Concurrency: %d\n", *concurrency)
 	fmt.Printf("  Duration: %d seconds\n", *duration)
 	fmt.Printf("  Target RPS: %d\n", *rps)
+	fmt.Printf("  Logs per Request: %d\n", *batchSize)
 	fmt.Println()
 
 	stats := &Stats{}
@@ -90,33 +110,51 @@ func worker(id int, endTime time.Time, interval time.Duration, stats *Stats, wg
 				return
 			}
 
-			req := LogRequest{
-				Timestamp: time.Now(),
-				Severity:  severities[stats.totalRequests.Load()%int64(len(severities))],
-				Source:    fmt.Sprintf("worker-%d", id),
-				Message:   fmt.Sprintf("Load test message from worker %d", id),
+			logs := make([]LogRequest, *batchSize)
+			for i := range logs {
+				logs[i] = LogRequest{
+					Timestamp: time.Now(),
+					Severity:  severities[(stats.totalRequests.Load()+int64(i))%int64(len(severities))],
+					Source:    fmt.Sprintf("worker-%d", id),
+					Message:   fmt.Sprintf("Load test message from worker %d", id),
+				}
 			}
 
-			sendRequest(client, req, stats)
+			sendRequest(client, logs, stats)
 		}
 	}
 }
 
-func sendRequest(client *http.Client, logReq LogRequest, stats *Stats) {
+func sendRequest(client *http.Client, logs []LogRequest, stats *Stats) {
 	stats.totalRequests.Add(1)
 
-	payload, err := json.Marshal(logReq)
+	var body interface{} = logs[0]
+	path := "/api/v1/logs/ingest"
+	expected := http.StatusCreated
+	if len(logs) > 1 {
+		body = BatchRequest{Logs: logs}
+		path = "/api/v1/logs/ingest/batch"
+		expected = http.StatusAccepted
+	}
+
+	payload, err := json.Marshal(body)
 	if err != nil {
 		stats.failedRequests.Add(1)
 		return
 	}
 
+	req, err := http.NewRequest(http.MethodPost, *baseURL+path, bytes.NewBuffer(payload))
+	if err != nil {
+		stats.failedRequests.Add(1)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if *apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+*apiKey)
+	}
+
 	start := time.Now()
-	resp, err := client.Post(
-		*baseURL+"/api/v1/logs/ingest",
-		"application/json",
-		bytes.NewBuffer(payload),
-	)
+	resp, err := client.Do(req)
 	latency := time.Since(start)
 
 	if err != nil {
@@ -125,8 +163,9 @@ func sendRequest(client *http.Client, logReq LogRequest, stats *Stats) {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusCreated {
+	if resp.StatusCode == expected {
 		stats.successRequests.Add(1)
+		stats.acceptedRows.Add(acceptedRows(resp, len(logs)))
 	} else {
 		stats.failedRequests.Add(1)
 	}
@@ -157,24 +196,46 @@ func sendRequest(client *http.Client, logReq LogRequest, stats *Stats) {
 	}
 }
 
+// acceptedRows returns how many logs a successful response accepted
+func acceptedRows(resp *http.Response, sent int) int64 {
+	if sent == 1 {
+		return 1
+	}
+
+	var batchResp BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&batchResp); err != nil {
+		return 0
+	}
+	return int64(batchResp.Accepted)
+}
+
 func printStats(stats *Stats, startTime time.Time) {
-	ticker := time.NewTicker(5 * time.Second)
+	ticker := time.NewTicker(statsInterval)
 	defer ticker.Stop()
 
+	var lastRows int64
 	for range ticker.C {
 		elapsed := time.Since(startTime).Seconds()
 		total := stats.totalRequests.Load()
 		success := stats.successRequests.Load()
 		failed := stats.failedRequests.Load()
 
+		rows := stats.acceptedRows.Load()
+		rowRate := float64(rows-lastRows) / statsInterval.Seconds()
+		lastRows = rows
+
+		stats.mu.Lock()
+		stats.intervalRates = append(stats.intervalRates, rowRate)
+		stats.mu.Unlock()
+
 		rps := float64(total) / elapsed
 		avgLatency := float64(0)
 		if total > 0 {
 			avgLatency = float64(stats.totalLatency.Load()) / float64(total)
 		}
 
-		fmt.Printf("[%.0fs] Requests: %d | Success: %d | Failed: %d | RPS: %.2f | Avg Latency: %.2fms\n",
-			elapsed, total, success, failed, rps, avgLatency)
+		fmt.Printf("[%.0fs] Requests: %d | Success: %d | Failed: %d | RPS: %.2f | Rows/s: %.0f | Avg Latency: %.2fms\n",
+			elapsed, total, success, failed, rps, rowRate, avgLatency)
 	}
 }
 
@@ -199,8 +260,30 @@ func printFinalStats(stats *Stats, elapsed time.Duration) {
 	fmt.Printf("Successful:         %d (%.2f%%)\n", success, successRate)
 	fmt.Printf("Failed:             %d\n", failed)
 	fmt.Printf("Requests/Second:    %.2f\n", rps)
+	fmt.Printf("Rows Accepted:      %d\n", stats.acceptedRows.Load())
+	fmt.Printf("Rows/Second:        %.2f\n", float64(stats.acceptedRows.Load())/elapsed.Seconds())
+	if sustained, ok := sustainedRate(stats); ok {
+		fmt.Printf("Sustained Rows/s:   %.2f (slowest %s interval after warm-up)\n", sustained, statsInterval)
+	}
 	fmt.Printf("Avg Latency:        %.2f ms\n", avgLatency)
 	fmt.Printf("Min Latency:        %d ms\n", stats.minLatency.Load())
 	fmt.Printf("Max Latency:        %d ms\n", stats.maxLatency.Load())
 	fmt.Println(string(bytes.Repeat([]byte("="), 60)))
 }
+
+// sustainedRate returns the lowest rows/sec of the sampled intervals,
+// skipping the first one as warm-up
+func sustainedRate(stats *Stats) (float64, bool) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	if len(stats.intervalRates) < 2 {
+		return 0, false
+	}
+
+	lowest := stats.intervalRates[1]
+	for _, rate := range stats.intervalRates[2:] {
+		lowest = min(lowest, rate)
+	}
+	return lowest, true
+}
@@ -9,12 +9,14 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
+	"os"
 	"time"
 )
 
 var (
-	url   = flag.String("url", "http://localhost:8080", "Base URL")
-	count = flag.Int("count", 1000, "Number of logs to generate")
+	url    = flag.String("url", "http://localhost:8080", "Base URL")
+	count  = flag.Int("count", 1000, "Number of logs to generate")
+	apiKey = flag.String("key", os.Getenv("THREATLOG_API_KEY"), "API key with the ingest role")
 )
 
 type LogRequest struct {
@@ -99,11 +101,16 @@ func sendLog(client *http.Client, log LogRequest) bool {
 		return false
 	}
 
-	resp, err := client.Post(
-		*url+"/api/v1/logs/ingest",
-		"application/json",
-		bytes.NewBuffer(payload),
-	)
+	req, err := http.NewRequest(http.MethodPost, *url+"/api/v1/logs/ingest", bytes.NewBuffer(payload))
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if *apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+*apiKey)
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return false
 	}